@@ -2,21 +2,55 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"log/slog"
+	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/future-bots/executor/internal/broker"
+	"github.com/future-bots/executor/internal/grpc"
 	"github.com/future-bots/executor/internal/http"
+	"github.com/future-bots/executor/internal/instrument"
+	"github.com/future-bots/executor/internal/messaging"
 	"github.com/future-bots/executor/internal/migrations"
+	"github.com/future-bots/executor/internal/outbox"
+	"github.com/future-bots/executor/internal/position"
 	"github.com/future-bots/executor/internal/repository"
+	"github.com/future-bots/executor/internal/risk"
+	"github.com/future-bots/executor/internal/routing"
 	"github.com/future-bots/executor/internal/service"
+	"github.com/future-bots/executor/internal/session"
+	"github.com/future-bots/executor/internal/stream"
 	"github.com/future-bots/platform/config"
 	platformdb "github.com/future-bots/platform/db"
 	"github.com/future-bots/platform/server"
+	marketsv1 "github.com/future-bots/proto/markets/v1"
+	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
+// orderStore is an order repository that also holds the event outbox.
+type orderStore interface {
+	service.OrderRepository
+	service.ReconciliationLog
+	service.SessionLog
+	outbox.Store
+	messaging.DeadLetterStore
+	routing.Store
+}
+
+// liveBroker is the broker live orders reach, which reports whether it can
+// take them.
+type liveBroker interface {
+	service.Broker
+	Ready(ctx context.Context) error
+}
+
 func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -24,25 +58,314 @@ func main() {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
 	addr := config.EnvOrDefault("EXECUTOR_ADDR", ":8081")
 	shutdownTimeout := config.DurationFromEnv("EXECUTOR_SHUTDOWN_TIMEOUT", 10*time.Second)
+	brokers := splitAndClean(os.Getenv("EXECUTOR_KAFKA_BROKERS"))
 
-	repo := repository.NewMemory()
-	svc := service.New(repo, nil)
-	handler := http.NewRouter(logger, svc)
+	opts := []service.Option{service.WithLogger(logger)}
+	var publisher *messaging.Publisher
+	if len(brokers) > 0 {
+		publisher = messaging.NewPublisher(messaging.NewKafkaWriter(brokers), nil)
+		defer publisher.Close()
+	}
 
+	var (
+		repo      orderStore
+		positions position.Reader
+	)
 	if dsn := os.Getenv("EXECUTOR_DATABASE_URL"); dsn != "" {
 		driverName := config.EnvOrDefault("EXECUTOR_DATABASE_DRIVER", "pgx")
+		database, err := sql.Open(driverName, dsn)
+		if err != nil {
+			logger.Error("failed to open database", "error", err)
+			os.Exit(1)
+		}
+		defer database.Close()
+
 		migrateCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 		defer cancel()
-
-		if err := platformdb.RunFromDSN(migrateCtx, driverName, dsn, migrations.Files, migrations.Dir); err != nil {
+		if err := platformdb.Run(migrateCtx, database, migrations.Files, migrations.Dir); err != nil {
 			logger.Error("failed to run database migrations", "error", err)
 			os.Exit(1)
 		}
 		logger.Info("database migrations applied")
+		// Executions book their positions in the transaction that records
+		// them.
+		repo = repository.NewPostgres(database, repository.WithPositions(instrument.Multiplier))
+		positions = repository.NewPostgresPositions(database)
+	} else {
+		logger.Warn("EXECUTOR_DATABASE_URL not set, using in-memory order repository")
+		memory := repository.NewMemory(repository.WithPositions(instrument.Multiplier))
+		repo, positions = memory, memory.Positions()
+	}
+
+	if publisher != nil {
+		// Order events reach Kafka through the outbox so they are published
+		// at least once and only after the state change is committed.
+		opts = append(opts, service.WithOutbox())
+		relay := outbox.NewRelay(repo, publisher,
+			outbox.WithLogger(logger),
+			outbox.WithMaxAttempts(config.IntFromEnv("EXECUTOR_OUTBOX_MAX_ATTEMPTS", 20)),
+			outbox.WithLease(config.DurationFromEnv("EXECUTOR_OUTBOX_LEASE", time.Minute)),
+			outbox.WithBackoff(
+				config.DurationFromEnv("EXECUTOR_OUTBOX_RETRY_BACKOFF", time.Second),
+				config.DurationFromEnv("EXECUTOR_OUTBOX_RETRY_MAX_BACKOFF", time.Minute)))
+		go relay.Run(ctx, config.DurationFromEnv("EXECUTOR_OUTBOX_INTERVAL", 500*time.Millisecond))
+	}
+
+	opts = append(opts, service.WithReconciliationLog(repo), service.WithSessionLog(repo),
+		service.WithUnconfirmedTimeout(config.DurationFromEnv("EXECUTOR_UNCONFIRMED_TIMEOUT", service.DefaultUnconfirmedTimeout)))
+
+	// Simulators fill against the ssi_ps depth, so every one of them
+	// follows the market data feed.
+	var simulators []*broker.Simulator
+	adapter := func(source, kind, idPrefix string) service.Broker {
+		switch kind {
+		case "simulator":
+			logger.Warn("SIMULATED BROKER: orders are matched in-process and never reach an exchange", "variable", source, "broker", idPrefix)
+			sim := broker.NewSimulator(nil, 0, broker.WithIDPrefix(idPrefix))
+			simulators = append(simulators, sim)
+			return sim
+		case "fix":
+			// Each FIX adapter reads its own gateway settings.
+			prefix := "EXECUTOR_FIX_"
+			switch source {
+			case "EXECUTOR_SECONDARY_BROKER":
+				prefix = "EXECUTOR_SECONDARY_FIX_"
+			case "EXECUTOR_BROKERS":
+				prefix = "EXECUTOR_FIX_" + idPrefix + "_"
+			}
+			cfg, err := fixConfig(prefix)
+			if err != nil {
+				logger.Error("invalid FIX gateway configuration", "variable", source, "error", err)
+				os.Exit(1)
+			}
+			gateway := broker.NewFIX(cfg, broker.WithFIXLogger(logger),
+				broker.WithFIXReconnect(config.DurationFromEnv(prefix+"RECONNECT_INTERVAL", 5*time.Second)))
+			go gateway.Run(ctx)
+			logger.Info("connecting to FIX gateway", "addr", cfg.Addr, "sender_comp_id", cfg.SenderCompID, "target_comp_id", cfg.TargetCompID)
+			return gateway
+		case "none":
+			return nil
+		default:
+			logger.Error("unsupported broker adapter", "variable", source, "broker", kind)
+			os.Exit(1)
+			return nil
+		}
+	}
+	resilient := func(primary, failover service.Broker) *broker.Resilient {
+		return broker.NewResilient(primary,
+			broker.WithRetry(
+				config.IntFromEnv("EXECUTOR_BROKER_MAX_ATTEMPTS", 3),
+				config.DurationFromEnv("EXECUTOR_BROKER_RETRY_BACKOFF", 100*time.Millisecond),
+				config.DurationFromEnv("EXECUTOR_BROKER_RETRY_MAX_BACKOFF", 2*time.Second)),
+			broker.WithCircuitBreaker(
+				config.IntFromEnv("EXECUTOR_BROKER_BREAKER_THRESHOLD", 5),
+				config.DurationFromEnv("EXECUTOR_BROKER_BREAKER_COOLDOWN", 30*time.Second)),
+			broker.WithFailover(failover),
+			broker.WithResilientLogger(logger))
+	}
+	var (
+		venue  liveBroker
+		router *routing.Router
+	)
+	if entries := splitAndClean(os.Getenv("EXECUTOR_BROKERS")); len(entries) > 0 {
+		// Each named broker gets its own retries and circuit breaker; the
+		// router fails over between them by rule.
+		var venues []routing.Venue
+		for _, entry := range entries {
+			name, kind, ok := strings.Cut(entry, "=")
+			name, kind = strings.TrimSpace(name), strings.TrimSpace(kind)
+			if !ok || name == "" || kind == "none" {
+				logger.Error("invalid broker entry, expected name=adapter", "variable", "EXECUTOR_BROKERS", "entry", entry)
+				os.Exit(1)
+			}
+			venues = append(venues, routing.Venue{Name: name, Broker: resilient(adapter("EXECUTOR_BROKERS", kind, strings.ToUpper(name)), nil)})
+		}
+		router = routing.NewRouter(repo, venues,
+			routing.WithMarginRequirement(instrument.MarginRequirement(config.FloatFromEnv("EXECUTOR_ROUTING_MARGIN_RATE", 0.17))),
+			routing.WithLogger(logger))
+		loadCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := router.Load(loadCtx)
+		cancel()
+		if err != nil {
+			logger.Error("failed to load routing rules", "error", err)
+			os.Exit(1)
+		}
+		go router.Run(ctx, config.DurationFromEnv("EXECUTOR_ROUTING_RELOAD_INTERVAL", 30*time.Second))
+		venue = router
+		opts = append(opts, service.WithBroker(router), service.WithOrderRouter(router))
+		logger.Info("routing orders across brokers", "brokers", len(venues), "rules", len(router.Rules()))
+	} else if kind := os.Getenv("EXECUTOR_BROKER"); kind == "" {
+		// No default: a live deployment missing its setting must not quietly
+		// trade against the simulator.
+		logger.Error("EXECUTOR_BROKER is required: set it to fix, simulator or none")
+		os.Exit(1)
+	} else if primary := adapter("EXECUTOR_BROKER", kind, "SIM"); primary != nil {
+		venue = resilient(primary, adapter("EXECUTOR_SECONDARY_BROKER", config.EnvOrDefault("EXECUTOR_SECONDARY_BROKER", "none"), "SIMB"))
+		opts = append(opts, service.WithBroker(venue))
+	} else {
+		logger.Warn("no broker configured, orders will rest in the routed state")
+	}
+
+	modes, err := executionModes()
+	if err != nil {
+		logger.Error("invalid execution mode configuration", "error", err)
+		os.Exit(1)
+	}
+	// Paper orders never reach the live broker: they fill against the same
+	// market data in a simulator of their own.
+	paper := broker.NewSimulator(nil, 0, broker.WithIDPrefix("PAPER"))
+	opts = append(opts, service.WithExecutionModes(modes), service.WithPaperBroker(paper))
+
+	var calendar *session.Calendar
+	switch name := config.EnvOrDefault("EXECUTOR_SESSION_CALENDAR", "hose"); name {
+	case "hose":
+		calendar = session.HOSEDerivatives()
+		opts = append(opts, service.WithSessionClock(calendar), service.WithTradingSessions(calendar))
+	case "none":
+		logger.Warn("no session calendar configured, intents are accepted and algorithm slices scheduled around the clock")
+	default:
+		logger.Error("unsupported session calendar", "calendar", name)
+		os.Exit(1)
+	}
+
+	bands := instrument.NewBands()
+	opts = append(opts, service.WithInstrumentRules(instrument.NewHOSEDerivatives(bands)))
+
+	fees := feeSchedule()
+	if err := fees.Validate(); err != nil {
+		logger.Error("invalid fee schedule", "error", err)
+		os.Exit(1)
+	}
+	opts = append(opts, service.WithFeeModel(instrument.NewDerivativesFees(fees)))
+
+	limits, err := rateLimitPolicy()
+	if err != nil {
+		logger.Error("invalid rate limit configuration", "error", err)
+		os.Exit(1)
+	}
+	opts = append(opts, service.WithRateLimits(limits))
+
+	if riskURL := os.Getenv("EXECUTOR_RISK_URL"); riskURL != "" {
+		policy := service.RiskPolicy{
+			Timeout:  config.DurationFromEnv("EXECUTOR_RISK_TIMEOUT", 2*time.Second),
+			FailOpen: config.BoolFromEnv("EXECUTOR_RISK_FAIL_OPEN", false),
+		}
+		opts = append(opts, service.WithRiskChecker(risk.NewHTTPClient(riskURL, nil), policy))
+		logger.Info("pre-trade risk checks enabled", "url", riskURL, "timeout", policy.Timeout.String(), "fail_open", policy.FailOpen)
 	} else {
-		logger.Warn("EXECUTOR_DATABASE_URL not set, skipping database migrations")
+		logger.Warn("EXECUTOR_RISK_URL not set, skipping pre-trade risk checks")
+	}
+
+	// The order stream holds open connections; closing the hub on shutdown
+	// ends them so the HTTP server can drain.
+	hub := stream.NewHub(config.IntFromEnv("EXECUTOR_STREAM_REPLAY_SIZE", stream.DefaultReplaySize))
+	go func() {
+		<-ctx.Done()
+		hub.Close()
+	}()
+	opts = append(opts, service.WithOrderUpdates(hub))
+
+	// gRPC event streams are fed from the same lifecycle events as Kafka and
+	// end on shutdown like the order stream.
+	grpcEvents := grpc.NewEvents(nil)
+	go func() {
+		<-ctx.Done()
+		grpcEvents.Close()
+	}()
+	opts = append(opts, service.WithEventPublisher(grpcEvents))
+
+	svc := service.New(repo, nil, opts...)
+	routerOpts := []http.RouterOption{http.WithPositions(positions), http.WithOrderStream(hub)}
+
+	sweepInterval := config.DurationFromEnv("EXECUTOR_EXPIRY_SWEEP_INTERVAL", 5*time.Second)
+	go service.RunExpirySweeper(ctx, svc, sweepInterval, logger)
+	if calendar != nil {
+		go service.RunSessionCloser(ctx, svc, calendar, logger)
+	}
+	go service.RunChildScheduler(ctx, svc, config.DurationFromEnv("EXECUTOR_ALGO_INTERVAL", time.Second), logger)
+
+	go service.ProcessFills(ctx, paper.Fills(), svc, logger)
+	if router != nil {
+		routerOpts = append(routerOpts, http.WithRouting(router))
+	}
+	if venue != nil {
+		routerOpts = append(routerOpts, http.WithReadinessCheck("broker", venue.Ready))
+		go service.ProcessFills(ctx, venue.Fills(), svc, logger)
+		go service.RunReconciler(ctx, svc, config.DurationFromEnv("EXECUTOR_RECONCILE_INTERVAL", time.Minute), logger)
 	}
 
+	if len(brokers) > 0 {
+		topic := config.EnvOrDefault("EXECUTOR_MARKETDATA_TOPIC", "ssi_ps")
+		reader, err := messaging.NewSnapshotReader(brokers, topic)
+		if err != nil {
+			logger.Error("failed to init market data reader", "error", err)
+			os.Exit(1)
+		}
+		snapshots := messaging.NewSnapshotConsumer(reader, func(snapshot *marketsv1.SsiPsSnapshot) {
+			bands.OnSnapshot(snapshot)
+			paper.OnSnapshot(snapshot)
+			for _, sim := range simulators {
+				sim.OnSnapshot(snapshot)
+			}
+		}, logger)
+		defer snapshots.Close()
+		go func() {
+			if err := snapshots.Run(ctx); err != nil {
+				logger.Error("market data consumer exited with error", "error", err)
+			}
+		}()
+		logger.Info("following market data", "topic", topic)
+	} else {
+		logger.Warn("EXECUTOR_KAFKA_BROKERS not set, price bands are not enforced")
+	}
+
+	if len(brokers) > 0 {
+		dlqWriter := messaging.NewKafkaWriter(brokers)
+		defer dlqWriter.Close()
+		consumer, err := newIntentConsumer(ctx, brokers, svc, logger,
+			messaging.WithRetry(
+				config.IntFromEnv("EXECUTOR_INTENT_MAX_ATTEMPTS", 3),
+				config.DurationFromEnv("EXECUTOR_INTENT_RETRY_BACKOFF", 100*time.Millisecond),
+				config.DurationFromEnv("EXECUTOR_INTENT_RETRY_MAX_BACKOFF", 2*time.Second)),
+			messaging.WithDeadLetters(dlqWriter, repo))
+		if err != nil {
+			logger.Error("failed to init intent consumer", "error", err)
+			os.Exit(1)
+		}
+		if consumer != nil {
+			defer consumer.Close()
+			routerOpts = append(routerOpts, http.WithDeadLetters(consumer))
+			go func() {
+				if err := consumer.Run(ctx); err != nil {
+					logger.Error("intent consumer exited with error", "error", err)
+					stop()
+				}
+			}()
+		}
+	} else {
+		logger.Warn("EXECUTOR_KAFKA_BROKERS not set, skipping Kafka intent consumer")
+	}
+
+	grpcAddr := config.EnvOrDefault("EXECUTOR_GRPC_ADDR", ":9090")
+	grpcListener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		logger.Error("failed to listen for gRPC", "addr", grpcAddr, "error", err)
+		os.Exit(1)
+	}
+	grpcServer := grpc.NewGRPCServer(logger, svc, grpcEvents)
+	go func() {
+		logger.Info("serving gRPC", "addr", grpcListener.Addr().String())
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			logger.Error("gRPC server exited with error", "error", err)
+			stop()
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		grpcServer.GracefulStop()
+	}()
+
+	handler := http.NewRouter(logger, svc, routerOpts...)
 	if err := server.Run(ctx, handler, server.Config{Addr: addr, ShutdownTimeout: shutdownTimeout}, logger); err != nil {
 		logger.Error("executor service exited with error", "error", err)
 		os.Exit(1)
@@ -50,3 +373,126 @@ func main() {
 
 	logger.Info("executor service stopped")
 }
+
+// newIntentConsumer subscribes to the configured intent topics, discovering
+// orders.intent.* topics on the cluster when none are listed explicitly.
+func newIntentConsumer(ctx context.Context, brokers []string, svc service.Service, logger *slog.Logger, opts ...messaging.ConsumerOption) (*messaging.Consumer, error) {
+	topics := splitAndClean(os.Getenv("EXECUTOR_INTENT_TOPICS"))
+	if len(topics) == 0 {
+		discoverCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		discovered, err := messaging.DiscoverIntentTopics(discoverCtx, brokers[0])
+		if err != nil {
+			return nil, err
+		}
+		topics = discovered
+	}
+	if len(topics) == 0 {
+		logger.Warn("no order intent topics found, skipping Kafka intent consumer")
+		return nil, nil
+	}
+
+	groupID := config.EnvOrDefault("EXECUTOR_KAFKA_GROUP", "executor")
+	reader, err := messaging.NewKafkaReader(messaging.ReaderConfig{Brokers: brokers, Topics: topics, GroupID: groupID})
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("intent consumer started", "topics", len(topics), "group", groupID)
+	return messaging.NewConsumer(reader, svc, logger, opts...), nil
+}
+
+// rateLimitPolicy reads the default per-bot and per-account limits, with
+// per-id overrides as JSON in EXECUTOR_RATE_LIMIT_OVERRIDES, for example
+// {"bots":{"scalper":{"orders_per_second":20}}}.
+func rateLimitPolicy() (service.RateLimitPolicy, error) {
+	fromEnv := func(prefix string) service.RateLimits {
+		return service.RateLimits{
+			OrdersPerSecond:   config.FloatFromEnv(prefix+"_ORDERS_PER_SECOND", 0),
+			OrderBurst:        config.IntFromEnv(prefix+"_ORDER_BURST", 0),
+			MaxOpenOrders:     config.IntFromEnv(prefix+"_MAX_OPEN_ORDERS", 0),
+			MessagesPerMinute: config.FloatFromEnv(prefix+"_MESSAGES_PER_MINUTE", 0),
+		}
+	}
+	policy := service.RateLimitPolicy{
+		Bot:     fromEnv("EXECUTOR_BOT"),
+		Account: fromEnv("EXECUTOR_ACCOUNT"),
+	}
+	if raw := os.Getenv("EXECUTOR_RATE_LIMIT_OVERRIDES"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+			return policy, fmt.Errorf("EXECUTOR_RATE_LIMIT_OVERRIDES: %w", err)
+		}
+	}
+	return policy, nil
+}
+
+// feeSchedule reads the fill charges, defaulting to the published exchange
+// and depository fees.
+func feeSchedule() instrument.FeeSchedule {
+	defaults := instrument.DefaultFeeSchedule()
+	return instrument.FeeSchedule{
+		Commission:    config.FloatFromEnv("EXECUTOR_FEE_COMMISSION", defaults.Commission),
+		ExchangeFee:   config.FloatFromEnv("EXECUTOR_FEE_EXCHANGE", defaults.ExchangeFee),
+		ClearingFee:   config.FloatFromEnv("EXECUTOR_FEE_CLEARING", defaults.ClearingFee),
+		VSDFee:        config.FloatFromEnv("EXECUTOR_FEE_VSD", defaults.VSDFee),
+		IncomeTaxRate: config.FloatFromEnv("EXECUTOR_INCOME_TAX_RATE", defaults.IncomeTaxRate),
+	}
+}
+
+// fixConfig reads the FIX gateway settings under prefix.
+func fixConfig(prefix string) (broker.FIXConfig, error) {
+	cfg := broker.FIXConfig{
+		Addr:           os.Getenv(prefix + "ADDR"),
+		SenderCompID:   os.Getenv(prefix + "SENDER_COMP_ID"),
+		TargetCompID:   os.Getenv(prefix + "TARGET_COMP_ID"),
+		Account:        os.Getenv(prefix + "ACCOUNT"),
+		HeartBtInt:     config.DurationFromEnv(prefix+"HEARTBEAT", 30*time.Second),
+		RequestTimeout: config.DurationFromEnv(prefix+"REQUEST_TIMEOUT", 10*time.Second),
+		SeqFile:        os.Getenv(prefix + "SEQ_FILE"),
+	}
+	for _, required := range []struct{ name, value string }{
+		{"ADDR", cfg.Addr},
+		{"SENDER_COMP_ID", cfg.SenderCompID},
+		{"TARGET_COMP_ID", cfg.TargetCompID},
+	} {
+		if required.value == "" {
+			return cfg, fmt.Errorf("%s%s is required", prefix, required.name)
+		}
+	}
+	return cfg, nil
+}
+
+// executionModes reads the default execution mode and the bots and accounts
+// listed as trading live or on paper.
+func executionModes() (service.ExecutionModes, error) {
+	modes := service.ExecutionModes{
+		Default:  service.Mode(config.EnvOrDefault("EXECUTOR_EXECUTION_MODE", string(service.ModeLive))),
+		Bots:     map[string]service.Mode{},
+		Accounts: map[string]service.Mode{},
+	}
+	for _, list := range []struct {
+		env    string
+		target map[string]service.Mode
+		mode   service.Mode
+	}{
+		{"EXECUTOR_LIVE_BOTS", modes.Bots, service.ModeLive},
+		{"EXECUTOR_LIVE_ACCOUNTS", modes.Accounts, service.ModeLive},
+		{"EXECUTOR_PAPER_BOTS", modes.Bots, service.ModePaper},
+		{"EXECUTOR_PAPER_ACCOUNTS", modes.Accounts, service.ModePaper},
+	} {
+		for _, id := range splitAndClean(os.Getenv(list.env)) {
+			list.target[id] = list.mode
+		}
+	}
+	return modes, modes.Validate()
+}
+
+func splitAndClean(csv string) []string {
+	parts := strings.Split(csv, ",")
+	cleaned := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if v := strings.TrimSpace(p); v != "" {
+			cleaned = append(cleaned, v)
+		}
+	}
+	return cleaned
+}
@@ -2,6 +2,29 @@ module github.com/future-bots/executor
 
 go 1.22.2
 
-require github.com/future-bots/platform v0.0.0
+require (
+	github.com/future-bots/platform v0.0.0
+	github.com/future-bots/proto v0.0.0
+	github.com/jackc/pgx/v5 v5.5.5
+	github.com/segmentio/kafka-go v0.4.43
+	google.golang.org/grpc v1.64.0
+	google.golang.org/protobuf v1.34.1
+)
+
+require (
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
+	github.com/jackc/puddle/v2 v2.2.1 // indirect
+	github.com/klauspost/compress v1.15.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	golang.org/x/crypto v0.21.0 // indirect
+	golang.org/x/net v0.22.0 // indirect
+	golang.org/x/sync v0.6.0 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
+)
 
 replace github.com/future-bots/platform => ../../libs/go/platform
+
+replace github.com/future-bots/proto => ../../proto/gen/go
@@ -0,0 +1,760 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/future-bots/executor/internal/fix"
+	"github.com/future-bots/executor/internal/service"
+)
+
+// ErrFIXReject is wrapped by the errors of requests the FIX gateway refused:
+// rejected orders, cancel and replace rejects, and session-level rejects.
+var ErrFIXReject = errors.New("FIX request rejected")
+
+// FIXConfig connects a FIX adapter to a broker's FIX 4.4 gateway.
+type FIXConfig struct {
+	// Addr is the gateway's host:port.
+	Addr         string
+	SenderCompID string
+	TargetCompID string
+	// Account is sent in tag 1 of every order when set.
+	Account string
+	// HeartBtInt is the heartbeat interval proposed at logon; 30s when zero.
+	HeartBtInt time.Duration
+	// RequestTimeout bounds the wait for the gateway's answer to a request;
+	// 10s when zero.
+	RequestTimeout time.Duration
+	// SeqFile keeps the session's sequence numbers across restarts, so the
+	// gateway resends the reports missed while the executor was down. When
+	// empty, the first logon of each process resets the sequences.
+	SeqFile string
+}
+
+// FIXOption customises a FIX adapter.
+type FIXOption func(*FIX)
+
+// WithFIXLogger reports connections and unexpected reports to logger.
+func WithFIXLogger(logger *slog.Logger) FIXOption {
+	return func(f *FIX) {
+		if logger != nil {
+			f.logger = logger
+		}
+	}
+}
+
+// WithFIXDialer replaces the TCP dialer, for TLS or tests.
+func WithFIXDialer(dial func(ctx context.Context, network, addr string) (net.Conn, error)) FIXOption {
+	return func(f *FIX) {
+		if dial != nil {
+			f.dial = dial
+		}
+	}
+}
+
+// WithFIXReconnect sets the wait between connection attempts; 5s by default.
+func WithFIXReconnect(wait time.Duration) FIXOption {
+	return func(f *FIX) {
+		if wait > 0 {
+			f.reconnect = wait
+		}
+	}
+}
+
+// WithFIXClock replaces time.Now for transact times.
+func WithFIXClock(now func() time.Time) FIXOption {
+	return func(f *FIX) {
+		if now != nil {
+			f.now = now
+		}
+	}
+}
+
+// fixOrder is the adapter's view of one order at the gateway.
+type fixOrder struct {
+	// clOrdID is the ClOrdID of the last request the gateway accepted.
+	clOrdID string
+	// requests counts the cancel and replace requests sent, numbering
+	// their ClOrdIDs.
+	requests int
+	side     string
+	// ordStatus is the OrdStatus of the last report on the order.
+	ordStatus string
+	// ordType and stopPx are sent again with replace requests, so an
+	// amended stop stays a stop.
+	ordType string
+	stopPx  float64
+	state   service.BrokerOrderState
+	fills   []service.BrokerFill
+}
+
+// waiter collects the answers to one request. Mass status requests are
+// answered by several reports, the last flagged LastRptRequested.
+type waiter struct {
+	reports []*fix.Message
+	done    chan struct{}
+}
+
+// FIX is a service.Broker speaking FIX 4.4 to a broker's gateway as the
+// initiator. Run keeps the session connected; while it is logged off, new
+// requests fail with service.ErrBrokerUnavailable. Messages missed while
+// disconnected are recovered by resend requests when it logs on again.
+//
+// Orders are sent as NewOrderSingle with the executor order id as ClOrdID,
+// and the gateway's OrderID becomes the provider order id. Cancels and
+// amends are OrderCancelRequest and OrderCancelReplaceRequest, queries
+// OrderStatusRequest and open orders an OrderMassStatusRequest. Trade
+// execution reports are streamed as fills.
+type FIX struct {
+	cfg       FIXConfig
+	session   *fix.Session
+	dial      func(ctx context.Context, network, addr string) (net.Conn, error)
+	reconnect time.Duration
+	now       func() time.Time
+	logger    *slog.Logger
+	fills     chan service.BrokerFill
+
+	mu     sync.Mutex
+	orders map[string]*fixOrder
+	// byClOrdID finds orders by any ClOrdID sent for them.
+	byClOrdID map[string]*fixOrder
+	// waiters are keyed by ClOrdID, OrdStatusReqID or MassStatusReqID, and
+	// bySeq maps the MsgSeqNum of each request to its key for rejects.
+	waiters map[string]*waiter
+	bySeq   map[int]string
+	reqSeq  int
+}
+
+// NewFIX constructs a FIX adapter; call Run to connect it.
+func NewFIX(cfg FIXConfig, opts ...FIXOption) *FIX {
+	if cfg.HeartBtInt <= 0 {
+		cfg.HeartBtInt = 30 * time.Second
+	}
+	if cfg.RequestTimeout <= 0 {
+		cfg.RequestTimeout = 10 * time.Second
+	}
+	var dialer net.Dialer
+	f := &FIX{
+		cfg:       cfg,
+		dial:      dialer.DialContext,
+		reconnect: 5 * time.Second,
+		now:       time.Now,
+		logger:    slog.Default(),
+		fills:     make(chan service.BrokerFill, 1024),
+		orders:    make(map[string]*fixOrder),
+		byClOrdID: make(map[string]*fixOrder),
+		waiters:   make(map[string]*waiter),
+		bySeq:     make(map[int]string),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	// Without stored sequences the process has no history to resume, so the
+	// first logon resets both sequences; reconnects resume them.
+	sessionOpts := []fix.Option{fix.WithLogger(f.logger), fix.WithClock(f.now)}
+	if cfg.SeqFile != "" {
+		sessionOpts = append(sessionOpts, fix.WithSeqStore(fix.NewFileSeqStore(cfg.SeqFile)))
+	}
+	f.session = fix.NewSession(fix.Config{
+		SenderCompID: cfg.SenderCompID,
+		TargetCompID: cfg.TargetCompID,
+		HeartBtInt:   cfg.HeartBtInt,
+		ResetSeqNum:  true,
+	}, f.handle, sessionOpts...)
+	return f
+}
+
+// Run connects to the gateway and logs on, reconnecting whenever the
+// connection ends, until ctx is cancelled.
+func (f *FIX) Run(ctx context.Context) {
+	for {
+		conn, err := f.dial(ctx, "tcp", f.cfg.Addr)
+		if err == nil {
+			err = f.session.Initiate(ctx, conn)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		f.logger.Warn("FIX session ended, reconnecting", "addr", f.cfg.Addr, "error", err, "retry_in", f.reconnect.String())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(f.reconnect):
+		}
+	}
+}
+
+// Ready returns nil while the session is logged on.
+func (f *FIX) Ready(context.Context) error {
+	if !f.session.LoggedOn() {
+		return fmt.Errorf("%s: %w", f.cfg.Addr, f.unavailable())
+	}
+	return nil
+}
+
+// Place implements service.Broker, returning once the gateway acknowledges
+// the order.
+func (f *FIX) Place(ctx context.Context, order service.Order) (service.BrokerAck, error) {
+	side, err := fixSide(order.Side)
+	if err != nil {
+		return service.BrokerAck{}, service.BrokerRejection{Reason: err.Error()}
+	}
+	if order.Quantity <= 0 {
+		return service.BrokerAck{}, service.BrokerRejection{Reason: "quantity must be greater than zero"}
+	}
+	msg := fix.NewMessage(fix.MsgTypeNewOrderSingle).
+		Set(fix.TagClOrdID, order.ID).
+		Set(fix.TagSymbol, order.Symbol).
+		Set(fix.TagSide, side).
+		SetTime(fix.TagTransactTime, f.now()).
+		SetFloat(fix.TagOrderQty, order.Quantity).
+		Set(fix.TagOrdType, fixOrdType(order)).
+		Set(fix.TagTimeInForce, fixTimeInForce(order.TimeInForce))
+	if f.cfg.Account != "" {
+		msg.Set(fix.TagAccount, f.cfg.Account)
+	}
+	if order.Price > 0 {
+		msg.SetFloat(fix.TagPrice, order.Price)
+	}
+	if order.Type == service.OrderTypeStop {
+		msg.SetFloat(fix.TagStopPx, order.StopPrice)
+	}
+
+	reports, err := f.request(ctx, order.ID, msg)
+	if err != nil {
+		return service.BrokerAck{}, fmt.Errorf("place order %s: %w", order.ID, err)
+	}
+	report := reports[0]
+	if report.MsgType() != fix.MsgTypeExecutionReport || report.Get(fix.TagExecType) == execTypeRejected {
+		return service.BrokerAck{}, fmt.Errorf("place order %s: %w: %w", order.ID, ErrFIXReject, service.BrokerRejection{Reason: reason(report)})
+	}
+	providerOrderID := report.Get(fix.TagOrderID)
+	f.mu.Lock()
+	if o := f.orders[providerOrderID]; o != nil {
+		o.ordType = msg.Get(fix.TagOrdType)
+		if order.Type == service.OrderTypeStop {
+			o.stopPx = order.StopPrice
+		}
+	}
+	f.mu.Unlock()
+	return service.BrokerAck{ProviderOrderID: providerOrderID, AcceptedAt: f.now().UTC()}, nil
+}
+
+// Cancel implements service.Broker. The gateway may take a cancel as pending
+// and confirm it later; until it reports the order canceled, Cancel returns
+// service.ErrCancelPending and does not send the request again.
+func (f *FIX) Cancel(ctx context.Context, providerOrderID string) error {
+	o, clOrdID, err := f.next(ctx, providerOrderID)
+	if err != nil {
+		return err
+	}
+	if o.ordStatus == ordStatusPendingCancel {
+		return fmt.Errorf("cancel order %s: %w", providerOrderID, service.ErrCancelPending)
+	}
+	msg := fix.NewMessage(fix.MsgTypeOrderCancelRequest).
+		Set(fix.TagOrigClOrdID, o.origClOrdID).
+		Set(fix.TagClOrdID, clOrdID).
+		Set(fix.TagOrderID, providerOrderID).
+		Set(fix.TagSymbol, o.state.Symbol).
+		Set(fix.TagSide, o.side).
+		SetTime(fix.TagTransactTime, f.now()).
+		SetFloat(fix.TagOrderQty, o.state.Quantity)
+	if f.cfg.Account != "" {
+		msg.Set(fix.TagAccount, f.cfg.Account)
+	}
+	reports, err := f.request(ctx, clOrdID, msg)
+	if err != nil {
+		return fmt.Errorf("cancel order %s: %w", providerOrderID, err)
+	}
+	report := reports[0]
+	if err := f.answer(report, providerOrderID, "cancel"); err != nil {
+		return err
+	}
+	switch status := report.Get(fix.TagOrdStatus); {
+	case status == ordStatusCanceled:
+		return nil
+	case workingStatus(status):
+		return fmt.Errorf("cancel order %s: OrdStatus %s: %w", providerOrderID, status, service.ErrCancelPending)
+	default:
+		return fmt.Errorf("cancel order %s: OrdStatus %s: %w", providerOrderID, status, ErrOrderClosed)
+	}
+}
+
+// Amend implements service.Broker with an OrderCancelReplaceRequest for the
+// new price and total quantity. The order keeps its OrdType and StopPx; a
+// price of zero sends none, as for a market or stop order.
+func (f *FIX) Amend(ctx context.Context, providerOrderID string, price, quantity float64) error {
+	o, clOrdID, err := f.next(ctx, providerOrderID)
+	if err != nil {
+		return err
+	}
+	ordType := o.ordType
+	if ordType == "" {
+		// Learned from a report without OrdType, such as a mass status
+		// after a restart.
+		ordType = ordTypeMarket
+		if price > 0 {
+			ordType = ordTypeLimit
+		}
+	}
+	msg := fix.NewMessage(fix.MsgTypeOrderCancelReplaceRequest).
+		Set(fix.TagOrigClOrdID, o.origClOrdID).
+		Set(fix.TagClOrdID, clOrdID).
+		Set(fix.TagOrderID, providerOrderID).
+		Set(fix.TagSymbol, o.state.Symbol).
+		Set(fix.TagSide, o.side).
+		SetTime(fix.TagTransactTime, f.now()).
+		SetFloat(fix.TagOrderQty, quantity).
+		Set(fix.TagOrdType, ordType)
+	if price > 0 {
+		msg.SetFloat(fix.TagPrice, price)
+	}
+	if (ordType == ordTypeStop || ordType == ordTypeStopLimit) && o.stopPx > 0 {
+		msg.SetFloat(fix.TagStopPx, o.stopPx)
+	}
+	if f.cfg.Account != "" {
+		msg.Set(fix.TagAccount, f.cfg.Account)
+	}
+	reports, err := f.request(ctx, clOrdID, msg)
+	if err != nil {
+		return fmt.Errorf("amend order %s: %w", providerOrderID, err)
+	}
+	return f.answer(reports[0], providerOrderID, "amend")
+}
+
+// Query implements service.Broker with an OrderStatusRequest.
+func (f *FIX) Query(ctx context.Context, providerOrderID string) (service.BrokerOrderState, error) {
+	o, err := f.lookup(ctx, providerOrderID)
+	if err != nil {
+		return service.BrokerOrderState{}, err
+	}
+	id := f.requestID("STATUS")
+	msg := fix.NewMessage(fix.MsgTypeOrderStatusRequest).
+		Set(fix.TagOrdStatusReqID, id).
+		Set(fix.TagClOrdID, o.clOrdID).
+		Set(fix.TagOrderID, providerOrderID).
+		Set(fix.TagSymbol, o.state.Symbol).
+		Set(fix.TagSide, o.side)
+	reports, err := f.request(ctx, id, msg)
+	if err != nil {
+		return service.BrokerOrderState{}, fmt.Errorf("query order %s: %w", providerOrderID, err)
+	}
+	if report := reports[0]; report.MsgType() != fix.MsgTypeExecutionReport {
+		return service.BrokerOrderState{}, fmt.Errorf("query order %s: %w: %s", providerOrderID, ErrFIXReject, reason(report))
+	} else if report.Get(fix.TagOrdStatus) == ordStatusRejected {
+		return service.BrokerOrderState{}, fmt.Errorf("query order %s: %w", providerOrderID, service.ErrUnknownBrokerOrder)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.orders[providerOrderID].state, nil
+}
+
+// Fills implements service.Broker.
+func (f *FIX) Fills() <-chan service.BrokerFill {
+	return f.fills
+}
+
+// OpenOrders implements service.Broker with an OrderMassStatusRequest for
+// every order of the session. Orders it reports that the adapter did not
+// know, such as those placed before a restart, are remembered.
+func (f *FIX) OpenOrders(ctx context.Context) ([]service.BrokerOrderState, error) {
+	id := f.requestID("MASS")
+	msg := fix.NewMessage(fix.MsgTypeOrderMassStatusRequest).
+		Set(fix.TagMassStatusReqID, id).
+		Set(fix.TagMassStatusReqType, massStatusAllOrders)
+	if f.cfg.Account != "" {
+		msg.Set(fix.TagAccount, f.cfg.Account)
+	}
+	reports, err := f.request(ctx, id, msg)
+	if err != nil {
+		return nil, fmt.Errorf("list open orders: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]service.BrokerOrderState, 0, len(reports))
+	for _, report := range reports {
+		if o := f.orders[report.Get(fix.TagOrderID)]; o != nil && o.state.Open {
+			out = append(out, o.state)
+		}
+	}
+	return out, nil
+}
+
+// Executions implements service.Broker with the fills reported since the
+// adapter started, including those the gateway resends after a restart when
+// the sequences are stored. FIX has no execution query, so without a
+// SeqFile fills of orders learned from a mass status after a restart are
+// not listed.
+func (f *FIX) Executions(ctx context.Context, providerOrderID string) ([]service.BrokerFill, error) {
+	if _, err := f.lookup(ctx, providerOrderID); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fills := f.orders[providerOrderID].fills
+	out := make([]service.BrokerFill, len(fills))
+	copy(out, fills)
+	return out, nil
+}
+
+// lookup returns a copy of the order, asking the gateway for its orders when
+// the adapter does not know it.
+func (f *FIX) lookup(ctx context.Context, providerOrderID string) (fixOrder, error) {
+	f.mu.Lock()
+	o, ok := f.orders[providerOrderID]
+	f.mu.Unlock()
+	if !ok {
+		if _, err := f.OpenOrders(ctx); err != nil {
+			return fixOrder{}, err
+		}
+		f.mu.Lock()
+		o, ok = f.orders[providerOrderID]
+		f.mu.Unlock()
+		if !ok {
+			return fixOrder{}, service.ErrUnknownBrokerOrder
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return *o, nil
+}
+
+// pendingOrder is an order about to be cancelled or replaced.
+type pendingOrder struct {
+	fixOrder
+	origClOrdID string
+}
+
+// next returns the order and a fresh ClOrdID for a cancel or replace
+// request: the executor order id with a request number.
+func (f *FIX) next(ctx context.Context, providerOrderID string) (pendingOrder, string, error) {
+	if _, err := f.lookup(ctx, providerOrderID); err != nil {
+		return pendingOrder{}, "", err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	o := f.orders[providerOrderID]
+	if !o.state.Open {
+		return pendingOrder{}, "", ErrOrderClosed
+	}
+	o.requests++
+	clOrdID := o.state.ClientOrderID + "." + strconv.Itoa(o.requests)
+	f.byClOrdID[clOrdID] = o
+	return pendingOrder{fixOrder: *o, origClOrdID: o.clOrdID}, clOrdID, nil
+}
+
+// answer turns the gateway's answer to a cancel or replace into an error.
+func (f *FIX) answer(report *fix.Message, providerOrderID, action string) error {
+	if report.MsgType() != fix.MsgTypeOrderCancelReject && report.MsgType() != fix.MsgTypeReject &&
+		report.MsgType() != fix.MsgTypeBusinessMessageReject {
+		return nil
+	}
+	if report.Get(fix.TagCxlRejReason) == cxlRejUnknownOrder {
+		return fmt.Errorf("%s order %s: %w", action, providerOrderID, service.ErrUnknownBrokerOrder)
+	}
+	return fmt.Errorf("%s order %s: %w: %s", action, providerOrderID, ErrFIXReject, reason(report))
+}
+
+func (f *FIX) requestID(prefix string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reqSeq++
+	return fmt.Sprintf("%s-%d-%d", prefix, f.now().UnixNano(), f.reqSeq)
+}
+
+// request sends msg and waits for the reports answering key. A request the
+// session could not send never reached the gateway and fails with
+// service.ErrBrokerUnavailable; one left unanswered fails with
+// context.DeadlineExceeded, as it may have been acted on.
+func (f *FIX) request(ctx context.Context, key string, msg *fix.Message) ([]*fix.Message, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.cfg.RequestTimeout)
+	defer cancel()
+
+	w := &waiter{done: make(chan struct{})}
+	f.mu.Lock()
+	if _, dup := f.waiters[key]; dup {
+		f.mu.Unlock()
+		return nil, fmt.Errorf("request %s already in flight", key)
+	}
+	f.waiters[key] = w
+	// The lock is held while sending so a reject cannot arrive before its
+	// MsgSeqNum is known.
+	err := f.session.Send(msg)
+	seq, _ := msg.Int(fix.TagMsgSeqNum)
+	if err == nil {
+		f.bySeq[seq] = key
+	}
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		delete(f.waiters, key)
+		delete(f.bySeq, seq)
+		f.mu.Unlock()
+	}()
+	if errors.Is(err, fix.ErrNotLoggedOn) {
+		return nil, f.unavailable()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-w.done:
+		return w.reports, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no answer from FIX gateway: %w", ctx.Err())
+	}
+}
+
+func (f *FIX) unavailable() error {
+	return fmt.Errorf("%w: %w", fix.ErrNotLoggedOn, service.ErrBrokerUnavailable)
+}
+
+// handle applies the gateway's application messages.
+func (f *FIX) handle(msg *fix.Message) {
+	switch msg.MsgType() {
+	case fix.MsgTypeExecutionReport:
+		f.executionReport(msg)
+	case fix.MsgTypeOrderCancelReject:
+		f.mu.Lock()
+		f.deliverLocked(msg.Get(fix.TagClOrdID), msg, true)
+		f.mu.Unlock()
+	case fix.MsgTypeReject, fix.MsgTypeBusinessMessageReject:
+		seq, _ := msg.Int(fix.TagRefSeqNum)
+		f.mu.Lock()
+		key, ok := f.bySeq[seq]
+		if ok {
+			f.deliverLocked(key, msg, true)
+		}
+		f.mu.Unlock()
+		if !ok {
+			f.logger.Warn("FIX reject of an unknown request", "ref_seq_num", seq, "text", msg.Get(fix.TagText))
+		}
+	default:
+		f.logger.Warn("unexpected FIX message", "msg_type", msg.MsgType())
+	}
+}
+
+func (f *FIX) executionReport(report *fix.Message) {
+	f.mu.Lock()
+	if id := report.Get(fix.TagMassStatusReqID); id != "" {
+		f.updateLocked(report)
+		f.deliverLocked(id, report, report.Bool(fix.TagLastRptRequested) || report.Get(fix.TagTotNumReports) == "0")
+		f.mu.Unlock()
+		return
+	}
+	if id := report.Get(fix.TagOrdStatusReqID); id != "" {
+		f.updateLocked(report)
+		f.deliverLocked(id, report, true)
+		f.mu.Unlock()
+		return
+	}
+	o := f.updateLocked(report)
+	var fill *service.BrokerFill
+	if report.Get(fix.TagExecType) == execTypeTrade && o != nil {
+		fill = f.fillLocked(o, report)
+	}
+	f.deliverLocked(report.Get(fix.TagClOrdID), report, true)
+	f.mu.Unlock()
+
+	if fill != nil {
+		f.fills <- *fill
+	}
+}
+
+// updateLocked records the order state a report carries and returns the
+// order, nil when the report names none.
+func (f *FIX) updateLocked(report *fix.Message) *fixOrder {
+	orderID := report.Get(fix.TagOrderID)
+	if orderID == "" || orderID == "NONE" {
+		return nil
+	}
+	clOrdID := report.Get(fix.TagClOrdID)
+	o, ok := f.orders[orderID]
+	if !ok {
+		o, ok = f.byClOrdID[clOrdID]
+		if !ok {
+			o = &fixOrder{state: service.BrokerOrderState{ClientOrderID: baseClOrdID(clOrdID)}}
+		}
+		o.state.ProviderOrderID = orderID
+		f.orders[orderID] = o
+	}
+	if clOrdID != "" {
+		f.byClOrdID[clOrdID] = o
+		if report.Get(fix.TagExecType) != execTypeRejected {
+			o.clOrdID = clOrdID
+		}
+	}
+	if v := report.Get(fix.TagSymbol); v != "" {
+		o.state.Symbol = v
+	}
+	if v := report.Get(fix.TagSide); v != "" {
+		o.side = v
+		o.state.Side = serviceSide(v)
+	}
+	if q, err := report.Float(fix.TagOrderQty); err == nil && q > 0 {
+		o.state.Quantity = q
+	}
+	if p, err := report.Float(fix.TagPrice); err == nil && report.Has(fix.TagPrice) {
+		o.state.Price = p
+	}
+	if q, err := report.Float(fix.TagCumQty); err == nil && report.Has(fix.TagCumQty) {
+		o.state.FilledQuantity = q
+	}
+	if v := report.Get(fix.TagOrdType); v != "" {
+		o.ordType = v
+	}
+	if p, err := report.Float(fix.TagStopPx); err == nil && p > 0 {
+		o.stopPx = p
+	}
+	o.ordStatus = report.Get(fix.TagOrdStatus)
+	o.state.Open = workingStatus(o.ordStatus)
+	return o
+}
+
+func (f *FIX) fillLocked(o *fixOrder, report *fix.Message) *service.BrokerFill {
+	execID := report.Get(fix.TagExecID)
+	for _, known := range o.fills {
+		if known.ExecutionID == execID {
+			// Resent after a reconnect.
+			return nil
+		}
+	}
+	qty, qerr := report.Float(fix.TagLastQty)
+	px, perr := report.Float(fix.TagLastPx)
+	if qerr != nil || perr != nil || qty <= 0 {
+		f.logger.Warn("FIX trade report without a valid quantity or price", "exec_id", execID, "order_id", o.state.ProviderOrderID)
+		return nil
+	}
+	filledAt := report.Time(fix.TagTransactTime)
+	if filledAt.IsZero() {
+		filledAt = f.now().UTC()
+	}
+	fill := service.BrokerFill{
+		ExecutionID:     execID,
+		ProviderOrderID: o.state.ProviderOrderID,
+		ClientOrderID:   o.state.ClientOrderID,
+		Quantity:        qty,
+		Price:           px,
+		FilledAt:        filledAt,
+	}
+	o.fills = append(o.fills, fill)
+	return &fill
+}
+
+func (f *FIX) deliverLocked(key string, msg *fix.Message, last bool) {
+	w, ok := f.waiters[key]
+	if !ok {
+		return
+	}
+	select {
+	case <-w.done:
+		return
+	default:
+	}
+	w.reports = append(w.reports, msg)
+	if last {
+		close(w.done)
+	}
+}
+
+// FIX field values used by the adapter.
+const (
+	sideBuy                = "1"
+	sideSell               = "2"
+	ordTypeMarket          = "1"
+	ordTypeLimit           = "2"
+	ordTypeStop            = "3"
+	ordTypeStopLimit       = "4"
+	execTypeRejected       = "8"
+	execTypeTrade          = "F"
+	ordStatusCanceled      = "4"
+	ordStatusPendingCancel = "6"
+	ordStatusRejected      = "8"
+	cxlRejUnknownOrder     = "1"
+	massStatusAllOrders    = "7"
+)
+
+func fixSide(side string) (string, error) {
+	switch side {
+	case "buy":
+		return sideBuy, nil
+	case "sell":
+		return sideSell, nil
+	}
+	return "", fmt.Errorf("unsupported side %q", side)
+}
+
+func serviceSide(side string) string {
+	if side == sideSell {
+		return "sell"
+	}
+	return "buy"
+}
+
+func fixOrdType(order service.Order) string {
+	switch {
+	case order.Type == service.OrderTypeStop && order.Price > 0:
+		return ordTypeStopLimit
+	case order.Type == service.OrderTypeStop:
+		return ordTypeStop
+	case order.Price > 0:
+		return ordTypeLimit
+	}
+	return ordTypeMarket
+}
+
+func fixTimeInForce(tif service.TimeInForce) string {
+	switch tif {
+	case service.TimeInForceGTC:
+		return "1"
+	case service.TimeInForceIOC:
+		return "3"
+	case service.TimeInForceFOK:
+		return "4"
+	}
+	return "0"
+}
+
+// workingStatus reports whether an OrdStatus leaves the order working: new,
+// partially filled, pending cancel or replace, or replaced.
+func workingStatus(status string) bool {
+	switch status {
+	case "0", "1", "5", "6", "A", "E":
+		return true
+	}
+	return false
+}
+
+// baseClOrdID strips the request number of a cancel or replace ClOrdID,
+// leaving the executor order id.
+func baseClOrdID(clOrdID string) string {
+	for i := len(clOrdID) - 1; i >= 0; i-- {
+		if clOrdID[i] == '.' {
+			if _, err := strconv.Atoi(clOrdID[i+1:]); err == nil {
+				return clOrdID[:i]
+			}
+			break
+		}
+	}
+	return clOrdID
+}
+
+// reason describes a reject from its Text, or its reason code.
+func reason(msg *fix.Message) string {
+	if text := msg.Get(fix.TagText); text != "" {
+		return text
+	}
+	for _, tag := range []fix.Tag{fix.TagOrdRejReason, fix.TagCxlRejReason, fix.TagSessionRejReason} {
+		if code := msg.Get(tag); code != "" {
+			return fmt.Sprintf("reason code %s", code)
+		}
+	}
+	return "no reason given"
+}
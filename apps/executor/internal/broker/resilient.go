@@ -0,0 +1,452 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/future-bots/executor/internal/service"
+)
+
+// ErrCircuitOpen is returned without calling a broker whose circuit breaker
+// is open. It wraps service.ErrBrokerUnavailable, so the request may be sent
+// to the secondary broker.
+var ErrCircuitOpen = fmt.Errorf("circuit open: %w", service.ErrBrokerUnavailable)
+
+// BreakerState is the state of a broker's circuit breaker.
+type BreakerState string
+
+const (
+	// BreakerClosed passes every call through.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen fails calls fast until the cooldown has passed.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a single probe through; its outcome closes or
+	// reopens the circuit.
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// VenueHealth reports the circuit breaker of one broker.
+type VenueHealth struct {
+	Name                string       `json:"name"`
+	State               BreakerState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutive_failures"`
+	OpenedAt            *time.Time   `json:"opened_at,omitempty"`
+}
+
+// breaker counts consecutive transient failures of one broker and opens
+// after threshold of them for cooldown.
+type breaker struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	open      bool
+	probing   bool
+}
+
+// allow reports whether a call may go through, claiming the probe when the
+// cooldown of an open circuit has passed.
+func (b *breaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return nil
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+// record books the outcome of an allowed call and returns the new state when
+// the call opened or closed the circuit. Only transient errors count as
+// failures: a venue rejecting an order is a venue that is reachable.
+func (b *breaker) record(err error) (changed BreakerState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil || !transient(err) {
+		b.failures = 0
+		if b.open {
+			b.open = false
+			return BreakerClosed
+		}
+		return ""
+	}
+	b.failures++
+	if b.open || b.failures >= b.threshold {
+		wasOpen := b.open
+		b.open = true
+		b.openedAt = b.now()
+		if !wasOpen {
+			return BreakerOpen
+		}
+	}
+	return ""
+}
+
+func (b *breaker) health(name string) VenueHealth {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	h := VenueHealth{Name: name, State: BreakerClosed, ConsecutiveFailures: b.failures}
+	if b.open {
+		h.State = BreakerOpen
+		if b.probing || b.now().Sub(b.openedAt) >= b.cooldown {
+			h.State = BreakerHalfOpen
+		}
+		openedAt := b.openedAt
+		h.OpenedAt = &openedAt
+	}
+	return h
+}
+
+// transient reports whether err is a connectivity failure rather than an
+// answer from the venue.
+func transient(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, service.ErrBrokerUnavailable) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.As(err, &netErr)
+}
+
+// undelivered reports whether err proves the request never reached the
+// venue, which makes even a Place safe to repeat.
+func undelivered(err error) bool {
+	return errors.Is(err, service.ErrBrokerUnavailable)
+}
+
+type venue struct {
+	name    string
+	broker  service.Broker
+	breaker *breaker
+}
+
+// Resilient guards a broker adapter against connectivity failures. Calls
+// that are safe to repeat — queries, cancels, amends to absolute values and
+// placements that provably never reached the venue — are retried with
+// jittered exponential backoff. A circuit breaker per broker stops calling
+// it after consecutive transient failures, so new orders are rejected at
+// once instead of piling up behind timeouts. With a secondary broker,
+// placements the primary cannot take fail over to it, and requests about
+// existing orders go to whichever broker holds them. Resilient implements
+// service.Broker.
+type Resilient struct {
+	primary     *venue
+	secondary   *venue
+	attempts    int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	threshold   int
+	cooldown    time.Duration
+	now         func() time.Time
+	logger      *slog.Logger
+
+	mu sync.Mutex
+	// held maps provider order ids placed at the secondary broker to it.
+	// Ids it does not know are looked up at each broker in turn.
+	held map[string]*venue
+
+	fillsOnce sync.Once
+	fills     chan service.BrokerFill
+}
+
+// ResilientOption customises a Resilient broker.
+type ResilientOption func(*Resilient)
+
+// WithRetry retries calls that are safe to repeat up to maxAttempts times in
+// total, waiting around base, doubling up to max, between attempts.
+func WithRetry(maxAttempts int, base, max time.Duration) ResilientOption {
+	return func(r *Resilient) {
+		if maxAttempts > 0 {
+			r.attempts = maxAttempts
+		}
+		if base > 0 {
+			r.baseBackoff = base
+		}
+		if max > 0 {
+			r.maxBackoff = max
+		}
+	}
+}
+
+// WithCircuitBreaker opens a broker's circuit after threshold consecutive
+// transient failures and probes it again after cooldown.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ResilientOption {
+	return func(r *Resilient) {
+		if threshold > 0 {
+			r.threshold = threshold
+		}
+		if cooldown > 0 {
+			r.cooldown = cooldown
+		}
+	}
+}
+
+// WithFailover sends placements the primary broker cannot take to secondary.
+func WithFailover(secondary service.Broker) ResilientOption {
+	return func(r *Resilient) {
+		if secondary != nil {
+			r.secondary = &venue{name: "secondary", broker: secondary}
+		}
+	}
+}
+
+// WithResilientClock overrides the clock used by the circuit breakers.
+func WithResilientClock(now func() time.Time) ResilientOption {
+	return func(r *Resilient) {
+		if now != nil {
+			r.now = now
+		}
+	}
+}
+
+// WithResilientLogger reports circuit and failover events to logger.
+func WithResilientLogger(logger *slog.Logger) ResilientOption {
+	return func(r *Resilient) {
+		if logger != nil {
+			r.logger = logger
+		}
+	}
+}
+
+// NewResilient wraps primary. By default calls are tried 3 times with
+// backoff from 100ms to 2s, and a circuit opens after 5 consecutive failures
+// for 30s.
+func NewResilient(primary service.Broker, opts ...ResilientOption) *Resilient {
+	r := &Resilient{
+		primary:     &venue{name: "primary", broker: primary},
+		attempts:    3,
+		baseBackoff: 100 * time.Millisecond,
+		maxBackoff:  2 * time.Second,
+		threshold:   5,
+		cooldown:    30 * time.Second,
+		now:         time.Now,
+		logger:      slog.Default(),
+		held:        make(map[string]*venue),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	for _, v := range r.venues() {
+		v.breaker = &breaker{now: r.now, threshold: r.threshold, cooldown: r.cooldown}
+	}
+	return r
+}
+
+// Health reports the circuit breaker of every broker, primary first.
+func (r *Resilient) Health() []VenueHealth {
+	venues := r.venues()
+	health := make([]VenueHealth, 0, len(venues))
+	for _, v := range venues {
+		health = append(health, v.breaker.health(v.name))
+	}
+	return health
+}
+
+// Ready returns nil while some broker can take new orders, that is while
+// not every circuit is open.
+func (r *Resilient) Ready(context.Context) error {
+	var open []string
+	for _, h := range r.Health() {
+		if h.State != BreakerOpen {
+			return nil
+		}
+		open = append(open, h.Name)
+	}
+	return fmt.Errorf("broker %v %w", open, ErrCircuitOpen)
+}
+
+// Place implements service.Broker.
+func (r *Resilient) Place(ctx context.Context, order service.Order) (service.BrokerAck, error) {
+	ack, err := r.place(ctx, r.primary, order)
+	if err == nil || r.secondary == nil || !undelivered(err) {
+		return ack, err
+	}
+	r.logger.Warn("failing over order placement", "order_id", order.ID, "error", err)
+	ack, secondaryErr := r.place(ctx, r.secondary, order)
+	if secondaryErr != nil {
+		return ack, fmt.Errorf("primary: %w; secondary: %w", err, secondaryErr)
+	}
+	r.mu.Lock()
+	r.held[ack.ProviderOrderID] = r.secondary
+	r.mu.Unlock()
+	return ack, nil
+}
+
+func (r *Resilient) place(ctx context.Context, v *venue, order service.Order) (service.BrokerAck, error) {
+	var ack service.BrokerAck
+	err := r.call(ctx, v, undelivered, func() (err error) {
+		ack, err = v.broker.Place(ctx, order)
+		return err
+	})
+	return ack, err
+}
+
+// Cancel implements service.Broker.
+func (r *Resilient) Cancel(ctx context.Context, providerOrderID string) error {
+	return r.onHolder(ctx, providerOrderID, func(b service.Broker) error {
+		return b.Cancel(ctx, providerOrderID)
+	})
+}
+
+// Amend implements service.Broker. Amends set absolute values, so repeating
+// one is harmless.
+func (r *Resilient) Amend(ctx context.Context, providerOrderID string, price, quantity float64) error {
+	return r.onHolder(ctx, providerOrderID, func(b service.Broker) error {
+		return b.Amend(ctx, providerOrderID, price, quantity)
+	})
+}
+
+// Query implements service.Broker.
+func (r *Resilient) Query(ctx context.Context, providerOrderID string) (service.BrokerOrderState, error) {
+	var state service.BrokerOrderState
+	err := r.onHolder(ctx, providerOrderID, func(b service.Broker) (err error) {
+		state, err = b.Query(ctx, providerOrderID)
+		return err
+	})
+	return state, err
+}
+
+// Executions implements service.Broker.
+func (r *Resilient) Executions(ctx context.Context, providerOrderID string) ([]service.BrokerFill, error) {
+	var fills []service.BrokerFill
+	err := r.onHolder(ctx, providerOrderID, func(b service.Broker) (err error) {
+		fills, err = b.Executions(ctx, providerOrderID)
+		return err
+	})
+	return fills, err
+}
+
+// OpenOrders implements service.Broker, listing the working orders of every
+// broker. It fails when any broker cannot answer, so reconciliation never
+// mistakes an unreachable broker for one without orders.
+func (r *Resilient) OpenOrders(ctx context.Context) ([]service.BrokerOrderState, error) {
+	var open []service.BrokerOrderState
+	for _, v := range r.venues() {
+		var states []service.BrokerOrderState
+		if err := r.call(ctx, v, transient, func() (err error) {
+			states, err = v.broker.OpenOrders(ctx)
+			return err
+		}); err != nil {
+			return nil, fmt.Errorf("%s broker: %w", v.name, err)
+		}
+		open = append(open, states...)
+	}
+	return open, nil
+}
+
+// Fills implements service.Broker, merging the fill streams of every broker.
+func (r *Resilient) Fills() <-chan service.BrokerFill {
+	if r.secondary == nil {
+		return r.primary.broker.Fills()
+	}
+	r.fillsOnce.Do(func() {
+		r.fills = make(chan service.BrokerFill)
+		var wg sync.WaitGroup
+		for _, v := range r.venues() {
+			wg.Add(1)
+			go func(in <-chan service.BrokerFill) {
+				defer wg.Done()
+				for fill := range in {
+					r.fills <- fill
+				}
+			}(v.broker.Fills())
+		}
+		go func() {
+			wg.Wait()
+			close(r.fills)
+		}()
+	})
+	return r.fills
+}
+
+// onHolder runs fn against the broker holding providerOrderID. Unless the
+// order is known to have failed over, the primary is asked first and the
+// secondary when the primary does not know the order or cannot be reached.
+func (r *Resilient) onHolder(ctx context.Context, providerOrderID string, fn func(service.Broker) error) error {
+	r.mu.Lock()
+	held := r.held[providerOrderID]
+	r.mu.Unlock()
+	if held != nil {
+		return r.call(ctx, held, transient, func() error { return fn(held.broker) })
+	}
+
+	var unreachable error
+	for _, v := range r.venues() {
+		err := r.call(ctx, v, transient, func() error { return fn(v.broker) })
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, service.ErrUnknownBrokerOrder):
+			continue
+		case transient(err):
+			if unreachable == nil {
+				unreachable = err
+			}
+		default:
+			return err
+		}
+	}
+	if unreachable != nil {
+		return unreachable
+	}
+	return service.ErrUnknownBrokerOrder
+}
+
+// call runs fn through v's circuit breaker, retrying the errors retry
+// accepts with jittered exponential backoff.
+func (r *Resilient) call(ctx context.Context, v *venue, retry func(error) bool, fn func() error) error {
+	backoff := r.baseBackoff
+	for attempt := 1; ; attempt++ {
+		if err := v.breaker.allow(); err != nil {
+			return err
+		}
+		err := fn()
+		if err != nil && transient(err) {
+			r.logger.Warn("broker call failed", "broker", v.name, "attempt", attempt, "error", err)
+		}
+		switch v.breaker.record(err) {
+		case BreakerOpen:
+			r.logger.Error("broker circuit opened", "broker", v.name, "cooldown", r.cooldown.String())
+		case BreakerClosed:
+			r.logger.Info("broker circuit closed", "broker", v.name)
+		}
+		if err == nil || !retry(err) || attempt >= r.attempts || ctx.Err() != nil {
+			return err
+		}
+		timer := time.NewTimer(jitter(backoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, r.maxBackoff)
+	}
+}
+
+func (r *Resilient) venues() []*venue {
+	if r.secondary == nil {
+		return []*venue{r.primary}
+	}
+	return []*venue{r.primary, r.secondary}
+}
+
+// jitter picks a wait between half of d and d, so retries from many callers
+// spread out.
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(d-half)
+}
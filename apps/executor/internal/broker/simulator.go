@@ -0,0 +1,421 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/future-bots/executor/internal/service"
+	marketsv1 "github.com/future-bots/proto/markets/v1"
+)
+
+// ErrOrderClosed is returned when cancelling or amending an order that is no
+// longer working at the simulator.
+var ErrOrderClosed = errors.New("order is no longer open")
+
+// level is a single price level of visible depth.
+type level struct {
+	price  float64
+	volume float64
+}
+
+// book is the simulator's view of depth for one symbol. Volume consumed by
+// simulated fills is removed until the next snapshot replaces the book.
+type book struct {
+	bids []level
+	asks []level
+	last float64
+}
+
+type simOrder struct {
+	state service.BrokerOrderState
+	fills []service.BrokerFill
+	// stop is the trigger of a stop order that has not been activated yet.
+	stop float64
+}
+
+// Simulator is a deterministic in-process broker that matches orders against
+// the best bid/offer levels of the latest SsiPsSnapshot for each symbol. Market
+// orders (price 0) take any visible level; limit orders only take levels at or
+// better than their price and otherwise rest until a later snapshot crosses.
+// Stop orders stay dormant until the last traded price reaches the trigger.
+// IOC orders never rest and FOK orders are refused unless the visible depth
+// covers the whole quantity.
+type Simulator struct {
+	mu       sync.Mutex
+	books    map[string]*book
+	orders   map[string]*simOrder
+	working  []string
+	orderSeq int64
+	execSeq  int64
+	fills    chan service.BrokerFill
+	// overflow holds fills the stream had no room for, in order. Fills are
+	// never sent blocking under mu: a consumer calling back into the
+	// simulator would deadlock, so they wait here for flush.
+	overflow []service.BrokerFill
+	// flushing is set while a flush goroutine owns delivery of overflow.
+	flushing bool
+	now      func() time.Time
+	// prefix starts every provider order and execution id.
+	prefix string
+}
+
+// SimulatorOption customises a Simulator.
+type SimulatorOption func(*Simulator)
+
+// WithIDPrefix replaces the "SIM" prefix of provider order ids and execution
+// ids, keeping the ids of several simulators in one executor apart.
+func WithIDPrefix(prefix string) SimulatorOption {
+	return func(s *Simulator) {
+		if prefix != "" {
+			s.prefix = prefix
+		}
+	}
+}
+
+// NewSimulator constructs an empty simulator. bufferSize bounds the fill
+// stream; when zero a buffer of 1024 reports is used. Fills that do not fit
+// are delivered in order by a goroutine once the stream has room.
+func NewSimulator(now func() time.Time, bufferSize int, opts ...SimulatorOption) *Simulator {
+	if now == nil {
+		now = time.Now
+	}
+	if bufferSize <= 0 {
+		bufferSize = 1024
+	}
+	s := &Simulator{
+		books:  make(map[string]*book),
+		orders: make(map[string]*simOrder),
+		fills:  make(chan service.BrokerFill, bufferSize),
+		now:    func() time.Time { return now().UTC() },
+		prefix: "SIM",
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// OnSnapshot replaces the depth for the snapshot's symbol and matches any
+// resting orders against it.
+func (s *Simulator) OnSnapshot(snapshot *marketsv1.SsiPsSnapshot) {
+	if snapshot == nil || snapshot.GetCode() == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.unlock()
+
+	s.books[snapshot.GetCode()] = &book{bids: bidLevels(snapshot), asks: offerLevels(snapshot), last: snapshot.GetLastPrice()}
+	for _, id := range s.working {
+		if o := s.orders[id]; o.state.Symbol == snapshot.GetCode() {
+			s.trigger(o)
+			s.match(o)
+		}
+	}
+	s.compact()
+}
+
+// Place implements service.Broker.
+func (s *Simulator) Place(_ context.Context, order service.Order) (service.BrokerAck, error) {
+	if order.Quantity <= 0 {
+		return service.BrokerAck{}, service.BrokerRejection{Reason: "quantity must be greater than zero"}
+	}
+	if order.Side != "buy" && order.Side != "sell" {
+		return service.BrokerAck{}, service.BrokerRejection{Reason: fmt.Sprintf("unsupported side %q", order.Side)}
+	}
+
+	s.mu.Lock()
+	defer s.unlock()
+
+	if order.TimeInForce == service.TimeInForceFOK && s.available(order) < order.Quantity {
+		return service.BrokerAck{}, service.BrokerRejection{Reason: fmt.Sprintf("FOK order for %g cannot be filled in full", order.Quantity)}
+	}
+
+	s.orderSeq++
+	id := fmt.Sprintf("%s-%08d", s.prefix, s.orderSeq)
+	o := &simOrder{state: service.BrokerOrderState{
+		ProviderOrderID: id,
+		ClientOrderID:   order.ID,
+		Symbol:          order.Symbol,
+		Side:            order.Side,
+		Quantity:        order.Quantity,
+		Price:           order.Price,
+		Open:            true,
+	}}
+	if order.Type == service.OrderTypeStop {
+		o.stop = order.StopPrice
+	}
+	s.orders[id] = o
+	s.working = append(s.working, id)
+	s.trigger(o)
+	s.match(o)
+	if order.TimeInForce.Immediate() {
+		o.state.Open = false
+	}
+	s.compact()
+	return service.BrokerAck{ProviderOrderID: id, AcceptedAt: s.now()}, nil
+}
+
+// Cancel implements service.Broker.
+func (s *Simulator) Cancel(_ context.Context, providerOrderID string) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	o, ok := s.orders[providerOrderID]
+	if !ok {
+		return service.ErrUnknownBrokerOrder
+	}
+	if !o.state.Open {
+		return ErrOrderClosed
+	}
+	o.state.Open = false
+	s.compact()
+	return nil
+}
+
+// Amend implements service.Broker. The new quantity is the total order size and
+// may not drop below what has already filled.
+func (s *Simulator) Amend(_ context.Context, providerOrderID string, price, quantity float64) error {
+	s.mu.Lock()
+	defer s.unlock()
+
+	o, ok := s.orders[providerOrderID]
+	if !ok {
+		return service.ErrUnknownBrokerOrder
+	}
+	if !o.state.Open {
+		return ErrOrderClosed
+	}
+	if quantity < o.state.FilledQuantity {
+		return fmt.Errorf("quantity %g is below filled quantity %g", quantity, o.state.FilledQuantity)
+	}
+	o.state.Price = price
+	o.state.Quantity = quantity
+	if o.state.FilledQuantity >= o.state.Quantity {
+		o.state.Open = false
+	}
+	s.match(o)
+	s.compact()
+	return nil
+}
+
+// Query implements service.Broker.
+func (s *Simulator) Query(_ context.Context, providerOrderID string) (service.BrokerOrderState, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	o, ok := s.orders[providerOrderID]
+	if !ok {
+		return service.BrokerOrderState{}, service.ErrUnknownBrokerOrder
+	}
+	return o.state, nil
+}
+
+// Fills implements service.Broker.
+func (s *Simulator) Fills() <-chan service.BrokerFill {
+	return s.fills
+}
+
+// OpenOrders implements service.Broker.
+func (s *Simulator) OpenOrders(context.Context) ([]service.BrokerOrderState, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	out := make([]service.BrokerOrderState, 0, len(s.working))
+	for _, id := range s.working {
+		out = append(out, s.orders[id].state)
+	}
+	return out, nil
+}
+
+// Executions implements service.Broker.
+func (s *Simulator) Executions(_ context.Context, providerOrderID string) ([]service.BrokerFill, error) {
+	s.mu.Lock()
+	defer s.unlock()
+
+	o, ok := s.orders[providerOrderID]
+	if !ok {
+		return nil, service.ErrUnknownBrokerOrder
+	}
+	out := make([]service.BrokerFill, len(o.fills))
+	copy(out, o.fills)
+	return out, nil
+}
+
+// trigger activates a dormant stop order once the last traded price reaches
+// its trigger: at or above for buys, at or below for sells.
+func (s *Simulator) trigger(o *simOrder) {
+	if o.stop == 0 {
+		return
+	}
+	b, ok := s.books[o.state.Symbol]
+	if !ok || b.last <= 0 {
+		return
+	}
+	if (o.state.Side == "buy" && b.last >= o.stop) || (o.state.Side == "sell" && b.last <= o.stop) {
+		o.stop = 0
+	}
+}
+
+// available returns the visible volume an order could take right now.
+func (s *Simulator) available(order service.Order) float64 {
+	b, ok := s.books[order.Symbol]
+	if !ok {
+		return 0
+	}
+	levels, crosses := b.opposite(order.Side, order.Price)
+	var total float64
+	for _, lvl := range levels {
+		if crosses(lvl.price) {
+			total += lvl.volume
+		}
+	}
+	return total
+}
+
+// match fills as much of o as the current book allows, best level first.
+func (s *Simulator) match(o *simOrder) {
+	if !o.state.Open || o.stop != 0 {
+		return
+	}
+	b, ok := s.books[o.state.Symbol]
+	if !ok {
+		return
+	}
+	levels, crosses := b.opposite(o.state.Side, o.state.Price)
+
+	for i := range levels {
+		remaining := o.state.Quantity - o.state.FilledQuantity
+		if remaining <= 0 {
+			break
+		}
+		lvl := &levels[i]
+		if lvl.volume <= 0 || !crosses(lvl.price) {
+			continue
+		}
+		qty := remaining
+		if lvl.volume < qty {
+			qty = lvl.volume
+		}
+		lvl.volume -= qty
+		o.state.FilledQuantity += qty
+
+		s.execSeq++
+		fill := service.BrokerFill{
+			ExecutionID:     fmt.Sprintf("%sX-%08d", s.prefix, s.execSeq),
+			ProviderOrderID: o.state.ProviderOrderID,
+			ClientOrderID:   o.state.ClientOrderID,
+			Quantity:        qty,
+			Price:           lvl.price,
+			FilledAt:        s.now(),
+		}
+		o.fills = append(o.fills, fill)
+		s.overflow = append(s.overflow, fill)
+	}
+	if o.state.FilledQuantity >= o.state.Quantity {
+		o.state.Open = false
+	}
+}
+
+// unlock hands the stream the waiting fills it has room for and releases
+// mu. Fills it has no room for are left to a flush goroutine.
+func (s *Simulator) unlock() {
+	defer s.mu.Unlock()
+	if s.flushing {
+		return
+	}
+	for len(s.overflow) > 0 {
+		select {
+		case s.fills <- s.overflow[0]:
+			s.overflow[0] = service.BrokerFill{}
+			s.overflow = s.overflow[1:]
+		default:
+			s.flushing = true
+			go s.flush()
+			return
+		}
+	}
+	s.overflow = nil
+}
+
+// flush delivers the waiting fills in order, blocking on the stream without
+// holding mu, and exits once none are left.
+func (s *Simulator) flush() {
+	for {
+		s.mu.Lock()
+		if len(s.overflow) == 0 {
+			s.overflow = nil
+			s.flushing = false
+			s.mu.Unlock()
+			return
+		}
+		fill := s.overflow[0]
+		s.overflow[0] = service.BrokerFill{}
+		s.overflow = s.overflow[1:]
+		s.mu.Unlock()
+		s.fills <- fill
+	}
+}
+
+// opposite returns the levels an order on side would trade against and a
+// predicate reporting whether a level price is acceptable for limit price.
+func (b *book) opposite(side string, limit float64) ([]level, func(float64) bool) {
+	if side == "sell" {
+		return b.bids, func(p float64) bool { return limit == 0 || p >= limit }
+	}
+	return b.asks, func(p float64) bool { return limit == 0 || p <= limit }
+}
+
+// compact drops closed orders from the working list, preserving time priority.
+func (s *Simulator) compact() {
+	working := s.working[:0]
+	for _, id := range s.working {
+		if s.orders[id].state.Open {
+			working = append(working, id)
+		}
+	}
+	s.working = working
+}
+
+func bidLevels(snap *marketsv1.SsiPsSnapshot) []level {
+	return depth([]level{
+		{snap.GetBestBid_1(), float64(snap.GetBestBid_1Volume())},
+		{snap.GetBestBid_2(), float64(snap.GetBestBid_2Volume())},
+		{snap.GetBestBid_3(), float64(snap.GetBestBid_3Volume())},
+		{snap.GetBestBid_4(), float64(snap.GetBestBid_4Volume())},
+		{snap.GetBestBid_5(), float64(snap.GetBestBid_5Volume())},
+		{snap.GetBestBid_6(), float64(snap.GetBestBid_6Volume())},
+		{snap.GetBestBid_7(), float64(snap.GetBestBid_7Volume())},
+		{snap.GetBestBid_8(), float64(snap.GetBestBid_8Volume())},
+		{snap.GetBestBid_9(), float64(snap.GetBestBid_9Volume())},
+		{snap.GetBestBid_10(), float64(snap.GetBestBid_10Volume())},
+	})
+}
+
+func offerLevels(snap *marketsv1.SsiPsSnapshot) []level {
+	return depth([]level{
+		{snap.GetBestOffer_1(), float64(snap.GetBestOffer_1Volume())},
+		{snap.GetBestOffer_2(), float64(snap.GetBestOffer_2Volume())},
+		{snap.GetBestOffer_3(), float64(snap.GetBestOffer_3Volume())},
+		{snap.GetBestOffer_4(), float64(snap.GetBestOffer_4Volume())},
+		{snap.GetBestOffer_5(), float64(snap.GetBestOffer_5Volume())},
+		{snap.GetBestOffer_6(), float64(snap.GetBestOffer_6Volume())},
+		{snap.GetBestOffer_7(), float64(snap.GetBestOffer_7Volume())},
+		{snap.GetBestOffer_8(), float64(snap.GetBestOffer_8Volume())},
+		{snap.GetBestOffer_9(), float64(snap.GetBestOffer_9Volume())},
+		{snap.GetBestOffer_10(), float64(snap.GetBestOffer_10Volume())},
+	})
+}
+
+// depth keeps the populated levels in feed order (best first).
+func depth(levels []level) []level {
+	out := levels[:0]
+	for _, l := range levels {
+		if l.price > 0 && l.volume > 0 {
+			out = append(out, l)
+		}
+	}
+	return out
+}
@@ -0,0 +1,382 @@
+// Package fix speaks the session layer of FIX 4.4: tag=value framing, logon,
+// heartbeats and test requests, and sequence number recovery with resend
+// requests and gap fills. Broker adapters build their application messages
+// on top of it.
+package fix
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BeginString is the only protocol version spoken.
+const BeginString = "FIX.4.4"
+
+// soh separates fields.
+const soh = '\x01'
+
+// TimestampFormat is the layout of UTCTimestamp fields.
+const TimestampFormat = "20060102-15:04:05.000"
+
+// ErrGarbled is returned when a message cannot be framed: a missing or bad
+// BeginString, BodyLength or CheckSum.
+var ErrGarbled = errors.New("garbled FIX message")
+
+// Tag is a FIX field number.
+type Tag int
+
+// Header, trailer and session-level tags.
+const (
+	TagBeginSeqNo       Tag = 7
+	TagBeginString      Tag = 8
+	TagBodyLength       Tag = 9
+	TagCheckSum         Tag = 10
+	TagEndSeqNo         Tag = 16
+	TagMsgSeqNum        Tag = 34
+	TagMsgType          Tag = 35
+	TagNewSeqNo         Tag = 36
+	TagPossDupFlag      Tag = 43
+	TagRefSeqNum        Tag = 45
+	TagSenderCompID     Tag = 49
+	TagSendingTime      Tag = 52
+	TagTargetCompID     Tag = 56
+	TagText             Tag = 58
+	TagEncryptMethod    Tag = 98
+	TagHeartBtInt       Tag = 108
+	TagTestReqID        Tag = 112
+	TagOrigSendingTime  Tag = 122
+	TagGapFillFlag      Tag = 123
+	TagResetSeqNumFlag  Tag = 141
+	TagRefMsgType       Tag = 372
+	TagSessionRejReason Tag = 373
+)
+
+// Application tags used by the order routing messages.
+const (
+	TagAccount           Tag = 1
+	TagAvgPx             Tag = 6
+	TagClOrdID           Tag = 11
+	TagCumQty            Tag = 14
+	TagExecID            Tag = 17
+	TagLastPx            Tag = 31
+	TagLastQty           Tag = 32
+	TagOrderID           Tag = 37
+	TagOrderQty          Tag = 38
+	TagOrdStatus         Tag = 39
+	TagOrdType           Tag = 40
+	TagOrigClOrdID       Tag = 41
+	TagPrice             Tag = 44
+	TagSide              Tag = 54
+	TagSymbol            Tag = 55
+	TagTimeInForce       Tag = 59
+	TagTransactTime      Tag = 60
+	TagStopPx            Tag = 99
+	TagCxlRejReason      Tag = 102
+	TagOrdRejReason      Tag = 103
+	TagExpireTime        Tag = 126
+	TagExecType          Tag = 150
+	TagLeavesQty         Tag = 151
+	TagCxlRejResponseTo  Tag = 434
+	TagMassStatusReqID   Tag = 584
+	TagMassStatusReqType Tag = 585
+	TagOrdStatusReqID    Tag = 790
+	TagTotNumReports     Tag = 911
+	TagLastRptRequested  Tag = 912
+)
+
+// Session-level message types.
+const (
+	MsgTypeHeartbeat     = "0"
+	MsgTypeTestRequest   = "1"
+	MsgTypeResendRequest = "2"
+	MsgTypeReject        = "3"
+	MsgTypeSequenceReset = "4"
+	MsgTypeLogout        = "5"
+	MsgTypeLogon         = "A"
+)
+
+// Application message types.
+const (
+	MsgTypeExecutionReport           = "8"
+	MsgTypeOrderCancelReject         = "9"
+	MsgTypeNewOrderSingle            = "D"
+	MsgTypeOrderCancelRequest        = "F"
+	MsgTypeOrderCancelReplaceRequest = "G"
+	MsgTypeOrderStatusRequest        = "H"
+	MsgTypeBusinessMessageReject     = "j"
+	MsgTypeOrderMassStatusRequest    = "AF"
+)
+
+// Admin reports whether msgType is a session-level message. Session-level
+// messages are never resent; a gap fill stands in for them.
+func Admin(msgType string) bool {
+	switch msgType {
+	case MsgTypeHeartbeat, MsgTypeTestRequest, MsgTypeResendRequest, MsgTypeReject,
+		MsgTypeSequenceReset, MsgTypeLogout, MsgTypeLogon:
+		return true
+	}
+	return false
+}
+
+// Field is one tag=value pair.
+type Field struct {
+	Tag   Tag
+	Value string
+}
+
+// Message is a FIX message as an ordered list of fields. BeginString,
+// BodyLength and CheckSum are computed when it is encoded and are not held.
+type Message struct {
+	Fields []Field
+}
+
+// NewMessage starts a message of msgType.
+func NewMessage(msgType string) *Message {
+	return &Message{Fields: []Field{{Tag: TagMsgType, Value: msgType}}}
+}
+
+// MsgType returns the message type.
+func (m *Message) MsgType() string {
+	return m.Get(TagMsgType)
+}
+
+// Has reports whether the message carries tag.
+func (m *Message) Has(tag Tag) bool {
+	for _, f := range m.Fields {
+		if f.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the value of the first field with tag, or "".
+func (m *Message) Get(tag Tag) string {
+	for _, f := range m.Fields {
+		if f.Tag == tag {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// Int returns the integer value of tag.
+func (m *Message) Int(tag Tag) (int, error) {
+	v, err := strconv.Atoi(m.Get(tag))
+	if err != nil {
+		return 0, fmt.Errorf("tag %d: %w", tag, err)
+	}
+	return v, nil
+}
+
+// Float returns the decimal value of tag, zero when it is absent.
+func (m *Message) Float(tag Tag) (float64, error) {
+	v := m.Get(tag)
+	if v == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("tag %d: %w", tag, err)
+	}
+	return f, nil
+}
+
+// Time returns the UTCTimestamp value of tag, the zero time when it is
+// absent or malformed.
+func (m *Message) Time(tag Tag) time.Time {
+	for _, layout := range []string{TimestampFormat, "20060102-15:04:05"} {
+		if t, err := time.Parse(layout, m.Get(tag)); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// Bool returns whether tag is "Y".
+func (m *Message) Bool(tag Tag) bool {
+	return m.Get(tag) == "Y"
+}
+
+// Set replaces the first field with tag, or appends it.
+func (m *Message) Set(tag Tag, value string) *Message {
+	for i, f := range m.Fields {
+		if f.Tag == tag {
+			m.Fields[i].Value = value
+			return m
+		}
+	}
+	m.Fields = append(m.Fields, Field{Tag: tag, Value: value})
+	return m
+}
+
+// SetInt sets tag to an integer.
+func (m *Message) SetInt(tag Tag, v int) *Message {
+	return m.Set(tag, strconv.Itoa(v))
+}
+
+// SetFloat sets tag to a decimal without exponent.
+func (m *Message) SetFloat(tag Tag, v float64) *Message {
+	return m.Set(tag, strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+// SetTime sets tag to a UTCTimestamp with milliseconds.
+func (m *Message) SetTime(tag Tag, t time.Time) *Message {
+	return m.Set(tag, t.UTC().Format(TimestampFormat))
+}
+
+// SetBool sets tag to "Y" or "N".
+func (m *Message) SetBool(tag Tag, v bool) *Message {
+	if v {
+		return m.Set(tag, "Y")
+	}
+	return m.Set(tag, "N")
+}
+
+// headerTags are the standard header fields after MsgType, in the order
+// they are written.
+var headerTags = []Tag{TagSenderCompID, TagTargetCompID, TagMsgSeqNum, TagPossDupFlag, TagSendingTime, TagOrigSendingTime}
+
+// Bytes encodes the message with BodyLength and CheckSum. MsgType and the
+// other standard header fields come first; the body fields keep their order.
+func (m *Message) Bytes() []byte {
+	var body bytes.Buffer
+	writeField(&body, TagMsgType, m.MsgType())
+	for _, tag := range headerTags {
+		if m.Has(tag) {
+			writeField(&body, tag, m.Get(tag))
+		}
+	}
+	for _, f := range m.Fields {
+		if f.Tag == TagBeginString || f.Tag == TagBodyLength || f.Tag == TagCheckSum || f.Tag == TagMsgType || header(f.Tag) {
+			continue
+		}
+		writeField(&body, f.Tag, f.Value)
+	}
+	var out bytes.Buffer
+	writeField(&out, TagBeginString, BeginString)
+	writeField(&out, TagBodyLength, strconv.Itoa(body.Len()))
+	out.Write(body.Bytes())
+	writeField(&out, TagCheckSum, fmt.Sprintf("%03d", checksum(out.Bytes())))
+	return out.Bytes()
+}
+
+// String renders the message with | for SOH, for logs.
+func (m *Message) String() string {
+	return string(bytes.ReplaceAll(m.Bytes(), []byte{soh}, []byte{'|'}))
+}
+
+// Parse decodes one complete message.
+func Parse(raw []byte) (*Message, error) {
+	return ReadMessage(bufio.NewReader(bytes.NewReader(raw)))
+}
+
+// ReadMessage reads the next message from r, checking its BeginString,
+// BodyLength and CheckSum.
+func ReadMessage(r *bufio.Reader) (*Message, error) {
+	begin, err := r.ReadString(soh)
+	if err != nil {
+		return nil, err
+	}
+	if begin != "8="+BeginString+string(soh) {
+		return nil, fmt.Errorf("%w: BeginString %q", ErrGarbled, begin)
+	}
+	lengthField, err := r.ReadString(soh)
+	if err != nil {
+		return nil, noEOF(err)
+	}
+	tag, value, ok := cutField(lengthField)
+	if !ok || tag != TagBodyLength {
+		return nil, fmt.Errorf("%w: expected BodyLength, got %q", ErrGarbled, lengthField)
+	}
+	length, err := strconv.Atoi(value)
+	if err != nil || length <= 0 {
+		return nil, fmt.Errorf("%w: BodyLength %q", ErrGarbled, value)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, noEOF(err)
+	}
+	trailer, err := r.ReadString(soh)
+	if err != nil {
+		return nil, noEOF(err)
+	}
+	tag, value, ok = cutField(trailer)
+	if !ok || tag != TagCheckSum {
+		return nil, fmt.Errorf("%w: expected CheckSum, got %q", ErrGarbled, trailer)
+	}
+	sum := checksum([]byte(begin)) + checksum([]byte(lengthField)) + checksum(body)
+	if want, err := strconv.Atoi(value); err != nil || want != sum%256 {
+		return nil, fmt.Errorf("%w: CheckSum %s, computed %03d", ErrGarbled, value, sum%256)
+	}
+
+	msg := &Message{}
+	for len(body) > 0 {
+		end := bytes.IndexByte(body, soh)
+		if end < 0 {
+			return nil, fmt.Errorf("%w: unterminated field", ErrGarbled)
+		}
+		tag, value, ok := cutField(string(body[:end+1]))
+		if !ok {
+			return nil, fmt.Errorf("%w: field %q", ErrGarbled, body[:end])
+		}
+		msg.Fields = append(msg.Fields, Field{Tag: tag, Value: value})
+		body = body[end+1:]
+	}
+	if len(msg.Fields) == 0 || msg.Fields[0].Tag != TagMsgType {
+		return nil, fmt.Errorf("%w: MsgType is not the first body field", ErrGarbled)
+	}
+	return msg, nil
+}
+
+func header(tag Tag) bool {
+	for _, t := range headerTags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// cutField splits "tag=value\x01".
+func cutField(field string) (Tag, string, bool) {
+	eq := strings.IndexByte(field, '=')
+	if eq <= 0 || field[len(field)-1] != soh {
+		return 0, "", false
+	}
+	tag, err := strconv.Atoi(field[:eq])
+	if err != nil || tag <= 0 {
+		return 0, "", false
+	}
+	return Tag(tag), field[eq+1 : len(field)-1], true
+}
+
+func writeField(b *bytes.Buffer, tag Tag, value string) {
+	b.WriteString(strconv.Itoa(int(tag)))
+	b.WriteByte('=')
+	b.WriteString(value)
+	b.WriteByte(soh)
+}
+
+func checksum(b []byte) int {
+	var sum int
+	for _, c := range b {
+		sum += int(c)
+	}
+	return sum % 256
+}
+
+// noEOF reports a message cut short as such rather than as a clean end of
+// stream.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,651 @@
+package fix
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrNotLoggedOn is returned by Send while no connection is logged on.
+	// The message was not sent and takes no sequence number.
+	ErrNotLoggedOn = errors.New("FIX session not logged on")
+	// ErrLoggedOut is returned when the counterparty ends the session.
+	ErrLoggedOut = errors.New("FIX session logged out by counterparty")
+	// ErrHeartbeatTimeout is returned when the counterparty stays silent
+	// after a test request.
+	ErrHeartbeatTimeout = errors.New("FIX counterparty missed its heartbeat")
+	// ErrSequence is returned when the counterparty's sequence numbers
+	// cannot be recovered, such as a MsgSeqNum lower than expected.
+	ErrSequence = errors.New("FIX sequence number error")
+)
+
+// Config identifies a session and sets its timers.
+type Config struct {
+	SenderCompID string
+	TargetCompID string
+	// HeartBtInt is the heartbeat interval an initiator proposes at logon,
+	// in whole seconds on the wire. Acceptors use the initiator's.
+	HeartBtInt time.Duration
+	// ResetSeqNum restarts both sequences at 1 at the first logon of the
+	// session. Later logons over new connections resume the sequences, so
+	// messages missed while disconnected are resent. Sequences restored
+	// from a SeqStore are resumed rather than reset.
+	ResetSeqNum bool
+	// LogonTimeout bounds the wait for the counterparty's logon; 10s when
+	// zero.
+	LogonTimeout time.Duration
+}
+
+// Handler receives the application messages and session-level rejects of a
+// session in sequence order, resent ones included, on the goroutine serving
+// the connection. It must not block.
+type Handler func(msg *Message)
+
+// Option customises a Session.
+type Option func(*Session)
+
+// WithLogger reports logons, logouts and sequence recovery to logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Session) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
+}
+
+// WithClock replaces time.Now for sending times and heartbeats.
+func WithClock(now func() time.Time) Option {
+	return func(s *Session) {
+		if now != nil {
+			s.now = now
+		}
+	}
+}
+
+// WithSeqStore loads the sequence numbers from store and saves them there as
+// they advance, so the session resumes them after a restart.
+func WithSeqStore(store SeqStore) Option {
+	return func(s *Session) {
+		s.store = store
+	}
+}
+
+// Session is a FIX 4.4 session between two CompIDs. It outlives the
+// connections serving it: sequence numbers and the application messages
+// sent are kept, so a counterparty that missed messages can ask for them
+// again after logging on over a new connection.
+type Session struct {
+	cfg     Config
+	handler Handler
+	logger  *slog.Logger
+	now     func() time.Time
+	store   SeqStore
+
+	mu         sync.Mutex
+	conn       net.Conn
+	loggedOn   bool
+	heartBtInt time.Duration
+	started    bool
+	nextSender int
+	nextTarget int
+	// sent holds the encoded application messages by MsgSeqNum.
+	sent     map[int][]byte
+	lastSent time.Time
+}
+
+// NewSession constructs a session that passes application messages to
+// handler.
+func NewSession(cfg Config, handler Handler, opts ...Option) *Session {
+	if cfg.LogonTimeout <= 0 {
+		cfg.LogonTimeout = 10 * time.Second
+	}
+	s := &Session{
+		cfg:        cfg,
+		handler:    handler,
+		logger:     slog.Default(),
+		now:        time.Now,
+		nextSender: 1,
+		nextTarget: 1,
+		sent:       make(map[int][]byte),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.store != nil {
+		sender, target, ok, err := s.store.Load()
+		switch {
+		case err != nil:
+			s.logger.Error("FIX sequence numbers not restored", "error", err)
+		case ok:
+			// The session resumes where the last process left off.
+			s.nextSender, s.nextTarget, s.started = sender, target, true
+			s.logger.Info("FIX sequence numbers restored", "next_sender", sender, "next_target", target)
+		}
+	}
+	return s
+}
+
+// LoggedOn reports whether a connection is logged on.
+func (s *Session) LoggedOn() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loggedOn
+}
+
+// SeqNums returns the next MsgSeqNum to send and the next expected from the
+// counterparty.
+func (s *Session) SeqNums() (sender, target int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.nextSender, s.nextTarget
+}
+
+// Initiate logs on over conn and serves it until the counterparty logs out
+// or goes silent, the connection fails or ctx is cancelled, which logs out.
+// It returns why the connection ended and always closes conn.
+func (s *Session) Initiate(ctx context.Context, conn net.Conn) error {
+	return s.serve(ctx, conn, true)
+}
+
+// Accept waits for the counterparty's logon over conn, answers it and
+// serves the connection like Initiate.
+func (s *Session) Accept(ctx context.Context, conn net.Conn) error {
+	return s.serve(ctx, conn, false)
+}
+
+// Send stamps msg with the session header and the next MsgSeqNum and
+// writes it. Application messages are kept for resending.
+func (s *Session) Send(msg *Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loggedOn {
+		return ErrNotLoggedOn
+	}
+	return s.sendLocked(msg)
+}
+
+func (s *Session) sendLocked(msg *Message) error {
+	seq := s.nextSender
+	s.stamp(msg, seq)
+	raw := msg.Bytes()
+	if !Admin(msg.MsgType()) {
+		s.sent[seq] = raw
+	}
+	s.nextSender++
+	// Saved before the write: after a crash the counterparty sees a gap it
+	// asks to be filled, never a MsgSeqNum it already has.
+	s.saveLocked()
+	return s.writeLocked(raw)
+}
+
+// saveLocked records the sequence numbers in the store, if any.
+func (s *Session) saveLocked() {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.Save(s.nextSender, s.nextTarget); err != nil {
+		s.logger.Error("FIX sequence numbers not saved", "error", err)
+	}
+}
+
+func (s *Session) stamp(msg *Message, seq int) {
+	msg.Set(TagSenderCompID, s.cfg.SenderCompID)
+	msg.Set(TagTargetCompID, s.cfg.TargetCompID)
+	msg.SetInt(TagMsgSeqNum, seq)
+	msg.SetTime(TagSendingTime, s.now())
+}
+
+func (s *Session) writeLocked(raw []byte) error {
+	if s.conn == nil {
+		return ErrNotLoggedOn
+	}
+	s.lastSent = s.now()
+	_, err := s.conn.Write(raw)
+	return err
+}
+
+// read is one message, or the error that ended the connection, from the
+// reading goroutine.
+type read struct {
+	msg *Message
+	err error
+}
+
+func (s *Session) serve(ctx context.Context, conn net.Conn, initiator bool) error {
+	defer conn.Close()
+	reads := make(chan read)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		r := bufio.NewReader(conn)
+		for {
+			msg, err := ReadMessage(r)
+			select {
+			case reads <- read{msg: msg, err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	c := &connection{Session: s, reads: reads, queued: make(map[int]*Message)}
+	if err := c.logon(ctx, conn, initiator); err != nil {
+		s.detach()
+		return err
+	}
+	err := c.run(ctx)
+	s.detach()
+	return err
+}
+
+// detach marks the session logged off and forgets the connection.
+func (s *Session) detach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loggedOn = false
+	s.conn = nil
+}
+
+// connection is the state of one logged-on connection.
+type connection struct {
+	*Session
+	reads <-chan read
+	// queued holds messages received ahead of a sequence gap until the
+	// resent messages fill it.
+	queued map[int]*Message
+	// resending is the MsgSeqNum that exposed an outstanding gap, zero when
+	// there is none.
+	resending int
+	lastRecv  time.Time
+	// testReqID is the outstanding test request, "" when none.
+	testReqID string
+	testSent  time.Time
+	testSeq   int
+}
+
+func (c *connection) logon(ctx context.Context, conn net.Conn, initiator bool) error {
+	timer := time.NewTimer(c.cfg.LogonTimeout)
+	defer timer.Stop()
+
+	c.mu.Lock()
+	c.conn = conn
+	reset := c.cfg.ResetSeqNum && !c.started
+	c.started = true
+	if initiator {
+		if reset {
+			c.resetLocked()
+		}
+		c.heartBtInt = c.cfg.HeartBtInt
+		if err := c.sendLocked(c.logonMessage(reset)); err != nil {
+			c.mu.Unlock()
+			return fmt.Errorf("send logon: %w", err)
+		}
+	}
+	c.mu.Unlock()
+
+	var msg *Message
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return errors.New("FIX logon timed out")
+	case r := <-c.reads:
+		if r.err != nil {
+			return fmt.Errorf("read logon: %w", r.err)
+		}
+		msg = r.msg
+	}
+	if msg.MsgType() != MsgTypeLogon {
+		return fmt.Errorf("expected logon, received MsgType %s", msg.MsgType())
+	}
+	if err := c.checkCompIDs(msg); err != nil {
+		c.logout(err.Error())
+		return err
+	}
+
+	c.mu.Lock()
+	if !initiator {
+		heartBtInt, err := msg.Int(TagHeartBtInt)
+		if err != nil || heartBtInt < 0 {
+			c.mu.Unlock()
+			return fmt.Errorf("logon HeartBtInt: %q", msg.Get(TagHeartBtInt))
+		}
+		c.heartBtInt = time.Duration(heartBtInt) * time.Second
+		reset = msg.Bool(TagResetSeqNumFlag)
+		if reset {
+			c.resetLocked()
+		}
+		if err := c.sendLocked(c.logonMessage(reset)); err != nil {
+			c.mu.Unlock()
+			return fmt.Errorf("send logon: %w", err)
+		}
+	} else if msg.Bool(TagResetSeqNumFlag) && !reset {
+		// The acceptor reset on its own: expect its sequence from 1.
+		c.nextTarget = 1
+		c.saveLocked()
+	}
+	c.loggedOn = true
+	c.mu.Unlock()
+
+	c.lastRecv = c.now()
+	c.logger.Info("FIX session logged on", "sender", c.cfg.SenderCompID, "target", c.cfg.TargetCompID)
+	return c.sequence(msg)
+}
+
+func (c *connection) logonMessage(reset bool) *Message {
+	msg := NewMessage(MsgTypeLogon).
+		SetInt(TagEncryptMethod, 0).
+		SetInt(TagHeartBtInt, int((c.heartBtInt+time.Second-1)/time.Second))
+	if reset {
+		msg.SetBool(TagResetSeqNumFlag, true)
+	}
+	return msg
+}
+
+func (c *connection) resetLocked() {
+	c.nextSender, c.nextTarget = 1, 1
+	c.sent = make(map[int][]byte)
+	c.saveLocked()
+}
+
+func (c *connection) checkCompIDs(msg *Message) error {
+	if msg.Get(TagSenderCompID) != c.cfg.TargetCompID || msg.Get(TagTargetCompID) != c.cfg.SenderCompID {
+		return fmt.Errorf("unexpected CompIDs %s->%s", msg.Get(TagSenderCompID), msg.Get(TagTargetCompID))
+	}
+	return nil
+}
+
+func (c *connection) run(ctx context.Context) error {
+	c.mu.Lock()
+	interval := c.heartBtInt
+	c.mu.Unlock()
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval / 4)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			c.logout("session ending")
+			return ctx.Err()
+		case r := <-c.reads:
+			if r.err != nil {
+				if errors.Is(r.err, io.EOF) {
+					return fmt.Errorf("FIX connection closed: %w", r.err)
+				}
+				return fmt.Errorf("read FIX message: %w", r.err)
+			}
+			c.lastRecv, c.testReqID = c.now(), ""
+			if err := c.checkCompIDs(r.msg); err != nil {
+				c.logout(err.Error())
+				return err
+			}
+			if err := c.sequence(r.msg); err != nil {
+				return err
+			}
+		case <-tick:
+			if err := c.heartbeat(interval); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// heartbeat sends a heartbeat after an interval without sending, and a test
+// request after an interval without receiving. The counterparty gets one
+// more interval to answer.
+func (c *connection) heartbeat(interval time.Duration) error {
+	now := c.now()
+	if c.testReqID != "" && now.Sub(c.testSent) >= interval {
+		c.logout("heartbeat timeout")
+		return ErrHeartbeatTimeout
+	}
+	if c.testReqID == "" && now.Sub(c.lastRecv) >= interval+interval/5 {
+		c.testSeq++
+		c.testReqID, c.testSent = "TEST-"+strconv.Itoa(c.testSeq), now
+		return c.Send(NewMessage(MsgTypeTestRequest).Set(TagTestReqID, c.testReqID))
+	}
+	c.mu.Lock()
+	idle := now.Sub(c.lastSent) >= interval
+	c.mu.Unlock()
+	if idle {
+		return c.Send(NewMessage(MsgTypeHeartbeat))
+	}
+	return nil
+}
+
+// sequence applies msg in MsgSeqNum order. Messages ahead of a gap are held
+// while the missing ones are requested; duplicates already applied are
+// dropped.
+func (c *connection) sequence(msg *Message) error {
+	seq, err := msg.Int(TagMsgSeqNum)
+	if err != nil {
+		c.logout("MsgSeqNum missing")
+		return fmt.Errorf("%w: %v", ErrSequence, err)
+	}
+
+	switch msg.MsgType() {
+	case MsgTypeSequenceReset:
+		if !msg.Bool(TagGapFillFlag) {
+			// Reset mode ignores MsgSeqNum.
+			return c.reset(msg)
+		}
+	case MsgTypeResendRequest:
+		// Answered at once so two sessions recovering from gaps at the
+		// same time do not wait on each other.
+		if !msg.Bool(TagPossDupFlag) {
+			if err := c.resend(msg); err != nil {
+				return err
+			}
+		}
+	}
+
+	c.mu.Lock()
+	expected := c.nextTarget
+	c.mu.Unlock()
+	switch {
+	case seq > expected:
+		c.queued[seq] = msg
+		if c.resending == 0 {
+			c.resending = seq
+			c.logger.Warn("FIX sequence gap, requesting resend", "expected", expected, "received", seq)
+			return c.Send(NewMessage(MsgTypeResendRequest).SetInt(TagBeginSeqNo, expected).SetInt(TagEndSeqNo, 0))
+		}
+		return nil
+	case seq < expected:
+		if msg.Bool(TagPossDupFlag) {
+			return nil
+		}
+		reason := fmt.Sprintf("MsgSeqNum too low, expecting %d but received %d", expected, seq)
+		c.logout(reason)
+		return fmt.Errorf("%w: %s", ErrSequence, reason)
+	}
+
+	for {
+		if err := c.apply(msg, seq); err != nil {
+			return err
+		}
+		c.mu.Lock()
+		expected = c.nextTarget
+		c.mu.Unlock()
+		for queued := range c.queued {
+			if queued < expected {
+				delete(c.queued, queued)
+			}
+		}
+		next, ok := c.queued[expected]
+		if !ok {
+			break
+		}
+		delete(c.queued, expected)
+		msg, seq = next, expected
+	}
+	if c.resending != 0 && expected > c.resending {
+		c.logger.Info("FIX sequence gap filled", "next_expected", expected)
+		c.resending = 0
+	}
+	return nil
+}
+
+// apply handles the in-sequence message msg and advances the expected
+// MsgSeqNum past it.
+func (c *connection) apply(msg *Message, seq int) error {
+	next := seq + 1
+	switch msg.MsgType() {
+	case MsgTypeSequenceReset:
+		newSeq, err := msg.Int(TagNewSeqNo)
+		if err != nil || newSeq <= seq {
+			c.logout("invalid NewSeqNo")
+			return fmt.Errorf("%w: gap fill to %q", ErrSequence, msg.Get(TagNewSeqNo))
+		}
+		next = newSeq
+	case MsgTypeTestRequest:
+		c.advance(next)
+		return c.Send(NewMessage(MsgTypeHeartbeat).Set(TagTestReqID, msg.Get(TagTestReqID)))
+	case MsgTypeLogout:
+		c.advance(next)
+		c.mu.Lock()
+		c.loggedOn = false
+		_ = c.sendLocked(NewMessage(MsgTypeLogout))
+		c.mu.Unlock()
+		if text := msg.Get(TagText); text != "" {
+			return fmt.Errorf("%w: %s", ErrLoggedOut, text)
+		}
+		return ErrLoggedOut
+	case MsgTypeReject:
+		c.logger.Warn("FIX message rejected by counterparty", "ref_seq_num", msg.Get(TagRefSeqNum), "text", msg.Get(TagText))
+		c.handler(msg)
+		c.advance(next)
+		return nil
+	case MsgTypeHeartbeat, MsgTypeResendRequest, MsgTypeLogon:
+	default:
+		// Advanced once handled, so a stored sequence never skips a message
+		// the handler did not see.
+		c.handler(msg)
+		c.advance(next)
+		return nil
+	}
+	c.advance(next)
+	return nil
+}
+
+func (c *connection) advance(next int) {
+	c.mu.Lock()
+	c.nextTarget = next
+	c.saveLocked()
+	c.mu.Unlock()
+}
+
+// reset handles a SequenceReset in reset mode: the next expected MsgSeqNum
+// jumps to NewSeqNo and held messages before it are dropped.
+func (c *connection) reset(msg *Message) error {
+	newSeq, err := msg.Int(TagNewSeqNo)
+	if err != nil {
+		c.logout("invalid NewSeqNo")
+		return fmt.Errorf("%w: sequence reset to %q", ErrSequence, msg.Get(TagNewSeqNo))
+	}
+	c.mu.Lock()
+	if newSeq < c.nextTarget {
+		c.mu.Unlock()
+		reason := fmt.Sprintf("sequence reset to %d below expected %d", newSeq, c.nextTarget)
+		c.logout(reason)
+		return fmt.Errorf("%w: %s", ErrSequence, reason)
+	}
+	c.nextTarget = newSeq
+	c.saveLocked()
+	c.mu.Unlock()
+	c.logger.Warn("FIX sequence reset by counterparty", "next_expected", newSeq)
+	for queued := range c.queued {
+		if queued < newSeq {
+			delete(c.queued, queued)
+		}
+	}
+	if next, ok := c.queued[newSeq]; ok {
+		delete(c.queued, newSeq)
+		return c.sequence(next)
+	}
+	return nil
+}
+
+// resend answers a resend request: kept application messages go out again
+// with PossDupFlag, and runs of session-level messages are replaced by gap
+// fills.
+func (c *connection) resend(msg *Message) error {
+	begin, err := msg.Int(TagBeginSeqNo)
+	if err != nil || begin < 1 {
+		return nil
+	}
+	end, _ := msg.Int(TagEndSeqNo)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	last := c.nextSender - 1
+	if end == 0 || end > last {
+		end = last
+	}
+	c.logger.Info("FIX resend requested", "begin", begin, "end", end)
+	gapStart := 0
+	for seq := begin; seq <= end; seq++ {
+		raw, ok := c.sent[seq]
+		if !ok {
+			if gapStart == 0 {
+				gapStart = seq
+			}
+			continue
+		}
+		if gapStart != 0 {
+			if err := c.gapFillLocked(gapStart, seq); err != nil {
+				return err
+			}
+			gapStart = 0
+		}
+		orig, err := Parse(raw)
+		if err != nil {
+			return fmt.Errorf("decode kept message %d: %w", seq, err)
+		}
+		orig.Set(TagOrigSendingTime, orig.Get(TagSendingTime))
+		orig.SetTime(TagSendingTime, c.now())
+		orig.SetBool(TagPossDupFlag, true)
+		if err := c.writeLocked(orig.Bytes()); err != nil {
+			return err
+		}
+	}
+	if gapStart != 0 {
+		return c.gapFillLocked(gapStart, end+1)
+	}
+	return nil
+}
+
+func (c *connection) gapFillLocked(seq, newSeq int) error {
+	fill := NewMessage(MsgTypeSequenceReset).
+		SetBool(TagGapFillFlag, true).
+		SetInt(TagNewSeqNo, newSeq)
+	c.stamp(fill, seq)
+	fill.SetBool(TagPossDupFlag, true)
+	return c.writeLocked(fill.Bytes())
+}
+
+// logout tells the counterparty why the session is ending; the connection
+// is closed right after, so its answer is not awaited.
+func (c *connection) logout(text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loggedOn = false
+	if err := c.sendLocked(NewMessage(MsgTypeLogout).Set(TagText, text)); err != nil {
+		c.logger.Debug("failed to send FIX logout", "error", err)
+	}
+	c.logger.Info("FIX session logged out", "sender", c.cfg.SenderCompID, "target", c.cfg.TargetCompID, "reason", text)
+}
@@ -0,0 +1,68 @@
+package fix
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// SeqStore keeps a session's sequence numbers across process restarts, so a
+// restarted session resumes them and asks for the messages it missed instead
+// of resetting.
+type SeqStore interface {
+	// Load returns the stored next MsgSeqNum to send and next expected;
+	// ok is false when nothing is stored yet.
+	Load() (sender, target int, ok bool, err error)
+	// Save records the next MsgSeqNum to send and next expected.
+	Save(sender, target int) error
+}
+
+// FileSeqStore keeps sequence numbers in the file at path, replaced whole on
+// every save.
+type FileSeqStore struct {
+	path string
+}
+
+// NewFileSeqStore returns a store writing to path; the file is created by
+// the first save.
+func NewFileSeqStore(path string) *FileSeqStore {
+	return &FileSeqStore{path: path}
+}
+
+// Load implements SeqStore.
+func (s *FileSeqStore) Load() (sender, target int, ok bool, err error) {
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, 0, false, nil
+	}
+	if err != nil {
+		return 0, 0, false, fmt.Errorf("read FIX sequence file: %w", err)
+	}
+	if _, err := fmt.Sscanf(string(raw), "%d %d", &sender, &target); err != nil || sender < 1 || target < 1 {
+		return 0, 0, false, fmt.Errorf("FIX sequence file %s: invalid contents %q", s.path, raw)
+	}
+	return sender, target, true, nil
+}
+
+// Save implements SeqStore. The numbers are written to a temporary file
+// renamed over the old one, so a crash leaves either set whole.
+func (s *FileSeqStore) Save(sender, target int) error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("save FIX sequence numbers: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := fmt.Fprintf(tmp, "%d %d\n", sender, target); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save FIX sequence numbers: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save FIX sequence numbers: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("save FIX sequence numbers: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/future-bots/executor/internal/messaging"
+	"github.com/future-bots/executor/internal/service"
+	ordersv1 "github.com/future-bots/proto/orders/v1"
+)
+
+// subscriberBuffer bounds the events queued for one stream. A stream that
+// falls this far behind is cut off so it cannot stall order processing.
+const subscriberBuffer = 256
+
+// EventFilter narrows a subscription. Empty fields match every order.
+type EventFilter struct {
+	BotID     string
+	AccountID string
+}
+
+// Matches reports whether the event's order satisfies the filter.
+func (f EventFilter) Matches(event *ordersv1.OrderEvent) bool {
+	return (f.BotID == "" || event.GetBotId() == f.BotID) &&
+		(f.AccountID == "" || event.GetAccountId() == f.AccountID)
+}
+
+// Subscription delivers the events matching its filter. Events is closed
+// when the subscriber falls behind or the fan-out closes.
+type Subscription struct {
+	Events <-chan *ordersv1.OrderEvent
+	events chan *ordersv1.OrderEvent
+	filter EventFilter
+}
+
+// Events converts order lifecycle events to OrderEvent envelopes and fans
+// them out to StreamOrderEvents subscribers. It implements
+// service.EventPublisher and must be registered with the service.
+type Events struct {
+	mu     sync.Mutex
+	now    func() time.Time
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewEvents builds an empty fan-out stamping envelopes with now.
+func NewEvents(now func() time.Time) *Events {
+	if now == nil {
+		now = time.Now
+	}
+	return &Events{now: now, subs: make(map[*Subscription]struct{})}
+}
+
+// PublishOrderEvent implements service.EventPublisher. It never blocks: a
+// subscriber whose queue is full is dropped instead. Events that have no
+// envelope form are skipped.
+func (e *Events) PublishOrderEvent(_ context.Context, event service.Event) error {
+	envelope, err := messaging.NewEnvelope(event, e.now())
+	if err != nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return nil
+	}
+	for sub := range e.subs {
+		if !sub.filter.Matches(envelope) {
+			continue
+		}
+		select {
+		case sub.events <- envelope:
+		default:
+			delete(e.subs, sub)
+			close(sub.events)
+		}
+	}
+	return nil
+}
+
+// Subscribe registers a subscriber for events matching filter. On a closed
+// fan-out the returned subscription's channel is already closed.
+func (e *Events) Subscribe(filter EventFilter) *Subscription {
+	ch := make(chan *ordersv1.OrderEvent, subscriberBuffer)
+	sub := &Subscription{Events: ch, events: ch, filter: filter}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		close(ch)
+		return sub
+	}
+	e.subs[sub] = struct{}{}
+	return sub
+}
+
+// Unsubscribe removes sub. It is safe to call after the subscriber was
+// dropped or the fan-out closed.
+func (e *Events) Unsubscribe(sub *Subscription) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.subs[sub]; ok {
+		delete(e.subs, sub)
+		close(sub.events)
+	}
+}
+
+// Closed reports whether Close was called.
+func (e *Events) Closed() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.closed
+}
+
+// Close ends every subscription so streams return and the gRPC server can
+// stop gracefully.
+func (e *Events) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return
+	}
+	e.closed = true
+	for sub := range e.subs {
+		delete(e.subs, sub)
+		close(sub.events)
+	}
+}
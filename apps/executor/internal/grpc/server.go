@@ -0,0 +1,181 @@
+// Package grpc serves the executor API over gRPC using the messages of
+// proto/orders/v1, backed by the same service.Service as the HTTP router.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/future-bots/executor/internal/messaging"
+	"github.com/future-bots/executor/internal/service"
+	ordersv1 "github.com/future-bots/proto/orders/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys read and written by the server.
+const (
+	// MetadataCorrelationID carries the correlation id stamped on the
+	// events of a submitted order, like the X-Correlation-ID header.
+	MetadataCorrelationID = "x-correlation-id"
+	// MetadataReplayed is set to "true" in the response header when
+	// SubmitOrder returns the order of an intent submitted before.
+	MetadataReplayed = "idempotent-replayed"
+)
+
+// Server implements ordersv1.ExecutorServiceServer.
+type Server struct {
+	ordersv1.UnimplementedExecutorServiceServer
+	logger *slog.Logger
+	svc    service.Service
+	events *Events
+}
+
+// NewServer builds the gRPC service on top of svc. events feeds
+// StreamOrderEvents and must be registered with svc as an event publisher.
+func NewServer(logger *slog.Logger, svc service.Service, events *Events) *Server {
+	return &Server{logger: logger, svc: svc, events: events}
+}
+
+// NewGRPCServer returns a gRPC server with the executor service registered.
+func NewGRPCServer(logger *slog.Logger, svc service.Service, events *Events, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
+	ordersv1.RegisterExecutorServiceServer(server, NewServer(logger, svc, events))
+	return server
+}
+
+// SubmitOrder implements ordersv1.ExecutorServiceServer.
+func (s *Server) SubmitOrder(ctx context.Context, intent *ordersv1.OrderIntent) (*ordersv1.Order, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(MetadataCorrelationID); len(ids) > 0 {
+			ctx = service.WithCorrelationID(ctx, ids[0])
+		}
+	}
+	order, err := s.svc.SubmitOrder(ctx, messaging.IntentFromProto(intent, ""))
+	if errors.Is(err, service.ErrDuplicateIntent) {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataReplayed, "true"))
+		return messaging.OrderToProto(order), nil
+	}
+	if err != nil {
+		return nil, s.status("submit", intent.GetIntentId(), err)
+	}
+	s.logger.Info("accepted order intent", "bot_id", order.BotID, "symbol", order.Symbol, "side", order.Side)
+	return messaging.OrderToProto(order), nil
+}
+
+// GetOrder implements ordersv1.ExecutorServiceServer.
+func (s *Server) GetOrder(ctx context.Context, req *ordersv1.GetOrderRequest) (*ordersv1.Order, error) {
+	order, err := s.svc.GetOrder(ctx, req.GetOrderId())
+	if err != nil {
+		return nil, s.status("get", req.GetOrderId(), err)
+	}
+	return messaging.OrderToProto(order), nil
+}
+
+// CancelOrder implements ordersv1.ExecutorServiceServer.
+func (s *Server) CancelOrder(ctx context.Context, req *ordersv1.CancelOrderRequest) (*ordersv1.Order, error) {
+	order, err := s.svc.CancelOrder(ctx, req.GetOrderId(), service.CancelRequest{
+		InitiatedBy: service.Initiator(req.GetInitiatedBy()),
+		Reason:      req.GetReason(),
+		Force:       req.GetForce(),
+	})
+	if err != nil {
+		return nil, s.status("cancel", req.GetOrderId(), err)
+	}
+	s.logger.Info("cancelled order", "order_id", order.ID, "initiated_by", req.GetInitiatedBy())
+	return messaging.OrderToProto(order), nil
+}
+
+// ListOrders implements ordersv1.ExecutorServiceServer.
+func (s *Server) ListOrders(ctx context.Context, req *ordersv1.ListOrdersRequest) (*ordersv1.ListOrdersResponse, error) {
+	filter := service.OrderFilter{
+		BotID:     req.GetBotId(),
+		AccountID: req.GetAccountId(),
+		Symbol:    req.GetSymbol(),
+		Side:      messaging.SideFromProto(req.GetSide()),
+		Mode:      messaging.ModeFromProto(req.GetMode()),
+	}
+	for _, st := range req.GetStatuses() {
+		if st == ordersv1.OrderStatus_ORDER_STATUS_UNSPECIFIED {
+			continue
+		}
+		filter.Statuses = append(filter.Statuses, messaging.StatusFromProto(st))
+	}
+	if req.GetCreatedFrom() != nil {
+		filter.CreatedFrom = req.GetCreatedFrom().AsTime()
+	}
+	if req.GetCreatedTo() != nil {
+		filter.CreatedTo = req.GetCreatedTo().AsTime()
+	}
+	page, err := s.svc.ListOrders(ctx, filter, service.PageRequest{
+		Cursor:    req.GetCursor(),
+		Limit:     int(req.GetLimit()),
+		Ascending: req.GetAscending(),
+	})
+	if err != nil {
+		return nil, s.status("list", "", err)
+	}
+	resp := &ordersv1.ListOrdersResponse{NextCursor: page.NextCursor, Orders: make([]*ordersv1.Order, 0, len(page.Items))}
+	for _, order := range page.Items {
+		resp.Orders = append(resp.Orders, messaging.OrderToProto(order))
+	}
+	return resp, nil
+}
+
+// StreamOrderEvents implements ordersv1.ExecutorServiceServer. A client
+// that cannot keep up is cut off with codes.ResourceExhausted and should
+// reconnect and reload its orders with ListOrders.
+func (s *Server) StreamOrderEvents(req *ordersv1.StreamOrderEventsRequest, stream ordersv1.ExecutorService_StreamOrderEventsServer) error {
+	sub := s.events.Subscribe(EventFilter{BotID: req.GetBotId(), AccountID: req.GetAccountId()})
+	defer s.events.Unsubscribe(sub)
+
+	// Send the headers at once so the client knows it is subscribed.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, ok := <-sub.Events:
+			if !ok {
+				if s.events.Closed() {
+					return status.Error(codes.Unavailable, "executor shutting down")
+				}
+				return status.Error(codes.ResourceExhausted, "event stream fell behind")
+			}
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// status maps service errors onto gRPC status codes, mirroring the HTTP
+// router.
+func (s *Server) status(op, id string, err error) error {
+	var ve service.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		return status.Error(codes.InvalidArgument, ve.Error())
+	case errors.Is(err, service.ErrOrderNotFound):
+		return status.Error(codes.NotFound, "order not found")
+	case errors.Is(err, service.ErrThrottled):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, service.ErrSequenceRegression), errors.Is(err, service.ErrInvalidTransition):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrCancelPending):
+		// The broker is already working the cancel; a retry would not help.
+		s.logger.Info("cancel pending at the broker", "id", id, "error", err)
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, service.ErrBrokerRequest):
+		s.logger.Error("broker refused order update", "op", op, "id", id, "error", err)
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		s.logger.Error("order operation failed", "op", op, "id", id, "error", err)
+		return status.Error(codes.Internal, "failed to "+op+" order")
+	}
+}
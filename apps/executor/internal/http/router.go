@@ -1,17 +1,93 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/future-bots/executor/internal/messaging"
+	"github.com/future-bots/executor/internal/position"
+	"github.com/future-bots/executor/internal/routing"
 	"github.com/future-bots/executor/internal/service"
+	"github.com/future-bots/executor/internal/stream"
 	"github.com/future-bots/platform/httpx"
 )
 
+// RouterOption enables optional parts of the API surface.
+type RouterOption func(*routerConfig)
+
+type routerConfig struct {
+	positions   position.Reader
+	deadLetters DeadLetterAdmin
+	routing     RoutingAdmin
+	orderStream *stream.Hub
+	readiness   []readinessCheck
+}
+
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// DeadLetterAdmin lists and re-drives dead-lettered intents; the intent
+// consumer implements it.
+type DeadLetterAdmin interface {
+	DeadLetters(ctx context.Context, limit int) ([]messaging.DeadLetter, error)
+	Redrive(ctx context.Context, id string) (messaging.DeadLetter, error)
+}
+
+// RoutingAdmin reads and replaces the order routing rules; routing.Router
+// implements it.
+type RoutingAdmin interface {
+	Rules() []routing.Rule
+	SetRules(ctx context.Context, rules []routing.Rule) error
+	Venues(ctx context.Context) []routing.VenueStatus
+}
+
+// WithPositions exposes GET /api/v1/positions backed by reader.
+func WithPositions(reader position.Reader) RouterOption {
+	return func(c *routerConfig) {
+		c.positions = reader
+	}
+}
+
+// WithRouting exposes the /api/v1/admin/routing endpoints backed by admin.
+func WithRouting(admin RoutingAdmin) RouterOption {
+	return func(c *routerConfig) {
+		c.routing = admin
+	}
+}
+
+// WithDeadLetters exposes the /api/v1/admin/dead-letters endpoints backed by
+// admin.
+func WithDeadLetters(admin DeadLetterAdmin) RouterOption {
+	return func(c *routerConfig) {
+		c.deadLetters = admin
+	}
+}
+
+// WithReadinessCheck makes GET /readyz answer 503 while check fails, naming
+// the failure under name.
+func WithReadinessCheck(name string, check func(ctx context.Context) error) RouterOption {
+	return func(c *routerConfig) {
+		c.readiness = append(c.readiness, readinessCheck{name: name, check: check})
+	}
+}
+
 // NewRouter constructs an HTTP handler exposing the executor API surface.
-func NewRouter(logger *slog.Logger, svc service.Service) http.Handler {
+func NewRouter(logger *slog.Logger, svc service.Service, opts ...RouterOption) http.Handler {
+	var cfg routerConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /openapi.json", serveOpenAPI)
@@ -21,8 +97,21 @@ func NewRouter(logger *slog.Logger, svc service.Service) http.Handler {
 		httpx.JSON(w, http.StatusOK, map[string]string{"status": "ok"})
 	})
 
-	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, _ *http.Request) {
-		httpx.JSON(w, http.StatusOK, map[string]string{"status": "ready"})
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, r *http.Request) {
+		if len(cfg.readiness) == 0 {
+			httpx.JSON(w, http.StatusOK, map[string]string{"status": "ready"})
+			return
+		}
+		status, code := "ready", http.StatusOK
+		checks := make(map[string]string, len(cfg.readiness))
+		for _, rc := range cfg.readiness {
+			checks[rc.name] = "ok"
+			if err := rc.check(r.Context()); err != nil {
+				checks[rc.name] = err.Error()
+				status, code = "unavailable", http.StatusServiceUnavailable
+			}
+		}
+		httpx.JSON(w, code, map[string]any{"status": status, "checks": checks})
 	})
 
 	mux.HandleFunc("POST /api/v1/orders", func(w http.ResponseWriter, r *http.Request) {
@@ -32,9 +121,30 @@ func NewRouter(logger *slog.Logger, svc service.Service) http.Handler {
 			httpx.Error(w, http.StatusBadRequest, "invalid request body")
 			return
 		}
+		if key := r.Header.Get("Idempotency-Key"); key != "" {
+			if intent.IntentID != "" && intent.IntentID != key {
+				httpx.Error(w, http.StatusBadRequest, "Idempotency-Key does not match intent_id")
+				return
+			}
+			intent.IntentID = key
+		}
 
-		order, err := svc.SubmitOrder(r.Context(), intent)
+		ctx := service.WithCorrelationID(r.Context(), r.Header.Get("X-Correlation-ID"))
+		order, err := svc.SubmitOrder(ctx, intent)
+		if errors.Is(err, service.ErrDuplicateIntent) {
+			w.Header().Set("Idempotent-Replayed", "true")
+			httpx.JSON(w, http.StatusOK, order)
+			return
+		}
 		if err != nil {
+			if errors.Is(err, service.ErrSequenceRegression) {
+				httpx.Error(w, http.StatusConflict, err.Error())
+				return
+			}
+			if errors.Is(err, service.ErrThrottled) {
+				writeThrottled(w, err)
+				return
+			}
 			var ve service.ValidationError
 			if errors.As(err, &ve) {
 				httpx.Error(w, http.StatusBadRequest, ve.Error())
@@ -49,6 +159,26 @@ func NewRouter(logger *slog.Logger, svc service.Service) http.Handler {
 		httpx.JSON(w, http.StatusAccepted, order)
 	})
 
+	mux.HandleFunc("GET /api/v1/orders", func(w http.ResponseWriter, r *http.Request) {
+		filter, page, err := parseOrderListQuery(r.URL.Query())
+		if err != nil {
+			httpx.Error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		result, err := svc.ListOrders(r.Context(), filter, page)
+		if err != nil {
+			var ve service.ValidationError
+			if errors.As(err, &ve) {
+				httpx.Error(w, http.StatusBadRequest, ve.Error())
+				return
+			}
+			logger.Error("failed to list orders", "error", err)
+			httpx.Error(w, http.StatusInternalServerError, "failed to list orders")
+			return
+		}
+		httpx.JSON(w, http.StatusOK, result)
+	})
+
 	mux.HandleFunc("GET /api/v1/orders/{order_id}", func(w http.ResponseWriter, r *http.Request) {
 		orderID := r.PathValue("order_id")
 		order, err := svc.GetOrder(r.Context(), orderID)
@@ -64,5 +194,374 @@ func NewRouter(logger *slog.Logger, svc service.Service) http.Handler {
 		httpx.JSON(w, http.StatusOK, order)
 	})
 
+	mux.HandleFunc("GET /api/v1/orders/{order_id}/history", func(w http.ResponseWriter, r *http.Request) {
+		orderID := r.PathValue("order_id")
+		history, err := svc.GetOrderHistory(r.Context(), orderID)
+		if err != nil {
+			if errors.Is(err, service.ErrOrderNotFound) {
+				httpx.Error(w, http.StatusNotFound, "order not found")
+				return
+			}
+			logger.Error("failed to fetch order history", "order_id", orderID, "error", err)
+			httpx.Error(w, http.StatusInternalServerError, "failed to fetch order history")
+			return
+		}
+		httpx.JSON(w, http.StatusOK, map[string]any{"items": history})
+	})
+
+	mux.HandleFunc("GET /api/v1/orders/{order_id}/executions", func(w http.ResponseWriter, r *http.Request) {
+		orderID := r.PathValue("order_id")
+		executions, err := svc.GetOrderExecutions(r.Context(), orderID)
+		if err != nil {
+			if errors.Is(err, service.ErrOrderNotFound) {
+				httpx.Error(w, http.StatusNotFound, "order not found")
+				return
+			}
+			logger.Error("failed to fetch order executions", "order_id", orderID, "error", err)
+			httpx.Error(w, http.StatusInternalServerError, "failed to fetch order executions")
+			return
+		}
+		httpx.JSON(w, http.StatusOK, map[string]any{"items": executions})
+	})
+
+	mux.HandleFunc("GET /api/v1/orders/{order_id}/children", func(w http.ResponseWriter, r *http.Request) {
+		orderID := r.PathValue("order_id")
+		progress, err := svc.GetParentProgress(r.Context(), orderID)
+		if err != nil {
+			var ve service.ValidationError
+			switch {
+			case errors.As(err, &ve):
+				httpx.Error(w, http.StatusBadRequest, ve.Error())
+			case errors.Is(err, service.ErrOrderNotFound):
+				httpx.Error(w, http.StatusNotFound, "order not found")
+			default:
+				logger.Error("failed to fetch child orders", "order_id", orderID, "error", err)
+				httpx.Error(w, http.StatusInternalServerError, "failed to fetch child orders")
+			}
+			return
+		}
+		httpx.JSON(w, http.StatusOK, progress)
+	})
+
+	mux.HandleFunc("DELETE /api/v1/orders/{order_id}", func(w http.ResponseWriter, r *http.Request) {
+		orderID := r.PathValue("order_id")
+		req := service.CancelRequest{
+			InitiatedBy: service.Initiator(r.URL.Query().Get("initiated_by")),
+			Reason:      r.URL.Query().Get("reason"),
+		}
+		if raw := r.URL.Query().Get("force"); raw != "" {
+			force, err := strconv.ParseBool(raw)
+			if err != nil {
+				httpx.Error(w, http.StatusBadRequest, "force must be true or false")
+				return
+			}
+			req.Force = force
+		}
+		order, err := svc.CancelOrder(r.Context(), orderID, req)
+		if err != nil {
+			writeOrderUpdateError(w, logger, "cancel", orderID, err)
+			return
+		}
+		logger.Info("cancelled order", "order_id", orderID, "initiated_by", req.InitiatedBy)
+		httpx.JSON(w, http.StatusOK, order)
+	})
+
+	mux.HandleFunc("PATCH /api/v1/orders/{order_id}", func(w http.ResponseWriter, r *http.Request) {
+		orderID := r.PathValue("order_id")
+		var req service.AmendRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			logger.Error("invalid amend request", "order_id", orderID, "error", err)
+			httpx.Error(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		order, err := svc.AmendOrder(r.Context(), orderID, req)
+		if err != nil {
+			writeOrderUpdateError(w, logger, "amend", orderID, err)
+			return
+		}
+		logger.Info("amended order", "order_id", orderID, "price", order.Price, "quantity", order.Quantity)
+		httpx.JSON(w, http.StatusOK, order)
+	})
+
+	mux.HandleFunc("GET /api/v1/rate-limits", func(w http.ResponseWriter, r *http.Request) {
+		status, err := svc.RateLimitStatus(r.Context())
+		if err != nil {
+			logger.Error("failed to fetch rate limit status", "error", err)
+			httpx.Error(w, http.StatusInternalServerError, "failed to fetch rate limit status")
+			return
+		}
+		httpx.JSON(w, http.StatusOK, status)
+	})
+
+	mux.HandleFunc("GET /api/v1/reconciliation/runs", func(w http.ResponseWriter, r *http.Request) {
+		limit := 0
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				httpx.Error(w, http.StatusBadRequest, "limit must be an integer")
+				return
+			}
+			limit = n
+		}
+		runs, err := svc.ReconciliationRuns(r.Context(), limit)
+		if err != nil {
+			var ve service.ValidationError
+			if errors.As(err, &ve) {
+				httpx.Error(w, http.StatusBadRequest, ve.Error())
+				return
+			}
+			logger.Error("failed to list reconciliation runs", "error", err)
+			httpx.Error(w, http.StatusInternalServerError, "failed to list reconciliation runs")
+			return
+		}
+		httpx.JSON(w, http.StatusOK, map[string]any{"items": runs})
+	})
+
+	mux.HandleFunc("POST /api/v1/reconciliation/runs", func(w http.ResponseWriter, r *http.Request) {
+		run, err := svc.Reconcile(r.Context())
+		if err != nil {
+			logger.Error("reconciliation run failed", "run_id", run.ID, "error", err)
+			httpx.JSON(w, http.StatusBadGateway, run)
+			return
+		}
+		logger.Info("reconciliation run finished", "run_id", run.ID, "discrepancies", len(run.Discrepancies))
+		httpx.JSON(w, http.StatusOK, run)
+	})
+
+	mux.HandleFunc("GET /api/v1/sessions/summaries", func(w http.ResponseWriter, r *http.Request) {
+		limit := 0
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				httpx.Error(w, http.StatusBadRequest, "limit must be an integer")
+				return
+			}
+			limit = n
+		}
+		summaries, err := svc.SessionSummaries(r.Context(), limit)
+		if err != nil {
+			var ve service.ValidationError
+			if errors.As(err, &ve) {
+				httpx.Error(w, http.StatusBadRequest, ve.Error())
+				return
+			}
+			logger.Error("failed to list session summaries", "error", err)
+			httpx.Error(w, http.StatusInternalServerError, "failed to list session summaries")
+			return
+		}
+		httpx.JSON(w, http.StatusOK, map[string]any{"items": summaries})
+	})
+
+	mux.HandleFunc("POST /api/v1/sessions/close", func(w http.ResponseWriter, r *http.Request) {
+		summary, err := svc.CloseSession(r.Context())
+		if err != nil {
+			logger.Error("session close failed", "summary_id", summary.ID, "error", err)
+			httpx.JSON(w, http.StatusBadGateway, summary)
+			return
+		}
+		logger.Info("trading session closed", "summary_id", summary.ID, "day_orders_cancelled", summary.DayOrdersCancelled)
+		httpx.JSON(w, http.StatusOK, summary)
+	})
+
+	if cfg.positions != nil {
+		mux.HandleFunc("GET /api/v1/positions", func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			mode, err := parseMode(q)
+			if err != nil {
+				httpx.Error(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			filter := position.Filter{
+				AccountID: q.Get("account_id"),
+				BotID:     q.Get("bot_id"),
+				Mode:      mode,
+				Symbol:    q.Get("symbol"),
+			}
+			positions, err := cfg.positions.List(r.Context(), filter)
+			if err != nil {
+				logger.Error("failed to list positions", "error", err)
+				httpx.Error(w, http.StatusInternalServerError, "failed to list positions")
+				return
+			}
+			if positions == nil {
+				positions = []position.Position{}
+			}
+			httpx.JSON(w, http.StatusOK, map[string]any{"items": positions})
+		})
+	}
+
+	if cfg.orderStream != nil {
+		mux.HandleFunc("GET /api/v1/orders/stream", serveOrderStream(logger, cfg.orderStream))
+	}
+
+	if cfg.deadLetters != nil {
+		mux.HandleFunc("GET /api/v1/admin/dead-letters", func(w http.ResponseWriter, r *http.Request) {
+			limit := service.DefaultPageSize
+			if raw := r.URL.Query().Get("limit"); raw != "" {
+				n, err := strconv.Atoi(raw)
+				if err != nil || n <= 0 || n > service.MaxPageSize {
+					httpx.Error(w, http.StatusBadRequest, fmt.Sprintf("limit must be an integer between 1 and %d", service.MaxPageSize))
+					return
+				}
+				limit = n
+			}
+			letters, err := cfg.deadLetters.DeadLetters(r.Context(), limit)
+			if err != nil {
+				logger.Error("failed to list dead letters", "error", err)
+				httpx.Error(w, http.StatusInternalServerError, "failed to list dead letters")
+				return
+			}
+			httpx.JSON(w, http.StatusOK, map[string]any{"items": letters})
+		})
+
+		mux.HandleFunc("POST /api/v1/admin/dead-letters/{id}/redrive", func(w http.ResponseWriter, r *http.Request) {
+			id := r.PathValue("id")
+			letter, err := cfg.deadLetters.Redrive(r.Context(), id)
+			switch {
+			case errors.Is(err, messaging.ErrDeadLetterNotFound):
+				httpx.Error(w, http.StatusNotFound, err.Error())
+			case errors.Is(err, messaging.ErrUndecodableIntent):
+				httpx.Error(w, http.StatusUnprocessableEntity, err.Error())
+			case err != nil:
+				logger.Error("dead letter re-drive failed", "id", id, "error", err)
+				httpx.Error(w, http.StatusBadGateway, err.Error())
+			default:
+				httpx.JSON(w, http.StatusOK, letter)
+			}
+		})
+	}
+
+	if cfg.routing != nil {
+		mux.HandleFunc("GET /api/v1/admin/routing/rules", func(w http.ResponseWriter, r *http.Request) {
+			httpx.JSON(w, http.StatusOK, map[string]any{"items": cfg.routing.Rules()})
+		})
+
+		mux.HandleFunc("PUT /api/v1/admin/routing/rules", func(w http.ResponseWriter, r *http.Request) {
+			var req struct {
+				Items []routing.Rule `json:"items"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				httpx.Error(w, http.StatusBadRequest, "invalid routing rules payload")
+				return
+			}
+			if req.Items == nil {
+				req.Items = []routing.Rule{}
+			}
+			if err := cfg.routing.SetRules(r.Context(), req.Items); err != nil {
+				var ve service.ValidationError
+				if errors.As(err, &ve) {
+					httpx.Error(w, http.StatusBadRequest, ve.Error())
+					return
+				}
+				logger.Error("failed to update routing rules", "error", err)
+				httpx.Error(w, http.StatusInternalServerError, "failed to update routing rules")
+				return
+			}
+			logger.Info("routing rules replaced", "rules", len(req.Items))
+			httpx.JSON(w, http.StatusOK, map[string]any{"items": cfg.routing.Rules()})
+		})
+
+		mux.HandleFunc("GET /api/v1/admin/routing/venues", func(w http.ResponseWriter, r *http.Request) {
+			httpx.JSON(w, http.StatusOK, map[string]any{"items": cfg.routing.Venues(r.Context())})
+		})
+	}
+
 	return mux
 }
+
+// parseOrderListQuery reads the GET /api/v1/orders filters. Statuses may be
+// repeated or comma separated; timestamps use RFC 3339.
+func parseOrderListQuery(q url.Values) (service.OrderFilter, service.PageRequest, error) {
+	filter := service.OrderFilter{
+		BotID:     q.Get("bot_id"),
+		AccountID: q.Get("account_id"),
+		Symbol:    q.Get("symbol"),
+		Side:      q.Get("side"),
+		ParentID:  q.Get("parent_id"),
+		BracketID: q.Get("bracket_id"),
+	}
+	mode, err := parseMode(q)
+	if err != nil {
+		return filter, service.PageRequest{}, err
+	}
+	filter.Mode = mode
+	for _, raw := range q["status"] {
+		for _, status := range strings.Split(raw, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				filter.Statuses = append(filter.Statuses, service.Status(status))
+			}
+		}
+	}
+	for _, bound := range []struct {
+		key    string
+		target *time.Time
+	}{{"created_from", &filter.CreatedFrom}, {"created_to", &filter.CreatedTo}} {
+		if raw := q.Get(bound.key); raw != "" {
+			t, err := time.Parse(time.RFC3339Nano, raw)
+			if err != nil {
+				return filter, service.PageRequest{}, fmt.Errorf("%s must be an RFC 3339 timestamp", bound.key)
+			}
+			*bound.target = t.UTC()
+		}
+	}
+
+	page := service.PageRequest{Cursor: q.Get("cursor")}
+	if raw := q.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return filter, page, fmt.Errorf("limit must be an integer")
+		}
+		page.Limit = limit
+	}
+	switch q.Get("sort") {
+	case "", "desc":
+	case "asc":
+		page.Ascending = true
+	default:
+		return filter, page, fmt.Errorf("sort must be asc or desc")
+	}
+	return filter, page, nil
+}
+
+// writeOrderUpdateError maps cancel/amend failures onto HTTP status codes.
+func writeOrderUpdateError(w http.ResponseWriter, logger *slog.Logger, op, orderID string, err error) {
+	var ve service.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		httpx.Error(w, http.StatusBadRequest, ve.Error())
+	case errors.Is(err, service.ErrOrderNotFound):
+		httpx.Error(w, http.StatusNotFound, "order not found")
+	case errors.Is(err, service.ErrThrottled):
+		writeThrottled(w, err)
+	case errors.Is(err, service.ErrInvalidTransition):
+		httpx.Error(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrCancelPending):
+		logger.Info("cancel pending at the broker", "order_id", orderID, "error", err)
+		httpx.Error(w, http.StatusAccepted, err.Error())
+	case errors.Is(err, service.ErrBrokerRequest):
+		logger.Error("broker refused order update", "op", op, "order_id", orderID, "error", err)
+		httpx.Error(w, http.StatusBadGateway, err.Error())
+	default:
+		logger.Error("order update failed", "op", op, "order_id", orderID, "error", err)
+		httpx.Error(w, http.StatusInternalServerError, "failed to "+op+" order")
+	}
+}
+
+// writeThrottled answers 429 with a Retry-After hint when the limit frees up
+// with time.
+func writeThrottled(w http.ResponseWriter, err error) {
+	var te service.ThrottleError
+	if errors.As(err, &te) && te.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(te.RetryAfter.Seconds()))))
+	}
+	httpx.Error(w, http.StatusTooManyRequests, err.Error())
+}
+
+// parseMode reads the optional live/paper mode filter.
+func parseMode(q url.Values) (service.Mode, error) {
+	mode := service.Mode(q.Get("mode"))
+	if mode != "" && !mode.Valid() {
+		return "", fmt.Errorf("mode must be live or paper")
+	}
+	return mode, nil
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/future-bots/executor/internal/stream"
+	"github.com/future-bots/platform/httpx"
+)
+
+// streamHeartbeat is how often an idle order stream sends a comment so
+// proxies keep the connection open.
+const streamHeartbeat = 15 * time.Second
+
+// Server-sent event names on the order stream.
+const (
+	streamEventOrder = "order"
+	streamEventFill  = "fill"
+	streamEventReset = "reset"
+)
+
+// WithOrderStream exposes GET /api/v1/orders/stream backed by hub.
+func WithOrderStream(hub *stream.Hub) RouterOption {
+	return func(c *routerConfig) {
+		c.orderStream = hub
+	}
+}
+
+// serveOrderStream streams order updates as server-sent events. Each event
+// carries the hub id, so a reconnecting client resumes through Last-Event-ID;
+// when the updates it missed are no longer retained it receives a reset
+// event and has to reload its orders over the REST API.
+func serveOrderStream(logger *slog.Logger, hub *stream.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		filter := stream.Filter{BotID: q.Get("bot_id"), AccountID: q.Get("account_id")}
+		var lastID uint64
+		if raw := strings.TrimSpace(r.Header.Get("Last-Event-ID")); raw != "" {
+			id, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				httpx.Error(w, http.StatusBadRequest, "Last-Event-ID must be a stream event id")
+				return
+			}
+			lastID = id
+		}
+
+		sub, replay := hub.Subscribe(filter, lastID)
+		defer hub.Unsubscribe(sub)
+
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		if replay.Gap {
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: {}\n\n", replay.Head, streamEventReset)
+		}
+		for _, entry := range replay.Entries {
+			if err := writeStreamEntry(w, entry); err != nil {
+				logger.Error("failed to encode order update", "error", err)
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			case entry, ok := <-sub.Updates:
+				if !ok {
+					return
+				}
+				if err := writeStreamEntry(w, entry); err != nil {
+					logger.Error("failed to encode order update", "error", err)
+					return
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeStreamEntry(w http.ResponseWriter, entry stream.Entry) error {
+	data, err := json.Marshal(entry.Update)
+	if err != nil {
+		return err
+	}
+	event := streamEventOrder
+	if entry.Update.Execution != nil {
+		event = streamEventFill
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", entry.ID, event, data)
+	return err
+}
@@ -0,0 +1,95 @@
+package instrument
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/future-bots/executor/internal/service"
+)
+
+// DerivativesMultiplier is the VND value of one index point of a VN30 future.
+const DerivativesMultiplier = 100_000
+
+// Multiplier returns the VND value of one price unit of symbol: the contract
+// multiplier for VN30 futures and 1 for everything else.
+func Multiplier(symbol string) float64 {
+	if IsDerivative(symbol) {
+		return DerivativesMultiplier
+	}
+	return 1
+}
+
+// FeeSchedule lists the charges on VN30 futures fills. Fees are in VND per
+// contract; IncomeTaxRate is the personal income tax withheld on the sale
+// value (price × multiplier × contracts) of sell fills.
+type FeeSchedule struct {
+	// Commission is the broker's commission.
+	Commission float64
+	// ExchangeFee is the exchange trading fee.
+	ExchangeFee float64
+	// ClearingFee is the clearing member's fee.
+	ClearingFee float64
+	// VSDFee is the depository's (VSDC) position management fee.
+	VSDFee        float64
+	IncomeTaxRate float64
+}
+
+// DefaultFeeSchedule returns the published exchange and VSDC fees and the
+// 0.1% income tax rate. Brokers set their own commission, so it is zero.
+func DefaultFeeSchedule() FeeSchedule {
+	return FeeSchedule{
+		ExchangeFee:   2_700,
+		VSDFee:        2_550,
+		IncomeTaxRate: 0.001,
+	}
+}
+
+// Validate rejects negative charges and tax rates of 100% or more.
+func (s FeeSchedule) Validate() error {
+	for _, f := range []struct {
+		name  string
+		value float64
+	}{
+		{"commission", s.Commission},
+		{"exchange fee", s.ExchangeFee},
+		{"clearing fee", s.ClearingFee},
+		{"VSD fee", s.VSDFee},
+		{"income tax rate", s.IncomeTaxRate},
+	} {
+		if f.value < 0 || math.IsNaN(f.value) {
+			return fmt.Errorf("%s %g must not be negative", f.name, f.value)
+		}
+	}
+	if s.IncomeTaxRate >= 1 {
+		return fmt.Errorf("income tax rate %g must be below 1", s.IncomeTaxRate)
+	}
+	return nil
+}
+
+// perContract sums the fees charged on every contract traded.
+func (s FeeSchedule) perContract() float64 {
+	return s.Commission + s.ExchangeFee + s.ClearingFee + s.VSDFee
+}
+
+// DerivativesFees charges VN30 futures fills according to a FeeSchedule,
+// rounding to whole dong. Other symbols are not charged.
+type DerivativesFees struct {
+	schedule FeeSchedule
+}
+
+// NewDerivativesFees builds the fee model for schedule.
+func NewDerivativesFees(schedule FeeSchedule) *DerivativesFees {
+	return &DerivativesFees{schedule: schedule}
+}
+
+// Charges implements service.FeeModel.
+func (f *DerivativesFees) Charges(order service.Order, quantity, price float64) service.FillCharges {
+	if !IsDerivative(order.Symbol) || quantity <= 0 {
+		return service.FillCharges{}
+	}
+	charges := service.FillCharges{Fee: math.Round(f.schedule.perContract() * quantity)}
+	if order.Side == "sell" {
+		charges.Tax = math.Round(price * DerivativesMultiplier * quantity * f.schedule.IncomeTaxRate)
+	}
+	return charges
+}
@@ -0,0 +1,158 @@
+// Package instrument implements exchange trading rules enforced by the
+// executor before orders reach the risk engine.
+package instrument
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/future-bots/executor/internal/service"
+	marketsv1 "github.com/future-bots/proto/markets/v1"
+)
+
+const (
+	// DerivativesTick is the minimum price increment of VN30 index futures.
+	DerivativesTick = 0.1
+	// DerivativesBandRatio is the daily price limit applied around the
+	// reference price when a snapshot carries no ceiling/floor.
+	DerivativesBandRatio = 0.07
+
+	ticksPerPoint = 1 / DerivativesTick
+)
+
+// derivativePrefixes match VN30 index futures codes in both the legacy
+// (VN30F1M, VN30F2406) and KRX (41I1...) naming schemes.
+var derivativePrefixes = []string{"VN30F", "41I1"}
+
+// IsDerivative reports whether symbol is a VN30 index future.
+func IsDerivative(symbol string) bool {
+	symbol = strings.ToUpper(symbol)
+	for _, prefix := range derivativePrefixes {
+		if strings.HasPrefix(symbol, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Band is the day's permitted price range for a symbol.
+type Band struct {
+	Ceiling   float64
+	Floor     float64
+	Reference float64
+}
+
+// Bands keeps the latest ceiling/floor/reference prices per symbol from the
+// ssi_ps snapshot feed.
+type Bands struct {
+	mu    sync.RWMutex
+	bands map[string]Band
+}
+
+// NewBands constructs an empty band cache.
+func NewBands() *Bands {
+	return &Bands{bands: make(map[string]Band)}
+}
+
+// OnSnapshot records the band carried by snapshot. Snapshots without any
+// price limits leave the previous band in place.
+func (b *Bands) OnSnapshot(snapshot *marketsv1.SsiPsSnapshot) {
+	if snapshot == nil || snapshot.GetCode() == "" {
+		return
+	}
+	band := Band{
+		Ceiling:   snapshot.GetCeilingPrice(),
+		Floor:     snapshot.GetFloorPrice(),
+		Reference: snapshot.GetReferencePrice(),
+	}
+	if band.Ceiling <= 0 && band.Floor <= 0 && band.Reference <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bands[snapshot.GetCode()] = band
+}
+
+// Band returns the latest band for symbol.
+func (b *Bands) Band(symbol string) (Band, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	band, ok := b.bands[symbol]
+	return band, ok
+}
+
+// HOSEDerivatives enforces the VN30 futures rules: prices on the 0.1 point
+// tick grid and within the day's ceiling/floor band, and whole contracts.
+// Symbols that are not VN30 futures pass unchecked. Until a snapshot has been
+// seen for a symbol only the tick and contract rules apply.
+type HOSEDerivatives struct {
+	bands *Bands
+}
+
+// NewHOSEDerivatives builds the rule set on top of a band cache.
+func NewHOSEDerivatives(bands *Bands) *HOSEDerivatives {
+	return &HOSEDerivatives{bands: bands}
+}
+
+// Check implements service.InstrumentRules.
+func (r *HOSEDerivatives) Check(order service.Order) error {
+	if !IsDerivative(order.Symbol) {
+		return nil
+	}
+	if order.Quantity != math.Trunc(order.Quantity) {
+		return service.RuleViolation{
+			Category: service.RejectionInvalidQuantity,
+			Reason:   fmt.Sprintf("quantity %g is not a whole number of contracts", order.Quantity),
+		}
+	}
+
+	band, hasBand := Band{}, false
+	if r.bands != nil {
+		band, hasBand = r.bands.Band(order.Symbol)
+	}
+	ceiling, floor := band.limits()
+	for _, p := range []struct {
+		name  string
+		value float64
+	}{{"price", order.Price}, {"stop_price", order.StopPrice}} {
+		if p.value == 0 {
+			continue
+		}
+		if !onTick(p.value) {
+			return service.RuleViolation{
+				Category: service.RejectionInvalidPrice,
+				Reason:   fmt.Sprintf("%s %g is not a multiple of the %g tick", p.name, p.value, DerivativesTick),
+			}
+		}
+		if hasBand && (p.value > ceiling || p.value < floor) {
+			return service.RuleViolation{
+				Category: service.RejectionInvalidPrice,
+				Reason:   fmt.Sprintf("%s %g is outside the %g-%g band", p.name, p.value, floor, ceiling),
+			}
+		}
+	}
+	return nil
+}
+
+// limits returns the ceiling and floor, deriving them from the reference
+// price when the snapshot did not carry them.
+func (b Band) limits() (ceiling, floor float64) {
+	ceiling, floor = b.Ceiling, b.Floor
+	if ceiling <= 0 && b.Reference > 0 {
+		ceiling = math.Floor(b.Reference*(1+DerivativesBandRatio)*ticksPerPoint+1e-9) / ticksPerPoint
+	}
+	if floor <= 0 && b.Reference > 0 {
+		floor = math.Ceil(b.Reference*(1-DerivativesBandRatio)*ticksPerPoint-1e-9) / ticksPerPoint
+	}
+	if ceiling <= 0 {
+		ceiling = math.Inf(1)
+	}
+	return ceiling, floor
+}
+
+func onTick(price float64) bool {
+	ticks := price * ticksPerPoint
+	return math.Abs(ticks-math.Round(ticks)) < 1e-6
+}
@@ -0,0 +1,21 @@
+package instrument
+
+import "github.com/future-bots/executor/internal/service"
+
+// MarginRequirement returns the initial margin, in VND, of an order's
+// unfilled quantity at rate of its notional value. The notional is priced at
+// the limit price, or the stop price for stop orders without one; orders with
+// neither, such as market orders, require no margin.
+func MarginRequirement(rate float64) func(service.Order) float64 {
+	return func(order service.Order) float64 {
+		price := order.Price
+		if price <= 0 {
+			price = order.StopPrice
+		}
+		remaining := order.Quantity - order.FilledQuantity
+		if price <= 0 || remaining <= 0 {
+			return 0
+		}
+		return price * Multiplier(order.Symbol) * remaining * rate
+	}
+}
@@ -0,0 +1,299 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/future-bots/executor/internal/service"
+	ordersv1 "github.com/future-bots/proto/orders/v1"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// HeaderCorrelationID carries the tracing correlation id on intents and events.
+	HeaderCorrelationID = "correlation_id"
+	// HeaderEventType carries the OrderEvent variant for consumers that filter
+	// without decoding the payload.
+	HeaderEventType = "event_type"
+)
+
+// Reader defines the subset of kafka.Reader used by the consumer.
+type Reader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Consumer reads OrderIntent messages from Kafka and submits them to the
+// executor service. Outcomes are reported back to bots by the service's event
+// publisher. Messages that cannot be decoded, or whose submission keeps
+// failing, are dead-lettered so they never block their partition.
+type Consumer struct {
+	reader      Reader
+	svc         service.Service
+	logger      *slog.Logger
+	maxAttempts int
+	base        time.Duration
+	max         time.Duration
+	dlqWriter   Writer
+	deadLetters DeadLetterStore
+	now         func() time.Time
+}
+
+// ConsumerOption customises a Consumer.
+type ConsumerOption func(*Consumer)
+
+// WithRetry makes up to maxAttempts submissions of an intent whose
+// submission fails, waiting base before the first retry and doubling up to
+// max, before dead-lettering it.
+func WithRetry(maxAttempts int, base, max time.Duration) ConsumerOption {
+	return func(c *Consumer) {
+		if maxAttempts > 0 {
+			c.maxAttempts = maxAttempts
+		}
+		if base > 0 {
+			c.base = base
+		}
+		if max >= c.base {
+			c.max = max
+		}
+	}
+}
+
+// WithDeadLetters writes dead-lettered intents to their dead-letter topic
+// through writer and records them in store for listing and re-drive. Either
+// may be nil.
+func WithDeadLetters(writer Writer, store DeadLetterStore) ConsumerOption {
+	return func(c *Consumer) {
+		c.dlqWriter = writer
+		c.deadLetters = store
+	}
+}
+
+// WithConsumerClock overrides the time source, for tests.
+func WithConsumerClock(now func() time.Time) ConsumerOption {
+	return func(c *Consumer) {
+		if now != nil {
+			c.now = now
+		}
+	}
+}
+
+// NewConsumer wires a consumer around the provided reader and service.
+func NewConsumer(reader Reader, svc service.Service, logger *slog.Logger, opts ...ConsumerOption) *Consumer {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	c := &Consumer{
+		reader:      reader,
+		svc:         svc,
+		logger:      logger,
+		maxAttempts: 3,
+		base:        100 * time.Millisecond,
+		max:         2 * time.Second,
+		now:         func() time.Time { return time.Now().UTC() },
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// ReaderConfig captures the settings for the intent topic reader.
+type ReaderConfig struct {
+	Brokers []string
+	Topics  []string
+	GroupID string
+}
+
+// NewKafkaReader builds a consumer-group reader over the provided intent topics.
+func NewKafkaReader(cfg ReaderConfig) (*kafka.Reader, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("brokers required")
+	}
+	if len(cfg.Topics) == 0 {
+		return nil, fmt.Errorf("at least one intent topic required")
+	}
+	if cfg.GroupID == "" {
+		return nil, fmt.Errorf("group id required")
+	}
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     cfg.Brokers,
+		GroupID:     cfg.GroupID,
+		GroupTopics: cfg.Topics,
+		MinBytes:    1,
+		MaxBytes:    10 << 20,
+	}), nil
+}
+
+// DiscoverIntentTopics lists the order intent topics currently known to the
+// cluster reachable at broker.
+func DiscoverIntentTopics(ctx context.Context, broker string) ([]string, error) {
+	conn, err := kafka.DialContext(ctx, "tcp", broker)
+	if err != nil {
+		return nil, fmt.Errorf("connect broker: %w", err)
+	}
+	defer conn.Close()
+
+	partitions, err := conn.ReadPartitions()
+	if err != nil {
+		return nil, fmt.Errorf("read partitions: %w", err)
+	}
+
+	seen := make(map[string]struct{})
+	topics := make([]string, 0)
+	for _, p := range partitions {
+		if !strings.HasPrefix(p.Topic, IntentTopicPrefix) {
+			continue
+		}
+		if _, ok := seen[p.Topic]; ok {
+			continue
+		}
+		seen[p.Topic] = struct{}{}
+		topics = append(topics, p.Topic)
+	}
+	return topics, nil
+}
+
+// Close releases reader resources.
+func (c *Consumer) Close() error {
+	if c.reader != nil {
+		return c.reader.Close()
+	}
+	return nil
+}
+
+// Run consumes intents until context cancellation.
+func (c *Consumer) Run(ctx context.Context) error {
+	for {
+		m, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return fmt.Errorf("fetch message: %w", err)
+		}
+
+		if err := c.Handle(ctx, m); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			// Leave the message uncommitted so it is delivered again.
+			return fmt.Errorf("handle message: %w", err)
+		}
+
+		if err := c.reader.CommitMessages(ctx, m); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return fmt.Errorf("commit message: %w", err)
+		}
+	}
+}
+
+// permanentError marks a message that will fail however often it is retried.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+
+func (e permanentError) Unwrap() error { return e.err }
+
+// Handle decodes a single intent message and submits it to the service,
+// retrying failed submissions with backoff and dead-lettering the message
+// once the attempts are used up or when it cannot be decoded. Rejected
+// intents are not failures: the service reports them back to the bot. The
+// returned error is non-nil only when the message could not be
+// dead-lettered, or ctx ended while waiting to retry.
+func (c *Consumer) Handle(ctx context.Context, msg kafka.Message) error {
+	for attempt := 1; ; attempt++ {
+		err := c.process(ctx, msg)
+		if err == nil {
+			return nil
+		}
+		var permanent permanentError
+		if errors.As(err, &permanent) || attempt >= c.maxAttempts {
+			return c.deadLetter(ctx, msg, err, attempt)
+		}
+		delay := c.backoff(attempt)
+		c.logger.Warn("retrying order intent",
+			"topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset, "attempt", attempt, "delay", delay.String(), "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// backoff returns the wait after the given failed attempt.
+func (c *Consumer) backoff(attempt int) time.Duration {
+	delay := c.base
+	for i := 1; i < attempt && delay < c.max; i++ {
+		delay *= 2
+	}
+	return min(delay, c.max)
+}
+
+// process makes one attempt at an intent message. It returns nil once the
+// service has decided on the intent, whatever the decision.
+func (c *Consumer) process(ctx context.Context, msg kafka.Message) error {
+	var payload ordersv1.OrderIntent
+	if err := proto.Unmarshal(msg.Value, &payload); err != nil {
+		return permanentError{fmt.Errorf("%w: %w", ErrUndecodableIntent, err)}
+	}
+
+	intent := IntentFromProto(&payload, msg.Topic)
+	correlationID := headerValue(msg.Headers, HeaderCorrelationID)
+	if correlationID == "" {
+		correlationID = intent.IntentID
+	}
+	ctx = service.WithCorrelationID(ctx, correlationID)
+
+	order, err := c.svc.SubmitOrder(ctx, intent)
+	if errors.Is(err, service.ErrDuplicateIntent) {
+		c.logger.Info("skipped replayed order intent",
+			"intent_id", intent.IntentID, "order_id", order.ID, "bot_id", intent.BotID)
+		return nil
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrSequenceRegression) {
+			c.logger.Warn("rejected out-of-order intent",
+				"intent_id", intent.IntentID, "bot_id", intent.BotID, "sequence", intent.Sequence, "error", err)
+			return nil
+		}
+		if errors.Is(err, service.ErrThrottled) {
+			c.logger.Warn("throttled order intent",
+				"intent_id", intent.IntentID, "bot_id", intent.BotID, "error", err)
+			return nil
+		}
+		var ve service.ValidationError
+		if errors.As(err, &ve) {
+			c.logger.Warn("rejected invalid order intent",
+				"intent_id", intent.IntentID, "bot_id", intent.BotID, "reason", ve.Reason)
+			return nil
+		}
+		c.logger.Error("order submission failed",
+			"intent_id", intent.IntentID, "bot_id", intent.BotID, "error", err)
+		return err
+	}
+
+	c.logger.Info("accepted order intent",
+		"intent_id", intent.IntentID, "order_id", order.ID, "bot_id", intent.BotID, "account_id", intent.AccountID)
+	return nil
+}
+
+func headerValue(headers []kafka.Header, key string) string {
+	for _, h := range headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,174 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// DeadLetterTopicPrefix prefixes the dead-letter topic of every intent topic.
+// It sits outside IntentTopicPrefix so dead-letter topics are never
+// discovered as intent topics.
+const DeadLetterTopicPrefix = "dlq."
+
+// Headers added to dead-lettered messages next to the original ones.
+const (
+	HeaderDeadLetterTopic     = "dlq_original_topic"
+	HeaderDeadLetterPartition = "dlq_original_partition"
+	HeaderDeadLetterOffset    = "dlq_original_offset"
+	HeaderDeadLetterError     = "dlq_error"
+	HeaderDeadLetterAttempts  = "dlq_attempts"
+	HeaderDeadLetterFailedAt  = "dlq_failed_at"
+)
+
+// ErrDeadLetterNotFound indicates a dead letter could not be located.
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// ErrUndecodableIntent marks intent messages that are not a valid
+// OrderIntent; they are dead-lettered without retrying.
+var ErrUndecodableIntent = errors.New("undecodable order intent")
+
+// ErrDeadLettersDisabled is returned by dead-letter operations on a consumer
+// without a dead-letter store.
+var ErrDeadLettersDisabled = errors.New("dead-letter store not configured")
+
+// DeadLetterTopic returns the topic intents from topic are dead-lettered to.
+func DeadLetterTopic(topic string) string {
+	return DeadLetterTopicPrefix + topic
+}
+
+// DeadLetter is an intent message the consumer gave up on, kept with its
+// original bytes so it can be inspected and re-driven.
+type DeadLetter struct {
+	ID        string            `json:"id"`
+	Topic     string            `json:"topic"`
+	Partition int               `json:"partition"`
+	Offset    int64             `json:"offset"`
+	Key       []byte            `json:"key,omitempty"`
+	Value     []byte            `json:"value"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Error     string            `json:"error"`
+	Attempts  int               `json:"attempts"`
+	FailedAt  time.Time         `json:"failed_at"`
+	// RedrivenAt is set once a re-drive of the message succeeded.
+	RedrivenAt *time.Time `json:"redriven_at,omitempty"`
+}
+
+// Message rebuilds the original Kafka message.
+func (d DeadLetter) Message() kafka.Message {
+	msg := kafka.Message{Topic: d.Topic, Partition: d.Partition, Offset: d.Offset, Key: d.Key, Value: d.Value}
+	for key, value := range d.Headers {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+	return msg
+}
+
+// DeadLetterStore keeps dead letters for the admin API.
+type DeadLetterStore interface {
+	// SaveDeadLetter stores a dead letter, replacing one with the same id so
+	// a message dead-lettered twice is kept once.
+	SaveDeadLetter(ctx context.Context, letter DeadLetter) error
+	// DeadLetters returns up to limit dead letters, newest first.
+	DeadLetters(ctx context.Context, limit int) ([]DeadLetter, error)
+	// GetDeadLetter returns ErrDeadLetterNotFound for unknown ids.
+	GetDeadLetter(ctx context.Context, id string) (DeadLetter, error)
+	// MarkDeadLetterRedriven records a successful re-drive.
+	MarkDeadLetterRedriven(ctx context.Context, id string, at time.Time) error
+}
+
+// newDeadLetter describes msg after it failed attempts times with cause.
+func newDeadLetter(msg kafka.Message, cause error, attempts int, at time.Time) DeadLetter {
+	letter := DeadLetter{
+		ID:        fmt.Sprintf("%s:%d:%d", msg.Topic, msg.Partition, msg.Offset),
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Error:     cause.Error(),
+		Attempts:  attempts,
+		FailedAt:  at,
+	}
+	if len(msg.Headers) > 0 {
+		letter.Headers = make(map[string]string, len(msg.Headers))
+		for _, h := range msg.Headers {
+			letter.Headers[h.Key] = string(h.Value)
+		}
+	}
+	return letter
+}
+
+// deadLetterMessage is the message written to the dead-letter topic: the
+// original key, value and headers plus the failure headers.
+func deadLetterMessage(msg kafka.Message, letter DeadLetter) kafka.Message {
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderDeadLetterTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderDeadLetterPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderDeadLetterOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: HeaderDeadLetterError, Value: []byte(letter.Error)},
+		kafka.Header{Key: HeaderDeadLetterAttempts, Value: []byte(strconv.Itoa(letter.Attempts))},
+		kafka.Header{Key: HeaderDeadLetterFailedAt, Value: []byte(letter.FailedAt.Format(time.RFC3339Nano))},
+	)
+	return kafka.Message{
+		Topic:   DeadLetterTopic(msg.Topic),
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+		Time:    letter.FailedAt,
+	}
+}
+
+// deadLetter parks msg on its dead-letter topic and in the store. Without
+// either the message is only logged.
+func (c *Consumer) deadLetter(ctx context.Context, msg kafka.Message, cause error, attempts int) error {
+	letter := newDeadLetter(msg, cause, attempts, c.now())
+	c.logger.Error("dead-lettering order intent",
+		"topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset, "attempts", attempts, "error", cause)
+	if c.dlqWriter != nil {
+		if err := c.dlqWriter.WriteMessages(ctx, deadLetterMessage(msg, letter)); err != nil {
+			return fmt.Errorf("write dead letter: %w", err)
+		}
+	}
+	if c.deadLetters != nil {
+		if err := c.deadLetters.SaveDeadLetter(ctx, letter); err != nil {
+			return fmt.Errorf("store dead letter: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeadLetters returns up to limit dead-lettered intents, newest first.
+func (c *Consumer) DeadLetters(ctx context.Context, limit int) ([]DeadLetter, error) {
+	if c.deadLetters == nil {
+		return nil, ErrDeadLettersDisabled
+	}
+	return c.deadLetters.DeadLetters(ctx, limit)
+}
+
+// Redrive processes a dead-lettered intent again, once and without retries.
+// On success the dead letter is marked re-driven; on failure it is returned
+// unchanged together with the error.
+func (c *Consumer) Redrive(ctx context.Context, id string) (DeadLetter, error) {
+	if c.deadLetters == nil {
+		return DeadLetter{}, ErrDeadLettersDisabled
+	}
+	letter, err := c.deadLetters.GetDeadLetter(ctx, id)
+	if err != nil {
+		return DeadLetter{}, err
+	}
+	if err := c.process(ctx, letter.Message()); err != nil {
+		return letter, err
+	}
+	at := c.now()
+	if err := c.deadLetters.MarkDeadLetterRedriven(ctx, id, at); err != nil {
+		return letter, err
+	}
+	letter.RedrivenAt = &at
+	c.logger.Info("re-drove dead-lettered order intent", "id", id)
+	return letter, nil
+}
@@ -0,0 +1,201 @@
+package messaging
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/future-bots/executor/internal/service"
+	ordersv1 "github.com/future-bots/proto/orders/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// IntentFromProto converts a Kafka order intent into the service representation.
+// Routing identifiers missing from the payload fall back to those encoded in the
+// topic name.
+func IntentFromProto(msg *ordersv1.OrderIntent, topic string) service.OrderIntent {
+	intent := service.OrderIntent{
+		IntentID:  msg.GetIntentId(),
+		Sequence:  msg.GetSequence(),
+		BotID:     msg.GetBotId(),
+		AccountID: msg.GetAccountId(),
+		Symbol:    msg.GetSymbol(),
+		Side:      SideFromProto(msg.GetSide()),
+		Quantity:  msg.GetQuantity(),
+	}
+	if msg.GetLimitPrice() != nil {
+		intent.Price = msg.GetLimitPrice().GetValue()
+	}
+	if msg.GetStopPrice() != nil {
+		intent.StopPrice = msg.GetStopPrice().GetValue()
+	}
+	intent.Type = typeFromProto(msg.GetType())
+	intent.TimeInForce = service.TimeInForce(msg.GetTimeInForce())
+	if msg.GetExpiresAt() != nil {
+		expiresAt := msg.GetExpiresAt().AsTime()
+		intent.ExpiresAt = &expiresAt
+	}
+	intent.Algorithm = service.Algorithm(msg.GetAlgorithm())
+	if msg.GetAlgoDuration() != nil {
+		intent.AlgoDurationSeconds = int64(msg.GetAlgoDuration().AsDuration() / time.Second)
+	}
+	intent.AlgoSlices = int(msg.GetAlgoSlices())
+	intent.DisplayQuantity = msg.GetDisplayQuantity()
+	if msg.GetTakeProfitPrice() != nil {
+		intent.TakeProfitPrice = msg.GetTakeProfitPrice().GetValue()
+	}
+	if msg.GetStopLossPrice() != nil {
+		intent.StopLossPrice = msg.GetStopLossPrice().GetValue()
+	}
+	if accountID, botID, ok := ParseIntentTopic(topic); ok {
+		if intent.AccountID == "" {
+			intent.AccountID = accountID
+		}
+		if intent.BotID == "" {
+			intent.BotID = botID
+		}
+	}
+	return intent
+}
+
+// NewEnvelope wraps a service event in the OrderEvent envelope published on the
+// orders.event topics.
+func NewEnvelope(event service.Event, publishedAt time.Time) (*ordersv1.OrderEvent, error) {
+	order := event.Order
+	occurred := timestamppb.New(event.OccurredAt)
+	envelope := &ordersv1.OrderEvent{
+		BotId:         order.BotID,
+		AccountId:     order.AccountID,
+		CorrelationId: event.CorrelationID,
+		PublishedAt:   timestamppb.New(publishedAt),
+		BracketId:     order.BracketID,
+		Leg:           legToProto(order.Leg),
+		Mode:          modeToProto(order.Mode),
+	}
+
+	switch event.Type {
+	case service.EventAck:
+		envelope.Event = &ordersv1.OrderEvent_Ack{Ack: &ordersv1.OrderIntentAck{
+			IntentId:        order.IntentID,
+			ExecutorOrderId: order.ID,
+			ReceivedAt:      occurred,
+		}}
+	case service.EventRejection:
+		envelope.Event = &ordersv1.OrderEvent_Rejection{Rejection: &ordersv1.OrderRejection{
+			IntentId:        order.IntentID,
+			ExecutorOrderId: order.ID,
+			Reason:          event.Reason,
+			Category:        rejectionToProto(event.Category),
+			RejectedAt:      occurred,
+		}}
+	case service.EventFill:
+		if event.Fill == nil {
+			return nil, fmt.Errorf("fill event for order %s has no fill", order.ID)
+		}
+		envelope.Event = &ordersv1.OrderEvent_Fill{Fill: &ordersv1.ExecutionFill{
+			IntentId:          order.IntentID,
+			ExecutorOrderId:   order.ID,
+			ProviderOrderId:   event.Fill.ProviderOrderID,
+			FilledQuantity:    event.Fill.Quantity,
+			RemainingQuantity: event.Fill.Remaining,
+			FillPrice:         event.Fill.Price,
+			FeePaid:           event.Fill.Fee,
+			TaxPaid:           event.Fill.Tax,
+			FilledAt:          timestamppb.New(event.Fill.FilledAt),
+		}}
+	case service.EventCancel:
+		envelope.Event = &ordersv1.OrderEvent_Cancel{Cancel: &ordersv1.OrderCancel{
+			IntentId:        order.IntentID,
+			ExecutorOrderId: order.ID,
+			InitiatedBy:     string(event.InitiatedBy),
+			CancelledAt:     occurred,
+		}}
+	case service.EventAmend:
+		envelope.Event = &ordersv1.OrderEvent_Amend{Amend: &ordersv1.OrderAmend{
+			IntentId:        order.IntentID,
+			ExecutorOrderId: order.ID,
+			Price:           order.Price,
+			Quantity:        order.Quantity,
+			Reason:          event.Reason,
+			AmendedAt:       occurred,
+		}}
+	default:
+		return nil, fmt.Errorf("unsupported event type %q", event.Type)
+	}
+	return envelope, nil
+}
+
+// typeFromProto maps the proto order type; unspecified leaves the service to
+// infer market or limit from the price.
+func typeFromProto(t ordersv1.OrderType) service.OrderType {
+	switch t {
+	case ordersv1.OrderType_ORDER_TYPE_MARKET:
+		return service.OrderTypeMarket
+	case ordersv1.OrderType_ORDER_TYPE_LIMIT:
+		return service.OrderTypeLimit
+	case ordersv1.OrderType_ORDER_TYPE_STOP:
+		return service.OrderTypeStop
+	default:
+		return ""
+	}
+}
+
+func legToProto(leg service.Leg) ordersv1.BracketLeg {
+	switch leg {
+	case service.LegEntry:
+		return ordersv1.BracketLeg_BRACKET_LEG_ENTRY
+	case service.LegTakeProfit:
+		return ordersv1.BracketLeg_BRACKET_LEG_TAKE_PROFIT
+	case service.LegStopLoss:
+		return ordersv1.BracketLeg_BRACKET_LEG_STOP_LOSS
+	default:
+		return ordersv1.BracketLeg_BRACKET_LEG_UNSPECIFIED
+	}
+}
+
+func modeToProto(mode service.Mode) ordersv1.ExecutionMode {
+	switch mode {
+	case service.ModeLive:
+		return ordersv1.ExecutionMode_EXECUTION_MODE_LIVE
+	case service.ModePaper:
+		return ordersv1.ExecutionMode_EXECUTION_MODE_PAPER
+	default:
+		return ordersv1.ExecutionMode_EXECUTION_MODE_UNSPECIFIED
+	}
+}
+
+// SideFromProto maps the proto side onto the service one, empty when unspecified.
+func SideFromProto(side ordersv1.OrderSide) string {
+	switch side {
+	case ordersv1.OrderSide_ORDER_SIDE_BUY:
+		return "buy"
+	case ordersv1.OrderSide_ORDER_SIDE_SELL:
+		return "sell"
+	default:
+		return ""
+	}
+}
+
+func rejectionToProto(category service.RejectionCategory) ordersv1.RejectionReason {
+	switch category {
+	case service.RejectionRiskLimit:
+		return ordersv1.RejectionReason_REJECTION_REASON_RISK_LIMIT
+	case service.RejectionInstrumentHalted:
+		return ordersv1.RejectionReason_REJECTION_REASON_INSTRUMENT_HALTED
+	case service.RejectionBrokerReject:
+		return ordersv1.RejectionReason_REJECTION_REASON_BROKER_REJECT
+	case service.RejectionTimeout:
+		return ordersv1.RejectionReason_REJECTION_REASON_TIMEOUT
+	case service.RejectionSystemError:
+		return ordersv1.RejectionReason_REJECTION_REASON_SYSTEM_ERROR
+	case service.RejectionInvalidPrice:
+		return ordersv1.RejectionReason_REJECTION_REASON_INVALID_PRICE
+	case service.RejectionInvalidQuantity:
+		return ordersv1.RejectionReason_REJECTION_REASON_INVALID_QUANTITY
+	case service.RejectionRateLimited:
+		return ordersv1.RejectionReason_REJECTION_REASON_RATE_LIMIT
+	case service.RejectionMarketClosed:
+		return ordersv1.RejectionReason_REJECTION_REASON_MARKET_CLOSED
+	default:
+		return ordersv1.RejectionReason_REJECTION_REASON_UNSPECIFIED
+	}
+}
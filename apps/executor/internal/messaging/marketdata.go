@@ -0,0 +1,82 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	marketsv1 "github.com/future-bots/proto/markets/v1"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+// MessageReader defines the subset of kafka.Reader used for uncommitted reads.
+type MessageReader interface {
+	ReadMessage(ctx context.Context) (kafka.Message, error)
+	Close() error
+}
+
+// SnapshotHandler receives each decoded market snapshot.
+type SnapshotHandler func(*marketsv1.SsiPsSnapshot)
+
+// SnapshotConsumer tails the ssi_ps topic and hands snapshots to a handler.
+// Every executor instance needs the full feed, so it reads without a consumer
+// group starting from the latest offset.
+type SnapshotConsumer struct {
+	reader  MessageReader
+	handler SnapshotHandler
+	logger  *slog.Logger
+}
+
+// NewSnapshotConsumer wires a consumer around the provided reader and handler.
+func NewSnapshotConsumer(reader MessageReader, handler SnapshotHandler, logger *slog.Logger) *SnapshotConsumer {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SnapshotConsumer{reader: reader, handler: handler, logger: logger}
+}
+
+// NewSnapshotReader builds a group-less reader positioned at the end of topic.
+func NewSnapshotReader(brokers []string, topic string) (*kafka.Reader, error) {
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("brokers required")
+	}
+	if topic == "" {
+		return nil, fmt.Errorf("topic required")
+	}
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		Topic:       topic,
+		StartOffset: kafka.LastOffset,
+		MinBytes:    1,
+		MaxBytes:    10 << 20,
+	}), nil
+}
+
+// Close releases reader resources.
+func (c *SnapshotConsumer) Close() error {
+	if c.reader != nil {
+		return c.reader.Close()
+	}
+	return nil
+}
+
+// Run consumes snapshots until context cancellation.
+func (c *SnapshotConsumer) Run(ctx context.Context) error {
+	for {
+		m, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			return fmt.Errorf("read snapshot: %w", err)
+		}
+		var snapshot marketsv1.SsiPsSnapshot
+		if err := proto.Unmarshal(m.Value, &snapshot); err != nil {
+			c.logger.Warn("failed to decode market snapshot", "offset", m.Offset, "error", err)
+			continue
+		}
+		c.handler(&snapshot)
+	}
+}
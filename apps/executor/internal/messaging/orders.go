@@ -0,0 +1,109 @@
+package messaging
+
+import (
+	"github.com/future-bots/executor/internal/service"
+	ordersv1 "github.com/future-bots/proto/orders/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// OrderToProto converts an order into the Order message of the gRPC API.
+func OrderToProto(order service.Order) *ordersv1.Order {
+	msg := &ordersv1.Order{
+		Id:              order.ID,
+		IntentId:        order.IntentID,
+		Sequence:        order.Sequence,
+		BotId:           order.BotID,
+		AccountId:       order.AccountID,
+		Symbol:          order.Symbol,
+		Side:            sideToProto(order.Side),
+		Type:            typeToProto(order.Type),
+		TimeInForce:     string(order.TimeInForce),
+		Quantity:        order.Quantity,
+		FilledQuantity:  order.FilledQuantity,
+		Status:          statusToProto(order.Status),
+		ProviderOrderId: order.ProviderOrderID,
+		CreatedAt:       timestamppb.New(order.CreatedAt),
+		UpdatedAt:       timestamppb.New(order.UpdatedAt),
+		ParentId:        order.ParentID,
+		Algorithm:       string(order.Algorithm),
+		BracketId:       order.BracketID,
+		Leg:             legToProto(order.Leg),
+		Mode:            modeToProto(order.Mode.OrLive()),
+		Route:           order.Route,
+		RouteReason:     order.RouteReason,
+	}
+	if order.Price != 0 {
+		msg.LimitPrice = wrapperspb.Double(order.Price)
+	}
+	if order.StopPrice != 0 {
+		msg.StopPrice = wrapperspb.Double(order.StopPrice)
+	}
+	if order.ExpiresAt != nil {
+		msg.ExpiresAt = timestamppb.New(*order.ExpiresAt)
+	}
+	return msg
+}
+
+var statuses = map[service.Status]ordersv1.OrderStatus{
+	service.StatusNew:             ordersv1.OrderStatus_ORDER_STATUS_NEW,
+	service.StatusPendingRisk:     ordersv1.OrderStatus_ORDER_STATUS_PENDING_RISK,
+	service.StatusRouted:          ordersv1.OrderStatus_ORDER_STATUS_ROUTED,
+	service.StatusPartiallyFilled: ordersv1.OrderStatus_ORDER_STATUS_PARTIALLY_FILLED,
+	service.StatusFilled:          ordersv1.OrderStatus_ORDER_STATUS_FILLED,
+	service.StatusCancelled:       ordersv1.OrderStatus_ORDER_STATUS_CANCELLED,
+	service.StatusRejected:        ordersv1.OrderStatus_ORDER_STATUS_REJECTED,
+	service.StatusExpired:         ordersv1.OrderStatus_ORDER_STATUS_EXPIRED,
+}
+
+func statusToProto(status service.Status) ordersv1.OrderStatus {
+	return statuses[status]
+}
+
+// StatusFromProto maps the proto status onto the service one, empty when
+// unspecified.
+func StatusFromProto(status ordersv1.OrderStatus) service.Status {
+	for s, p := range statuses {
+		if p == status {
+			return s
+		}
+	}
+	return ""
+}
+
+// ModeFromProto maps the proto execution mode onto the service one, empty
+// when unspecified.
+func ModeFromProto(mode ordersv1.ExecutionMode) service.Mode {
+	switch mode {
+	case ordersv1.ExecutionMode_EXECUTION_MODE_LIVE:
+		return service.ModeLive
+	case ordersv1.ExecutionMode_EXECUTION_MODE_PAPER:
+		return service.ModePaper
+	default:
+		return ""
+	}
+}
+
+func sideToProto(side string) ordersv1.OrderSide {
+	switch side {
+	case "buy":
+		return ordersv1.OrderSide_ORDER_SIDE_BUY
+	case "sell":
+		return ordersv1.OrderSide_ORDER_SIDE_SELL
+	default:
+		return ordersv1.OrderSide_ORDER_SIDE_UNSPECIFIED
+	}
+}
+
+func typeToProto(t service.OrderType) ordersv1.OrderType {
+	switch t {
+	case service.OrderTypeMarket:
+		return ordersv1.OrderType_ORDER_TYPE_MARKET
+	case service.OrderTypeLimit:
+		return ordersv1.OrderType_ORDER_TYPE_LIMIT
+	case service.OrderTypeStop:
+		return ordersv1.OrderType_ORDER_TYPE_STOP
+	default:
+		return ordersv1.OrderType_ORDER_TYPE_UNSPECIFIED
+	}
+}
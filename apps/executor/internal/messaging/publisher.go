@@ -0,0 +1,93 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/future-bots/executor/internal/service"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrUnroutable is returned when an event lacks the bot or account required to
+// derive its topic. It wraps service.ErrUndeliverable so the outbox relay does
+// not retry it.
+var ErrUnroutable = fmt.Errorf("%w: no bot_id/account_id to route on", service.ErrUndeliverable)
+
+// Writer defines the subset of kafka.Writer used by the publisher.
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// Publisher encodes service events as OrderEvent envelopes and writes them to
+// the per-bot orders.event topics.
+type Publisher struct {
+	writer Writer
+	now    func() time.Time
+}
+
+// NewPublisher constructs a publisher on top of the provided writer.
+func NewPublisher(writer Writer, now func() time.Time) *Publisher {
+	if now == nil {
+		now = time.Now
+	}
+	return &Publisher{
+		writer: writer,
+		now:    func() time.Time { return now().UTC() },
+	}
+}
+
+// NewKafkaWriter builds a writer that routes each message by its Topic field.
+func NewKafkaWriter(brokers []string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:                   kafka.TCP(brokers...),
+		AllowAutoTopicCreation: true,
+		RequiredAcks:           kafka.RequireAll,
+		Balancer:               &kafka.Hash{},
+	}
+}
+
+// PublishOrderEvent implements service.EventPublisher.
+func (p *Publisher) PublishOrderEvent(ctx context.Context, event service.Event) error {
+	if event.Order.AccountID == "" || event.Order.BotID == "" {
+		return ErrUnroutable
+	}
+
+	envelope, err := NewEnvelope(event, p.now())
+	if err != nil {
+		return err
+	}
+	payload, err := proto.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("marshal order event: %w", err)
+	}
+
+	key := event.Order.ID
+	if key == "" {
+		key = event.Order.IntentID
+	}
+	msg := kafka.Message{
+		Topic: EventTopic(event.Order.AccountID, event.Order.BotID),
+		Key:   []byte(key),
+		Value: payload,
+		Headers: []kafka.Header{
+			{Key: HeaderCorrelationID, Value: []byte(event.CorrelationID)},
+			{Key: HeaderEventType, Value: []byte(event.Type)},
+		},
+		Time: envelope.GetPublishedAt().AsTime(),
+	}
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("write order event: %w", err)
+	}
+	return nil
+}
+
+// Close releases the underlying writer.
+func (p *Publisher) Close() error {
+	if p.writer != nil {
+		return p.writer.Close()
+	}
+	return nil
+}
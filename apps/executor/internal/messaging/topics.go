@@ -0,0 +1,36 @@
+package messaging
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// IntentTopicPrefix prefixes every per-bot order intent topic.
+	IntentTopicPrefix = "orders.intent.account."
+	// EventTopicPrefix prefixes every per-bot order event topic.
+	EventTopicPrefix = "orders.event.account."
+)
+
+// IntentTopic returns the topic bots publish order intents on.
+func IntentTopic(accountID, botID string) string {
+	return fmt.Sprintf("%s%s.%s", IntentTopicPrefix, accountID, botID)
+}
+
+// EventTopic returns the topic the executor publishes order events on.
+func EventTopic(accountID, botID string) string {
+	return fmt.Sprintf("%s%s.%s", EventTopicPrefix, accountID, botID)
+}
+
+// ParseIntentTopic extracts the account and bot identifiers from an intent topic.
+func ParseIntentTopic(topic string) (accountID, botID string, ok bool) {
+	rest, found := strings.CutPrefix(topic, IntentTopicPrefix)
+	if !found {
+		return "", "", false
+	}
+	accountID, botID, found = strings.Cut(rest, ".")
+	if !found || accountID == "" || botID == "" {
+		return "", "", false
+	}
+	return accountID, botID, true
+}
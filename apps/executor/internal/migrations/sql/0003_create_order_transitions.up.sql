@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS order_transitions (
+    id BIGSERIAL PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(id),
+    from_status TEXT,
+    to_status TEXT NOT NULL,
+    reason TEXT,
+    transitioned_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS order_transitions_order_id_idx ON order_transitions (order_id, transitioned_at);
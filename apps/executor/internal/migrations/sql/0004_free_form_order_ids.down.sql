@@ -0,0 +1,21 @@
+DROP INDEX IF EXISTS executions_order_id_idx;
+DROP INDEX IF EXISTS orders_provider_order_id_idx;
+DROP INDEX IF EXISTS orders_bot_id_idx;
+
+ALTER TABLE executions DROP CONSTRAINT IF EXISTS executions_order_id_fkey;
+ALTER TABLE order_transitions DROP CONSTRAINT IF EXISTS order_transitions_order_id_fkey;
+
+ALTER TABLE order_transitions ALTER COLUMN order_id TYPE UUID USING order_id::uuid;
+ALTER TABLE executions ALTER COLUMN order_id TYPE UUID USING order_id::uuid;
+ALTER TABLE executions ALTER COLUMN id TYPE UUID USING id::uuid;
+ALTER TABLE executions ALTER COLUMN id SET DEFAULT gen_random_uuid();
+
+ALTER TABLE orders DROP COLUMN IF EXISTS filled_qty;
+ALTER TABLE orders DROP COLUMN IF EXISTS account_id;
+ALTER TABLE orders DROP COLUMN IF EXISTS intent_id;
+ALTER TABLE orders ALTER COLUMN bot_id TYPE UUID USING bot_id::uuid;
+ALTER TABLE orders ALTER COLUMN id TYPE UUID USING id::uuid;
+ALTER TABLE orders ALTER COLUMN id SET DEFAULT gen_random_uuid();
+
+ALTER TABLE executions ADD CONSTRAINT executions_order_id_fkey FOREIGN KEY (order_id) REFERENCES orders(id);
+ALTER TABLE order_transitions ADD CONSTRAINT order_transitions_order_id_fkey FOREIGN KEY (order_id) REFERENCES orders(id);
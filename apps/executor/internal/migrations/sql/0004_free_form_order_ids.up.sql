@@ -0,0 +1,23 @@
+-- Bot ids are free-form strings on the API and Kafka contracts, and executor
+-- order ids are generated by the service, so neither fits a UUID column.
+ALTER TABLE executions DROP CONSTRAINT IF EXISTS executions_order_id_fkey;
+ALTER TABLE order_transitions DROP CONSTRAINT IF EXISTS order_transitions_order_id_fkey;
+
+ALTER TABLE orders ALTER COLUMN id DROP DEFAULT;
+ALTER TABLE orders ALTER COLUMN id TYPE TEXT USING id::text;
+ALTER TABLE orders ALTER COLUMN bot_id TYPE TEXT USING bot_id::text;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS intent_id TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS account_id TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS filled_qty NUMERIC NOT NULL DEFAULT 0;
+
+ALTER TABLE executions ALTER COLUMN id DROP DEFAULT;
+ALTER TABLE executions ALTER COLUMN id TYPE TEXT USING id::text;
+ALTER TABLE executions ALTER COLUMN order_id TYPE TEXT USING order_id::text;
+ALTER TABLE order_transitions ALTER COLUMN order_id TYPE TEXT USING order_id::text;
+
+ALTER TABLE executions ADD CONSTRAINT executions_order_id_fkey FOREIGN KEY (order_id) REFERENCES orders(id);
+ALTER TABLE order_transitions ADD CONSTRAINT order_transitions_order_id_fkey FOREIGN KEY (order_id) REFERENCES orders(id);
+
+CREATE INDEX IF NOT EXISTS orders_bot_id_idx ON orders (bot_id, created_at);
+CREATE INDEX IF NOT EXISTS orders_provider_order_id_idx ON orders (provider_order_id);
+CREATE INDEX IF NOT EXISTS executions_order_id_idx ON executions (order_id, filled_at);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS orders_bot_account_sequence_idx;
+DROP INDEX IF EXISTS orders_bot_intent_idx;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS sequence;
@@ -0,0 +1,6 @@
+-- Intents are deduplicated per bot on intent_id, and the per bot + account
+-- sequence is kept so gaps and regressions can be detected after a restart.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS sequence BIGINT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS orders_bot_intent_idx ON orders (bot_id, intent_id) WHERE intent_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS orders_bot_account_sequence_idx ON orders (bot_id, account_id, sequence) WHERE sequence IS NOT NULL;
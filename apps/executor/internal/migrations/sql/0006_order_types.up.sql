@@ -0,0 +1,12 @@
+-- Order type, time-in-force and expiry from the OrderIntent contract. Existing
+-- rows predate explicit types and are backfilled from whether a price was set.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS order_type TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS time_in_force TEXT NOT NULL DEFAULT 'GTC';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS stop_price NUMERIC;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+UPDATE orders SET order_type = CASE WHEN price IS NULL THEN 'market' ELSE 'limit' END WHERE order_type IS NULL;
+ALTER TABLE orders ALTER COLUMN order_type SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS orders_expires_at_idx ON orders (expires_at)
+  WHERE expires_at IS NOT NULL AND status NOT IN ('filled', 'cancelled', 'rejected', 'expired');
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS orders_symbol_idx;
+DROP INDEX IF EXISTS orders_account_id_idx;
+DROP INDEX IF EXISTS orders_created_at_id_idx;
@@ -0,0 +1,5 @@
+-- Keyset pagination for GET /api/v1/orders walks (created_at, id); account
+-- and symbol filters are common operator queries.
+CREATE INDEX IF NOT EXISTS orders_created_at_id_idx ON orders (created_at, id);
+CREATE INDEX IF NOT EXISTS orders_account_id_idx ON orders (account_id, created_at);
+CREATE INDEX IF NOT EXISTS orders_symbol_idx ON orders (symbol, created_at);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS positions (
+    account_id TEXT NOT NULL DEFAULT '',
+    bot_id TEXT NOT NULL,
+    symbol TEXT NOT NULL,
+    quantity NUMERIC NOT NULL DEFAULT 0,
+    average_price NUMERIC NOT NULL DEFAULT 0,
+    realized_pnl NUMERIC NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ,
+    PRIMARY KEY (account_id, bot_id, symbol)
+);
+
+CREATE INDEX IF NOT EXISTS positions_bot_idx ON positions (bot_id, symbol);
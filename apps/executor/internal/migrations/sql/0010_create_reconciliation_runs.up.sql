@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS reconciliation_runs (
+    id TEXT PRIMARY KEY,
+    started_at TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL,
+    orders_checked INTEGER NOT NULL DEFAULT 0,
+    discrepancies JSONB NOT NULL DEFAULT '[]',
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS reconciliation_runs_started_at_idx ON reconciliation_runs (started_at DESC);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS orders_scheduled_idx;
+DROP INDEX IF EXISTS orders_parent_idx;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS display_quantity;
+ALTER TABLE orders DROP COLUMN IF EXISTS algo_slices;
+ALTER TABLE orders DROP COLUMN IF EXISTS algo_duration_seconds;
+ALTER TABLE orders DROP COLUMN IF EXISTS algorithm;
+ALTER TABLE orders DROP COLUMN IF EXISTS scheduled_at;
+ALTER TABLE orders DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,11 @@
+-- Execution algorithms: a parent order carries its algorithm parameters and
+-- each child links back to it with the time it is scheduled for release.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS parent_id TEXT REFERENCES orders(id);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS scheduled_at TIMESTAMPTZ;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS algorithm TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS algo_duration_seconds BIGINT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS algo_slices INTEGER;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS display_quantity NUMERIC;
+
+CREATE INDEX IF NOT EXISTS orders_parent_idx ON orders (parent_id, created_at) WHERE parent_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS orders_scheduled_idx ON orders (scheduled_at) WHERE status = 'new' AND parent_id IS NOT NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS orders_bracket_idx;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS leg;
+ALTER TABLE orders DROP COLUMN IF EXISTS bracket_id;
@@ -0,0 +1,6 @@
+-- Bracket orders: the entry and its take-profit/stop-loss exits share the
+-- entry's id as bracket_id, and leg records each order's role.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS bracket_id TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS leg TEXT;
+
+CREATE INDEX IF NOT EXISTS orders_bracket_idx ON orders (bracket_id) WHERE bracket_id IS NOT NULL;
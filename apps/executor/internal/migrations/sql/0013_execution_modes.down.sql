@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS orders_mode_idx;
+
+DELETE FROM positions WHERE mode <> 'live';
+ALTER TABLE positions DROP CONSTRAINT IF EXISTS positions_pkey;
+ALTER TABLE positions DROP COLUMN IF EXISTS mode;
+ALTER TABLE positions ADD PRIMARY KEY (account_id, bot_id, symbol);
+
+ALTER TABLE orders DROP COLUMN IF EXISTS mode;
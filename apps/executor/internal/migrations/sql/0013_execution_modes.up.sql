@@ -0,0 +1,9 @@
+-- Paper trading: orders record whether they trade live or on paper, and
+-- positions are kept per mode so paper PnL never mixes with live PnL.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS mode TEXT NOT NULL DEFAULT 'live';
+
+ALTER TABLE positions ADD COLUMN IF NOT EXISTS mode TEXT NOT NULL DEFAULT 'live';
+ALTER TABLE positions DROP CONSTRAINT IF EXISTS positions_pkey;
+ALTER TABLE positions ADD PRIMARY KEY (account_id, bot_id, mode, symbol);
+
+CREATE INDEX IF NOT EXISTS orders_mode_idx ON orders (mode, created_at) WHERE mode <> 'live';
@@ -0,0 +1,3 @@
+-- Fee model: executions keep the income tax withheld on a fill next to its
+-- fees, both in VND.
+ALTER TABLE executions ADD COLUMN IF NOT EXISTS tax NUMERIC NOT NULL DEFAULT 0;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS session_summaries (
+    id TEXT PRIMARY KEY,
+    trading_date DATE NOT NULL,
+    closed_at TIMESTAMPTZ NOT NULL,
+    day_orders_cancelled INTEGER NOT NULL DEFAULT 0,
+    orders_carried INTEGER NOT NULL DEFAULT 0,
+    orders_submitted INTEGER NOT NULL DEFAULT 0,
+    orders_filled INTEGER NOT NULL DEFAULT 0,
+    orders_cancelled INTEGER NOT NULL DEFAULT 0,
+    orders_rejected INTEGER NOT NULL DEFAULT 0,
+    orders_expired INTEGER NOT NULL DEFAULT 0,
+    executions INTEGER NOT NULL DEFAULT 0,
+    filled_quantity NUMERIC NOT NULL DEFAULT 0,
+    fees NUMERIC NOT NULL DEFAULT 0,
+    taxes NUMERIC NOT NULL DEFAULT 0,
+    error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS session_summaries_closed_at_idx ON session_summaries (closed_at DESC);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS routing_rules;
+ALTER TABLE orders DROP COLUMN IF EXISTS route_reason;
+ALTER TABLE orders DROP COLUMN IF EXISTS route;
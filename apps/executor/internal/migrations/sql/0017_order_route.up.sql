@@ -0,0 +1,11 @@
+-- Smart order routing: orders record the broker they were placed with and
+-- why it was chosen.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS route TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS route_reason TEXT;
+
+CREATE TABLE IF NOT EXISTS routing_rules (
+    position INTEGER PRIMARY KEY,
+    account_id TEXT,
+    symbol TEXT,
+    brokers JSONB NOT NULL
+);
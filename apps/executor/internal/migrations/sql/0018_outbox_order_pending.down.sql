@@ -0,0 +1 @@
+DROP INDEX IF EXISTS order_outbox_order_pending_idx;
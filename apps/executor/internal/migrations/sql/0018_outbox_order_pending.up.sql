@@ -0,0 +1,3 @@
+-- Relays look up the earlier pending records of an order to keep its events
+-- in order.
+CREATE INDEX IF NOT EXISTS order_outbox_order_pending_idx ON order_outbox (order_id, id) WHERE status = 'pending';
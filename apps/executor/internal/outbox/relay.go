@@ -0,0 +1,229 @@
+// Package outbox delivers order events that the repository stored alongside
+// the state changes that produced them, giving at-least-once publication that
+// never runs ahead of or behind the database.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/future-bots/executor/internal/service"
+)
+
+// Record is one stored event awaiting delivery.
+type Record struct {
+	ID            int64
+	Event         service.Event
+	Attempts      int
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+}
+
+// Store is the outbox side of the order repository.
+type Store interface {
+	// Pending returns up to limit undelivered records due at now, oldest
+	// first. A record is not due while an earlier record of the same order
+	// waits for a retry. With a lease the records are claimed until now plus
+	// lease, so other relays sharing the store skip them; a zero lease only
+	// lists them.
+	Pending(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]Record, error)
+	// MarkDelivered flags a record as published.
+	MarkDelivered(ctx context.Context, id int64, at time.Time) error
+	// MarkRetry records a failed attempt and when to try again.
+	MarkRetry(ctx context.Context, id int64, attempts int, next time.Time, reason string) error
+	// MarkFailed parks a record that will not be retried.
+	MarkFailed(ctx context.Context, id int64, attempts int, reason string) error
+}
+
+// Option customises a Relay.
+type Option func(*Relay)
+
+// WithBatchSize bounds how many records one pass reads.
+func WithBatchSize(n int) Option {
+	return func(r *Relay) {
+		if n > 0 {
+			r.batch = n
+		}
+	}
+}
+
+// WithBackoff sets the delay before the first retry and the ceiling it
+// doubles up to.
+func WithBackoff(base, max time.Duration) Option {
+	return func(r *Relay) {
+		if base > 0 {
+			r.base = base
+		}
+		if max >= r.base {
+			r.max = max
+		}
+	}
+}
+
+// WithLease sets how long a pass holds the records it reads before another
+// relay may take them; one minute by default. It must outlast a pass.
+func WithLease(lease time.Duration) Option {
+	return func(r *Relay) {
+		if lease > 0 {
+			r.lease = lease
+		}
+	}
+}
+
+// WithMaxAttempts parks a record after n failed deliveries. Zero retries
+// forever.
+func WithMaxAttempts(n int) Option {
+	return func(r *Relay) {
+		r.maxAttempts = n
+	}
+}
+
+// WithLogger configures the logger used for delivery failures.
+func WithLogger(logger *slog.Logger) Option {
+	return func(r *Relay) {
+		if logger != nil {
+			r.logger = logger
+		}
+	}
+}
+
+// WithClock overrides the time source, for tests.
+func WithClock(now func() time.Time) Option {
+	return func(r *Relay) {
+		if now != nil {
+			r.now = now
+		}
+	}
+}
+
+// Relay publishes pending outbox records and marks them delivered.
+type Relay struct {
+	store       Store
+	publisher   service.EventPublisher
+	batch       int
+	base        time.Duration
+	max         time.Duration
+	maxAttempts int
+	lease       time.Duration
+	logger      *slog.Logger
+	now         func() time.Time
+}
+
+// NewRelay builds a relay draining store into publisher.
+func NewRelay(store Store, publisher service.EventPublisher, opts ...Option) *Relay {
+	r := &Relay{
+		store:     store,
+		publisher: publisher,
+		batch:     100,
+		base:      time.Second,
+		max:       time.Minute,
+		lease:     time.Minute,
+		logger:    slog.Default(),
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// RunOnce makes one delivery pass and returns how many records were
+// published. Events of an order are delivered in the order they were stored:
+// once one of them is waiting for a retry, the later ones wait too.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	now := r.now()
+	records, err := r.store.Pending(ctx, now, r.lease, r.batch)
+	if err != nil {
+		return 0, err
+	}
+	// blocked holds the retry of the record each waiting order is behind.
+	blocked := make(map[string]Record)
+	delivered := 0
+	for _, rec := range records {
+		key := orderKey(rec.Event)
+		if head, ok := blocked[key]; ok {
+			// Release the claim so the record is due with the one it waits
+			// behind rather than when the lease runs out.
+			if err := r.store.MarkRetry(ctx, rec.ID, rec.Attempts, head.NextAttemptAt, fmt.Sprintf("waiting behind outbox record %d", head.ID)); err != nil {
+				r.logger.Error("failed to reschedule order event", "outbox_id", rec.ID, "error", err)
+			}
+			continue
+		}
+		if err := r.publisher.PublishOrderEvent(ctx, rec.Event); err != nil {
+			if ctx.Err() != nil {
+				return delivered, ctx.Err()
+			}
+			if next, retried := r.fail(ctx, rec, now, err); retried {
+				blocked[key] = Record{ID: rec.ID, NextAttemptAt: next}
+			}
+			continue
+		}
+		if err := r.store.MarkDelivered(ctx, rec.ID, now); err != nil {
+			return delivered, err
+		}
+		delivered++
+	}
+	return delivered, nil
+}
+
+// fail records a failed delivery and reports whether and when the record
+// will be retried.
+func (r *Relay) fail(ctx context.Context, rec Record, now time.Time, cause error) (time.Time, bool) {
+	attempts := rec.Attempts + 1
+	log := r.logger.With("outbox_id", rec.ID, "type", rec.Event.Type, "order_id", rec.Event.Order.ID, "attempts", attempts, "error", cause)
+	if errors.Is(cause, service.ErrUndeliverable) || (r.maxAttempts > 0 && attempts >= r.maxAttempts) {
+		log.Error("giving up on order event")
+		if err := r.store.MarkFailed(ctx, rec.ID, attempts, cause.Error()); err != nil {
+			log.Error("failed to park order event", "mark_error", err)
+		}
+		return time.Time{}, false
+	}
+	next := now.Add(r.backoff(attempts))
+	log.Warn("order event delivery failed, will retry", "next_attempt_at", next)
+	if err := r.store.MarkRetry(ctx, rec.ID, attempts, next, cause.Error()); err != nil {
+		log.Error("failed to schedule order event retry", "mark_error", err)
+	}
+	return next, true
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.base
+	for i := 1; i < attempts && delay < r.max; i++ {
+		delay *= 2
+	}
+	if delay > r.max {
+		delay = r.max
+	}
+	return delay
+}
+
+// Run calls RunOnce every interval until ctx is cancelled, draining
+// immediately again while full batches keep coming back.
+func (r *Relay) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := r.RunOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Error("outbox relay pass failed", "error", err)
+		}
+		if err == nil && n >= r.batch {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func orderKey(event service.Event) string {
+	if event.Order.ID != "" {
+		return event.Order.ID
+	}
+	return event.Order.BotID + "/" + event.Order.IntentID
+}
@@ -0,0 +1,121 @@
+// Package position keeps the net holdings of every account/bot/symbol,
+// booked from each execution in the transaction that records it.
+package position
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/future-bots/executor/internal/service"
+)
+
+// Key identifies one position.
+type Key struct {
+	AccountID string `json:"account_id"`
+	BotID     string `json:"bot_id"`
+	// Mode keeps paper positions apart from live ones.
+	Mode   service.Mode `json:"mode"`
+	Symbol string       `json:"symbol"`
+}
+
+// Position is the net holding for a key. Quantity is signed: positive for
+// long, negative for short. AveragePrice is the average entry price of the
+// open quantity and RealizedPnL accumulates closed profit net of fees, both in
+// price units per unit of quantity.
+type Position struct {
+	Key
+	Quantity     float64   `json:"quantity"`
+	AveragePrice float64   `json:"average_price"`
+	RealizedPnL  float64   `json:"realized_pnl"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Filter narrows position listings. Empty fields match everything.
+type Filter struct {
+	AccountID string
+	BotID     string
+	Mode      service.Mode
+	Symbol    string
+}
+
+// Matches reports whether p satisfies the filter.
+func (f Filter) Matches(p Position) bool {
+	return (f.AccountID == "" || p.AccountID == f.AccountID) &&
+		(f.BotID == "" || p.BotID == f.BotID) &&
+		(f.Mode == "" || p.Mode == f.Mode) &&
+		(f.Symbol == "" || p.Symbol == f.Symbol)
+}
+
+// Store persists positions. Update must run fn against the current position
+// (the zero position for an unknown key) and save the result atomically.
+type Store interface {
+	Update(ctx context.Context, key Key, fn func(*Position)) (Position, error)
+	List(ctx context.Context, filter Filter) ([]Position, error)
+}
+
+// Reader exposes positions to API handlers.
+type Reader interface {
+	List(ctx context.Context, filter Filter) ([]Position, error)
+}
+
+// quantityEpsilon absorbs float noise when a position is closed out.
+const quantityEpsilon = 1e-9
+
+// Apply books a fill of signed quantity (positive buys, negative sells) at
+// price into p using average-cost accounting. Adding to a position moves the
+// average price; reducing it realizes PnL against the average; crossing
+// through flat opens the remainder at the fill price. fee, in price units, is
+// deducted from realized PnL.
+func (p *Position) Apply(quantity, price, fee float64, at time.Time) {
+	p.RealizedPnL -= fee
+	p.UpdatedAt = at
+
+	switch {
+	case p.Quantity == 0 || sameSign(p.Quantity, quantity):
+		total := p.Quantity + quantity
+		p.AveragePrice = (p.AveragePrice*math.Abs(p.Quantity) + price*math.Abs(quantity)) / math.Abs(total)
+		p.Quantity = total
+	default:
+		closed := math.Min(math.Abs(quantity), math.Abs(p.Quantity))
+		direction := 1.0
+		if p.Quantity < 0 {
+			direction = -1
+		}
+		p.RealizedPnL += (price - p.AveragePrice) * closed * direction
+		p.Quantity += quantity
+		switch {
+		case math.Abs(p.Quantity) < quantityEpsilon:
+			p.Quantity = 0
+			p.AveragePrice = 0
+		case !sameSign(p.Quantity, direction):
+			p.AveragePrice = price
+		}
+	}
+}
+
+func sameSign(a, b float64) bool {
+	return (a > 0) == (b > 0)
+}
+
+// Book returns the key of the position an execution of order moves and the
+// update that books the execution into it. multiplier, when not nil, converts
+// the fee and tax on the execution, charged in currency, into price units by
+// dividing them by the currency value of one price unit of the symbol;
+// without it charges are deducted as they are.
+func Book(order service.Order, execution service.Execution, multiplier func(symbol string) float64) (Key, func(*Position)) {
+	quantity := execution.Quantity
+	if order.Side == "sell" {
+		quantity = -quantity
+	}
+	charges := execution.Fee + execution.Tax
+	if multiplier != nil {
+		if m := multiplier(order.Symbol); m > 0 {
+			charges /= m
+		}
+	}
+	key := Key{AccountID: order.AccountID, BotID: order.BotID, Mode: order.Mode.OrLive(), Symbol: order.Symbol}
+	return key, func(p *Position) {
+		p.Apply(quantity, execution.Price, charges, execution.FilledAt)
+	}
+}
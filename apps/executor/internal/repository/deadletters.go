@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/future-bots/executor/internal/messaging"
+)
+
+// memoryDeadLetterLimit bounds how many dead letters the memory repository keeps.
+const memoryDeadLetterLimit = 1000
+
+// SaveDeadLetter records a dead letter, replacing one with the same id.
+func (m *Memory) SaveDeadLetter(_ context.Context, letter messaging.DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, existing := range m.deadLetters {
+		if existing.ID == letter.ID {
+			m.deadLetters = append(m.deadLetters[:i], m.deadLetters[i+1:]...)
+			break
+		}
+	}
+	m.deadLetters = append(m.deadLetters, letter)
+	if len(m.deadLetters) > memoryDeadLetterLimit {
+		m.deadLetters = m.deadLetters[len(m.deadLetters)-memoryDeadLetterLimit:]
+	}
+	return nil
+}
+
+// DeadLetters returns up to limit dead letters, newest first.
+func (m *Memory) DeadLetters(_ context.Context, limit int) ([]messaging.DeadLetter, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := append([]messaging.DeadLetter{}, m.deadLetters...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].FailedAt.Equal(out[j].FailedAt) {
+			return out[i].FailedAt.After(out[j].FailedAt)
+		}
+		return out[i].ID > out[j].ID
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// GetDeadLetter returns the dead letter with the given id.
+func (m *Memory) GetDeadLetter(_ context.Context, id string) (messaging.DeadLetter, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, letter := range m.deadLetters {
+		if letter.ID == id {
+			return letter, nil
+		}
+	}
+	return messaging.DeadLetter{}, messaging.ErrDeadLetterNotFound
+}
+
+// MarkDeadLetterRedriven records a successful re-drive.
+func (m *Memory) MarkDeadLetterRedriven(_ context.Context, id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.deadLetters {
+		if m.deadLetters[i].ID == id {
+			m.deadLetters[i].RedrivenAt = &at
+			return nil
+		}
+	}
+	return messaging.ErrDeadLetterNotFound
+}
+
+const deadLetterColumns = `id, topic, kafka_partition, kafka_offset, message_key, message_value, headers, error, attempts, failed_at, redriven_at`
+
+// SaveDeadLetter upserts a dead letter; a message dead-lettered again replaces
+// the earlier failure and clears its re-drive.
+func (p *Postgres) SaveDeadLetter(ctx context.Context, letter messaging.DeadLetter) error {
+	headers, err := json.Marshal(letter.Headers)
+	if err != nil {
+		return fmt.Errorf("encode dead letter headers: %w", err)
+	}
+	if _, err := p.db.ExecContext(ctx, `INSERT INTO dead_letters (`+deadLetterColumns+`)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULL)
+ON CONFLICT (id) DO UPDATE SET error = EXCLUDED.error, attempts = EXCLUDED.attempts, failed_at = EXCLUDED.failed_at, redriven_at = NULL`,
+		letter.ID, letter.Topic, letter.Partition, letter.Offset, letter.Key, letter.Value, headers,
+		letter.Error, letter.Attempts, letter.FailedAt); err != nil {
+		return fmt.Errorf("insert dead letter: %w", err)
+	}
+	return nil
+}
+
+// DeadLetters returns up to limit dead letters, newest first.
+func (p *Postgres) DeadLetters(ctx context.Context, limit int) ([]messaging.DeadLetter, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT `+deadLetterColumns+`
+FROM dead_letters ORDER BY failed_at DESC, id DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("select dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	letters := make([]messaging.DeadLetter, 0)
+	for rows.Next() {
+		letter, err := scanDeadLetter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan dead letter: %w", err)
+		}
+		letters = append(letters, letter)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate dead letters: %w", err)
+	}
+	return letters, nil
+}
+
+// GetDeadLetter returns the dead letter with the given id.
+func (p *Postgres) GetDeadLetter(ctx context.Context, id string) (messaging.DeadLetter, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+deadLetterColumns+` FROM dead_letters WHERE id = $1`, id)
+	letter, err := scanDeadLetter(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return messaging.DeadLetter{}, messaging.ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return messaging.DeadLetter{}, fmt.Errorf("select dead letter: %w", err)
+	}
+	return letter, nil
+}
+
+// MarkDeadLetterRedriven records a successful re-drive.
+func (p *Postgres) MarkDeadLetterRedriven(ctx context.Context, id string, at time.Time) error {
+	res, err := p.db.ExecContext(ctx, `UPDATE dead_letters SET redriven_at = $2 WHERE id = $1`, id, at)
+	if err != nil {
+		return fmt.Errorf("update dead letter: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return messaging.ErrDeadLetterNotFound
+	}
+	return nil
+}
+
+func scanDeadLetter(row rowScanner) (messaging.DeadLetter, error) {
+	var (
+		letter   messaging.DeadLetter
+		headers  []byte
+		redriven sql.NullTime
+	)
+	if err := row.Scan(&letter.ID, &letter.Topic, &letter.Partition, &letter.Offset, &letter.Key, &letter.Value, &headers,
+		&letter.Error, &letter.Attempts, &letter.FailedAt, &redriven); err != nil {
+		return messaging.DeadLetter{}, err
+	}
+	if err := json.Unmarshal(headers, &letter.Headers); err != nil {
+		return messaging.DeadLetter{}, fmt.Errorf("decode headers of dead letter %s: %w", letter.ID, err)
+	}
+	letter.FailedAt = letter.FailedAt.UTC()
+	if redriven.Valid {
+		t := redriven.Time.UTC()
+		letter.RedrivenAt = &t
+	}
+	return letter, nil
+}
@@ -2,32 +2,103 @@ package repository
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
+	"github.com/future-bots/executor/internal/messaging"
+	"github.com/future-bots/executor/internal/outbox"
+	"github.com/future-bots/executor/internal/position"
+	"github.com/future-bots/executor/internal/routing"
 	"github.com/future-bots/executor/internal/service"
 )
 
 // Memory stores orders in-memory for testing and local development.
 type Memory struct {
-	mu     sync.RWMutex
-	orders map[string]service.Order
+	mu          sync.RWMutex
+	orders      map[string]service.Order
+	intents     map[string]string
+	transitions map[string][]service.Transition
+	executions  map[string][]service.Execution
+	executed    map[string]bool
+	positions   *MemoryPositions
+	opts        options
+	outbox      []outbox.Record
+	outboxSeq   int64
+	runs        []service.ReconciliationRun
+	summaries   []service.SessionSummary
+	rules       []routing.Rule
+	deadLetters []messaging.DeadLetter
 }
 
 // NewMemory constructs an empty memory-backed repository.
-func NewMemory() *Memory {
+func NewMemory(opts ...Option) *Memory {
 	return &Memory{
-		orders: make(map[string]service.Order),
+		orders:      make(map[string]service.Order),
+		intents:     make(map[string]string),
+		transitions: make(map[string][]service.Transition),
+		executions:  make(map[string][]service.Execution),
+		executed:    make(map[string]bool),
+		positions:   NewMemoryPositions(),
+		opts:        newOptions(opts),
 	}
 }
 
-// Create persists an order.
-func (m *Memory) Create(_ context.Context, order service.Order) error {
+// Positions returns the store RecordExecution books executions into with
+// WithPositions.
+func (m *Memory) Positions() *MemoryPositions {
+	return m.positions
+}
+
+// Create persists an order and records its initial status, checking guard
+// under the same lock.
+func (m *Memory) Create(_ context.Context, order service.Order, guard service.CreateGuard, events ...service.Event) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	key := intentKey(order.BotID, order.IntentID)
+	if order.IntentID != "" {
+		if _, ok := m.intents[key]; ok {
+			return service.ErrDuplicateIntent
+		}
+	}
+	if guard.Sequence {
+		if last := m.lastSequence(order.BotID, order.AccountID); order.Sequence <= last {
+			return service.SequenceError{BotID: order.BotID, AccountID: order.AccountID, Last: last, Got: order.Sequence}
+		}
+	}
+	for _, limit := range guard.OpenLimits {
+		if m.openOrders(limit) >= limit.Max {
+			return service.ThrottleError{Scope: limit.Scope, Key: limit.Key, Limit: service.LimitMaxOpenOrders}
+		}
+	}
+	if order.IntentID != "" {
+		m.intents[key] = order.ID
+	}
 	m.orders[order.ID] = order
+	at := order.CreatedAt
+	if at.IsZero() {
+		at = order.UpdatedAt
+	}
+	m.transitions[order.ID] = []service.Transition{{OrderID: order.ID, To: order.Status, At: at}}
+	m.appendEvents(events)
 	return nil
 }
 
+// openOrders counts the orders limit caps. Must be called with m.mu held.
+func (m *Memory) openOrders(limit service.OpenOrderLimit) int {
+	open := 0
+	for _, order := range m.orders {
+		owner := order.BotID
+		if limit.Scope == "account" {
+			owner = order.AccountID
+		}
+		if owner == limit.Key && order.ParentID == "" && !order.Leg.Exit() && !order.Status.Terminal() {
+			open++
+		}
+	}
+	return open
+}
+
 // Get retrieves an order by id.
 func (m *Memory) Get(_ context.Context, id string) (service.Order, error) {
 	m.mu.RLock()
@@ -38,3 +109,225 @@ func (m *Memory) Get(_ context.Context, id string) (service.Order, error) {
 	}
 	return order, nil
 }
+
+// GetByIntent retrieves the order created for a bot's intent id.
+func (m *Memory) GetByIntent(_ context.Context, botID, intentID string) (service.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	id, ok := m.intents[intentKey(botID, intentID)]
+	if !ok {
+		return service.Order{}, service.ErrOrderNotFound
+	}
+	return m.orders[id], nil
+}
+
+// LastSequence returns the highest sequence stored for the bot and account.
+func (m *Memory) LastSequence(_ context.Context, botID, accountID string) (uint64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastSequence(botID, accountID), nil
+}
+
+// lastSequence returns the highest sequence stored for the bot and account.
+// Must be called with m.mu held.
+func (m *Memory) lastSequence(botID, accountID string) uint64 {
+	var last uint64
+	for _, order := range m.orders {
+		if order.BotID == botID && order.AccountID == accountID && order.Sequence > last {
+			last = order.Sequence
+		}
+	}
+	return last
+}
+
+// Transition stores the updated order and appends the transition to its history.
+func (m *Memory) Transition(_ context.Context, order service.Order, transition service.Transition, events ...service.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.orders[order.ID]; !ok {
+		return service.ErrOrderNotFound
+	}
+	m.orders[order.ID] = order
+	m.transitions[order.ID] = append(m.transitions[order.ID], transition)
+	m.appendEvents(events)
+	return nil
+}
+
+// Transitions returns a copy of the order's status history.
+func (m *Memory) Transitions(_ context.Context, orderID string) ([]service.Transition, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	history := m.transitions[orderID]
+	out := make([]service.Transition, len(history))
+	copy(out, history)
+	return out, nil
+}
+
+// RecordExecution stores the fill alongside the updated order and transition
+// and, with WithPositions, books it into its position.
+func (m *Memory) RecordExecution(_ context.Context, order service.Order, execution service.Execution, transition service.Transition, events ...service.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.orders[order.ID]; !ok {
+		return service.ErrOrderNotFound
+	}
+	if m.executed[execution.ID] {
+		return service.ErrDuplicateExecution
+	}
+	m.executed[execution.ID] = true
+	if m.opts.positions {
+		key, book := position.Book(order, execution, m.opts.multiplier)
+		m.positions.mu.Lock()
+		m.positions.update(key, book)
+		m.positions.mu.Unlock()
+	}
+	m.orders[order.ID] = order
+	m.executions[order.ID] = append(m.executions[order.ID], execution)
+	m.transitions[order.ID] = append(m.transitions[order.ID], transition)
+	m.appendEvents(events)
+	return nil
+}
+
+// AppendEvents stores events in the outbox.
+func (m *Memory) AppendEvents(_ context.Context, events ...service.Event) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.appendEvents(events)
+	return nil
+}
+
+// Pending returns up to limit outbox records due at now, oldest first,
+// claiming them for lease.
+func (m *Memory) Pending(_ context.Context, now time.Time, lease time.Duration, limit int) ([]outbox.Record, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	waiting := make(map[string]bool)
+	var out []outbox.Record
+	for i := range m.outbox {
+		rec := &m.outbox[i]
+		key := rec.Event.Order.ID
+		if rec.NextAttemptAt.After(now) {
+			if key != "" {
+				waiting[key] = true
+			}
+			continue
+		}
+		if waiting[key] {
+			continue
+		}
+		if limit > 0 && len(out) == limit {
+			break
+		}
+		out = append(out, *rec)
+		if lease > 0 {
+			rec.NextAttemptAt = now.Add(lease)
+		}
+	}
+	return out, nil
+}
+
+// MarkDelivered drops a delivered record from the outbox.
+func (m *Memory) MarkDelivered(_ context.Context, id int64, _ time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeRecord(id)
+	return nil
+}
+
+// MarkRetry records a failed delivery attempt.
+func (m *Memory) MarkRetry(_ context.Context, id int64, attempts int, next time.Time, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.outbox {
+		if m.outbox[i].ID == id {
+			m.outbox[i].Attempts = attempts
+			m.outbox[i].NextAttemptAt = next
+		}
+	}
+	return nil
+}
+
+// MarkFailed drops a record that will not be retried from the outbox.
+func (m *Memory) MarkFailed(_ context.Context, id int64, _ int, _ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removeRecord(id)
+	return nil
+}
+
+func (m *Memory) appendEvents(events []service.Event) {
+	for _, event := range events {
+		m.outboxSeq++
+		m.outbox = append(m.outbox, outbox.Record{ID: m.outboxSeq, Event: event, NextAttemptAt: event.OccurredAt, CreatedAt: event.OccurredAt})
+	}
+}
+
+func (m *Memory) removeRecord(id int64) {
+	for i := range m.outbox {
+		if m.outbox[i].ID == id {
+			m.outbox = append(m.outbox[:i], m.outbox[i+1:]...)
+			return
+		}
+	}
+}
+
+// Expiring returns working orders whose deadline is at or before at, oldest
+// deadline first.
+func (m *Memory) Expiring(_ context.Context, at time.Time) ([]service.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]service.Order, 0)
+	for _, order := range m.orders {
+		if order.ExpiresAt != nil && !order.ExpiresAt.After(at) && !order.Status.Terminal() {
+			out = append(out, order)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ExpiresAt.Before(*out[j].ExpiresAt) })
+	return out, nil
+}
+
+// Scheduled returns child orders still waiting for release whose
+// scheduled_at is at or before at, earliest first.
+func (m *Memory) Scheduled(_ context.Context, at time.Time) ([]service.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]service.Order, 0)
+	for _, order := range m.orders {
+		if order.Status == service.StatusNew && order.ParentID != "" && order.ScheduledAt != nil && !order.ScheduledAt.After(at) {
+			out = append(out, order)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ScheduledAt.Before(*out[j].ScheduledAt) })
+	return out, nil
+}
+
+// ListOrders returns the orders matching query, sorted by creation time.
+func (m *Memory) ListOrders(_ context.Context, query service.OrderQuery) ([]service.Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]service.Order, 0)
+	for _, order := range m.orders {
+		if query.Filter.Matches(order) && query.IsAfter(order) {
+			out = append(out, order)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return query.Less(out[i], out[j]) })
+	if query.Limit > 0 && len(out) > query.Limit {
+		out = out[:query.Limit]
+	}
+	return out, nil
+}
+
+// Executions returns a copy of the fills recorded for an order.
+func (m *Memory) Executions(_ context.Context, orderID string) ([]service.Execution, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	fills := m.executions[orderID]
+	out := make([]service.Execution, len(fills))
+	copy(out, fills)
+	return out, nil
+}
+
+func intentKey(botID, intentID string) string {
+	return botID + "/" + intentID
+}
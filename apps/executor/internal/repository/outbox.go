@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/future-bots/executor/internal/outbox"
+	"github.com/future-bots/executor/internal/service"
+)
+
+// Outbox record states stored in order_outbox.status.
+const (
+	outboxPending   = "pending"
+	outboxDelivered = "delivered"
+	outboxFailed    = "failed"
+)
+
+// AppendEvents stores events in the outbox on their own.
+func (p *Postgres) AppendEvents(ctx context.Context, events ...service.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return p.inTx(ctx, func(tx *sql.Tx) error {
+		return insertEvents(ctx, tx, events)
+	})
+}
+
+// Pending returns up to limit outbox records due at now, oldest first. A
+// record waits while an earlier record of its order is not due. Rows locked
+// by another executor's relay are skipped, and with a lease the rows read
+// are claimed by moving their next attempt past now.
+func (p *Postgres) Pending(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]outbox.Record, error) {
+	var records []outbox.Record
+	err := p.inTx(ctx, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `SELECT id, payload, attempts, next_attempt_at, created_at
+FROM order_outbox o
+WHERE status = $1 AND next_attempt_at <= $2
+  AND NOT EXISTS (SELECT 1 FROM order_outbox earlier
+    WHERE earlier.status = $1 AND earlier.order_id = o.order_id AND earlier.id < o.id AND earlier.next_attempt_at > $2)
+ORDER BY id LIMIT $3
+FOR UPDATE SKIP LOCKED`, outboxPending, now, limit)
+		if err != nil {
+			return fmt.Errorf("select outbox: %w", err)
+		}
+		defer rows.Close()
+
+		var ids []int64
+		for rows.Next() {
+			var (
+				rec     outbox.Record
+				payload []byte
+			)
+			if err := rows.Scan(&rec.ID, &payload, &rec.Attempts, &rec.NextAttemptAt, &rec.CreatedAt); err != nil {
+				return fmt.Errorf("scan outbox record: %w", err)
+			}
+			if err := json.Unmarshal(payload, &rec.Event); err != nil {
+				return fmt.Errorf("decode outbox record %d: %w", rec.ID, err)
+			}
+			records = append(records, rec)
+			ids = append(ids, rec.ID)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("iterate outbox: %w", err)
+		}
+		if lease <= 0 || len(ids) == 0 {
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE order_outbox SET next_attempt_at = $2 WHERE id = ANY($1)`, ids, now.Add(lease)); err != nil {
+			return fmt.Errorf("claim outbox records: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// MarkDelivered flags an outbox record as published.
+func (p *Postgres) MarkDelivered(ctx context.Context, id int64, at time.Time) error {
+	if _, err := p.db.ExecContext(ctx, `UPDATE order_outbox SET status = $2, delivered_at = $3 WHERE id = $1`,
+		id, outboxDelivered, at); err != nil {
+		return fmt.Errorf("mark outbox record delivered: %w", err)
+	}
+	return nil
+}
+
+// MarkRetry records a failed delivery attempt and schedules the next one.
+func (p *Postgres) MarkRetry(ctx context.Context, id int64, attempts int, next time.Time, reason string) error {
+	if _, err := p.db.ExecContext(ctx, `UPDATE order_outbox SET attempts = $2, next_attempt_at = $3, last_error = $4 WHERE id = $1`,
+		id, attempts, next, reason); err != nil {
+		return fmt.Errorf("mark outbox record for retry: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed parks an outbox record that will not be retried.
+func (p *Postgres) MarkFailed(ctx context.Context, id int64, attempts int, reason string) error {
+	if _, err := p.db.ExecContext(ctx, `UPDATE order_outbox SET status = $2, attempts = $3, last_error = $4 WHERE id = $1`,
+		id, outboxFailed, attempts, reason); err != nil {
+		return fmt.Errorf("mark outbox record failed: %w", err)
+	}
+	return nil
+}
+
+func insertEvents(ctx context.Context, tx *sql.Tx, events []service.Event) error {
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("encode order event: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO order_outbox (order_id, event_type, payload, next_attempt_at, created_at)
+VALUES ($1, $2, $3, $4, $4)`,
+			nullString(event.Order.ID), string(event.Type), payload, event.OccurredAt); err != nil {
+			return fmt.Errorf("insert outbox record: %w", err)
+		}
+	}
+	return nil
+}
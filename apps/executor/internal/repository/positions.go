@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/future-bots/executor/internal/position"
+	"github.com/future-bots/executor/internal/service"
+)
+
+// Option customises an order repository.
+type Option func(*options)
+
+type options struct {
+	positions  bool
+	multiplier func(symbol string) float64
+}
+
+// WithPositions books every execution RecordExecution stores into its
+// position in the same transaction, so a fill moves its position exactly once
+// however often it is reported. multiplier converts the charges on the
+// execution into price units as described by position.Book; it may be nil.
+func WithPositions(multiplier func(symbol string) float64) Option {
+	return func(o *options) {
+		o.positions = true
+		o.multiplier = multiplier
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// MemoryPositions stores positions in-memory for testing and local development.
+type MemoryPositions struct {
+	mu        sync.Mutex
+	positions map[position.Key]position.Position
+}
+
+// NewMemoryPositions constructs an empty memory-backed position store.
+func NewMemoryPositions() *MemoryPositions {
+	return &MemoryPositions{positions: make(map[position.Key]position.Position)}
+}
+
+// Update applies fn to the stored position for key under the store lock.
+func (m *MemoryPositions) Update(_ context.Context, key position.Key, fn func(*position.Position)) (position.Position, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.update(key, fn), nil
+}
+
+func (m *MemoryPositions) update(key position.Key, fn func(*position.Position)) position.Position {
+	p, ok := m.positions[key]
+	if !ok {
+		p = position.Position{Key: key}
+	}
+	fn(&p)
+	m.positions[key] = p
+	return p
+}
+
+// List returns the positions matching filter ordered by key.
+func (m *MemoryPositions) List(_ context.Context, filter position.Filter) ([]position.Position, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]position.Position, 0, len(m.positions))
+	for _, p := range m.positions {
+		if filter.Matches(p) {
+			out = append(out, p)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return lessKey(out[i].Key, out[j].Key) })
+	return out, nil
+}
+
+func lessKey(a, b position.Key) bool {
+	if a.AccountID != b.AccountID {
+		return a.AccountID < b.AccountID
+	}
+	if a.BotID != b.BotID {
+		return a.BotID < b.BotID
+	}
+	if a.Mode != b.Mode {
+		return a.Mode < b.Mode
+	}
+	return a.Symbol < b.Symbol
+}
+
+// PostgresPositions persists positions in the positions table.
+type PostgresPositions struct {
+	pg *Postgres
+}
+
+// NewPostgresPositions constructs a position store on top of an open database
+// handle.
+func NewPostgresPositions(db *sql.DB) *PostgresPositions {
+	return &PostgresPositions{pg: NewPostgres(db)}
+}
+
+const positionColumns = `account_id, bot_id, mode, symbol, quantity, average_price, realized_pnl, updated_at`
+
+// Update locks the position row, applies fn and writes the result back in one
+// transaction so concurrent fills for the same key serialise.
+func (p *PostgresPositions) Update(ctx context.Context, key position.Key, fn func(*position.Position)) (position.Position, error) {
+	var out position.Position
+	err := p.pg.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		out, err = updatePosition(ctx, tx, key, fn)
+		return err
+	})
+	return out, err
+}
+
+// updatePosition locks the position row for key within tx, applies fn and
+// writes the result back.
+func updatePosition(ctx context.Context, tx *sql.Tx, key position.Key, fn func(*position.Position)) (position.Position, error) {
+	if _, err := tx.ExecContext(ctx, `INSERT INTO positions (account_id, bot_id, mode, symbol)
+VALUES ($1, $2, $3, $4) ON CONFLICT DO NOTHING`, key.AccountID, key.BotID, string(key.Mode.OrLive()), key.Symbol); err != nil {
+		return position.Position{}, fmt.Errorf("insert position: %w", err)
+	}
+	row := tx.QueryRowContext(ctx, `SELECT `+positionColumns+` FROM positions
+WHERE account_id = $1 AND bot_id = $2 AND mode = $3 AND symbol = $4 FOR UPDATE`, key.AccountID, key.BotID, string(key.Mode.OrLive()), key.Symbol)
+	current, err := scanPosition(row)
+	if err != nil {
+		return position.Position{}, fmt.Errorf("select position: %w", err)
+	}
+	fn(&current)
+	if _, err := tx.ExecContext(ctx, `UPDATE positions
+SET quantity = $5, average_price = $6, realized_pnl = $7, updated_at = $8
+WHERE account_id = $1 AND bot_id = $2 AND mode = $3 AND symbol = $4`,
+		key.AccountID, key.BotID, string(key.Mode.OrLive()), key.Symbol, current.Quantity, current.AveragePrice, current.RealizedPnL, current.UpdatedAt); err != nil {
+		return position.Position{}, fmt.Errorf("update position: %w", err)
+	}
+	return current, nil
+}
+
+// List returns the positions matching filter ordered by key.
+func (p *PostgresPositions) List(ctx context.Context, filter position.Filter) ([]position.Position, error) {
+	var (
+		where []string
+		args  []any
+	)
+	add := func(column, value string) {
+		if value == "" {
+			return
+		}
+		args = append(args, value)
+		where = append(where, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	add("account_id", filter.AccountID)
+	add("bot_id", filter.BotID)
+	add("mode", string(filter.Mode))
+	add("symbol", filter.Symbol)
+
+	stmt := `SELECT ` + positionColumns + ` FROM positions`
+	if len(where) > 0 {
+		stmt += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	stmt += ` ORDER BY account_id, bot_id, mode, symbol`
+
+	rows, err := p.pg.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select positions: %w", err)
+	}
+	defer rows.Close()
+	var out []position.Position
+	for rows.Next() {
+		pos, err := scanPosition(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan position: %w", err)
+		}
+		out = append(out, pos)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate positions: %w", err)
+	}
+	return out, nil
+}
+
+func scanPosition(row rowScanner) (position.Position, error) {
+	var (
+		pos       position.Position
+		mode      string
+		updatedAt sql.NullTime
+	)
+	if err := row.Scan(&pos.AccountID, &pos.BotID, &mode, &pos.Symbol, &pos.Quantity, &pos.AveragePrice, &pos.RealizedPnL, &updatedAt); err != nil {
+		return position.Position{}, err
+	}
+	pos.Mode = service.Mode(mode)
+	if updatedAt.Valid {
+		pos.UpdatedAt = updatedAt.Time
+	}
+	return pos, nil
+}
@@ -0,0 +1,495 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/future-bots/executor/internal/position"
+	"github.com/future-bots/executor/internal/service"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres persists orders, executions and transitions in PostgreSQL/TimescaleDB
+// using the tables created by the executor migrations.
+type Postgres struct {
+	db   *sql.DB
+	opts options
+}
+
+// NewPostgres constructs a repository on top of an open database handle.
+func NewPostgres(db *sql.DB, opts ...Option) *Postgres {
+	return &Postgres{db: db, opts: newOptions(opts)}
+}
+
+const orderColumns = `id, intent_id, sequence, bot_id, account_id, symbol, side, qty, price, order_type, time_in_force, stop_price, expires_at, filled_qty, status, provider_order_id, created_at, updated_at,
+parent_id, scheduled_at, algorithm, algo_duration_seconds, algo_slices, display_quantity, bracket_id, leg, mode, route, route_reason`
+
+// intentUniqueIndex is the unique index guarding against replayed intents.
+const intentUniqueIndex = "orders_bot_intent_idx"
+
+// executionPrimaryKey guards against recording an execution twice.
+const executionPrimaryKey = "executions_pkey"
+
+// Create inserts the order and its initial transition in one transaction,
+// checking guard within it.
+func (p *Postgres) Create(ctx context.Context, order service.Order, guard service.CreateGuard, events ...service.Event) error {
+	return p.inTx(ctx, func(tx *sql.Tx) error {
+		if guard.Sequence {
+			if err := checkSequence(ctx, tx, order); err != nil {
+				return err
+			}
+		}
+		if err := checkOpenLimits(ctx, tx, guard.OpenLimits); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO orders (`+orderColumns+`)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29)`,
+			order.ID, nullString(order.IntentID), nullSequence(order.Sequence), order.BotID, nullString(order.AccountID), order.Symbol, order.Side,
+			order.Quantity, nullFloat(order.Price), string(order.Type), string(order.TimeInForce), nullFloat(order.StopPrice), nullTime(order.ExpiresAt),
+			order.FilledQuantity, string(order.Status), nullString(order.ProviderOrderID),
+			order.CreatedAt, order.UpdatedAt,
+			nullString(order.ParentID), nullTime(order.ScheduledAt), nullString(string(order.Algorithm)), nullInt(order.AlgoDurationSeconds),
+			nullInt(int64(order.AlgoSlices)), nullFloat(order.DisplayQuantity), nullString(order.BracketID), nullString(string(order.Leg)), string(order.Mode.OrLive()),
+			nullString(order.Route), nullString(order.RouteReason)); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == intentUniqueIndex {
+				return service.ErrDuplicateIntent
+			}
+			return fmt.Errorf("insert order: %w", err)
+		}
+		at := order.CreatedAt
+		if at.IsZero() {
+			at = order.UpdatedAt
+		}
+		if err := insertTransition(ctx, tx, service.Transition{OrderID: order.ID, To: order.Status, At: at}); err != nil {
+			return err
+		}
+		return insertEvents(ctx, tx, events)
+	})
+}
+
+// Get retrieves an order by id.
+func (p *Postgres) Get(ctx context.Context, id string) (service.Order, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+orderColumns+` FROM orders WHERE id = $1`, id)
+	order, err := scanOrder(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service.Order{}, service.ErrOrderNotFound
+	}
+	if err != nil {
+		return service.Order{}, fmt.Errorf("select order: %w", err)
+	}
+	return order, nil
+}
+
+// GetByIntent retrieves the order created for a bot's intent id.
+func (p *Postgres) GetByIntent(ctx context.Context, botID, intentID string) (service.Order, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+orderColumns+` FROM orders WHERE bot_id = $1 AND intent_id = $2`, botID, intentID)
+	order, err := scanOrder(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return service.Order{}, service.ErrOrderNotFound
+	}
+	if err != nil {
+		return service.Order{}, fmt.Errorf("select order by intent: %w", err)
+	}
+	return order, nil
+}
+
+// LastSequence returns the highest sequence stored for the bot and account.
+func (p *Postgres) LastSequence(ctx context.Context, botID, accountID string) (uint64, error) {
+	return lastSequence(ctx, p.db, botID, accountID)
+}
+
+func lastSequence(ctx context.Context, q rowQuerier, botID, accountID string) (uint64, error) {
+	var last sql.NullInt64
+	if err := q.QueryRowContext(ctx, `SELECT MAX(sequence) FROM orders
+WHERE bot_id = $1 AND COALESCE(account_id, '') = $2`, botID, accountID).Scan(&last); err != nil {
+		return 0, fmt.Errorf("select last sequence: %w", err)
+	}
+	return uint64(last.Int64), nil
+}
+
+// Expiring returns working orders whose deadline is at or before at, oldest
+// deadline first.
+func (p *Postgres) Expiring(ctx context.Context, at time.Time) ([]service.Order, error) {
+	return p.queryOrders(ctx, `SELECT `+orderColumns+` FROM orders
+WHERE expires_at <= $1 AND status NOT IN ('filled', 'cancelled', 'rejected', 'expired')
+ORDER BY expires_at`, at)
+}
+
+// Scheduled returns child orders still waiting for release whose
+// scheduled_at is at or before at, earliest first.
+func (p *Postgres) Scheduled(ctx context.Context, at time.Time) ([]service.Order, error) {
+	return p.queryOrders(ctx, `SELECT `+orderColumns+` FROM orders
+WHERE status = 'new' AND parent_id IS NOT NULL AND scheduled_at <= $1
+ORDER BY scheduled_at`, at)
+}
+
+// ListOrders returns the orders matching query using keyset pagination on
+// (created_at, id).
+func (p *Postgres) ListOrders(ctx context.Context, query service.OrderQuery) ([]service.Order, error) {
+	var (
+		where []string
+		args  []any
+	)
+	add := func(clause string, value any) {
+		args = append(args, value)
+		where = append(where, fmt.Sprintf(clause, len(args)))
+	}
+	f := query.Filter
+	if f.BotID != "" {
+		add("bot_id = $%d", f.BotID)
+	}
+	if f.AccountID != "" {
+		add("account_id = $%d", f.AccountID)
+	}
+	if f.Symbol != "" {
+		add("symbol = $%d", f.Symbol)
+	}
+	if f.Side != "" {
+		add("side = $%d", f.Side)
+	}
+	if f.ParentID != "" {
+		add("parent_id = $%d", f.ParentID)
+	}
+	if f.BracketID != "" {
+		add("bracket_id = $%d", f.BracketID)
+	}
+	if f.TopLevel {
+		where = append(where, "parent_id IS NULL")
+	}
+	if f.Mode != "" {
+		add("mode = $%d", string(f.Mode))
+	}
+	if len(f.Statuses) > 0 {
+		statuses := make([]string, len(f.Statuses))
+		for i, status := range f.Statuses {
+			statuses[i] = string(status)
+		}
+		add("status = ANY($%d)", statuses)
+	}
+	if !f.CreatedFrom.IsZero() {
+		add("created_at >= $%d", f.CreatedFrom)
+	}
+	if !f.CreatedTo.IsZero() {
+		add("created_at < $%d", f.CreatedTo)
+	}
+	direction, cmp := "DESC", "<"
+	if query.Ascending {
+		direction, cmp = "ASC", ">"
+	}
+	if query.After != nil {
+		args = append(args, query.After.CreatedAt, query.After.ID)
+		where = append(where, fmt.Sprintf("(created_at, id) %s ($%d, $%d)", cmp, len(args)-1, len(args)))
+	}
+
+	stmt := `SELECT ` + orderColumns + ` FROM orders`
+	if len(where) > 0 {
+		stmt += ` WHERE ` + strings.Join(where, " AND ")
+	}
+	stmt += fmt.Sprintf(` ORDER BY created_at %s, id %s`, direction, direction)
+	if query.Limit > 0 {
+		args = append(args, query.Limit)
+		stmt += fmt.Sprintf(` LIMIT $%d`, len(args))
+	}
+	return p.queryOrders(ctx, stmt, args...)
+}
+
+// Executions returns the fills recorded for an order, oldest first.
+func (p *Postgres) Executions(ctx context.Context, orderID string) ([]service.Execution, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT id, order_id, fill_qty, fill_price, fee, tax, filled_at
+FROM executions WHERE order_id = $1 ORDER BY filled_at, id`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("select executions: %w", err)
+	}
+	defer rows.Close()
+
+	fills := make([]service.Execution, 0)
+	for rows.Next() {
+		var e service.Execution
+		if err := rows.Scan(&e.ID, &e.OrderID, &e.Quantity, &e.Price, &e.Fee, &e.Tax, &e.FilledAt); err != nil {
+			return nil, fmt.Errorf("scan execution: %w", err)
+		}
+		e.FilledAt = e.FilledAt.UTC()
+		fills = append(fills, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate executions: %w", err)
+	}
+	return fills, nil
+}
+
+// Transition updates the order row and appends the transition in one transaction.
+func (p *Postgres) Transition(ctx context.Context, order service.Order, transition service.Transition, events ...service.Event) error {
+	return p.inTx(ctx, func(tx *sql.Tx) error {
+		if err := updateOrder(ctx, tx, order); err != nil {
+			return err
+		}
+		if err := insertTransition(ctx, tx, transition); err != nil {
+			return err
+		}
+		return insertEvents(ctx, tx, events)
+	})
+}
+
+// Transitions returns the order's status history, oldest first.
+func (p *Postgres) Transitions(ctx context.Context, orderID string) ([]service.Transition, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT order_id, from_status, to_status, reason, transitioned_at
+FROM order_transitions WHERE order_id = $1 ORDER BY transitioned_at, id`, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("select transitions: %w", err)
+	}
+	defer rows.Close()
+
+	history := make([]service.Transition, 0)
+	for rows.Next() {
+		var (
+			t      service.Transition
+			from   sql.NullString
+			to     string
+			reason sql.NullString
+		)
+		if err := rows.Scan(&t.OrderID, &from, &to, &reason, &t.At); err != nil {
+			return nil, fmt.Errorf("scan transition: %w", err)
+		}
+		t.From = service.Status(from.String)
+		t.To = service.Status(to)
+		t.Reason = reason.String
+		t.At = t.At.UTC()
+		history = append(history, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate transitions: %w", err)
+	}
+	return history, nil
+}
+
+// RecordExecution inserts the fill, updates the order, appends the
+// transition and, with WithPositions, books the fill into its position in one
+// transaction. An execution id that is already recorded returns
+// service.ErrDuplicateExecution and changes nothing.
+func (p *Postgres) RecordExecution(ctx context.Context, order service.Order, execution service.Execution, transition service.Transition, events ...service.Event) error {
+	return p.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO executions (id, order_id, fill_qty, fill_price, fee, tax, filled_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			execution.ID, execution.OrderID, execution.Quantity, execution.Price, execution.Fee, execution.Tax, execution.FilledAt); err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == executionPrimaryKey {
+				return service.ErrDuplicateExecution
+			}
+			return fmt.Errorf("insert execution: %w", err)
+		}
+		if p.opts.positions {
+			key, book := position.Book(order, execution, p.opts.multiplier)
+			if _, err := updatePosition(ctx, tx, key, book); err != nil {
+				return err
+			}
+		}
+		if err := updateOrder(ctx, tx, order); err != nil {
+			return err
+		}
+		if err := insertTransition(ctx, tx, transition); err != nil {
+			return err
+		}
+		return insertEvents(ctx, tx, events)
+	})
+}
+
+func (p *Postgres) queryOrders(ctx context.Context, stmt string, args ...any) ([]service.Order, error) {
+	rows, err := p.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select orders: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make([]service.Order, 0)
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan order: %w", err)
+		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate orders: %w", err)
+	}
+	return orders, nil
+}
+
+func (p *Postgres) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// checkSequence refuses an order whose intent sequence does not advance past
+// the last one stored for its bot and account. The advisory lock, held until
+// the transaction ends, serializes sequenced inserts per bot and account.
+func checkSequence(ctx context.Context, tx *sql.Tx, order service.Order) error {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "sequence/"+order.BotID+"/"+order.AccountID); err != nil {
+		return fmt.Errorf("lock sequence of bot %s account %s: %w", order.BotID, order.AccountID, err)
+	}
+	last, err := lastSequence(ctx, tx, order.BotID, order.AccountID)
+	if err != nil {
+		return err
+	}
+	if order.Sequence <= last {
+		return service.SequenceError{BotID: order.BotID, AccountID: order.AccountID, Last: last, Got: order.Sequence}
+	}
+	return nil
+}
+
+// checkOpenLimits counts the open orders each limit caps. A transaction-scoped
+// advisory lock per bot or account serializes concurrent creates, from any
+// executor, until the one holding it commits.
+func checkOpenLimits(ctx context.Context, tx *sql.Tx, limits []service.OpenOrderLimit) error {
+	for _, limit := range limits {
+		column := "bot_id"
+		if limit.Scope == "account" {
+			column = "account_id"
+		}
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, "open_orders/"+limit.Scope+"/"+limit.Key); err != nil {
+			return fmt.Errorf("lock open orders of %s %s: %w", limit.Scope, limit.Key, err)
+		}
+		var open int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM orders
+WHERE `+column+` = $1 AND parent_id IS NULL AND (leg IS NULL OR leg = 'entry')
+AND status NOT IN ('filled', 'cancelled', 'rejected', 'expired')`, limit.Key).Scan(&open); err != nil {
+			return fmt.Errorf("count open orders of %s %s: %w", limit.Scope, limit.Key, err)
+		}
+		if open >= limit.Max {
+			return service.ThrottleError{Scope: limit.Scope, Key: limit.Key, Limit: service.LimitMaxOpenOrders}
+		}
+	}
+	return nil
+}
+
+func updateOrder(ctx context.Context, tx *sql.Tx, order service.Order) error {
+	res, err := tx.ExecContext(ctx, `UPDATE orders
+SET qty = $2, price = $3, filled_qty = $4, status = $5, provider_order_id = $6, updated_at = $7, route = $8, route_reason = $9
+WHERE id = $1`,
+		order.ID, order.Quantity, nullFloat(order.Price), order.FilledQuantity, string(order.Status),
+		nullString(order.ProviderOrderID), order.UpdatedAt, nullString(order.Route), nullString(order.RouteReason))
+	if err != nil {
+		return fmt.Errorf("update order: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return service.ErrOrderNotFound
+	}
+	return nil
+}
+
+func insertTransition(ctx context.Context, tx *sql.Tx, t service.Transition) error {
+	if _, err := tx.ExecContext(ctx, `INSERT INTO order_transitions (order_id, from_status, to_status, reason, transitioned_at)
+VALUES ($1, $2, $3, $4, $5)`,
+		t.OrderID, nullString(string(t.From)), string(t.To), nullString(t.Reason), t.At); err != nil {
+		return fmt.Errorf("insert transition: %w", err)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// rowQuerier is a *sql.DB or *sql.Tx.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func scanOrder(row rowScanner) (service.Order, error) {
+	var (
+		order     service.Order
+		intentID  sql.NullString
+		sequence  sql.NullInt64
+		accountID sql.NullString
+		price     sql.NullFloat64
+		orderType string
+		tif       string
+		stopPrice sql.NullFloat64
+		expiresAt sql.NullTime
+		status    string
+		provider  sql.NullString
+		parentID  sql.NullString
+		scheduled sql.NullTime
+		algorithm sql.NullString
+		duration  sql.NullInt64
+		slices    sql.NullInt64
+		display   sql.NullFloat64
+		bracketID sql.NullString
+		leg       sql.NullString
+		mode      string
+		route     sql.NullString
+		reason    sql.NullString
+	)
+	if err := row.Scan(&order.ID, &intentID, &sequence, &order.BotID, &accountID, &order.Symbol, &order.Side,
+		&order.Quantity, &price, &orderType, &tif, &stopPrice, &expiresAt, &order.FilledQuantity, &status, &provider, &order.CreatedAt, &order.UpdatedAt,
+		&parentID, &scheduled, &algorithm, &duration, &slices, &display, &bracketID, &leg, &mode, &route, &reason); err != nil {
+		return service.Order{}, err
+	}
+	order.IntentID = intentID.String
+	order.Sequence = uint64(sequence.Int64)
+	order.AccountID = accountID.String
+	order.Price = price.Float64
+	order.Type = service.OrderType(orderType)
+	order.TimeInForce = service.TimeInForce(tif)
+	order.StopPrice = stopPrice.Float64
+	if expiresAt.Valid {
+		t := expiresAt.Time.UTC()
+		order.ExpiresAt = &t
+	}
+	order.Status = service.Status(status)
+	order.ProviderOrderID = provider.String
+	order.ParentID = parentID.String
+	if scheduled.Valid {
+		t := scheduled.Time.UTC()
+		order.ScheduledAt = &t
+	}
+	order.Algorithm = service.Algorithm(algorithm.String)
+	order.AlgoDurationSeconds = duration.Int64
+	order.AlgoSlices = int(slices.Int64)
+	order.DisplayQuantity = display.Float64
+	order.BracketID = bracketID.String
+	order.Leg = service.Leg(leg.String)
+	order.Mode = service.Mode(mode)
+	order.Route = route.String
+	order.RouteReason = reason.String
+	order.CreatedAt = order.CreatedAt.UTC()
+	order.UpdatedAt = order.UpdatedAt.UTC()
+	return order, nil
+}
+
+func nullString(v string) sql.NullString {
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
+func nullFloat(v float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: v, Valid: v != 0}
+}
+
+func nullInt(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
+
+func nullSequence(v uint64) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(v), Valid: v != 0}
+}
+
+func nullTime(v *time.Time) sql.NullTime {
+	if v == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *v, Valid: true}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/future-bots/executor/internal/service"
+)
+
+// memoryRunLimit bounds how many reconciliation runs the memory repository keeps.
+const memoryRunLimit = 1000
+
+// SaveReconciliationRun records a reconciliation run, keeping the most recent
+// ones.
+func (m *Memory) SaveReconciliationRun(_ context.Context, run service.ReconciliationRun) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.runs = append(m.runs, run)
+	if len(m.runs) > memoryRunLimit {
+		m.runs = m.runs[len(m.runs)-memoryRunLimit:]
+	}
+	return nil
+}
+
+// ReconciliationRuns returns up to limit runs, newest first.
+func (m *Memory) ReconciliationRuns(_ context.Context, limit int) ([]service.ReconciliationRun, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]service.ReconciliationRun, 0, limit)
+	for i := len(m.runs) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, m.runs[i])
+	}
+	return out, nil
+}
+
+// SaveReconciliationRun inserts a reconciliation run and its discrepancies.
+func (p *Postgres) SaveReconciliationRun(ctx context.Context, run service.ReconciliationRun) error {
+	discrepancies, err := json.Marshal(run.Discrepancies)
+	if err != nil {
+		return fmt.Errorf("encode discrepancies: %w", err)
+	}
+	if _, err := p.db.ExecContext(ctx, `INSERT INTO reconciliation_runs (id, started_at, finished_at, orders_checked, discrepancies, error)
+VALUES ($1, $2, $3, $4, $5, $6)`,
+		run.ID, run.StartedAt, run.FinishedAt, run.OrdersChecked, discrepancies, nullString(run.Error)); err != nil {
+		return fmt.Errorf("insert reconciliation run: %w", err)
+	}
+	return nil
+}
+
+// ReconciliationRuns returns up to limit runs, newest first.
+func (p *Postgres) ReconciliationRuns(ctx context.Context, limit int) ([]service.ReconciliationRun, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT id, started_at, finished_at, orders_checked, discrepancies, error
+FROM reconciliation_runs ORDER BY started_at DESC, id DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("select reconciliation runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]service.ReconciliationRun, 0)
+	for rows.Next() {
+		var (
+			run           service.ReconciliationRun
+			discrepancies []byte
+			runErr        sql.NullString
+		)
+		if err := rows.Scan(&run.ID, &run.StartedAt, &run.FinishedAt, &run.OrdersChecked, &discrepancies, &runErr); err != nil {
+			return nil, fmt.Errorf("scan reconciliation run: %w", err)
+		}
+		if err := json.Unmarshal(discrepancies, &run.Discrepancies); err != nil {
+			return nil, fmt.Errorf("decode discrepancies of run %s: %w", run.ID, err)
+		}
+		run.StartedAt = run.StartedAt.UTC()
+		run.FinishedAt = run.FinishedAt.UTC()
+		run.Error = runErr.String
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate reconciliation runs: %w", err)
+	}
+	return runs, nil
+}
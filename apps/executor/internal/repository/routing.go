@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/future-bots/executor/internal/routing"
+)
+
+// RoutingRules returns the stored routing rules in priority order.
+func (m *Memory) RoutingRules(context.Context) ([]routing.Rule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]routing.Rule{}, m.rules...), nil
+}
+
+// SaveRoutingRules replaces the stored routing rules.
+func (m *Memory) SaveRoutingRules(_ context.Context, rules []routing.Rule) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = append([]routing.Rule{}, rules...)
+	return nil
+}
+
+// RoutingRules returns the stored routing rules in priority order.
+func (p *Postgres) RoutingRules(ctx context.Context) ([]routing.Rule, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT account_id, symbol, brokers FROM routing_rules ORDER BY position`)
+	if err != nil {
+		return nil, fmt.Errorf("select routing rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]routing.Rule, 0)
+	for rows.Next() {
+		var (
+			rule      routing.Rule
+			accountID sql.NullString
+			symbol    sql.NullString
+			brokers   []byte
+		)
+		if err := rows.Scan(&accountID, &symbol, &brokers); err != nil {
+			return nil, fmt.Errorf("scan routing rule: %w", err)
+		}
+		if err := json.Unmarshal(brokers, &rule.Brokers); err != nil {
+			return nil, fmt.Errorf("decode routing rule brokers: %w", err)
+		}
+		rule.AccountID = accountID.String
+		rule.Symbol = symbol.String
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate routing rules: %w", err)
+	}
+	return rules, nil
+}
+
+// SaveRoutingRules replaces the stored routing rules in one transaction.
+func (p *Postgres) SaveRoutingRules(ctx context.Context, rules []routing.Rule) error {
+	return p.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM routing_rules`); err != nil {
+			return fmt.Errorf("delete routing rules: %w", err)
+		}
+		for i, rule := range rules {
+			brokers, err := json.Marshal(rule.Brokers)
+			if err != nil {
+				return fmt.Errorf("encode routing rule brokers: %w", err)
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO routing_rules (position, account_id, symbol, brokers) VALUES ($1, $2, $3, $4)`,
+				i+1, nullString(rule.AccountID), nullString(rule.Symbol), brokers); err != nil {
+				return fmt.Errorf("insert routing rule: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/future-bots/executor/internal/service"
+)
+
+// memorySummaryLimit bounds how many session summaries the memory repository
+// keeps.
+const memorySummaryLimit = 1000
+
+// SaveSessionSummary records an end-of-day summary, keeping the most recent
+// ones.
+func (m *Memory) SaveSessionSummary(_ context.Context, summary service.SessionSummary) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.summaries = append(m.summaries, summary)
+	if len(m.summaries) > memorySummaryLimit {
+		m.summaries = m.summaries[len(m.summaries)-memorySummaryLimit:]
+	}
+	return nil
+}
+
+// SessionSummaries returns up to limit summaries, newest first.
+func (m *Memory) SessionSummaries(_ context.Context, limit int) ([]service.SessionSummary, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]service.SessionSummary, 0, limit)
+	for i := len(m.summaries) - 1; i >= 0 && len(out) < limit; i-- {
+		out = append(out, m.summaries[i])
+	}
+	return out, nil
+}
+
+// SaveSessionSummary inserts an end-of-day summary.
+func (p *Postgres) SaveSessionSummary(ctx context.Context, summary service.SessionSummary) error {
+	if _, err := p.db.ExecContext(ctx, `INSERT INTO session_summaries (id, trading_date, closed_at, day_orders_cancelled, orders_carried,
+orders_submitted, orders_filled, orders_cancelled, orders_rejected, orders_expired, executions, filled_quantity, fees, taxes, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		summary.ID, summary.TradingDate, summary.ClosedAt, summary.DayOrdersCancelled, summary.OrdersCarried,
+		summary.OrdersSubmitted, summary.OrdersFilled, summary.OrdersCancelled, summary.OrdersRejected, summary.OrdersExpired,
+		summary.Executions, summary.FilledQuantity, summary.Fees, summary.Taxes, nullString(summary.Error)); err != nil {
+		return fmt.Errorf("insert session summary: %w", err)
+	}
+	return nil
+}
+
+// SessionSummaries returns up to limit summaries, newest first.
+func (p *Postgres) SessionSummaries(ctx context.Context, limit int) ([]service.SessionSummary, error) {
+	rows, err := p.db.QueryContext(ctx, `SELECT id, trading_date, closed_at, day_orders_cancelled, orders_carried,
+orders_submitted, orders_filled, orders_cancelled, orders_rejected, orders_expired, executions, filled_quantity, fees, taxes, error
+FROM session_summaries ORDER BY closed_at DESC, id DESC LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("select session summaries: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := make([]service.SessionSummary, 0)
+	for rows.Next() {
+		var (
+			summary     service.SessionSummary
+			tradingDate time.Time
+			summaryErr  sql.NullString
+		)
+		if err := rows.Scan(&summary.ID, &tradingDate, &summary.ClosedAt, &summary.DayOrdersCancelled, &summary.OrdersCarried,
+			&summary.OrdersSubmitted, &summary.OrdersFilled, &summary.OrdersCancelled, &summary.OrdersRejected, &summary.OrdersExpired,
+			&summary.Executions, &summary.FilledQuantity, &summary.Fees, &summary.Taxes, &summaryErr); err != nil {
+			return nil, fmt.Errorf("scan session summary: %w", err)
+		}
+		summary.TradingDate = tradingDate.Format(time.DateOnly)
+		summary.ClosedAt = summary.ClosedAt.UTC()
+		summary.Error = summaryErr.String
+		summaries = append(summaries, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate session summaries: %w", err)
+	}
+	return summaries, nil
+}
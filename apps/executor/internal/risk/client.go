@@ -0,0 +1,102 @@
+package risk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/future-bots/executor/internal/service"
+)
+
+// evaluateRequest mirrors the risk service's RiskCheckRequest payload.
+type evaluateRequest struct {
+	BotID        string  `json:"bot_id"`
+	AccountID    string  `json:"account_id"`
+	Symbol       string  `json:"symbol"`
+	ProposedSide string  `json:"proposed_side"`
+	ProposedQty  float64 `json:"proposed_qty"`
+}
+
+// evaluateResponse mirrors the risk service's RiskCheckDecision payload.
+type evaluateResponse struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// HTTPClient calls the risk service's POST /api/v1/risk/evaluate endpoint.
+type HTTPClient struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewHTTPClient targets the risk service at baseURL. A nil client uses
+// http.DefaultClient; deadlines come from the request context.
+func NewHTTPClient(baseURL string, client *http.Client) *HTTPClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPClient{
+		endpoint: strings.TrimRight(baseURL, "/") + "/api/v1/risk/evaluate",
+		client:   client,
+	}
+}
+
+// Check implements service.RiskChecker. A 4xx answer is a definitive denial
+// carrying the service's error message; transport failures and 5xx answers are
+// returned as errors so the caller's fail-open/fail-closed policy applies.
+func (c *HTTPClient) Check(ctx context.Context, req service.RiskRequest) (service.RiskDecision, error) {
+	body, err := json.Marshal(evaluateRequest{
+		BotID:        req.BotID,
+		AccountID:    req.AccountID,
+		Symbol:       req.Symbol,
+		ProposedSide: req.Side,
+		ProposedQty:  req.Quantity,
+	})
+	if err != nil {
+		return service.RiskDecision{}, fmt.Errorf("encode risk request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return service.RiskDecision{}, fmt.Errorf("build risk request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return service.RiskDecision{}, fmt.Errorf("call risk service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return service.RiskDecision{}, fmt.Errorf("read risk response: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		var decision evaluateResponse
+		if err := json.Unmarshal(payload, &decision); err != nil {
+			return service.RiskDecision{}, fmt.Errorf("decode risk decision: %w", err)
+		}
+		return service.RiskDecision{Allowed: decision.Allowed, Reason: decision.Reason}, nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		var e errorResponse
+		_ = json.Unmarshal(payload, &e)
+		reason := e.Error
+		if reason == "" {
+			reason = fmt.Sprintf("risk service returned %d", resp.StatusCode)
+		}
+		return service.RiskDecision{Allowed: false, Reason: reason}, nil
+	default:
+		return service.RiskDecision{}, fmt.Errorf("risk service returned %d", resp.StatusCode)
+	}
+}
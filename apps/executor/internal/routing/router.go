@@ -0,0 +1,430 @@
+// Package routing chooses the broker adapter of each live order when an
+// executor trades through several brokers. Rules map accounts and symbols to
+// brokers in order of preference; brokers whose circuit is open or whose
+// account lacks the margin for the order are passed over. Rules are stored,
+// so they can be changed while the executor runs.
+package routing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/future-bots/executor/internal/service"
+)
+
+// ErrNoRoute is returned by Routes when no broker can take an order.
+var ErrNoRoute = errors.New("no broker can take the order")
+
+// Rule sends the orders it matches to Brokers, most preferred first. Empty
+// AccountID and Symbol match every order.
+type Rule struct {
+	AccountID string   `json:"account_id,omitempty"`
+	Symbol    string   `json:"symbol,omitempty"`
+	Brokers   []string `json:"brokers"`
+}
+
+// Matches reports whether the rule applies to order.
+func (r Rule) Matches(order service.Order) bool {
+	return (r.AccountID == "" || r.AccountID == order.AccountID) &&
+		(r.Symbol == "" || r.Symbol == order.Symbol)
+}
+
+func (r Rule) String() string {
+	account, symbol := r.AccountID, r.Symbol
+	if account == "" {
+		account = "*"
+	}
+	if symbol == "" {
+		symbol = "*"
+	}
+	return fmt.Sprintf("account %s, symbol %s", account, symbol)
+}
+
+// Store persists the routing rules in priority order.
+type Store interface {
+	RoutingRules(ctx context.Context) ([]Rule, error)
+	// SaveRoutingRules replaces every rule.
+	SaveRoutingRules(ctx context.Context, rules []Rule) error
+}
+
+// MarginSource reports the margin an account has available at a broker, in
+// VND.
+type MarginSource interface {
+	AvailableMargin(ctx context.Context, accountID string) (float64, error)
+}
+
+// Venue is a broker adapter orders can be routed to.
+type Venue struct {
+	Name   string
+	Broker service.Broker
+	// Margin is checked against the order's margin requirement; nil skips
+	// the check.
+	Margin MarginSource
+}
+
+// readiness is implemented by brokers that know whether they can take
+// orders, such as broker.Resilient.
+type readiness interface {
+	Ready(ctx context.Context) error
+}
+
+// VenueStatus reports whether a venue can take orders.
+type VenueStatus struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+	Error string `json:"error,omitempty"`
+}
+
+// Option customises a Router.
+type Option func(*Router)
+
+// WithMarginRequirement checks the available margin of venues with a margin
+// source against requirement(order). Orders requiring no margin, such as
+// market orders without a price, are not checked.
+func WithMarginRequirement(requirement func(service.Order) float64) Option {
+	return func(r *Router) {
+		r.requirement = requirement
+	}
+}
+
+// WithLogger reports rule reloads and routing decisions to logger.
+func WithLogger(logger *slog.Logger) Option {
+	return func(r *Router) {
+		if logger != nil {
+			r.logger = logger
+		}
+	}
+}
+
+// Router chooses among venues by rule, health and margin. It implements
+// service.OrderRouter, and service.Broker over all venues for reconciliation
+// and for orders that carry no route.
+type Router struct {
+	venues      []Venue
+	byName      map[string]Venue
+	store       Store
+	requirement func(service.Order) float64
+	logger      *slog.Logger
+
+	mu    sync.RWMutex
+	rules []Rule
+
+	fillsOnce sync.Once
+	fills     chan service.BrokerFill
+}
+
+// NewRouter routes over venues, in their order when no rule matches, with
+// the rules kept in store. Call Load to read the stored rules.
+func NewRouter(store Store, venues []Venue, opts ...Option) *Router {
+	r := &Router{
+		venues: venues,
+		byName: make(map[string]Venue, len(venues)),
+		store:  store,
+		logger: slog.Default(),
+	}
+	for _, v := range venues {
+		r.byName[v.Name] = v
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Validate rejects rules without brokers or naming brokers the router does
+// not know.
+func (r *Router) Validate(rules []Rule) error {
+	for i, rule := range rules {
+		if len(rule.Brokers) == 0 {
+			return service.ValidationError{Reason: fmt.Sprintf("rule %d lists no brokers", i+1)}
+		}
+		seen := make(map[string]bool, len(rule.Brokers))
+		for _, name := range rule.Brokers {
+			if _, ok := r.byName[name]; !ok {
+				return service.ValidationError{Reason: fmt.Sprintf("rule %d names unknown broker %q", i+1, name)}
+			}
+			if seen[name] {
+				return service.ValidationError{Reason: fmt.Sprintf("rule %d lists broker %q twice", i+1, name)}
+			}
+			seen[name] = true
+		}
+	}
+	return nil
+}
+
+// Load replaces the rules in use with the stored ones.
+func (r *Router) Load(ctx context.Context) error {
+	rules, err := r.store.RoutingRules(ctx)
+	if err != nil {
+		return fmt.Errorf("load routing rules: %w", err)
+	}
+	if err := r.Validate(rules); err != nil {
+		return fmt.Errorf("stored routing rules: %w", err)
+	}
+	r.mu.Lock()
+	r.rules = rules
+	r.mu.Unlock()
+	return nil
+}
+
+// Rules returns the rules in use, in priority order.
+func (r *Router) Rules() []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Rule{}, r.rules...)
+}
+
+// SetRules validates and stores rules and puts them in use.
+func (r *Router) SetRules(ctx context.Context, rules []Rule) error {
+	if err := r.Validate(rules); err != nil {
+		return err
+	}
+	if err := r.store.SaveRoutingRules(ctx, rules); err != nil {
+		return fmt.Errorf("save routing rules: %w", err)
+	}
+	r.mu.Lock()
+	r.rules = append([]Rule{}, rules...)
+	r.mu.Unlock()
+	r.logger.Info("routing rules updated", "rules", len(rules))
+	return nil
+}
+
+// Run reloads the stored rules every interval until ctx is cancelled, so
+// changes made through another executor instance take effect.
+func (r *Router) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.Load(ctx); err != nil {
+				r.logger.Error("failed to reload routing rules", "error", err)
+			}
+		}
+	}
+}
+
+// Venues reports whether each venue can take orders, in registration order.
+func (r *Router) Venues(ctx context.Context) []VenueStatus {
+	out := make([]VenueStatus, 0, len(r.venues))
+	for _, v := range r.venues {
+		status := VenueStatus{Name: v.Name, Ready: true}
+		if err := ready(ctx, v); err != nil {
+			status.Ready, status.Error = false, err.Error()
+		}
+		out = append(out, status)
+	}
+	return out
+}
+
+// Ready returns nil while some venue can take orders.
+func (r *Router) Ready(ctx context.Context) error {
+	var failures []string
+	for _, status := range r.Venues(ctx) {
+		if status.Ready {
+			return nil
+		}
+		failures = append(failures, status.Name+": "+status.Error)
+	}
+	return fmt.Errorf("no broker ready: %s", strings.Join(failures, "; "))
+}
+
+// Routes implements service.OrderRouter. The first matching rule lists the
+// candidate brokers, or every venue when none matches; candidates that are
+// not ready or lack margin are left out and named in the reason of the
+// routes after them.
+func (r *Router) Routes(ctx context.Context, order service.Order) ([]service.Route, error) {
+	var (
+		candidates []string
+		basis      = "default broker order"
+	)
+	r.mu.RLock()
+	for i, rule := range r.rules {
+		if rule.Matches(order) {
+			candidates = rule.Brokers
+			basis = fmt.Sprintf("rule %d (%s)", i+1, rule)
+			break
+		}
+	}
+	r.mu.RUnlock()
+	if candidates == nil {
+		for _, v := range r.venues {
+			candidates = append(candidates, v.Name)
+		}
+	}
+
+	var required float64
+	if r.requirement != nil {
+		required = r.requirement(order)
+	}
+	var (
+		routes  []service.Route
+		skipped []string
+	)
+	for _, name := range candidates {
+		v, ok := r.byName[name]
+		if !ok {
+			skipped = append(skipped, name+": unknown broker")
+			continue
+		}
+		if err := ready(ctx, v); err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if required > 0 && v.Margin != nil {
+			available, err := v.Margin.AvailableMargin(ctx, order.AccountID)
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("%s: margin unknown: %v", name, err))
+				continue
+			}
+			if available < required {
+				skipped = append(skipped, fmt.Sprintf("%s: available margin %.0f below required %.0f", name, available, required))
+				continue
+			}
+		}
+		reason := basis
+		if len(skipped) > 0 {
+			reason += "; skipped " + strings.Join(skipped, ", ")
+		}
+		routes = append(routes, service.Route{Broker: name, Reason: reason})
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("%w: %s; %s", ErrNoRoute, basis, strings.Join(skipped, ", "))
+	}
+	return routes, nil
+}
+
+// Venue implements service.OrderRouter.
+func (r *Router) Venue(name string) service.Broker {
+	v, ok := r.byName[name]
+	if !ok {
+		return nil
+	}
+	return v.Broker
+}
+
+func ready(ctx context.Context, v Venue) error {
+	if rd, ok := v.Broker.(readiness); ok {
+		return rd.Ready(ctx)
+	}
+	return nil
+}
+
+// Place implements service.Broker for callers without route tracking,
+// placing with the first route that is reachable.
+func (r *Router) Place(ctx context.Context, order service.Order) (service.BrokerAck, error) {
+	routes, err := r.Routes(ctx, order)
+	if err != nil {
+		return service.BrokerAck{}, fmt.Errorf("%w: %w", service.ErrBrokerUnavailable, err)
+	}
+	var errs []error
+	for _, route := range routes {
+		ack, err := r.byName[route.Broker].Broker.Place(ctx, order)
+		if err == nil || !errors.Is(err, service.ErrBrokerUnavailable) {
+			return ack, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", route.Broker, err))
+	}
+	return service.BrokerAck{}, errors.Join(errs...)
+}
+
+// Cancel implements service.Broker.
+func (r *Router) Cancel(ctx context.Context, providerOrderID string) error {
+	return r.onHolder(func(b service.Broker) error { return b.Cancel(ctx, providerOrderID) })
+}
+
+// Amend implements service.Broker.
+func (r *Router) Amend(ctx context.Context, providerOrderID string, price, quantity float64) error {
+	return r.onHolder(func(b service.Broker) error { return b.Amend(ctx, providerOrderID, price, quantity) })
+}
+
+// Query implements service.Broker.
+func (r *Router) Query(ctx context.Context, providerOrderID string) (service.BrokerOrderState, error) {
+	var state service.BrokerOrderState
+	err := r.onHolder(func(b service.Broker) (err error) {
+		state, err = b.Query(ctx, providerOrderID)
+		return err
+	})
+	return state, err
+}
+
+// Executions implements service.Broker.
+func (r *Router) Executions(ctx context.Context, providerOrderID string) ([]service.BrokerFill, error) {
+	var fills []service.BrokerFill
+	err := r.onHolder(func(b service.Broker) (err error) {
+		fills, err = b.Executions(ctx, providerOrderID)
+		return err
+	})
+	return fills, err
+}
+
+// OpenOrders implements service.Broker, listing the working orders of every
+// venue. It fails when any venue cannot answer.
+func (r *Router) OpenOrders(ctx context.Context) ([]service.BrokerOrderState, error) {
+	var out []service.BrokerOrderState
+	for _, v := range r.venues {
+		open, err := v.Broker.OpenOrders(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Name, err)
+		}
+		out = append(out, open...)
+	}
+	return out, nil
+}
+
+// Fills implements service.Broker, merging the fill streams of every venue.
+func (r *Router) Fills() <-chan service.BrokerFill {
+	r.fillsOnce.Do(func() {
+		r.fills = make(chan service.BrokerFill)
+		var wg sync.WaitGroup
+		for _, v := range r.venues {
+			ch := v.Broker.Fills()
+			if ch == nil {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for fill := range ch {
+					r.fills <- fill
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(r.fills)
+		}()
+	})
+	return r.fills
+}
+
+// onHolder runs fn against each venue in turn until one knows the order.
+func (r *Router) onHolder(fn func(service.Broker) error) error {
+	var unreachable error
+	for _, v := range r.venues {
+		err := fn(v.Broker)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, service.ErrUnknownBrokerOrder):
+			continue
+		case errors.Is(err, service.ErrBrokerUnavailable) || errors.Is(err, context.DeadlineExceeded):
+			if unreachable == nil {
+				unreachable = err
+			}
+		default:
+			return err
+		}
+	}
+	if unreachable != nil {
+		return unreachable
+	}
+	return service.ErrUnknownBrokerOrder
+}
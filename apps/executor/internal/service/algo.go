@@ -0,0 +1,485 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Algorithm selects how a parent order is sliced into child orders.
+type Algorithm string
+
+const (
+	// AlgorithmTWAP spreads the quantity evenly over a duration of trading
+	// time.
+	AlgorithmTWAP Algorithm = "twap"
+	// AlgorithmIceberg shows a display quantity at a time, replenishing it
+	// each time a child fills.
+	AlgorithmIceberg Algorithm = "iceberg"
+)
+
+// MaxAlgoSlices bounds the number of TWAP child orders.
+const MaxAlgoSlices = 500
+
+// SessionClock maps wall-clock time onto exchange trading time.
+type SessionClock interface {
+	// Next returns t when the market is open, otherwise the next session
+	// open.
+	Next(t time.Time) time.Time
+	// Add advances t by d of trading time, skipping breaks and closed days.
+	Add(t time.Time, d time.Duration) time.Time
+}
+
+// WithSessionClock schedules child orders against the venue's trading
+// sessions. Without it the market is treated as always open.
+func WithSessionClock(clock SessionClock) Option {
+	return func(s *service) {
+		if clock != nil {
+			s.clock = clock
+		}
+	}
+}
+
+type continuousClock struct{}
+
+func (continuousClock) Next(t time.Time) time.Time                 { return t }
+func (continuousClock) Add(t time.Time, d time.Duration) time.Time { return t.Add(d) }
+
+// ParentProgress summarises an algorithmic parent order and its children.
+type ParentProgress struct {
+	Parent   Order   `json:"parent"`
+	Children []Order `json:"children"`
+	// WorkingQuantity is released to the broker and not yet filled.
+	WorkingQuantity float64 `json:"working_quantity"`
+	// ScheduledQuantity waits in children that have not been released.
+	ScheduledQuantity float64 `json:"scheduled_quantity"`
+	// CompletedChildren counts children in a terminal status.
+	CompletedChildren int `json:"completed_children"`
+}
+
+func validateAlgorithm(intent OrderIntent) error {
+	algorithm := Algorithm(strings.ToLower(strings.TrimSpace(string(intent.Algorithm))))
+	if algorithm == "" {
+		if intent.AlgoDurationSeconds != 0 || intent.AlgoSlices != 0 || intent.DisplayQuantity != 0 {
+			return ValidationError{Reason: "algo_duration_seconds, algo_slices and display_quantity require an algorithm"}
+		}
+		return nil
+	}
+	switch algorithm {
+	case AlgorithmTWAP:
+		if intent.AlgoDurationSeconds <= 0 {
+			return ValidationError{Reason: "twap orders require a positive algo_duration_seconds"}
+		}
+		if intent.AlgoSlices < 0 || intent.AlgoSlices > MaxAlgoSlices {
+			return ValidationError{Reason: fmt.Sprintf("algo_slices must be between 0 and %d", MaxAlgoSlices)}
+		}
+		if intent.DisplayQuantity != 0 {
+			return ValidationError{Reason: "display_quantity is only valid for iceberg orders"}
+		}
+	case AlgorithmIceberg:
+		if intent.DisplayQuantity <= 0 || intent.DisplayQuantity >= intent.Quantity {
+			return ValidationError{Reason: "iceberg orders require a display_quantity below the order quantity"}
+		}
+		if intent.AlgoDurationSeconds != 0 || intent.AlgoSlices != 0 {
+			return ValidationError{Reason: "algo_duration_seconds and algo_slices are only valid for twap orders"}
+		}
+	default:
+		return ValidationError{Reason: "algorithm must be twap or iceberg"}
+	}
+	if orderTypeFor(intent) == OrderTypeStop {
+		return ValidationError{Reason: "stop orders cannot use an execution algorithm"}
+	}
+	if timeInForceFor(intent) != TimeInForceGTC {
+		return ValidationError{Reason: "algorithm orders must be GTC"}
+	}
+	return nil
+}
+
+// planChildren lays out the child orders of a parent accepted at now.
+func (s *service) planChildren(parent Order, now time.Time) []Order {
+	start := s.clock.Next(now)
+	if parent.Algorithm == AlgorithmIceberg {
+		return []Order{newChild(parent, math.Min(parent.DisplayQuantity, parent.Quantity), start, now)}
+	}
+
+	duration := time.Duration(parent.AlgoDurationSeconds) * time.Second
+	slices := parent.AlgoSlices
+	if slices == 0 {
+		slices = int(duration / time.Minute)
+	}
+	slices = max(1, min(slices, MaxAlgoSlices))
+	sizes := sliceQuantity(parent.Quantity, slices)
+	step := duration / time.Duration(len(sizes))
+	children := make([]Order, len(sizes))
+	for i, qty := range sizes {
+		// A slice falling on a session close waits for the next open.
+		at := s.clock.Next(s.clock.Add(start, time.Duration(i)*step))
+		children[i] = newChild(parent, qty, at, now)
+	}
+	return children
+}
+
+// sliceQuantity splits quantity into n near-equal parts. Whole quantities are
+// split into whole parts, front-loading the remainder and using fewer slices
+// when there are fewer units than slices.
+func sliceQuantity(quantity float64, n int) []float64 {
+	if quantity != math.Trunc(quantity) {
+		sizes := make([]float64, n)
+		for i := range sizes {
+			sizes[i] = quantity / float64(n)
+		}
+		return sizes
+	}
+	n = min(n, int(quantity))
+	base := math.Floor(quantity / float64(n))
+	extra := int(quantity - base*float64(n))
+	sizes := make([]float64, n)
+	for i := range sizes {
+		sizes[i] = base
+		if i < extra {
+			sizes[i]++
+		}
+	}
+	return sizes
+}
+
+func newChild(parent Order, quantity float64, at, now time.Time) Order {
+	scheduled := at.UTC()
+	return Order{
+		ID:          newOrderID(),
+		ParentID:    parent.ID,
+		Mode:        parent.Mode,
+		BotID:       parent.BotID,
+		AccountID:   parent.AccountID,
+		Symbol:      parent.Symbol,
+		Side:        parent.Side,
+		Quantity:    quantity,
+		Price:       parent.Price,
+		Type:        parent.Type,
+		TimeInForce: TimeInForceGTC,
+		ExpiresAt:   parent.ExpiresAt,
+		Status:      StatusNew,
+		ScheduledAt: &scheduled,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+// checkChildren runs the instrument rules against every planned child, so a
+// slice the venue would refuse rejects the parent up front.
+func (s *service) checkChildren(children []Order) *Event {
+	for _, child := range children {
+		if rejection := s.checkInstrument(child); rejection != nil {
+			rejection.Reason = "child order: " + rejection.Reason
+			return rejection
+		}
+	}
+	return nil
+}
+
+// startAlgo puts a parent that passed its checks to work and stores its
+// planned children. Due children are released by the caller once the parent
+// lock is dropped.
+func (s *service) startAlgo(ctx context.Context, parent *Order, children []Order) error {
+	reason := fmt.Sprintf("working %s in %d child orders", parent.Algorithm, len(children))
+	if err := s.transition(ctx, parent, StatusRouted, reason); err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err := s.repo.Create(ctx, child, CreateGuard{}); err != nil {
+			return fmt.Errorf("persist child order: %w", err)
+		}
+		s.created(ctx, child)
+	}
+	return nil
+}
+
+// children returns the child orders of a parent in schedule order.
+func (s *service) children(ctx context.Context, parentID string) ([]Order, error) {
+	query := OrderQuery{Filter: OrderFilter{ParentID: parentID}, Limit: MaxPageSize, Ascending: true}
+	var out []Order
+	for {
+		page, err := s.repo.ListOrders(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, page...)
+		if len(page) < query.Limit {
+			// TWAP slices share a creation time, so order by schedule.
+			sort.SliceStable(out, func(i, j int) bool { return out[i].ScheduledAt.Before(*out[j].ScheduledAt) })
+			return out, nil
+		}
+		last := page[len(page)-1]
+		query.After = &OrderCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// releaseDue releases the children of parentID whose scheduled time has come.
+func (s *service) releaseDue(ctx context.Context, parentID string) {
+	children, err := s.children(ctx, parentID)
+	if err != nil {
+		s.logger.Warn("failed to list child orders", "parent_id", parentID, "error", err)
+		return
+	}
+	now := s.now()
+	for _, child := range children {
+		if child.Status == StatusNew && child.ScheduledAt != nil && !child.ScheduledAt.After(now) {
+			if err := s.releaseChild(ctx, child.ID); err != nil {
+				s.logger.Warn("failed to release child order", "order_id", child.ID, "parent_id", parentID, "error", err)
+			}
+		}
+	}
+}
+
+// releaseChild routes a scheduled child, or cancels it when its parent has
+// already finished.
+func (s *service) releaseChild(ctx context.Context, id string) error {
+	unlock := s.locks.Lock(id)
+	defer unlock()
+
+	child, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if child.Status != StatusNew {
+		return nil
+	}
+	parent, err := s.repo.Get(ctx, child.ParentID)
+	if err != nil {
+		return err
+	}
+	if parent.Status.Terminal() {
+		return s.transition(ctx, &child, StatusCancelled, fmt.Sprintf("parent order %s", parent.Status))
+	}
+	if err := s.transition(ctx, &child, StatusPendingRisk, "slice released"); err != nil {
+		return err
+	}
+	return s.route(ctx, &child)
+}
+
+// childUpdated folds a child's fill and/or completion into its parent. It
+// locks the parent, so callers may hold the child lock but never the
+// parent's.
+func (s *service) childUpdated(ctx context.Context, child Order, fill *Fill) {
+	next, err := s.updateParent(ctx, child, fill)
+	if err != nil {
+		s.logger.Error("failed to update parent order", "parent_id", child.ParentID, "order_id", child.ID, "error", err)
+		return
+	}
+	if next != nil && !next.ScheduledAt.After(s.now()) {
+		if err := s.releaseChild(ctx, next.ID); err != nil {
+			s.logger.Warn("failed to release child order", "order_id", next.ID, "parent_id", next.ParentID, "error", err)
+		}
+	}
+}
+
+func (s *service) updateParent(ctx context.Context, child Order, fill *Fill) (*Order, error) {
+	unlock := s.locks.Lock(child.ParentID)
+	defer unlock()
+
+	parent, err := s.repo.Get(ctx, child.ParentID)
+	if err != nil {
+		return nil, err
+	}
+	if fill != nil {
+		if err := s.applyChildFill(ctx, &parent, child, *fill); err != nil {
+			return nil, err
+		}
+	}
+	if !child.Status.Terminal() || parent.Status.Terminal() {
+		return nil, nil
+	}
+	return s.advanceParent(ctx, &parent)
+}
+
+// applyChildFill credits a child execution to the parent and publishes it as
+// a fill of the parent, which is the order the bot knows about.
+func (s *service) applyChildFill(ctx context.Context, parent *Order, child Order, fill Fill) error {
+	now := s.now()
+	filled := math.Min(parent.FilledQuantity+fill.Quantity, parent.Quantity)
+	next := parent.Status
+	if !parent.Status.Terminal() {
+		next = StatusPartiallyFilled
+		if filled >= parent.Quantity {
+			next = StatusFilled
+		}
+	}
+	entry := Transition{
+		OrderID: parent.ID,
+		From:    parent.Status,
+		To:      next,
+		Reason:  fmt.Sprintf("child %s filled %g @ %g", child.ID, fill.Quantity, fill.Price),
+		At:      now,
+	}
+	updated := *parent
+	updated.Status = next
+	updated.FilledQuantity = filled
+	updated.UpdatedAt = now
+	fill.Remaining = updated.Quantity - filled
+	events := s.stage(ctx, updated, []Event{{Type: EventFill, Fill: &fill, OccurredAt: now}})
+	if err := s.repo.Transition(ctx, updated, entry, s.outboxed(events)...); err != nil {
+		return fmt.Errorf("persist parent fill: %w", err)
+	}
+	*parent = updated
+	s.deliver(ctx, events)
+	s.updated(ctx, updated, entry, nil)
+	return nil
+}
+
+// advanceParent decides what follows once a child has finished: the next
+// iceberg slice, or closing a parent whose children are all done without
+// filling it.
+func (s *service) advanceParent(ctx context.Context, parent *Order) (*Order, error) {
+	children, err := s.children(ctx, parent.ID)
+	if err != nil {
+		return nil, err
+	}
+	allFilled := true
+	for _, child := range children {
+		if !child.Status.Terminal() {
+			return nil, nil
+		}
+		allFilled = allFilled && child.Status == StatusFilled
+	}
+
+	remaining := parent.Quantity - parent.FilledQuantity
+	if parent.Algorithm == AlgorithmIceberg && allFilled && remaining > 0 {
+		now := s.now()
+		child := newChild(*parent, math.Min(parent.DisplayQuantity, remaining), s.clock.Next(now), now)
+		if err := s.repo.Create(ctx, child, CreateGuard{}); err != nil {
+			return nil, fmt.Errorf("persist child order: %w", err)
+		}
+		s.created(ctx, child)
+		return &child, nil
+	}
+
+	reason := fmt.Sprintf("%s finished with %g of %g filled", parent.Algorithm, parent.FilledQuantity, parent.Quantity)
+	return nil, s.transition(ctx, parent, StatusCancelled, reason, Event{
+		Type:        EventCancel,
+		Reason:      reason,
+		InitiatedBy: InitiatedBySystem,
+	})
+}
+
+// cancelChildren withdraws the unfinished children of a cancelled parent.
+func (s *service) cancelChildren(ctx context.Context, parent Order, reason string) {
+	children, err := s.children(ctx, parent.ID)
+	if err != nil {
+		s.logger.Error("failed to list child orders", "parent_id", parent.ID, "error", err)
+		return
+	}
+	for _, child := range children {
+		if child.Status.Terminal() {
+			continue
+		}
+		if err := s.cancelChild(ctx, child.ID, reason); err != nil {
+			s.logger.Warn("failed to cancel child order", "order_id", child.ID, "parent_id", parent.ID, "error", err)
+		}
+	}
+}
+
+func (s *service) cancelChild(ctx context.Context, id, reason string) error {
+	unlock := s.locks.Lock(id)
+	defer unlock()
+
+	child, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !child.Status.CanTransitionTo(StatusCancelled) {
+		return nil
+	}
+	if s.unconfirmed(child) {
+		return errUnconfirmed(child)
+	}
+	if venue := s.brokerFor(child); venue != nil && child.ProviderOrderID != "" {
+		if err := venue.Cancel(ctx, child.ProviderOrderID); err != nil {
+			return fmt.Errorf("%w: cancel %s: %w", ErrBrokerRequest, child.ProviderOrderID, err)
+		}
+	}
+	return s.transition(ctx, &child, StatusCancelled, reason)
+}
+
+func (s *service) GetParentProgress(ctx context.Context, id string) (ParentProgress, error) {
+	parent, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return ParentProgress{}, err
+	}
+	if parent.Algorithm == "" {
+		return ParentProgress{}, ValidationError{Reason: "order " + id + " is not an algorithm parent"}
+	}
+	children, err := s.children(ctx, id)
+	if err != nil {
+		return ParentProgress{}, err
+	}
+	progress := ParentProgress{Parent: parent, Children: children}
+	if progress.Children == nil {
+		progress.Children = []Order{}
+	}
+	for _, child := range children {
+		switch {
+		case child.Status.Terminal():
+			progress.CompletedChildren++
+		case child.Status == StatusNew:
+			progress.ScheduledQuantity += child.Quantity
+		default:
+			progress.WorkingQuantity += child.Quantity - child.FilledQuantity
+		}
+	}
+	return progress, nil
+}
+
+// childExecutions gathers the fills of every child of a parent, oldest first.
+func (s *service) childExecutions(ctx context.Context, parentID string) ([]Execution, error) {
+	children, err := s.children(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Execution, 0)
+	for _, child := range children {
+		fills, err := s.repo.Executions(ctx, child.ID)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, fills...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].FilledAt.Before(out[j].FilledAt) })
+	return out, nil
+}
+
+func (s *service) ReleaseChildOrders(ctx context.Context) (int, error) {
+	due, err := s.repo.Scheduled(ctx, s.now())
+	if err != nil {
+		return 0, err
+	}
+	released := 0
+	for _, child := range due {
+		if err := s.releaseChild(ctx, child.ID); err != nil {
+			s.logger.Warn("failed to release child order", "order_id", child.ID, "parent_id", child.ParentID, "error", err)
+			continue
+		}
+		released++
+	}
+	return released, nil
+}
+
+// RunChildScheduler calls svc.ReleaseChildOrders every interval until ctx is
+// cancelled.
+func RunChildScheduler(ctx context.Context, svc Service, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := svc.ReleaseChildOrders(ctx); err != nil {
+				logger.Error("failed to release child orders", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Leg identifies an order's role within a bracket.
+type Leg string
+
+const (
+	// LegEntry opens the position; its exits are armed once it fills.
+	LegEntry Leg = "entry"
+	// LegTakeProfit is the limit exit on the opposite side of the entry.
+	LegTakeProfit Leg = "take_profit"
+	// LegStopLoss is the stop exit on the opposite side of the entry.
+	LegStopLoss Leg = "stop_loss"
+)
+
+// Exit reports whether the leg closes the bracket's position.
+func (l Leg) Exit() bool {
+	return l == LegTakeProfit || l == LegStopLoss
+}
+
+func bracketed(intent OrderIntent) bool {
+	return intent.TakeProfitPrice != 0 || intent.StopLossPrice != 0
+}
+
+// validateBracket checks that the exits sit on the profitable and losing
+// side of the entry respectively.
+func validateBracket(intent OrderIntent) error {
+	if !bracketed(intent) {
+		return nil
+	}
+	tp, sl := intent.TakeProfitPrice, intent.StopLossPrice
+	if tp < 0 || sl < 0 {
+		return ValidationError{Reason: "take_profit_price and stop_loss_price must not be negative"}
+	}
+	if strings.TrimSpace(string(intent.Algorithm)) != "" {
+		return ValidationError{Reason: "bracket exits cannot be combined with an execution algorithm"}
+	}
+	entry := intent.Price
+	if entry == 0 {
+		entry = intent.StopPrice
+	}
+	// above reports whether a is strictly beyond b in the entry's favour.
+	above := func(a, b float64) bool { return a > b }
+	if strings.ToLower(strings.TrimSpace(intent.Side)) == "sell" {
+		above = func(a, b float64) bool { return a < b }
+	}
+	if tp > 0 && entry > 0 && !above(tp, entry) {
+		return ValidationError{Reason: "take_profit_price must be beyond the entry price in the direction of profit"}
+	}
+	if sl > 0 && entry > 0 && !above(entry, sl) {
+		return ValidationError{Reason: "stop_loss_price must be beyond the entry price in the direction of loss"}
+	}
+	if tp > 0 && sl > 0 && !above(tp, sl) {
+		return ValidationError{Reason: "take_profit_price and stop_loss_price are on the wrong sides of each other"}
+	}
+	return nil
+}
+
+// planLegs builds the unarmed exit legs of a bracket entry.
+func planLegs(intent OrderIntent, entry Order, now time.Time) []Order {
+	side := "sell"
+	if entry.Side == "sell" {
+		side = "buy"
+	}
+	leg := func(role Leg) Order {
+		return Order{
+			ID:          newOrderID(),
+			BotID:       entry.BotID,
+			AccountID:   entry.AccountID,
+			Symbol:      entry.Symbol,
+			Side:        side,
+			Quantity:    entry.Quantity,
+			TimeInForce: TimeInForceGTC,
+			Status:      StatusNew,
+			BracketID:   entry.BracketID,
+			Leg:         role,
+			Mode:        entry.Mode,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+		}
+	}
+	var legs []Order
+	if intent.TakeProfitPrice > 0 {
+		tp := leg(LegTakeProfit)
+		tp.Type, tp.Price = OrderTypeLimit, intent.TakeProfitPrice
+		legs = append(legs, tp)
+	}
+	if intent.StopLossPrice > 0 {
+		sl := leg(LegStopLoss)
+		sl.Type, sl.StopPrice = OrderTypeStop, intent.StopLossPrice
+		legs = append(legs, sl)
+	}
+	return legs
+}
+
+// checkLegs runs the instrument rules against the exit legs so an entry is
+// not sent whose exits the venue would refuse.
+func (s *service) checkLegs(legs []Order) *Event {
+	for _, leg := range legs {
+		if rejection := s.checkInstrument(leg); rejection != nil {
+			rejection.Reason = fmt.Sprintf("%s leg: %s", leg.Leg, rejection.Reason)
+			return rejection
+		}
+	}
+	return nil
+}
+
+// createLegs stores the exit legs, acknowledging each so bots learn their
+// ids.
+func (s *service) createLegs(ctx context.Context, legs []Order) error {
+	for _, leg := range legs {
+		ack := s.stage(ctx, leg, []Event{{Type: EventAck}})
+		if err := s.repo.Create(ctx, leg, CreateGuard{}, s.outboxed(ack)...); err != nil {
+			return fmt.Errorf("persist %s leg: %w", leg.Leg, err)
+		}
+		s.deliver(ctx, ack)
+		s.created(ctx, leg)
+	}
+	return nil
+}
+
+// legs returns the exit legs of a bracket.
+func (s *service) legs(ctx context.Context, bracketID string) ([]Order, error) {
+	orders, err := s.repo.ListOrders(ctx, OrderQuery{Filter: OrderFilter{BracketID: bracketID}, Ascending: true})
+	if err != nil {
+		return nil, err
+	}
+	legs := orders[:0]
+	for _, order := range orders {
+		if order.Leg.Exit() {
+			legs = append(legs, order)
+		}
+	}
+	return legs, nil
+}
+
+// entryClosed arms the exits for whatever the entry filled, or withdraws them
+// when it closed without a fill.
+func (s *service) entryClosed(ctx context.Context, entry Order) {
+	legs, err := s.legs(ctx, entry.BracketID)
+	if err != nil {
+		s.logger.Error("failed to list bracket legs", "bracket_id", entry.BracketID, "error", err)
+		return
+	}
+	for _, leg := range legs {
+		if leg.Status != StatusNew {
+			continue
+		}
+		if entry.FilledQuantity > 0 {
+			err = s.armLeg(ctx, leg.ID, entry.FilledQuantity, fmt.Sprintf("armed: entry %s %s with %g filled", entry.ID, entry.Status, entry.FilledQuantity))
+		} else {
+			err = s.cancelLeg(ctx, leg.ID, fmt.Sprintf("entry %s %s unfilled", entry.ID, entry.Status))
+		}
+		if err != nil {
+			s.logger.Warn("failed to update bracket leg, leaving it to reconciliation", "order_id", leg.ID, "bracket_id", entry.BracketID, "error", err)
+		}
+	}
+}
+
+func (s *service) armLeg(ctx context.Context, id string, quantity float64, reason string) error {
+	unlock := s.locks.Lock(id)
+	defer unlock()
+
+	leg, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if leg.Status != StatusNew {
+		return nil
+	}
+	leg.Quantity = quantity
+	if err := s.transition(ctx, &leg, StatusPendingRisk, reason); err != nil {
+		return err
+	}
+	return s.route(ctx, &leg)
+}
+
+// legFilled keeps the other exit of an OCO pair in step with a fill on one of
+// them: a full fill cancels it, a partial fill shrinks it to the quantity the
+// filled leg still has open so the rest of the position keeps its exit.
+func (s *service) legFilled(ctx context.Context, filled Order) {
+	legs, err := s.legs(ctx, filled.BracketID)
+	if err != nil {
+		s.logger.Error("failed to list bracket legs", "bracket_id", filled.BracketID, "error", err)
+		return
+	}
+	for _, leg := range legs {
+		if leg.ID == filled.ID || leg.Status.Terminal() {
+			continue
+		}
+		if filled.Status == StatusFilled {
+			err = s.cancelLeg(ctx, leg.ID, fmt.Sprintf("oco: %s leg %s filled", filled.Leg, filled.ID))
+		} else {
+			err = s.sizeLeg(ctx, leg.ID, filled.Quantity-filled.FilledQuantity,
+				fmt.Sprintf("oco: %s leg %s filled %g of %g", filled.Leg, filled.ID, filled.FilledQuantity, filled.Quantity))
+		}
+		if err != nil {
+			s.logger.Warn("failed to update oco leg, leaving it to reconciliation", "order_id", leg.ID, "bracket_id", filled.BracketID, "error", err)
+		}
+	}
+}
+
+// settleLegs repeats the follow-ups of a bracket: entryClosed once the entry
+// has closed, and legFilled for every exit that has filled and is still
+// working or filled. They are derived from the stored orders, so repeating
+// them is harmless and retries a cancel or resize the broker refused until
+// the sibling is closed or sized.
+func (s *service) settleLegs(ctx context.Context, bracketID string) {
+	entry, err := s.repo.Get(ctx, bracketID)
+	if err != nil {
+		s.logger.Error("failed to load bracket entry", "bracket_id", bracketID, "error", err)
+		return
+	}
+	if entry.Status.Terminal() {
+		s.entryClosed(ctx, entry)
+	}
+	legs, err := s.legs(ctx, bracketID)
+	if err != nil {
+		s.logger.Error("failed to list bracket legs", "bracket_id", bracketID, "error", err)
+		return
+	}
+	for _, leg := range legs {
+		if leg.FilledQuantity > 0 && (leg.Status == StatusFilled || leg.Status == StatusPartiallyFilled) {
+			s.legFilled(ctx, leg)
+		}
+	}
+}
+
+// entryFilledLate grows the working exits of a closed entry by a fill that
+// arrived after it was cancelled or expired. Exits already withdrawn cannot be
+// revived, so a late fill without any is only reported.
+func (s *service) entryFilledLate(ctx context.Context, entry Order) {
+	legs, err := s.legs(ctx, entry.BracketID)
+	if err != nil {
+		s.logger.Error("failed to list bracket legs", "bracket_id", entry.BracketID, "error", err)
+		return
+	}
+	open := entry.FilledQuantity
+	for _, leg := range legs {
+		open -= leg.FilledQuantity
+	}
+	working := false
+	for _, leg := range legs {
+		if leg.Status.Terminal() {
+			continue
+		}
+		working = true
+		if err := s.sizeLeg(ctx, leg.ID, open, fmt.Sprintf("late fill on %s entry %s", entry.Status, entry.ID)); err != nil {
+			s.logger.Warn("failed to update bracket leg", "order_id", leg.ID, "bracket_id", entry.BracketID, "error", err)
+		}
+	}
+	if !working {
+		s.logger.Warn("late entry fill has no working exits", "order_id", entry.ID, "bracket_id", entry.BracketID, "open_quantity", open)
+	}
+}
+
+// sizeLeg amends a working exit leg so that remaining of it is open.
+func (s *service) sizeLeg(ctx context.Context, id string, remaining float64, reason string) error {
+	unlock := s.locks.Lock(id)
+	defer unlock()
+
+	leg, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	quantity := leg.FilledQuantity + remaining
+	if leg.Status.Terminal() || quantity == leg.Quantity || quantity <= leg.FilledQuantity {
+		return nil
+	}
+	if s.unconfirmed(leg) {
+		return errUnconfirmed(leg)
+	}
+	if venue := s.brokerFor(leg); venue != nil && leg.ProviderOrderID != "" {
+		if err := venue.Amend(ctx, leg.ProviderOrderID, leg.Price, quantity); err != nil {
+			return fmt.Errorf("%w: amend %s: %w", ErrBrokerRequest, leg.ProviderOrderID, err)
+		}
+	}
+	return s.amended(ctx, &leg, leg.Price, quantity, fmt.Sprintf("%s: quantity %g -> %g", reason, leg.Quantity, quantity))
+}
+
+func (s *service) cancelLeg(ctx context.Context, id, reason string) error {
+	unlock := s.locks.Lock(id)
+	defer unlock()
+
+	leg, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !leg.Status.CanTransitionTo(StatusCancelled) {
+		return nil
+	}
+	if s.unconfirmed(leg) {
+		return errUnconfirmed(leg)
+	}
+	if venue := s.brokerFor(leg); venue != nil && leg.ProviderOrderID != "" {
+		if err := venue.Cancel(ctx, leg.ProviderOrderID); err != nil {
+			return fmt.Errorf("%w: cancel %s: %w", ErrBrokerRequest, leg.ProviderOrderID, err)
+		}
+	}
+	return s.transition(ctx, &leg, StatusCancelled, reason, Event{
+		Type:        EventCancel,
+		Reason:      reason,
+		InitiatedBy: InitiatedBySystem,
+	})
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ErrUnknownBrokerOrder is returned by brokers asked about an order they do not hold.
+var ErrUnknownBrokerOrder = errors.New("broker order not found")
+
+// ErrBrokerUnavailable is wrapped by broker errors for requests that never
+// reached the venue, such as a refused connection. Such requests are safe to
+// retry or to send to another broker.
+var ErrBrokerUnavailable = errors.New("broker unavailable")
+
+// ErrCancelPending is returned by Broker.Cancel when the venue has taken the
+// cancel request but not yet cancelled the order, which can still fill until
+// it does. The order stays working locally until the venue reports it closed.
+var ErrCancelPending = errors.New("cancel pending at the broker")
+
+// ErrBrokerRejected is matched by every BrokerRejection via errors.Is.
+var ErrBrokerRejected = errors.New("rejected by broker")
+
+// BrokerRejection is returned by brokers that refused a request, as opposed
+// to failing to deliver or answer it.
+type BrokerRejection struct {
+	Reason string
+}
+
+// Error implements the error interface.
+func (e BrokerRejection) Error() string {
+	return e.Reason
+}
+
+// Is allows errors.Is(err, ErrBrokerRejected).
+func (e BrokerRejection) Is(target error) bool {
+	return target == ErrBrokerRejected
+}
+
+// placementFailed reports whether a Place error proves the venue does not
+// hold the order. Other errors, such as timeouts, leave the outcome unknown.
+func placementFailed(err error) bool {
+	return errors.Is(err, ErrBrokerUnavailable) || errors.Is(err, ErrBrokerRejected)
+}
+
+// BrokerAck is returned when a broker accepts an order for execution.
+type BrokerAck struct {
+	ProviderOrderID string
+	AcceptedAt      time.Time
+}
+
+// BrokerOrderState reports the broker's view of an order.
+type BrokerOrderState struct {
+	ProviderOrderID string
+	ClientOrderID   string
+	Symbol          string
+	Side            string
+	Quantity        float64
+	Price           float64
+	FilledQuantity  float64
+	Open            bool
+}
+
+// BrokerFill is an execution report streamed from the broker.
+type BrokerFill struct {
+	ExecutionID     string
+	ProviderOrderID string
+	ClientOrderID   string
+	Quantity        float64
+	Price           float64
+	FilledAt        time.Time
+}
+
+// Broker is the seam between the executor and a trading venue. Adapters for
+// real provider Trading APIs and the in-process simulator both implement it.
+type Broker interface {
+	// Place submits an order and returns the provider's identifier for it.
+	// Refusals are returned as a BrokerRejection and requests that never
+	// reached the venue wrap ErrBrokerUnavailable; any other error means the
+	// venue may hold the order.
+	Place(ctx context.Context, order Order) (BrokerAck, error)
+	// Cancel withdraws the remaining quantity of a working order. It returns
+	// nil only once the venue has cancelled the order, and ErrCancelPending
+	// while the cancel is still being worked.
+	Cancel(ctx context.Context, providerOrderID string) error
+	// Amend replaces the price and total quantity of a working order.
+	Amend(ctx context.Context, providerOrderID string, price, quantity float64) error
+	// Query returns the broker's current state for an order.
+	Query(ctx context.Context, providerOrderID string) (BrokerOrderState, error)
+	// Fills streams execution reports for every order placed via this broker.
+	Fills() <-chan BrokerFill
+	// OpenOrders lists the orders still working at the broker.
+	OpenOrders(ctx context.Context) ([]BrokerOrderState, error)
+	// Executions lists the fills the broker reported for an order.
+	Executions(ctx context.Context, providerOrderID string) ([]BrokerFill, error)
+}
+
+// ProcessFills applies every fill streamed on fills to svc until ctx is
+// cancelled or the stream is closed. Failures are logged and skipped so one bad
+// report cannot stall the stream.
+func ProcessFills(ctx context.Context, fills <-chan BrokerFill, svc Service, logger *slog.Logger) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case fill, ok := <-fills:
+			if !ok {
+				return
+			}
+			if err := svc.HandleFill(ctx, fill); err != nil && !errors.Is(err, ErrDuplicateExecution) {
+				logger.Error("failed to apply broker fill",
+					"order_id", fill.ClientOrderID, "provider_order_id", fill.ProviderOrderID, "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrBrokerRequest wraps failures returned by the broker adapter while
+// cancelling or amending a working order.
+var ErrBrokerRequest = errors.New("broker request failed")
+
+// CancelRequest describes why an order is being cancelled and by whom.
+type CancelRequest struct {
+	InitiatedBy Initiator `json:"initiated_by,omitempty"`
+	Reason      string    `json:"reason,omitempty"`
+	// Force cancels an order whose placement is unconfirmed without the
+	// broker, for an operator who knows it never reached the venue. Other
+	// orders are cancelled at the broker as usual.
+	Force bool `json:"force,omitempty"`
+}
+
+// AmendRequest replaces the price and/or total quantity of a working order.
+// Nil fields keep their current value.
+type AmendRequest struct {
+	Price    *float64 `json:"price,omitempty"`
+	Quantity *float64 `json:"quantity,omitempty"`
+}
+
+func (s *service) CancelOrder(ctx context.Context, id string, req CancelRequest) (Order, error) {
+	if req.InitiatedBy == "" {
+		req.InitiatedBy = InitiatedByBot
+	}
+	if !req.InitiatedBy.Valid() {
+		return Order{}, ValidationError{Reason: "initiated_by must be bot, risk, broker or system"}
+	}
+
+	order, err := s.cancel(ctx, id, req)
+	if err != nil {
+		return Order{}, err
+	}
+	if order.Algorithm != "" {
+		// The parent lock is released first: cancelling a child reports back
+		// to the parent and takes it.
+		s.cancelChildren(ctx, order, "parent order cancelled")
+	}
+	return order, nil
+}
+
+func (s *service) cancel(ctx context.Context, id string, req CancelRequest) (Order, error) {
+	unlock := s.locks.Lock(id)
+	defer unlock()
+
+	order, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Order{}, err
+	}
+	if !order.Status.CanTransitionTo(StatusCancelled) {
+		return Order{}, TransitionError{OrderID: order.ID, From: order.Status, To: StatusCancelled}
+	}
+	if req.InitiatedBy == InitiatedByBot {
+		if err := s.throttleMessage(order); err != nil {
+			return Order{}, err
+		}
+	}
+	forced := false
+	if s.unconfirmed(order) {
+		if !req.Force {
+			return Order{}, errUnconfirmed(order)
+		}
+		// Nothing is sent: the broker has no order id to cancel. Should it
+		// hold the order after all, its fills are still applied as late fills
+		// and reconciliation reports it as an orphan.
+		forced = true
+		s.logger.Warn("force-cancelling order with unconfirmed placement", "order_id", order.ID, "initiated_by", req.InitiatedBy)
+	}
+	if venue := s.brokerFor(order); venue != nil && order.ProviderOrderID != "" {
+		if err := venue.Cancel(ctx, order.ProviderOrderID); err != nil {
+			return Order{}, fmt.Errorf("%w: cancel %s: %w", ErrBrokerRequest, order.ProviderOrderID, err)
+		}
+	}
+
+	reason := req.Reason
+	switch {
+	case reason == "" && forced:
+		reason = fmt.Sprintf("force-cancelled by %s with placement unconfirmed", req.InitiatedBy)
+	case reason == "":
+		reason = fmt.Sprintf("cancelled by %s", req.InitiatedBy)
+	}
+	if err := s.transition(ctx, &order, StatusCancelled, reason, Event{
+		Type:        EventCancel,
+		Reason:      reason,
+		InitiatedBy: req.InitiatedBy,
+	}); err != nil {
+		return Order{}, err
+	}
+	return order, nil
+}
+
+func (s *service) AmendOrder(ctx context.Context, id string, req AmendRequest) (Order, error) {
+	if req.Price == nil && req.Quantity == nil {
+		return Order{}, ValidationError{Reason: "price or quantity is required"}
+	}
+	if req.Price != nil && *req.Price < 0 {
+		return Order{}, ValidationError{Reason: "price must not be negative"}
+	}
+
+	unlock := s.locks.Lock(id)
+	defer unlock()
+
+	order, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return Order{}, err
+	}
+	if order.Status != StatusRouted && order.Status != StatusPartiallyFilled {
+		return Order{}, fmt.Errorf("%w: order %s is %s and cannot be amended", ErrInvalidTransition, order.ID, order.Status)
+	}
+	if order.Algorithm != "" {
+		return Order{}, ValidationError{Reason: "algorithm parent orders cannot be amended; cancel and resubmit"}
+	}
+	if order.ParentID != "" {
+		return Order{}, ValidationError{Reason: "algorithm child orders are worked by their parent and cannot be amended"}
+	}
+	if order.Leg != "" {
+		return Order{}, ValidationError{Reason: "bracket orders cannot be amended leg by leg; cancel and resubmit"}
+	}
+	if req.Price != nil && *req.Price <= 0 && (order.Type == OrderTypeLimit || (order.Type == OrderTypeStop && order.Price > 0)) {
+		return Order{}, ValidationError{Reason: "price must be greater than zero for limit and stop-limit orders"}
+	}
+	if req.Price != nil && *req.Price != 0 && (order.Type == OrderTypeMarket || (order.Type == OrderTypeStop && order.Price == 0)) {
+		return Order{}, ValidationError{Reason: "market and stop orders must not carry a price"}
+	}
+	if err := s.throttleMessage(order); err != nil {
+		return Order{}, err
+	}
+
+	price, quantity := order.Price, order.Quantity
+	if req.Price != nil {
+		price = *req.Price
+	}
+	if req.Quantity != nil {
+		quantity = *req.Quantity
+	}
+	if quantity <= order.FilledQuantity {
+		return Order{}, ValidationError{Reason: fmt.Sprintf("quantity must exceed filled quantity %g", order.FilledQuantity)}
+	}
+	amended := order
+	amended.Price, amended.Quantity = price, quantity
+	if s.rules != nil {
+		if err := s.rules.Check(amended); err != nil {
+			var violation RuleViolation
+			if errors.As(err, &violation) {
+				return Order{}, ValidationError{Reason: violation.Reason}
+			}
+			return Order{}, err
+		}
+	}
+	// Only a smaller quantity at the same price cannot add exposure; anything
+	// else goes through the same risk check as a new intent.
+	if quantity > order.Quantity || price != order.Price {
+		if rejection := s.checkRisk(ctx, amended); rejection != nil {
+			if rejection.Category == RejectionRiskLimit {
+				return Order{}, ValidationError{Reason: rejection.Reason}
+			}
+			return Order{}, fmt.Errorf("amend %s: %s", order.ID, rejection.Reason)
+		}
+	}
+	if s.unconfirmed(order) {
+		return Order{}, errUnconfirmed(order)
+	}
+	if venue := s.brokerFor(order); venue != nil && order.ProviderOrderID != "" {
+		if err := venue.Amend(ctx, order.ProviderOrderID, price, quantity); err != nil {
+			return Order{}, fmt.Errorf("%w: amend %s: %w", ErrBrokerRequest, order.ProviderOrderID, err)
+		}
+	}
+
+	reason := fmt.Sprintf("amended price %g -> %g, quantity %g -> %g", order.Price, price, order.Quantity, quantity)
+	if err := s.amended(ctx, &order, price, quantity, reason); err != nil {
+		return Order{}, err
+	}
+	return order, nil
+}
+
+// amended records a price and quantity the broker accepted for order and
+// publishes an amend event. Amendments keep the status, so they are recorded
+// as a self-transition to leave an audit trail in the order history.
+func (s *service) amended(ctx context.Context, order *Order, price, quantity float64, reason string) error {
+	now := s.now()
+	entry := Transition{OrderID: order.ID, From: order.Status, To: order.Status, Reason: reason, At: now}
+
+	updated := *order
+	updated.Price = price
+	updated.Quantity = quantity
+	updated.UpdatedAt = now
+	staged := s.stage(ctx, updated, []Event{{Type: EventAmend, Reason: reason}})
+	if err := s.repo.Transition(ctx, updated, entry, s.outboxed(staged)...); err != nil {
+		return fmt.Errorf("persist amendment: %w", err)
+	}
+	*order = updated
+	s.deliver(ctx, staged)
+	s.updated(ctx, updated, entry, nil)
+	return nil
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// EventType identifies the kind of order lifecycle notification emitted to bots.
+type EventType string
+
+const (
+	// EventAck confirms an intent was received and persisted.
+	EventAck EventType = "ack"
+	// EventRejection reports an intent that will not be executed.
+	EventRejection EventType = "rejection"
+	// EventFill reports a partial or complete execution.
+	EventFill EventType = "fill"
+	// EventCancel reports an order cancelled prior to full execution.
+	EventCancel EventType = "cancel"
+	// EventAmend reports a working order's new price or quantity.
+	EventAmend EventType = "amend"
+)
+
+// RejectionCategory is the machine friendly reason attached to rejections.
+type RejectionCategory string
+
+const (
+	RejectionUnspecified      RejectionCategory = "unspecified"
+	RejectionRiskLimit        RejectionCategory = "risk_limit"
+	RejectionInstrumentHalted RejectionCategory = "instrument_halted"
+	RejectionBrokerReject     RejectionCategory = "broker_reject"
+	RejectionTimeout          RejectionCategory = "timeout"
+	RejectionSystemError      RejectionCategory = "system_error"
+	RejectionInvalidPrice     RejectionCategory = "invalid_price"
+	RejectionInvalidQuantity  RejectionCategory = "invalid_quantity"
+	RejectionRateLimited      RejectionCategory = "rate_limited"
+	RejectionMarketClosed     RejectionCategory = "market_closed"
+)
+
+// Initiator identifies who requested an order cancellation.
+type Initiator string
+
+const (
+	InitiatedByBot    Initiator = "bot"
+	InitiatedByRisk   Initiator = "risk"
+	InitiatedByBroker Initiator = "broker"
+	InitiatedBySystem Initiator = "system"
+)
+
+// Valid reports whether i is one of the known initiators.
+func (i Initiator) Valid() bool {
+	switch i {
+	case InitiatedByBot, InitiatedByRisk, InitiatedByBroker, InitiatedBySystem:
+		return true
+	}
+	return false
+}
+
+// Fill captures a single execution reported against an order.
+type Fill struct {
+	ProviderOrderID string    `json:"provider_order_id,omitempty"`
+	Quantity        float64   `json:"quantity"`
+	Remaining       float64   `json:"remaining"`
+	Price           float64   `json:"price"`
+	Fee             float64   `json:"fee"`
+	Tax             float64   `json:"tax"`
+	FilledAt        time.Time `json:"filled_at"`
+}
+
+// Event is an order lifecycle notification destined for the originating bot.
+type Event struct {
+	Type          EventType         `json:"type"`
+	Order         Order             `json:"order"`
+	CorrelationID string            `json:"correlation_id,omitempty"`
+	Reason        string            `json:"reason,omitempty"`
+	Category      RejectionCategory `json:"category,omitempty"`
+	InitiatedBy   Initiator         `json:"initiated_by,omitempty"`
+	Fill          *Fill             `json:"fill,omitempty"`
+	OccurredAt    time.Time         `json:"occurred_at"`
+}
+
+// EventPublisher delivers order lifecycle events to downstream consumers.
+type EventPublisher interface {
+	PublishOrderEvent(ctx context.Context, event Event) error
+}
+
+// EventPublisherFunc allows using bare functions as event publishers.
+type EventPublisherFunc func(context.Context, Event) error
+
+// PublishOrderEvent implements EventPublisher.
+func (fn EventPublisherFunc) PublishOrderEvent(ctx context.Context, event Event) error {
+	if fn == nil {
+		return nil
+	}
+	return fn(ctx, event)
+}
+
+type correlationKey struct{}
+
+// WithCorrelationID returns a context carrying the tracing correlation id for
+// the intent being processed.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, correlationKey{}, id)
+}
+
+// CorrelationID extracts the correlation id stored by WithCorrelationID.
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationKey{}).(string)
+	return id
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+func (s *service) ExpireOrders(ctx context.Context) (int, error) {
+	now := s.now()
+	candidates, err := s.repo.Expiring(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, candidate := range candidates {
+		ok, err := s.expire(ctx, candidate.ID, now)
+		if err != nil {
+			s.logger.Warn("failed to expire order", "order_id", candidate.ID, "error", err)
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// expire re-reads the order under its lock so a fill or cancel that raced the
+// sweep is respected, then withdraws it at the broker and marks it expired.
+func (s *service) expire(ctx context.Context, id string, now time.Time) (bool, error) {
+	unlock := s.locks.Lock(id)
+	defer unlock()
+
+	order, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if order.ExpiresAt == nil || order.ExpiresAt.After(now) || !order.Status.CanTransitionTo(StatusExpired) {
+		return false, nil
+	}
+	if s.unconfirmed(order) {
+		// A later sweep expires it once reconciliation adopts the broker
+		// order.
+		return false, errUnconfirmed(order)
+	}
+	if venue := s.brokerFor(order); venue != nil && order.ProviderOrderID != "" {
+		if err := venue.Cancel(ctx, order.ProviderOrderID); err != nil && !errors.Is(err, ErrUnknownBrokerOrder) {
+			return false, err
+		}
+	}
+
+	reason := "expired at " + order.ExpiresAt.Format(time.RFC3339)
+	if err := s.transition(ctx, &order, StatusExpired, reason, Event{
+		Type:        EventCancel,
+		Reason:      reason,
+		InitiatedBy: InitiatedBySystem,
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RunExpirySweeper calls svc.ExpireOrders every interval until ctx is
+// cancelled.
+func RunExpirySweeper(ctx context.Context, svc Service, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := svc.ExpireOrders(ctx)
+			if err != nil {
+				logger.Error("expiry sweep failed", "error", err)
+				continue
+			}
+			if n > 0 {
+				logger.Info("expired stale orders", "count", n)
+			}
+		}
+	}
+}
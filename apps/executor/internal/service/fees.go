@@ -0,0 +1,22 @@
+package service
+
+// FillCharges are the costs of one fill in the account currency: Fee sums
+// broker, exchange and depository charges, Tax is the income tax withheld.
+type FillCharges struct {
+	Fee float64
+	Tax float64
+}
+
+// FeeModel prices fills. quantity and price describe the fill, not the
+// whole order.
+type FeeModel interface {
+	Charges(order Order, quantity, price float64) FillCharges
+}
+
+// WithFeeModel charges every fill, live and paper alike, according to model
+// and stores the result on the execution. Without it fills carry no fees.
+func WithFeeModel(model FeeModel) Option {
+	return func(s *service) {
+		s.fees = model
+	}
+}
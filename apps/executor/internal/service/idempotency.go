@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ErrDuplicateIntent is returned alongside the original order when an intent
+// with an already processed intent_id is submitted again.
+var ErrDuplicateIntent = errors.New("duplicate intent")
+
+// ErrSequenceRegression indicates an intent whose sequence does not advance
+// past the last one accepted for its bot and account.
+var ErrSequenceRegression = errors.New("sequence regression")
+
+// SequenceError describes an out-of-order intent sequence.
+type SequenceError struct {
+	BotID     string
+	AccountID string
+	Last      uint64
+	Got       uint64
+}
+
+// Error implements the error interface.
+func (e SequenceError) Error() string {
+	return fmt.Sprintf("sequence %d for bot %s account %s does not follow last accepted sequence %d",
+		e.Got, e.BotID, e.AccountID, e.Last)
+}
+
+// Is lets errors.Is match SequenceError against ErrSequenceRegression.
+func (e SequenceError) Is(target error) bool {
+	return target == ErrSequenceRegression
+}
+
+// newOrderID returns a random, collision-free order identifier.
+func newOrderID() string {
+	return newID("ord")
+}
+
+// newID returns prefix followed by a random version 4 UUID.
+func newID(prefix string) string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("read random %s id: %v", prefix, err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	h := hex.EncodeToString(b[:])
+	return fmt.Sprintf("%s-%s-%s-%s-%s-%s", prefix, h[0:8], h[8:12], h[12:16], h[16:20], h[20:32])
+}
+
+// warnSequenceGap logs when the order's intent sequence skips past the next
+// one expected for its bot and account. Gaps are accepted, and regressions
+// are refused by the repository when the order is created.
+func (s *service) warnSequenceGap(ctx context.Context, order Order) {
+	if order.Sequence == 0 {
+		return
+	}
+	last, err := s.repo.LastSequence(ctx, order.BotID, order.AccountID)
+	if err != nil {
+		s.logger.Warn("failed to load last intent sequence", "bot_id", order.BotID, "account_id", order.AccountID, "error", err)
+		return
+	}
+	if last > 0 && order.Sequence > last+1 {
+		s.logger.Warn("intent sequence gap detected",
+			"bot_id", order.BotID, "account_id", order.AccountID, "last", last, "got", order.Sequence, "missing", order.Sequence-last-1)
+	}
+}
+
+// replay returns the order previously created for the intent, if any.
+func (s *service) replay(ctx context.Context, order Order) (Order, bool, error) {
+	if order.IntentID == "" {
+		return Order{}, false, nil
+	}
+	existing, err := s.repo.GetByIntent(ctx, order.BotID, order.IntentID)
+	if errors.Is(err, ErrOrderNotFound) {
+		return Order{}, false, nil
+	}
+	if err != nil {
+		return Order{}, false, fmt.Errorf("lookup intent: %w", err)
+	}
+	return existing, true, nil
+}
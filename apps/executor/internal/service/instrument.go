@@ -0,0 +1,48 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RuleViolation is returned by InstrumentRules when an order breaks an
+// exchange trading rule. Category distinguishes it from risk rejections.
+type RuleViolation struct {
+	Category RejectionCategory
+	Reason   string
+}
+
+// Error implements the error interface.
+func (v RuleViolation) Error() string {
+	return v.Reason
+}
+
+// InstrumentRules enforces venue rules such as tick size, price bands and lot
+// size before an order reaches the risk engine.
+type InstrumentRules interface {
+	Check(order Order) error
+}
+
+// WithInstrumentRules validates every order against rules before risk checks.
+func WithInstrumentRules(rules InstrumentRules) Option {
+	return func(s *service) {
+		s.rules = rules
+	}
+}
+
+// checkInstrument returns a non-nil rejection when order violates the
+// configured instrument rules.
+func (s *service) checkInstrument(order Order) *Event {
+	if s.rules == nil {
+		return nil
+	}
+	err := s.rules.Check(order)
+	if err == nil {
+		return nil
+	}
+	var violation RuleViolation
+	if errors.As(err, &violation) {
+		return &Event{Type: EventRejection, Category: violation.Category, Reason: violation.Reason}
+	}
+	return &Event{Type: EventRejection, Category: RejectionSystemError, Reason: fmt.Sprintf("instrument rules unavailable: %v", err)}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Status enumerates the states an order moves through.
+type Status string
+
+const (
+	StatusNew             Status = "new"
+	StatusPendingRisk     Status = "pending_risk"
+	StatusRouted          Status = "routed"
+	StatusPartiallyFilled Status = "partially_filled"
+	StatusFilled          Status = "filled"
+	StatusCancelled       Status = "cancelled"
+	StatusRejected        Status = "rejected"
+	StatusExpired         Status = "expired"
+)
+
+// transitions lists the legal successors for every non-terminal status.
+var transitions = map[Status][]Status{
+	StatusNew:             {StatusPendingRisk, StatusRejected, StatusCancelled},
+	StatusPendingRisk:     {StatusRouted, StatusRejected, StatusCancelled},
+	StatusRouted:          {StatusPartiallyFilled, StatusFilled, StatusCancelled, StatusRejected, StatusExpired},
+	StatusPartiallyFilled: {StatusPartiallyFilled, StatusFilled, StatusCancelled, StatusExpired},
+}
+
+// Valid reports whether s is a known order status.
+func (s Status) Valid() bool {
+	switch s {
+	case StatusNew, StatusPendingRisk, StatusRouted, StatusPartiallyFilled,
+		StatusFilled, StatusCancelled, StatusRejected, StatusExpired:
+		return true
+	}
+	return false
+}
+
+// Terminal reports whether no further transitions are possible from s.
+func (s Status) Terminal() bool {
+	_, ok := transitions[s]
+	return !ok
+}
+
+// CanTransitionTo reports whether moving from s to next is permitted.
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, candidate := range transitions[s] {
+		if candidate == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrInvalidTransition is matched by every TransitionError via errors.Is.
+var ErrInvalidTransition = errors.New("invalid order status transition")
+
+// TransitionError is returned when an order is asked to move to a status that
+// is not reachable from its current one.
+type TransitionError struct {
+	OrderID string
+	From    Status
+	To      Status
+}
+
+// Error implements the error interface.
+func (e TransitionError) Error() string {
+	return fmt.Sprintf("order %s cannot transition from %s to %s", e.OrderID, e.From, e.To)
+}
+
+// Is allows errors.Is(err, ErrInvalidTransition).
+func (e TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// Transition records a single status change in an order's history. From is
+// empty for the initial status recorded at creation.
+type Transition struct {
+	OrderID string    `json:"order_id"`
+	From    Status    `json:"from,omitempty"`
+	To      Status    `json:"to"`
+	Reason  string    `json:"reason,omitempty"`
+	At      time.Time `json:"at"`
+}
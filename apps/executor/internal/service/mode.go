@@ -0,0 +1,93 @@
+package service
+
+import "fmt"
+
+// Mode is the execution mode of an order: live orders go to the broker,
+// paper orders to a simulated venue filling against live market data.
+type Mode string
+
+const (
+	ModeLive  Mode = "live"
+	ModePaper Mode = "paper"
+)
+
+// Valid reports whether m is a known mode.
+func (m Mode) Valid() bool {
+	return m == ModeLive || m == ModePaper
+}
+
+// OrLive returns m, or ModeLive for orders stored before modes existed.
+func (m Mode) OrLive() Mode {
+	if m == "" {
+		return ModeLive
+	}
+	return m
+}
+
+// ExecutionModes decides which bots and accounts trade on paper. Default
+// applies to bots and accounts without an override; an order is paper when
+// either its bot or its account is, so a paper setting can never be
+// overridden into live trading by the other one.
+type ExecutionModes struct {
+	Default  Mode
+	Bots     map[string]Mode
+	Accounts map[string]Mode
+}
+
+// Validate reports the first unknown mode in the configuration.
+func (m ExecutionModes) Validate() error {
+	if m.Default != "" && !m.Default.Valid() {
+		return fmt.Errorf("unknown execution mode %q", m.Default)
+	}
+	for _, modes := range []map[string]Mode{m.Bots, m.Accounts} {
+		for id, mode := range modes {
+			if !mode.Valid() {
+				return fmt.Errorf("unknown execution mode %q for %s", mode, id)
+			}
+		}
+	}
+	return nil
+}
+
+// For returns the mode of an order placed by botID for accountID.
+func (m ExecutionModes) For(botID, accountID string) Mode {
+	bot, botSet := m.Bots[botID]
+	account, accountSet := m.Accounts[accountID]
+	switch {
+	case bot == ModePaper || account == ModePaper:
+		return ModePaper
+	case botSet || accountSet:
+		return ModeLive
+	}
+	return m.Default.OrLive()
+}
+
+// WithExecutionModes assigns each new order the mode modes give its bot and
+// account. Without it every order is live.
+func WithExecutionModes(modes ExecutionModes) Option {
+	return func(s *service) {
+		s.modes = modes
+	}
+}
+
+// WithPaperBroker routes paper orders to b, normally a simulator fed with
+// live market data. Without it paper orders rest in the routed state; they
+// are never sent to the live broker.
+func WithPaperBroker(b Broker) Option {
+	return func(s *service) {
+		s.paper = b
+	}
+}
+
+// brokerFor returns the venue that holds order, nil when there is none.
+func (s *service) brokerFor(order Order) Broker {
+	if order.Mode == ModePaper {
+		return s.paper
+	}
+	if order.Route != "" && s.router != nil {
+		if venue := s.router.Venue(order.Route); venue != nil {
+			return venue
+		}
+	}
+	return s.broker
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OrderType describes how an order is executed, mirroring orders.proto.
+type OrderType string
+
+const (
+	// OrderTypeMarket takes any available liquidity and carries no price.
+	OrderTypeMarket OrderType = "market"
+	// OrderTypeLimit executes at the order price or better.
+	OrderTypeLimit OrderType = "limit"
+	// OrderTypeStop stays dormant until the market trades through StopPrice,
+	// then behaves as a market order, or a limit order when Price is set.
+	OrderTypeStop OrderType = "stop"
+)
+
+// TimeInForce controls how long an order stays working.
+type TimeInForce string
+
+const (
+	// TimeInForceGTC keeps the order working until filled, cancelled or its
+	// expires_at deadline passes.
+	TimeInForceGTC TimeInForce = "GTC"
+	// TimeInForceDay keeps the order working until the end of the trading
+	// day, when the session closer cancels it.
+	TimeInForceDay TimeInForce = "DAY"
+	// TimeInForceIOC fills what it can immediately and cancels the remainder.
+	TimeInForceIOC TimeInForce = "IOC"
+	// TimeInForceFOK fills the entire quantity immediately or not at all.
+	TimeInForceFOK TimeInForce = "FOK"
+)
+
+// Immediate reports whether the order must not rest on the book.
+func (tif TimeInForce) Immediate() bool {
+	return tif == TimeInForceIOC || tif == TimeInForceFOK
+}
+
+// orderTypeFor resolves the requested type, defaulting to limit when a price
+// is present and market otherwise for callers that predate explicit types.
+func orderTypeFor(intent OrderIntent) OrderType {
+	t := OrderType(strings.ToLower(strings.TrimSpace(string(intent.Type))))
+	if t != "" {
+		return t
+	}
+	if intent.Price > 0 {
+		return OrderTypeLimit
+	}
+	return OrderTypeMarket
+}
+
+func timeInForceFor(intent OrderIntent) TimeInForce {
+	tif := TimeInForce(strings.ToUpper(strings.TrimSpace(string(intent.TimeInForce))))
+	if tif == "" {
+		return TimeInForceGTC
+	}
+	return tif
+}
+
+// validateExecution checks the type, price, trigger, time-in-force and
+// expiry combination of an intent.
+func validateExecution(intent OrderIntent, now time.Time) error {
+	if intent.Price < 0 {
+		return ValidationError{Reason: "price must not be negative"}
+	}
+	if intent.StopPrice < 0 {
+		return ValidationError{Reason: "stop_price must not be negative"}
+	}
+
+	orderType := orderTypeFor(intent)
+	switch orderType {
+	case OrderTypeMarket:
+		if intent.Price != 0 {
+			return ValidationError{Reason: "market orders must not carry a price"}
+		}
+	case OrderTypeLimit:
+		if intent.Price <= 0 {
+			return ValidationError{Reason: "limit orders require a price"}
+		}
+	case OrderTypeStop:
+		if intent.StopPrice <= 0 {
+			return ValidationError{Reason: "stop orders require a stop_price trigger"}
+		}
+	default:
+		return ValidationError{Reason: "type must be market, limit or stop"}
+	}
+	if orderType != OrderTypeStop && intent.StopPrice != 0 {
+		return ValidationError{Reason: "stop_price is only valid for stop orders"}
+	}
+
+	switch tif := timeInForceFor(intent); tif {
+	case TimeInForceGTC, TimeInForceDay:
+	case TimeInForceIOC, TimeInForceFOK:
+		if orderType == OrderTypeStop {
+			return ValidationError{Reason: "stop orders cannot be " + string(tif)}
+		}
+	default:
+		return ValidationError{Reason: "time_in_force must be GTC, DAY, IOC or FOK"}
+	}
+
+	if intent.ExpiresAt != nil && !intent.ExpiresAt.After(now) {
+		return ValidationError{Reason: "expires_at must be in the future"}
+	}
+	return nil
+}
+
+// residuals tracks IOC orders whose unfilled remainder the broker has already
+// cancelled, keyed by order id with the quantity the broker reported filled.
+// Once the matching fills are applied the order is closed as cancelled.
+type residuals struct {
+	mu      sync.Mutex
+	pending map[string]float64
+}
+
+func newResiduals() *residuals {
+	return &residuals{pending: make(map[string]float64)}
+}
+
+func (r *residuals) set(orderID string, filled float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[orderID] = filled
+}
+
+// take reports whether the order has received all fills the broker reported
+// before cancelling the remainder, clearing the entry if so.
+func (r *residuals) take(orderID string, filled float64) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	target, ok := r.pending[orderID]
+	if !ok || filled < target {
+		return false
+	}
+	delete(r.pending, orderID)
+	return true
+}
+
+// settleImmediate closes the unfilled remainder of an IOC/FOK order once the
+// broker has finished matching it. Must be called with the order lock held.
+func (s *service) settleImmediate(ctx context.Context, order *Order) error {
+	venue := s.brokerFor(*order)
+	if !order.TimeInForce.Immediate() || venue == nil || order.ProviderOrderID == "" {
+		return nil
+	}
+	state, err := venue.Query(ctx, order.ProviderOrderID)
+	if err != nil {
+		s.logger.Warn("failed to query immediate order", "order_id", order.ID, "error", err)
+		return nil
+	}
+	if state.Open || state.FilledQuantity >= order.Quantity {
+		return nil
+	}
+	if state.FilledQuantity > order.FilledQuantity {
+		// Fills are still in flight on the broker stream; HandleFill closes
+		// the order once they have been applied.
+		s.residuals.set(order.ID, state.FilledQuantity)
+		return nil
+	}
+	return s.cancelRemainder(ctx, order)
+}
+
+func (s *service) cancelRemainder(ctx context.Context, order *Order) error {
+	reason := fmt.Sprintf("%s remainder of %g cancelled", order.TimeInForce, order.Quantity-order.FilledQuantity)
+	return s.transition(ctx, order, StatusCancelled, reason, Event{
+		Type:        EventCancel,
+		Reason:      reason,
+		InitiatedBy: InitiatedByBroker,
+	})
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrUndeliverable marks publish failures that retrying cannot fix, such as an
+// event without the identifiers needed to route it.
+var ErrUndeliverable = errors.New("event is undeliverable")
+
+// WithOutbox stores every order event in the repository together with the
+// state change that produced it (see OrderRepository). A relay then delivers
+// the stored events, so publishers registered with WithEventPublisher should be
+// limited to in-process consumers.
+func WithOutbox() Option {
+	return func(s *service) {
+		s.outbox = true
+	}
+}
+
+// prepare fills the correlation id of an event from the context, falling back
+// to the intent and order ids, and timestamps events that lack one.
+func (s *service) prepare(ctx context.Context, event Event) Event {
+	if event.CorrelationID == "" {
+		event.CorrelationID = CorrelationID(ctx)
+	}
+	if event.CorrelationID == "" {
+		event.CorrelationID = event.Order.IntentID
+	}
+	if event.CorrelationID == "" {
+		event.CorrelationID = event.Order.ID
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = s.now()
+	}
+	return event
+}
+
+// stage prepares events produced by a state change of order so they can be
+// written alongside it. Each event carries the order as persisted. Child
+// orders of an algorithm stage nothing; their parent reports to the bot.
+func (s *service) stage(ctx context.Context, order Order, events []Event) []Event {
+	if order.ParentID != "" {
+		return nil
+	}
+	staged := make([]Event, len(events))
+	for i, event := range events {
+		event.Order = order
+		if event.OccurredAt.IsZero() {
+			event.OccurredAt = order.UpdatedAt
+		}
+		staged[i] = s.prepare(ctx, event)
+	}
+	return staged
+}
+
+// outboxed returns the events to hand to the repository: all of them when the
+// outbox is enabled, none otherwise.
+func (s *service) outboxed(events []Event) []Event {
+	if !s.outbox {
+		return nil
+	}
+	return events
+}
+
+// deliver hands already persisted events to the registered publishers.
+func (s *service) deliver(ctx context.Context, events []Event) {
+	for _, event := range events {
+		for _, p := range s.publishers {
+			if err := p.PublishOrderEvent(ctx, event); err != nil {
+				s.logger.Warn("failed to publish order event",
+					"type", event.Type, "order_id", event.Order.ID, "intent_id", event.Order.IntentID, "error", err)
+			}
+		}
+	}
+}
+
+// publish emits an event that is not tied to a state change, such as the
+// rejection of an intent that was never persisted.
+func (s *service) publish(ctx context.Context, event Event) {
+	event = s.prepare(ctx, event)
+	if s.outbox {
+		if err := s.repo.AppendEvents(ctx, event); err != nil {
+			s.logger.Warn("failed to store order event",
+				"type", event.Type, "order_id", event.Order.ID, "intent_id", event.Order.IntentID, "error", err)
+		}
+	}
+	s.deliver(ctx, []Event{event})
+}
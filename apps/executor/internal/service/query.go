@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPageSize is used when a list request does not set a limit.
+	DefaultPageSize = 50
+	// MaxPageSize caps the number of orders returned per page.
+	MaxPageSize = 500
+)
+
+// OrderFilter narrows order listings. Zero values match every order.
+type OrderFilter struct {
+	BotID     string
+	AccountID string
+	Symbol    string
+	Side      string
+	Statuses  []Status
+	// ParentID selects the child orders of an algorithm parent.
+	ParentID string
+	// BracketID selects the entry and exit legs of a bracket.
+	BracketID string
+	// TopLevel excludes algorithm child orders.
+	TopLevel bool
+	Mode     Mode
+	// CreatedFrom is inclusive and CreatedTo exclusive.
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+}
+
+// PageRequest selects one page of a listing. Cursor is the NextCursor of the
+// previous page; orders are sorted by creation time, newest first unless
+// Ascending is set.
+type PageRequest struct {
+	Cursor    string
+	Limit     int
+	Ascending bool
+}
+
+// OrderPage is one page of orders and the cursor for the next one, empty on
+// the last page.
+type OrderPage struct {
+	Items      []Order `json:"items"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// OrderCursor is the keyset position of the last order on a page.
+type OrderCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// OrderQuery is the repository form of a list request. Repositories return
+// at most Limit orders matching Filter that sort strictly after After.
+type OrderQuery struct {
+	Filter    OrderFilter
+	After     *OrderCursor
+	Limit     int
+	Ascending bool
+}
+
+// Matches reports whether order satisfies the filter.
+func (f OrderFilter) Matches(order Order) bool {
+	if f.BotID != "" && order.BotID != f.BotID {
+		return false
+	}
+	if f.AccountID != "" && order.AccountID != f.AccountID {
+		return false
+	}
+	if f.Symbol != "" && order.Symbol != f.Symbol {
+		return false
+	}
+	if f.Side != "" && order.Side != f.Side {
+		return false
+	}
+	if f.ParentID != "" && order.ParentID != f.ParentID {
+		return false
+	}
+	if f.BracketID != "" && order.BracketID != f.BracketID {
+		return false
+	}
+	if f.TopLevel && order.ParentID != "" {
+		return false
+	}
+	if f.Mode != "" && order.Mode.OrLive() != f.Mode {
+		return false
+	}
+	if len(f.Statuses) > 0 {
+		found := false
+		for _, status := range f.Statuses {
+			if order.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.CreatedFrom.IsZero() && order.CreatedAt.Before(f.CreatedFrom) {
+		return false
+	}
+	if !f.CreatedTo.IsZero() && !order.CreatedAt.Before(f.CreatedTo) {
+		return false
+	}
+	return true
+}
+
+// Less reports whether order a sorts before b in the requested direction.
+func (q OrderQuery) Less(a, b Order) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt) == q.Ascending
+	}
+	if a.ID == b.ID {
+		return false
+	}
+	return (a.ID < b.ID) == q.Ascending
+}
+
+// IsAfter reports whether order sorts strictly after the query cursor.
+func (q OrderQuery) IsAfter(order Order) bool {
+	if q.After == nil {
+		return true
+	}
+	return q.Less(Order{CreatedAt: q.After.CreatedAt, ID: q.After.ID}, order)
+}
+
+func encodeCursor(order Order) string {
+	raw := strconv.FormatInt(order.CreatedAt.UnixNano(), 10) + "|" + order.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (*OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ValidationError{Reason: "invalid cursor"}
+	}
+	nanos, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, ValidationError{Reason: "invalid cursor"}
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ValidationError{Reason: "invalid cursor"}
+	}
+	return &OrderCursor{CreatedAt: time.Unix(0, n).UTC(), ID: id}, nil
+}
+
+func (s *service) ListOrders(ctx context.Context, filter OrderFilter, page PageRequest) (OrderPage, error) {
+	query, err := newOrderQuery(filter, page)
+	if err != nil {
+		return OrderPage{}, err
+	}
+	limit := query.Limit
+	// Fetch one extra row to learn whether another page exists.
+	query.Limit++
+	orders, err := s.repo.ListOrders(ctx, query)
+	if err != nil {
+		return OrderPage{}, err
+	}
+
+	result := OrderPage{Items: orders}
+	if len(orders) > limit {
+		result.Items = orders[:limit]
+		result.NextCursor = encodeCursor(result.Items[limit-1])
+	}
+	return result, nil
+}
+
+func (s *service) GetOrderExecutions(ctx context.Context, id string) ([]Execution, error) {
+	order, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.Algorithm != "" {
+		return s.childExecutions(ctx, id)
+	}
+	return s.repo.Executions(ctx, id)
+}
+
+func newOrderQuery(filter OrderFilter, page PageRequest) (OrderQuery, error) {
+	filter.BotID = strings.TrimSpace(filter.BotID)
+	filter.AccountID = strings.TrimSpace(filter.AccountID)
+	filter.Symbol = strings.TrimSpace(filter.Symbol)
+	filter.Side = strings.ToLower(strings.TrimSpace(filter.Side))
+	if filter.Side != "" && filter.Side != "buy" && filter.Side != "sell" {
+		return OrderQuery{}, ValidationError{Reason: "side must be buy or sell"}
+	}
+	for _, status := range filter.Statuses {
+		if !status.Valid() {
+			return OrderQuery{}, ValidationError{Reason: fmt.Sprintf("unknown status %q", status)}
+		}
+	}
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() && !filter.CreatedFrom.Before(filter.CreatedTo) {
+		return OrderQuery{}, ValidationError{Reason: "created_from must be before created_to"}
+	}
+
+	limit := page.Limit
+	switch {
+	case limit == 0:
+		limit = DefaultPageSize
+	case limit < 0 || limit > MaxPageSize:
+		return OrderQuery{}, ValidationError{Reason: fmt.Sprintf("limit must be between 1 and %d", MaxPageSize)}
+	}
+
+	query := OrderQuery{Filter: filter, Limit: limit, Ascending: page.Ascending}
+	if page.Cursor != "" {
+		after, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return OrderQuery{}, err
+		}
+		query.After = after
+	}
+	return query, nil
+}
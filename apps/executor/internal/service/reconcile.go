@@ -0,0 +1,422 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrDuplicateExecution is returned by HandleFill for an execution id that is
+// already recorded against the order.
+var ErrDuplicateExecution = errors.New("execution already recorded")
+
+// DiscrepancyKind classifies a difference between the broker and local state.
+type DiscrepancyKind string
+
+const (
+	// DiscrepancyMissedFill is a broker execution that was never applied
+	// locally. It is repaired by applying the fill.
+	DiscrepancyMissedFill DiscrepancyKind = "missed_fill"
+	// DiscrepancyMissedCancel is an order the broker has closed while it is
+	// still working locally. It is repaired by cancelling the remainder.
+	DiscrepancyMissedCancel DiscrepancyKind = "missed_cancel"
+	// DiscrepancyFilledQuantity is a broker filled quantity that the
+	// reported executions do not account for.
+	DiscrepancyFilledQuantity DiscrepancyKind = "filled_quantity_mismatch"
+	// DiscrepancyOrphanBrokerOrder is a working broker order without a
+	// matching working local order.
+	DiscrepancyOrphanBrokerOrder DiscrepancyKind = "orphan_broker_order"
+	// DiscrepancyUnknownProviderOrder is a local order whose
+	// provider_order_id the broker does not know.
+	DiscrepancyUnknownProviderOrder DiscrepancyKind = "unknown_provider_order"
+	// DiscrepancyUnconfirmedPlacement is a local order whose placement
+	// outcome was unknown. It is repaired by adopting the broker order
+	// listed for it; while none is listed it is only recorded.
+	DiscrepancyUnconfirmedPlacement DiscrepancyKind = "unconfirmed_placement"
+	// DiscrepancyCheckFailed is a working order that could not be compared
+	// with the broker, for example because a broker query failed. The next
+	// run checks it again.
+	DiscrepancyCheckFailed DiscrepancyKind = "check_failed"
+)
+
+// Discrepancy is one difference found by a reconciliation run.
+type Discrepancy struct {
+	Kind            DiscrepancyKind `json:"kind"`
+	OrderID         string          `json:"order_id,omitempty"`
+	ProviderOrderID string          `json:"provider_order_id,omitempty"`
+	Detail          string          `json:"detail"`
+	Repaired        bool            `json:"repaired"`
+}
+
+// ReconciliationRun records one comparison of broker and local order state.
+type ReconciliationRun struct {
+	ID            string        `json:"id"`
+	StartedAt     time.Time     `json:"started_at"`
+	FinishedAt    time.Time     `json:"finished_at"`
+	OrdersChecked int           `json:"orders_checked"`
+	Discrepancies []Discrepancy `json:"discrepancies"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// ReconciliationLog persists reconciliation runs.
+type ReconciliationLog interface {
+	SaveReconciliationRun(ctx context.Context, run ReconciliationRun) error
+	// ReconciliationRuns returns up to limit runs, newest first.
+	ReconciliationRuns(ctx context.Context, limit int) ([]ReconciliationRun, error)
+}
+
+// WithReconciliationLog stores reconciliation runs so they can be listed.
+func WithReconciliationLog(log ReconciliationLog) Option {
+	return func(s *service) {
+		s.reconciliations = log
+	}
+}
+
+// DefaultUnconfirmedTimeout is how long reconciliation waits for the broker
+// to list an order whose placement outcome is unknown before rejecting it as
+// never placed.
+const DefaultUnconfirmedTimeout = 10 * time.Minute
+
+// WithUnconfirmedTimeout replaces DefaultUnconfirmedTimeout. Zero keeps such
+// orders working until the broker lists them or an operator force-cancels
+// them.
+func WithUnconfirmedTimeout(timeout time.Duration) Option {
+	return func(s *service) {
+		s.unconfirmedTimeout = timeout
+	}
+}
+
+// workingStatuses are the local states of orders that should still be live at
+// the broker.
+var workingStatuses = []Status{StatusRouted, StatusPartiallyFilled}
+
+func (s *service) Reconcile(ctx context.Context) (ReconciliationRun, error) {
+	run := ReconciliationRun{ID: newID("rec"), StartedAt: s.now(), Discrepancies: []Discrepancy{}}
+	err := s.reconcile(ctx, &run)
+	if err != nil {
+		run.Error = err.Error()
+	}
+	run.FinishedAt = s.now()
+	if s.reconciliations != nil {
+		if serr := s.reconciliations.SaveReconciliationRun(ctx, run); serr != nil {
+			s.logger.Warn("failed to store reconciliation run", "run_id", run.ID, "error", serr)
+		}
+	}
+	return run, err
+}
+
+func (s *service) ReconciliationRuns(ctx context.Context, limit int) ([]ReconciliationRun, error) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		return nil, ValidationError{Reason: fmt.Sprintf("limit must not exceed %d", MaxPageSize)}
+	}
+	if s.reconciliations == nil {
+		return []ReconciliationRun{}, nil
+	}
+	return s.reconciliations.ReconciliationRuns(ctx, limit)
+}
+
+func (s *service) reconcile(ctx context.Context, run *ReconciliationRun) error {
+	if s.broker == nil {
+		return errors.New("no broker configured")
+	}
+	open, err := s.broker.OpenOrders(ctx)
+	if err != nil {
+		return fmt.Errorf("list broker open orders: %w", err)
+	}
+	local, err := s.workingOrders(ctx)
+	if err != nil {
+		return fmt.Errorf("list working orders: %w", err)
+	}
+
+	byClient := make(map[string]BrokerOrderState, len(open))
+	for _, state := range open {
+		byClient[state.ClientOrderID] = state
+	}
+	known := make(map[string]bool, len(local))
+	for _, order := range local {
+		known[order.ID] = true
+		if order.ProviderOrderID != "" {
+			known[order.ProviderOrderID] = true
+		}
+	}
+	for _, state := range open {
+		if known[state.ClientOrderID] || known[state.ProviderOrderID] {
+			continue
+		}
+		detail := "broker order has no local order"
+		if order, err := s.repo.Get(ctx, state.ClientOrderID); err == nil {
+			detail = fmt.Sprintf("local order is %s", order.Status)
+		}
+		run.Discrepancies = append(run.Discrepancies, Discrepancy{
+			Kind:            DiscrepancyOrphanBrokerOrder,
+			OrderID:         state.ClientOrderID,
+			ProviderOrderID: state.ProviderOrderID,
+			Detail:          fmt.Sprintf("%s: %s %g %s open at the broker", detail, state.Side, state.Quantity-state.FilledQuantity, state.Symbol),
+		})
+	}
+
+	for _, order := range local {
+		if s.unconfirmed(order) {
+			confirmed, found := s.confirmPlacement(ctx, order, byClient[order.ID])
+			run.Discrepancies = append(run.Discrepancies, found)
+			if !found.Repaired {
+				run.OrdersChecked++
+				continue
+			}
+			order = confirmed
+			if order.Status.Terminal() {
+				run.OrdersChecked++
+				continue
+			}
+			s.withdrawAdopted(ctx, order)
+		}
+		if order.ProviderOrderID == "" {
+			continue
+		}
+		run.OrdersChecked++
+		found, err := s.reconcileOrder(ctx, order)
+		if err != nil {
+			// One failing order must not keep the rest from being checked.
+			s.logger.Warn("failed to reconcile order", "run_id", run.ID, "order_id", order.ID, "error", err)
+			found = append(found, Discrepancy{
+				Kind:            DiscrepancyCheckFailed,
+				OrderID:         order.ID,
+				ProviderOrderID: order.ProviderOrderID,
+				Detail:          err.Error(),
+			})
+		}
+		run.Discrepancies = append(run.Discrepancies, found...)
+	}
+	s.settleBrackets(ctx, local)
+	return nil
+}
+
+// settleBrackets retries the follow-ups of every bracket with an exit leg
+// still working or not yet armed, whether the broker refused them or they
+// were held back while a placement was unconfirmed.
+func (s *service) settleBrackets(ctx context.Context, working []Order) {
+	unarmed, err := s.allOrders(ctx, OrderFilter{Statuses: []Status{StatusNew}, Mode: ModeLive})
+	if err != nil {
+		s.logger.Warn("failed to list unarmed orders", "error", err)
+	}
+	seen := make(map[string]bool)
+	for _, order := range append(working, unarmed...) {
+		if !order.Leg.Exit() || seen[order.BracketID] {
+			continue
+		}
+		seen[order.BracketID] = true
+		s.settleLegs(ctx, order.BracketID)
+	}
+}
+
+// workingOrders pages through every local live order that should be working
+// at the broker. Paper orders are held by the paper broker and not
+// reconciled.
+func (s *service) workingOrders(ctx context.Context) ([]Order, error) {
+	return s.allOrders(ctx, OrderFilter{Statuses: workingStatuses, Mode: ModeLive})
+}
+
+// reconcileOrder compares one working order with the broker, applying missed
+// fills and cancels.
+func (s *service) reconcileOrder(ctx context.Context, order Order) ([]Discrepancy, error) {
+	discrepancy := func(kind DiscrepancyKind, detail string, repaired bool) Discrepancy {
+		return Discrepancy{Kind: kind, OrderID: order.ID, ProviderOrderID: order.ProviderOrderID, Detail: detail, Repaired: repaired}
+	}
+
+	fills, err := s.broker.Executions(ctx, order.ProviderOrderID)
+	if errors.Is(err, ErrUnknownBrokerOrder) {
+		return []Discrepancy{discrepancy(DiscrepancyUnknownProviderOrder, "broker does not know the order", false)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("list broker executions: %w", err)
+	}
+	recorded, err := s.repo.Executions(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(recorded))
+	for _, execution := range recorded {
+		seen[execution.ID] = true
+	}
+
+	var found []Discrepancy
+	for _, fill := range fills {
+		if fill.ExecutionID == "" || seen[fill.ExecutionID] {
+			// Fills without an id cannot be matched; the filled quantity
+			// check below still flags them.
+			continue
+		}
+		fill.ClientOrderID = order.ID
+		err := s.HandleFill(ctx, fill)
+		if errors.Is(err, ErrDuplicateExecution) {
+			// The fill stream delivered it since the executions were read.
+			continue
+		}
+		detail := fmt.Sprintf("execution %s for %g @ %g was not applied", fill.ExecutionID, fill.Quantity, fill.Price)
+		if err != nil {
+			detail += ": " + err.Error()
+		}
+		found = append(found, discrepancy(DiscrepancyMissedFill, detail, err == nil))
+	}
+
+	state, err := s.broker.Query(ctx, order.ProviderOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("query broker order: %w", err)
+	}
+	current, err := s.repo.Get(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if state.FilledQuantity > current.FilledQuantity {
+		found = append(found, discrepancy(DiscrepancyFilledQuantity,
+			fmt.Sprintf("broker reports %g filled, executions account for %g", state.FilledQuantity, current.FilledQuantity), false))
+		return found, nil
+	}
+	if !state.Open && !current.Status.Terminal() {
+		reason := fmt.Sprintf("closed at the broker with %g of %g filled", state.FilledQuantity, current.Quantity)
+		repaired, err := s.closeAtBroker(ctx, order.ID, reason)
+		detail := reason
+		if err != nil {
+			detail += ": " + err.Error()
+		}
+		if repaired || err != nil {
+			found = append(found, discrepancy(DiscrepancyMissedCancel, detail, repaired))
+		}
+	}
+	return found, nil
+}
+
+// confirmPlacement adopts the broker order listed for an order whose
+// placement outcome was unknown. state is zero when the broker lists none: the
+// order either never reached the broker or has already closed there, and its
+// fills, if any, are still to arrive. Once that has lasted unconfirmedTimeout
+// the order is rejected as never placed.
+func (s *service) confirmPlacement(ctx context.Context, order Order, state BrokerOrderState) (Order, Discrepancy) {
+	found := Discrepancy{Kind: DiscrepancyUnconfirmedPlacement, OrderID: order.ID}
+	if state.ProviderOrderID == "" {
+		found.Detail = "placement outcome unknown and the broker lists no open order for it"
+		if s.unconfirmedTimeout > 0 && !s.now().Before(order.UpdatedAt.Add(s.unconfirmedTimeout)) {
+			return s.abandonPlacement(ctx, order, found)
+		}
+		return order, found
+	}
+	found.ProviderOrderID = state.ProviderOrderID
+
+	unlock := s.locks.Lock(order.ID)
+	defer unlock()
+	current, err := s.repo.Get(ctx, order.ID)
+	if err != nil {
+		found.Detail = "adopting the broker order: " + err.Error()
+		return order, found
+	}
+	found.Detail = "broker holds the order"
+	found.Repaired = true
+	if current.ProviderOrderID != "" {
+		// A fill reported the provider order id since the orders were read.
+		return current, found
+	}
+	now := s.now()
+	entry := Transition{
+		OrderID: current.ID,
+		From:    current.Status,
+		To:      current.Status,
+		Reason:  "placement confirmed by broker as " + state.ProviderOrderID,
+		At:      now,
+	}
+	current.ProviderOrderID = state.ProviderOrderID
+	current.UpdatedAt = now
+	if err := s.repo.Transition(ctx, current, entry); err != nil {
+		found.Detail, found.Repaired = "adopting the broker order: "+err.Error(), false
+		return order, found
+	}
+	s.updated(ctx, current, entry, nil)
+	return current, found
+}
+
+// abandonPlacement rejects an unconfirmed order the broker has not listed
+// for unconfirmedTimeout, releasing its open-order slot and its parent or
+// bracket. A fill arriving later is still applied as a late fill.
+func (s *service) abandonPlacement(ctx context.Context, order Order, found Discrepancy) (Order, Discrepancy) {
+	unlock := s.locks.Lock(order.ID)
+	defer unlock()
+	current, err := s.repo.Get(ctx, order.ID)
+	if err != nil {
+		found.Detail += "; rejecting it: " + err.Error()
+		return order, found
+	}
+	if !s.unconfirmed(current) {
+		// Adopted or closed since the orders were read.
+		return current, found
+	}
+	reason := fmt.Sprintf("never placed: the broker listed no order for it within %s", s.unconfirmedTimeout)
+	if err := s.transition(ctx, &current, StatusRejected, reason, rejectionEvent(RejectionBrokerReject, reason)); err != nil {
+		found.Detail += "; rejecting it: " + err.Error()
+		return order, found
+	}
+	found.Detail, found.Repaired = reason, true
+	return current, found
+}
+
+// withdrawAdopted cancels an adopted child whose withdrawal was held back
+// while its placement was unconfirmed because its parent has closed. Exit
+// legs are settled with the other brackets at the end of the run, and expired
+// orders are left to the next expiry sweep.
+func (s *service) withdrawAdopted(ctx context.Context, order Order) {
+	if order.ParentID == "" {
+		return
+	}
+	parent, err := s.repo.Get(ctx, order.ParentID)
+	if err != nil || !parent.Status.Terminal() {
+		return
+	}
+	if err := s.cancelChild(ctx, order.ID, fmt.Sprintf("parent order %s", parent.Status)); err != nil {
+		s.logger.Warn("failed to cancel child order", "order_id", order.ID, "parent_id", parent.ID, "error", err)
+	}
+}
+
+// closeAtBroker cancels a working order the broker has already closed,
+// without calling the broker again.
+func (s *service) closeAtBroker(ctx context.Context, id, reason string) (bool, error) {
+	unlock := s.locks.Lock(id)
+	defer unlock()
+
+	order, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	if !order.Status.CanTransitionTo(StatusCancelled) {
+		return false, nil
+	}
+	err = s.transition(ctx, &order, StatusCancelled, reason, Event{
+		Type:        EventCancel,
+		Reason:      reason,
+		InitiatedBy: InitiatedByBroker,
+	})
+	return err == nil, err
+}
+
+// RunReconciler calls svc.Reconcile every interval until ctx is cancelled.
+func RunReconciler(ctx context.Context, svc Service, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run, err := svc.Reconcile(ctx)
+			if err != nil {
+				logger.Error("reconciliation run failed", "run_id", run.ID, "error", err)
+				continue
+			}
+			if len(run.Discrepancies) > 0 {
+				logger.Warn("reconciliation found discrepancies", "run_id", run.ID, "orders_checked", run.OrdersChecked, "discrepancies", len(run.Discrepancies))
+			}
+		}
+	}
+}
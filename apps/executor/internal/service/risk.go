@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RiskRequest describes the exposure an intent would add, as sent to the risk engine.
+type RiskRequest struct {
+	BotID     string
+	AccountID string
+	Symbol    string
+	Side      string
+	Quantity  float64
+	Price     float64
+	// Mode lets checkers keep paper exposure apart from live exposure.
+	Mode Mode
+}
+
+// RiskDecision is the risk engine's verdict on a RiskRequest.
+type RiskDecision struct {
+	Allowed bool
+	Reason  string
+}
+
+// RiskChecker evaluates intents before they are routed to the broker.
+type RiskChecker interface {
+	Check(ctx context.Context, req RiskRequest) (RiskDecision, error)
+}
+
+// RiskCheckerFunc allows using bare functions as in-process risk checkers.
+type RiskCheckerFunc func(context.Context, RiskRequest) (RiskDecision, error)
+
+// Check implements RiskChecker.
+func (fn RiskCheckerFunc) Check(ctx context.Context, req RiskRequest) (RiskDecision, error) {
+	return fn(ctx, req)
+}
+
+// RiskPolicy controls how the service behaves when the risk engine cannot
+// answer.
+type RiskPolicy struct {
+	// Timeout bounds each risk check. Zero disables the deadline.
+	Timeout time.Duration
+	// FailOpen routes orders when the risk engine errors or times out. The
+	// default (fail-closed) rejects them.
+	FailOpen bool
+}
+
+// WithRiskChecker consults checker on every intent before routing.
+func WithRiskChecker(checker RiskChecker, policy RiskPolicy) Option {
+	return func(s *service) {
+		s.risk = checker
+		s.riskPolicy = policy
+	}
+}
+
+// checkRisk returns a non-nil rejection when the order must not be routed.
+func (s *service) checkRisk(ctx context.Context, order Order) *Event {
+	if s.risk == nil {
+		return nil
+	}
+	checkCtx := ctx
+	if s.riskPolicy.Timeout > 0 {
+		var cancel context.CancelFunc
+		checkCtx, cancel = context.WithTimeout(ctx, s.riskPolicy.Timeout)
+		defer cancel()
+	}
+
+	decision, err := s.risk.Check(checkCtx, RiskRequest{
+		BotID:     order.BotID,
+		AccountID: order.AccountID,
+		Symbol:    order.Symbol,
+		Side:      order.Side,
+		Quantity:  order.Quantity,
+		Price:     order.Price,
+		Mode:      order.Mode,
+	})
+	if err != nil {
+		if s.riskPolicy.FailOpen {
+			s.logger.Warn("risk check failed, routing order under fail-open policy", "order_id", order.ID, "error", err)
+			return nil
+		}
+		category := RejectionSystemError
+		if errors.Is(err, context.DeadlineExceeded) {
+			category = RejectionTimeout
+		}
+		return &Event{Type: EventRejection, Category: category, Reason: "risk check unavailable: " + err.Error()}
+	}
+	if !decision.Allowed {
+		reason := decision.Reason
+		if reason == "" {
+			reason = "rejected by risk engine"
+		}
+		return &Event{Type: EventRejection, Category: RejectionRiskLimit, Reason: reason}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Route is a broker adapter that may take an order and why it was chosen.
+type Route struct {
+	Broker string `json:"broker"`
+	Reason string `json:"reason"`
+}
+
+// OrderRouter spreads live orders over several named broker adapters.
+type OrderRouter interface {
+	// Routes returns the brokers that may take order, most preferred first.
+	// It returns an error, whose message is the rejection reason, when no
+	// broker can take it.
+	Routes(ctx context.Context, order Order) ([]Route, error)
+	// Venue returns the broker adapter named name, nil when there is none.
+	Venue(name string) Broker
+}
+
+// WithOrderRouter places live orders with the broker router chooses and
+// records the route on the order. Requests about routed orders go to the
+// broker that holds them; the broker registered with WithBroker still serves
+// reconciliation and orders placed before routing was enabled.
+func WithOrderRouter(router OrderRouter) Option {
+	return func(s *service) {
+		s.router = router
+	}
+}
+
+// placeRouted places a live order with the first route that takes it. A
+// broker that provably never received the request is skipped for the next
+// route and a rejection rejects the order. A placement with an unknown
+// outcome stays with its broker, as the broker may hold the order.
+func (s *service) placeRouted(ctx context.Context, order *Order) error {
+	routes, err := s.router.Routes(ctx, *order)
+	if err == nil && len(routes) == 0 {
+		err = errors.New("no broker can take the order")
+	}
+	if err != nil {
+		reason := fmt.Sprintf("no route: %v", err)
+		return s.transition(ctx, order, StatusRejected, reason, rejectionEvent(RejectionBrokerReject, reason))
+	}
+
+	var unavailable []string
+	for _, route := range routes {
+		venue := s.router.Venue(route.Broker)
+		if venue == nil {
+			unavailable = append(unavailable, route.Broker+": unknown broker")
+			continue
+		}
+		ack, err := venue.Place(ctx, *order)
+		if errors.Is(err, ErrBrokerUnavailable) {
+			s.logger.Warn("routed broker unavailable, trying the next route", "order_id", order.ID, "broker", route.Broker, "error", err)
+			unavailable = append(unavailable, fmt.Sprintf("%s: %v", route.Broker, err))
+			continue
+		}
+		if errors.Is(err, ErrBrokerRejected) {
+			reason := fmt.Sprintf("broker %s rejected order: %v", route.Broker, err)
+			return s.transition(ctx, order, StatusRejected, reason, rejectionEvent(RejectionBrokerReject, reason))
+		}
+		order.Route = route.Broker
+		order.RouteReason = route.Reason
+		if len(unavailable) > 0 {
+			order.RouteReason += "; failed over from " + strings.Join(unavailable, ", ")
+		}
+		if err != nil {
+			return s.placementUnknown(ctx, order, err)
+		}
+		order.ProviderOrderID = ack.ProviderOrderID
+		return s.transition(ctx, order, StatusRouted, "placed with broker "+route.Broker)
+	}
+	reason := "no routed broker available: " + strings.Join(unavailable, "; ")
+	return s.transition(ctx, order, StatusRejected, reason, rejectionEvent(RejectionBrokerReject, reason))
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"strings"
 	"time"
 )
@@ -23,73 +24,580 @@ var ErrOrderNotFound = errors.New("order not found")
 
 // OrderRepository describes the persistence contract for orders.
 type OrderRepository interface {
-	Create(ctx context.Context, order Order) error
+	// Create persists a new order and records its initial status in the
+	// transition log. It returns ErrDuplicateIntent when the bot already has an
+	// order for the same intent id, and checks guard in the same unit of work
+	// as the insert.
+	//
+	// Create, Transition and RecordExecution store the given events in the
+	// outbox within the same unit of work as the state change; see WithOutbox.
+	Create(ctx context.Context, order Order, guard CreateGuard, events ...Event) error
 	Get(ctx context.Context, id string) (Order, error)
+	// GetByIntent returns the order created for a bot's intent id, or
+	// ErrOrderNotFound.
+	GetByIntent(ctx context.Context, botID, intentID string) (Order, error)
+	// LastSequence returns the highest intent sequence stored for the bot and
+	// account, or zero when none has been recorded.
+	LastSequence(ctx context.Context, botID, accountID string) (uint64, error)
+	// Transition stores the order in its new status together with the
+	// transition that produced it.
+	Transition(ctx context.Context, order Order, transition Transition, events ...Event) error
+	// Transitions returns the status history of an order, oldest first.
+	Transitions(ctx context.Context, orderID string) ([]Transition, error)
+	// RecordExecution stores a fill, the order state it produced and the
+	// accompanying transition in one unit. It returns ErrDuplicateExecution,
+	// storing nothing, when the execution id is already recorded.
+	RecordExecution(ctx context.Context, order Order, execution Execution, transition Transition, events ...Event) error
+	// AppendEvents stores events that are not tied to a state change in the
+	// outbox.
+	AppendEvents(ctx context.Context, events ...Event) error
+	// Expiring returns the non-terminal orders whose expires_at is at or
+	// before the given time.
+	Expiring(ctx context.Context, at time.Time) ([]Order, error)
+	// Scheduled returns the child orders still in status new whose
+	// scheduled_at is at or before the given time, earliest first.
+	Scheduled(ctx context.Context, at time.Time) ([]Order, error)
+	// ListOrders returns up to query.Limit orders matching the query, in the
+	// requested creation-time order.
+	ListOrders(ctx context.Context, query OrderQuery) ([]Order, error)
+	// Executions returns the fills recorded for an order, oldest first.
+	Executions(ctx context.Context, orderID string) ([]Execution, error)
+}
+
+// CreateGuard holds the checks Create makes atomically with the insert, so
+// concurrent submissions, from this executor or another sharing the store,
+// cannot both pass them.
+type CreateGuard struct {
+	// Sequence requires the order's intent sequence to exceed every sequence
+	// stored for its bot and account; Create returns a SequenceError
+	// otherwise.
+	Sequence bool
+	// OpenLimits cap the bot's and account's open orders; Create returns a
+	// ThrottleError for the first one the order would exceed.
+	OpenLimits []OpenOrderLimit
+}
+
+// OpenOrderLimit caps the orders of a bot or account not yet in a terminal
+// status. Algorithm children count through their parent and bracket exits
+// through their entry, so neither is counted.
+type OpenOrderLimit struct {
+	// Scope is "bot" or "account" and Key the bot or account id.
+	Scope string
+	Key   string
+	Max   int
 }
 
 // OrderIntent represents the payload required to submit an order from a bot.
+// IntentID and Sequence are optional; when present they make resubmission
+// idempotent and let the executor detect lost or reordered intents.
 type OrderIntent struct {
-	BotID    string  `json:"bot_id"`
-	Symbol   string  `json:"symbol"`
-	Side     string  `json:"side"`
-	Quantity float64 `json:"quantity"`
-	Price    float64 `json:"price"`
+	IntentID    string      `json:"intent_id,omitempty"`
+	Sequence    uint64      `json:"sequence,omitempty"`
+	BotID       string      `json:"bot_id"`
+	AccountID   string      `json:"account_id,omitempty"`
+	Symbol      string      `json:"symbol"`
+	Side        string      `json:"side"`
+	Quantity    float64     `json:"quantity"`
+	Price       float64     `json:"price"`
+	Type        OrderType   `json:"type,omitempty"`
+	TimeInForce TimeInForce `json:"time_in_force,omitempty"`
+	StopPrice   float64     `json:"stop_price,omitempty"`
+	ExpiresAt   *time.Time  `json:"expires_at,omitempty"`
+	// Algorithm, when set, makes the intent a parent order that the executor
+	// slices into child orders; see algo.go.
+	Algorithm           Algorithm `json:"algorithm,omitempty"`
+	AlgoDurationSeconds int64     `json:"algo_duration_seconds,omitempty"`
+	AlgoSlices          int       `json:"algo_slices,omitempty"`
+	DisplayQuantity     float64   `json:"display_quantity,omitempty"`
+	// TakeProfitPrice and StopLossPrice attach bracket exits to the order;
+	// see bracket.go.
+	TakeProfitPrice float64 `json:"take_profit_price,omitempty"`
+	StopLossPrice   float64 `json:"stop_loss_price,omitempty"`
 }
 
 // Order describes the status of an order after processing.
 type Order struct {
-	ID        string    `json:"id"`
-	BotID     string    `json:"bot_id"`
-	Symbol    string    `json:"symbol"`
-	Side      string    `json:"side"`
-	Quantity  float64   `json:"quantity"`
-	Price     float64   `json:"price"`
-	Status    string    `json:"status"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              string      `json:"id"`
+	IntentID        string      `json:"intent_id,omitempty"`
+	Sequence        uint64      `json:"sequence,omitempty"`
+	BotID           string      `json:"bot_id"`
+	AccountID       string      `json:"account_id,omitempty"`
+	Symbol          string      `json:"symbol"`
+	Side            string      `json:"side"`
+	Quantity        float64     `json:"quantity"`
+	Price           float64     `json:"price"`
+	Type            OrderType   `json:"type"`
+	TimeInForce     TimeInForce `json:"time_in_force"`
+	StopPrice       float64     `json:"stop_price,omitempty"`
+	ExpiresAt       *time.Time  `json:"expires_at,omitempty"`
+	FilledQuantity  float64     `json:"filled_quantity"`
+	Status          Status      `json:"status"`
+	ProviderOrderID string      `json:"provider_order_id,omitempty"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+	// ParentID links a child order to the algorithmic parent that created
+	// it. Children are scheduled for ScheduledAt and report to bots only
+	// through their parent.
+	ParentID            string     `json:"parent_id,omitempty"`
+	ScheduledAt         *time.Time `json:"scheduled_at,omitempty"`
+	Algorithm           Algorithm  `json:"algorithm,omitempty"`
+	AlgoDurationSeconds int64      `json:"algo_duration_seconds,omitempty"`
+	AlgoSlices          int        `json:"algo_slices,omitempty"`
+	DisplayQuantity     float64    `json:"display_quantity,omitempty"`
+	// BracketID links the entry and exit legs of a bracket; it is the id of
+	// the entry order.
+	BracketID string `json:"bracket_id,omitempty"`
+	Leg       Leg    `json:"leg,omitempty"`
+	// Mode records whether the order trades live or on paper; see mode.go.
+	Mode Mode `json:"mode"`
+	// Route names the broker an order router placed the order with and
+	// RouteReason why; see routing.go.
+	Route       string `json:"route,omitempty"`
+	RouteReason string `json:"route_reason,omitempty"`
+}
+
+// Execution records a single fill against an order.
+type Execution struct {
+	ID       string    `json:"id"`
+	OrderID  string    `json:"order_id"`
+	Quantity float64   `json:"quantity"`
+	Price    float64   `json:"price"`
+	Fee      float64   `json:"fee"`
+	Tax      float64   `json:"tax"`
+	FilledAt time.Time `json:"filled_at"`
 }
 
 // Service exposes the executor operations.
 type Service interface {
+	// SubmitOrder accepts an intent and drives it through risk and routing.
+	// Replayed intents return the original order together with
+	// ErrDuplicateIntent; out-of-order sequences return a SequenceError.
 	SubmitOrder(ctx context.Context, intent OrderIntent) (Order, error)
 	GetOrder(ctx context.Context, id string) (Order, error)
+	GetOrderHistory(ctx context.Context, id string) ([]Transition, error)
+	// ListOrders returns one page of orders matching filter.
+	ListOrders(ctx context.Context, filter OrderFilter, page PageRequest) (OrderPage, error)
+	// GetOrderExecutions returns the fills recorded against an order.
+	GetOrderExecutions(ctx context.Context, id string) ([]Execution, error)
+	HandleFill(ctx context.Context, fill BrokerFill) error
+	// CancelOrder withdraws a working order at the broker and moves it to
+	// cancelled, publishing an OrderCancel event.
+	CancelOrder(ctx context.Context, id string, req CancelRequest) (Order, error)
+	// AmendOrder replaces the price and/or quantity of a routed or partially
+	// filled order.
+	AmendOrder(ctx context.Context, id string, req AmendRequest) (Order, error)
+	// ExpireOrders moves working orders past their expires_at deadline to
+	// expired and returns how many were expired.
+	ExpireOrders(ctx context.Context) (int, error)
+	// Reconcile compares working orders with the broker, repairs missed
+	// fills and cancels, and records the run.
+	Reconcile(ctx context.Context) (ReconciliationRun, error)
+	// ReconciliationRuns returns the most recent reconciliation runs.
+	ReconciliationRuns(ctx context.Context, limit int) ([]ReconciliationRun, error)
+	// GetParentProgress returns an algorithm parent with its child orders.
+	GetParentProgress(ctx context.Context, id string) (ParentProgress, error)
+	// ReleaseChildOrders routes child orders whose scheduled time has come
+	// and returns how many were released.
+	ReleaseChildOrders(ctx context.Context) (int, error)
+	// RateLimitStatus returns the rate limit policy and how often each bot
+	// and account has been throttled.
+	RateLimitStatus(ctx context.Context) (RateLimitStatus, error)
+	// CloseSession cancels the working DAY orders and records the end-of-day
+	// summary of the trading day.
+	CloseSession(ctx context.Context) (SessionSummary, error)
+	// SessionSummaries returns the most recent end-of-day summaries.
+	SessionSummaries(ctx context.Context, limit int) ([]SessionSummary, error)
+}
+
+// Option customises the executor service.
+type Option func(*service)
+
+// WithEventPublisher registers a sink for order lifecycle events. It may be
+// supplied multiple times; events are delivered to every publisher in order.
+func WithEventPublisher(p EventPublisher) Option {
+	return func(s *service) {
+		if p != nil {
+			s.publishers = append(s.publishers, p)
+		}
+	}
+}
+
+// WithBroker routes accepted orders to the provided broker adapter. Without a
+// broker, orders rest in the routed state.
+func WithBroker(b Broker) Option {
+	return func(s *service) {
+		s.broker = b
+	}
+}
+
+// WithLogger configures the logger used for non-fatal background failures.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *service) {
+		if logger != nil {
+			s.logger = logger
+		}
+	}
 }
 
 type service struct {
-	repo OrderRepository
-	now  func() time.Time
+	repo       OrderRepository
+	now        func() time.Time
+	broker     Broker
+	risk       RiskChecker
+	rules      InstrumentRules
+	riskPolicy RiskPolicy
+	publishers []EventPublisher
+	outbox     bool
+	// reconciliations stores reconciliation runs; nil keeps none.
+	reconciliations ReconciliationLog
+	logger          *slog.Logger
+	locks           *keyedMutex
+	residuals       *residuals
+	clock           SessionClock
+	// throttle enforces rate limits; nil leaves bots unlimited.
+	throttle *throttle
+	modes    ExecutionModes
+	updates  []OrderUpdateSink
+	// fees prices fills; nil leaves them free.
+	fees FeeModel
+	// paper receives paper orders; nil leaves them resting.
+	paper Broker
+	// sessions gates intents on trading hours; nil accepts them always.
+	sessions   TradingSessions
+	sessionLog SessionLog
+	// router chooses the broker of live orders; nil sends them all to broker.
+	router OrderRouter
+	// unconfirmedTimeout bounds how long reconciliation waits for an
+	// unconfirmed placement to show up at the broker; zero waits forever.
+	unconfirmedTimeout time.Duration
 }
 
 // New constructs an executor service.
-func New(repo OrderRepository, now func() time.Time) Service {
+func New(repo OrderRepository, now func() time.Time, opts ...Option) Service {
 	if now == nil {
 		now = time.Now
 	}
-	return &service{
-		repo: repo,
-		now:  func() time.Time { return now().UTC() },
+	s := &service{
+		repo:               repo,
+		now:                func() time.Time { return now().UTC() },
+		logger:             slog.Default(),
+		locks:              newKeyedMutex(),
+		residuals:          newResiduals(),
+		clock:              continuousClock{},
+		unconfirmedTimeout: DefaultUnconfirmedTimeout,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 func (s *service) SubmitOrder(ctx context.Context, intent OrderIntent) (Order, error) {
-	if err := validateIntent(intent); err != nil {
+	if err := validateIntent(intent, s.now()); err != nil {
+		s.reject(ctx, orderFromIntent(intent), RejectionUnspecified, err.Error())
+		return Order{}, err
+	}
+
+	order := orderFromIntent(intent)
+	order.Mode = s.modes.For(order.BotID, order.AccountID)
+	if order.IntentID != "" {
+		unlockIntent := s.locks.Lock("intent:" + order.BotID + "/" + order.IntentID)
+		defer unlockIntent()
+	}
+	existing, replayed, err := s.replay(ctx, order)
+	if err != nil {
+		return Order{}, err
+	}
+	if replayed {
+		return existing, ErrDuplicateIntent
+	}
+	if err := s.throttleOrder(order); err != nil {
+		if errors.Is(err, ErrThrottled) {
+			s.reject(ctx, order, RejectionRateLimited, err.Error())
+		}
 		return Order{}, err
 	}
 
 	now := s.now()
-	order := Order{
-		ID:        fmt.Sprintf("ord-%s", now.Format("20060102150405")),
-		BotID:     strings.TrimSpace(intent.BotID),
-		Symbol:    strings.TrimSpace(intent.Symbol),
-		Side:      strings.ToLower(strings.TrimSpace(intent.Side)),
-		Quantity:  intent.Quantity,
-		Price:     intent.Price,
-		Status:    "accepted",
-		UpdatedAt: now,
-	}
-
-	if err := s.repo.Create(ctx, order); err != nil {
+	order.ID = newOrderID()
+	order.Status = StatusNew
+	order.CreatedAt = now
+	order.UpdatedAt = now
+	if bracketed(intent) {
+		order.BracketID = order.ID
+		order.Leg = LegEntry
+	}
+
+	order, err = s.accept(ctx, intent, order)
+	if err == nil && order.Algorithm != "" && order.Status == StatusRouted {
+		s.releaseDue(ctx, order.ID)
+		order, err = s.repo.Get(ctx, order.ID)
+	}
+	return order, err
+}
+
+// accept persists a new order and takes it through the checks to the broker,
+// or to its child orders when it uses an execution algorithm.
+func (s *service) accept(ctx context.Context, intent OrderIntent, order Order) (Order, error) {
+	unlock := s.locks.Lock(order.ID)
+	defer unlock()
+
+	now := order.CreatedAt
+
+	ack := s.stage(ctx, order, []Event{{Type: EventAck, OccurredAt: now}})
+	if err := s.create(ctx, order, ack); err != nil {
+		if errors.Is(err, ErrDuplicateIntent) {
+			// Another instance created the order between the lookup and insert.
+			if existing, replayed, rerr := s.replay(ctx, order); rerr == nil && replayed {
+				return existing, ErrDuplicateIntent
+			}
+			return Order{}, err
+		}
+		if errors.Is(err, ErrSequenceRegression) {
+			s.reject(ctx, orderFromIntent(intent), RejectionUnspecified, err.Error())
+			return Order{}, err
+		}
+		var throttled ThrottleError
+		if errors.As(err, &throttled) {
+			err = s.throttle.record(throttled, s.now())
+			s.reject(ctx, order, RejectionRateLimited, err.Error())
+			return Order{}, err
+		}
+		s.reject(ctx, order, RejectionSystemError, "failed to persist order")
+		return Order{}, err
+	}
+	s.deliver(ctx, ack)
+
+	if err := s.transition(ctx, &order, StatusPendingRisk, "intent accepted"); err != nil {
+		return Order{}, err
+	}
+	var children, legs []Order
+	rejection := s.checkSession(now)
+	if rejection == nil {
+		rejection = s.checkInstrument(order)
+	}
+	if rejection == nil && order.Algorithm != "" {
+		children = s.planChildren(order, now)
+		rejection = s.checkChildren(children)
+	}
+	if rejection == nil && order.Leg == LegEntry {
+		legs = planLegs(intent, order, now)
+		rejection = s.checkLegs(legs)
+	}
+	if rejection == nil {
+		rejection = s.checkRisk(ctx, order)
+	}
+	if rejection != nil {
+		if err := s.transition(ctx, &order, StatusRejected, rejection.Reason, *rejection); err != nil {
+			return Order{}, err
+		}
+		return order, nil
+	}
+	if order.Algorithm != "" {
+		if err := s.startAlgo(ctx, &order, children); err != nil {
+			return Order{}, err
+		}
+		return order, nil
+	}
+	if err := s.createLegs(ctx, legs); err != nil {
+		return Order{}, err
+	}
+	if err := s.route(ctx, &order); err != nil {
+		return Order{}, err
+	}
+	if err := s.settleImmediate(ctx, &order); err != nil {
+		return Order{}, err
+	}
+	return order, nil
+}
+
+// create persists the new order, checking its intent sequence and the
+// open-order limits in the same unit of work.
+func (s *service) create(ctx context.Context, order Order, events []Event) error {
+	s.warnSequenceGap(ctx, order)
+	guard := CreateGuard{Sequence: order.Sequence > 0, OpenLimits: s.openOrderLimits(order)}
+	if err := s.repo.Create(ctx, order, guard, s.outboxed(events)...); err != nil {
+		return err
+	}
+	s.created(ctx, order)
+	return nil
+}
+
+// route hands the order to the broker and records the outcome. Broker
+// rejections are terminal for the order but not an error for the caller; a
+// placement with an unknown outcome leaves the order routed for
+// reconciliation.
+func (s *service) route(ctx context.Context, order *Order) error {
+	if s.router != nil && order.Mode != ModePaper {
+		return s.placeRouted(ctx, order)
+	}
+	venue := s.brokerFor(*order)
+	if venue == nil {
+		return s.transition(ctx, order, StatusRouted, "awaiting execution")
+	}
+
+	ack, err := venue.Place(ctx, *order)
+	if err != nil && placementFailed(err) {
+		reason := fmt.Sprintf("broker rejected order: %v", err)
+		return s.transition(ctx, order, StatusRejected, reason, rejectionEvent(RejectionBrokerReject, reason))
+	}
+	if err != nil {
+		return s.placementUnknown(ctx, order, err)
+	}
+	order.ProviderOrderID = ack.ProviderOrderID
+	return s.transition(ctx, order, StatusRouted, "placed with broker")
+}
+
+// placementUnknown records an order the broker may or may not have taken,
+// such as one whose placement timed out. Rejecting it would lose the position
+// if the venue did accept it, so it stays routed without a provider order id:
+// fills streamed for it are applied by order id, and reconciliation adopts the
+// broker's order once it is listed.
+func (s *service) placementUnknown(ctx context.Context, order *Order, err error) error {
+	s.logger.Warn("broker placement outcome unknown, awaiting reconciliation", "order_id", order.ID, "error", err)
+	return s.transition(ctx, order, StatusRouted, fmt.Sprintf("placement unconfirmed: %v", err))
+}
+
+// unconfirmed reports whether a live order's placement outcome is still
+// unknown. Algorithm parents are never placed themselves.
+func (s *service) unconfirmed(order Order) bool {
+	return order.Status == StatusRouted && order.ProviderOrderID == "" && order.Algorithm == "" && s.brokerFor(order) != nil
+}
+
+// errUnconfirmed refuses to withdraw or change an order whose placement is
+// unconfirmed: the broker may be working it, so it is left to reconciliation
+// or an operator's forced cancel.
+func errUnconfirmed(order Order) error {
+	return fmt.Errorf("%w: placement of order %s is unconfirmed until reconciliation finds it at the broker or it is force-cancelled", ErrBrokerRequest, order.ID)
+}
+
+func (s *service) HandleFill(ctx context.Context, fill BrokerFill) error {
+	if fill.Quantity <= 0 {
+		return ValidationError{Reason: "fill quantity must be greater than zero"}
+	}
+	order, err := s.fill(ctx, fill)
+	// Bracket follow-ups take other legs' locks, so they run once the filled
+	// order is unlocked.
+	switch {
+	case order.Leg == LegEntry && order.Status == StatusFilled:
+		s.entryClosed(ctx, order)
+	case order.Leg == LegEntry && order.Status.Terminal():
+		s.entryFilledLate(ctx, order)
+	case order.Leg.Exit():
+		s.legFilled(ctx, order)
+	}
+	return err
+}
+
+// fill applies a broker fill under the order lock. The returned order is
+// zero when nothing was applied.
+func (s *service) fill(ctx context.Context, fill BrokerFill) (Order, error) {
+	unlock := s.locks.Lock(fill.ClientOrderID)
+	defer unlock()
+
+	order, err := s.repo.Get(ctx, fill.ClientOrderID)
+	if err != nil {
 		return Order{}, err
 	}
+	if fill.ExecutionID != "" {
+		recorded, err := s.repo.Executions(ctx, order.ID)
+		if err != nil {
+			return Order{}, err
+		}
+		for _, execution := range recorded {
+			if execution.ID == fill.ExecutionID {
+				return Order{}, ErrDuplicateExecution
+			}
+		}
+	}
+
+	next := StatusPartiallyFilled
+	filled := order.FilledQuantity + fill.Quantity
+	if filled >= order.Quantity {
+		filled = order.Quantity
+		next = StatusFilled
+	}
+	// A fill that was in flight when the order was cancelled or expired still
+	// happened at the venue: it is recorded and booked, and the order stays
+	// closed.
+	late := order.Status == StatusCancelled || order.Status == StatusExpired
+	if late {
+		next = order.Status
+	} else if !order.Status.CanTransitionTo(next) {
+		return Order{}, TransitionError{OrderID: order.ID, From: order.Status, To: next}
+	}
+
+	filledAt := fill.FilledAt
+	if filledAt.IsZero() {
+		filledAt = s.now()
+	}
+	execution := Execution{
+		ID:       fill.ExecutionID,
+		OrderID:  order.ID,
+		Quantity: fill.Quantity,
+		Price:    fill.Price,
+		FilledAt: filledAt,
+	}
+	if execution.ID == "" {
+		execution.ID = fmt.Sprintf("%s-%s", order.ID, filledAt.Format("20060102150405.000000000"))
+	}
+	if s.fees != nil {
+		charges := s.fees.Charges(order, fill.Quantity, fill.Price)
+		execution.Fee, execution.Tax = charges.Fee, charges.Tax
+	}
+
+	now := s.now()
+	transition := Transition{
+		OrderID: order.ID,
+		From:    order.Status,
+		To:      next,
+		Reason:  fmt.Sprintf("filled %g @ %g", fill.Quantity, fill.Price),
+		At:      now,
+	}
+	remaining := order.Quantity - filled
+	if late {
+		transition.Reason = fmt.Sprintf("late fill %g @ %g after the order was %s", fill.Quantity, fill.Price, order.Status)
+		remaining = 0
+	}
+	order.Status = next
+	order.FilledQuantity = filled
+	order.UpdatedAt = now
+	if order.ProviderOrderID == "" {
+		order.ProviderOrderID = fill.ProviderOrderID
+	}
+	events := s.stage(ctx, order, []Event{{
+		Type: EventFill,
+		Fill: &Fill{
+			ProviderOrderID: order.ProviderOrderID,
+			Quantity:        fill.Quantity,
+			Remaining:       remaining,
+			Price:           fill.Price,
+			Fee:             execution.Fee,
+			Tax:             execution.Tax,
+			FilledAt:        filledAt,
+		},
+		OccurredAt: now,
+	}})
+	if err := s.repo.RecordExecution(ctx, order, execution, transition, s.outboxed(events)...); err != nil {
+		return Order{}, fmt.Errorf("record execution: %w", err)
+	}
+	s.deliver(ctx, events)
+	s.updated(ctx, order, transition, &execution)
+	if order.ParentID != "" {
+		s.childUpdated(ctx, order, &Fill{
+			ProviderOrderID: order.ProviderOrderID,
+			Quantity:        fill.Quantity,
+			Price:           fill.Price,
+			Fee:             execution.Fee,
+			Tax:             execution.Tax,
+			FilledAt:        filledAt,
+		})
+	}
+
+	if next == StatusPartiallyFilled && s.residuals.take(order.ID, filled) {
+		err := s.cancelRemainder(ctx, &order)
+		return order, err
+	}
 	return order, nil
 }
 
@@ -97,7 +605,84 @@ func (s *service) GetOrder(ctx context.Context, id string) (Order, error) {
 	return s.repo.Get(ctx, id)
 }
 
-func validateIntent(intent OrderIntent) error {
+func (s *service) GetOrderHistory(ctx context.Context, id string) ([]Transition, error) {
+	if _, err := s.repo.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.Transitions(ctx, id)
+}
+
+// transition moves order to the next status, enforcing the lifecycle rules and
+// persisting the change with its timestamped history entry. The events it
+// produced are stored with the change and delivered once it is persisted.
+func (s *service) transition(ctx context.Context, order *Order, next Status, reason string, events ...Event) error {
+	if !order.Status.CanTransitionTo(next) {
+		return TransitionError{OrderID: order.ID, From: order.Status, To: next}
+	}
+	now := s.now()
+	entry := Transition{OrderID: order.ID, From: order.Status, To: next, Reason: reason, At: now}
+
+	updated := *order
+	updated.Status = next
+	updated.UpdatedAt = now
+	staged := s.stage(ctx, updated, events)
+	if err := s.repo.Transition(ctx, updated, entry, s.outboxed(staged)...); err != nil {
+		return fmt.Errorf("persist transition %s -> %s: %w", entry.From, entry.To, err)
+	}
+	*order = updated
+	s.deliver(ctx, staged)
+	s.updated(ctx, updated, entry, nil)
+	switch {
+	case updated.ParentID != "" && next.Terminal():
+		s.childUpdated(ctx, updated, nil)
+	case updated.Leg == LegEntry && next.Terminal():
+		s.entryClosed(ctx, updated)
+	}
+	return nil
+}
+
+// reject publishes a rejection for an intent that was not persisted.
+func (s *service) reject(ctx context.Context, order Order, category RejectionCategory, reason string) {
+	event := rejectionEvent(category, reason)
+	event.Order = order
+	s.publish(ctx, event)
+}
+
+func rejectionEvent(category RejectionCategory, reason string) Event {
+	return Event{Type: EventRejection, Category: category, Reason: reason}
+}
+
+func orderFromIntent(intent OrderIntent) Order {
+	return Order{
+		IntentID:    strings.TrimSpace(intent.IntentID),
+		Sequence:    intent.Sequence,
+		BotID:       strings.TrimSpace(intent.BotID),
+		AccountID:   strings.TrimSpace(intent.AccountID),
+		Symbol:      strings.TrimSpace(intent.Symbol),
+		Side:        strings.ToLower(strings.TrimSpace(intent.Side)),
+		Quantity:    intent.Quantity,
+		Price:       intent.Price,
+		Type:        orderTypeFor(intent),
+		TimeInForce: timeInForceFor(intent),
+		StopPrice:   intent.StopPrice,
+		ExpiresAt:   utcTime(intent.ExpiresAt),
+
+		Algorithm:           Algorithm(strings.ToLower(strings.TrimSpace(string(intent.Algorithm)))),
+		AlgoDurationSeconds: intent.AlgoDurationSeconds,
+		AlgoSlices:          intent.AlgoSlices,
+		DisplayQuantity:     intent.DisplayQuantity,
+	}
+}
+
+func utcTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+func validateIntent(intent OrderIntent, now time.Time) error {
 	if strings.TrimSpace(intent.BotID) == "" {
 		return ValidationError{Reason: "bot_id is required"}
 	}
@@ -111,5 +696,11 @@ func validateIntent(intent OrderIntent) error {
 	if side != "buy" && side != "sell" {
 		return ValidationError{Reason: "side must be buy or sell"}
 	}
-	return nil
+	if err := validateExecution(intent, now); err != nil {
+		return err
+	}
+	if err := validateAlgorithm(intent); err != nil {
+		return err
+	}
+	return validateBracket(intent)
 }
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// TradingSessions tells when the venue takes orders and when its trading day
+// ends.
+type TradingSessions interface {
+	// Accepting reports whether the venue takes orders at t.
+	Accepting(t time.Time) bool
+	// NextSession returns t when the venue takes orders, otherwise the start
+	// of the next session.
+	NextSession(t time.Time) time.Time
+	// NextDayClose returns the end of the first trading day closing after t.
+	NextDayClose(t time.Time) time.Time
+	// Location returns the venue time zone, which dates trading days.
+	Location() *time.Location
+}
+
+// WithTradingSessions rejects intents that arrive while the venue does not
+// take orders and dates end-of-day summaries in the venue time zone. Without
+// it intents are accepted around the clock.
+func WithTradingSessions(sessions TradingSessions) Option {
+	return func(s *service) {
+		s.sessions = sessions
+	}
+}
+
+// SessionSummary records the close of a trading day: the DAY orders cancelled
+// and the outcome of the orders created during the day.
+type SessionSummary struct {
+	ID string `json:"id"`
+	// TradingDate is the venue-local date of the trading day, YYYY-MM-DD.
+	TradingDate string    `json:"trading_date"`
+	ClosedAt    time.Time `json:"closed_at"`
+	// DayOrdersCancelled counts the DAY orders cancelled at the close.
+	DayOrdersCancelled int `json:"day_orders_cancelled"`
+	// OrdersCarried counts the orders still working after the close, which
+	// carry over to the next trading day.
+	OrdersCarried int `json:"orders_carried"`
+	// OrdersSubmitted counts the orders created during the trading day; the
+	// counts by status below break them down.
+	OrdersSubmitted int     `json:"orders_submitted"`
+	OrdersFilled    int     `json:"orders_filled"`
+	OrdersCancelled int     `json:"orders_cancelled"`
+	OrdersRejected  int     `json:"orders_rejected"`
+	OrdersExpired   int     `json:"orders_expired"`
+	Executions      int     `json:"executions"`
+	FilledQuantity  float64 `json:"filled_quantity"`
+	Fees            float64 `json:"fees"`
+	Taxes           float64 `json:"taxes"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// SessionLog persists end-of-day summaries.
+type SessionLog interface {
+	SaveSessionSummary(ctx context.Context, summary SessionSummary) error
+	// SessionSummaries returns up to limit summaries, newest first.
+	SessionSummaries(ctx context.Context, limit int) ([]SessionSummary, error)
+}
+
+// WithSessionLog stores end-of-day summaries so they can be listed.
+func WithSessionLog(log SessionLog) Option {
+	return func(s *service) {
+		s.sessionLog = log
+	}
+}
+
+// dayCloseReason is the cancel reason of DAY orders at the close.
+const dayCloseReason = "DAY order cancelled at session close"
+
+// checkSession rejects intents arriving while the venue takes no orders.
+func (s *service) checkSession(now time.Time) *Event {
+	if s.sessions == nil || s.sessions.Accepting(now) {
+		return nil
+	}
+	next := s.sessions.NextSession(now).In(s.sessions.Location())
+	return &Event{
+		Type:     EventRejection,
+		Category: RejectionMarketClosed,
+		Reason:   fmt.Sprintf("market closed: orders are accepted again from %s", next.Format("2006-01-02 15:04 MST")),
+	}
+}
+
+func (s *service) CloseSession(ctx context.Context) (SessionSummary, error) {
+	now := s.now()
+	loc := time.UTC
+	if s.sessions != nil {
+		loc = s.sessions.Location()
+	}
+	local := now.In(loc)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	summary := SessionSummary{ID: newID("eod"), TradingDate: day.Format(time.DateOnly), ClosedAt: now}
+
+	err := s.closeSession(ctx, &summary, day.UTC(), now)
+	if err != nil {
+		summary.Error = err.Error()
+	}
+	if s.sessionLog != nil {
+		if serr := s.sessionLog.SaveSessionSummary(ctx, summary); serr != nil {
+			s.logger.Warn("failed to store session summary", "summary_id", summary.ID, "error", serr)
+		}
+	}
+	return summary, err
+}
+
+func (s *service) closeSession(ctx context.Context, summary *SessionSummary, from, to time.Time) error {
+	open, err := s.allOrders(ctx, OrderFilter{Statuses: openStatuses})
+	if err != nil {
+		return fmt.Errorf("list open orders: %w", err)
+	}
+	var failed []error
+	for _, order := range open {
+		if order.TimeInForce != TimeInForceDay {
+			summary.OrdersCarried++
+			continue
+		}
+		_, err := s.CancelOrder(ctx, order.ID, CancelRequest{InitiatedBy: InitiatedBySystem, Reason: dayCloseReason})
+		var terr TransitionError
+		switch {
+		case err == nil:
+			summary.DayOrdersCancelled++
+		case errors.As(err, &terr):
+			// Filled or cancelled since it was listed.
+		default:
+			summary.OrdersCarried++
+			failed = append(failed, fmt.Errorf("order %s: %w", order.ID, err))
+		}
+	}
+
+	created, err := s.allOrders(ctx, OrderFilter{CreatedFrom: from, CreatedTo: to.Add(time.Nanosecond)})
+	if err != nil {
+		return fmt.Errorf("list orders of the day: %w", err)
+	}
+	for _, order := range created {
+		summary.OrdersSubmitted++
+		switch order.Status {
+		case StatusFilled:
+			summary.OrdersFilled++
+		case StatusCancelled:
+			summary.OrdersCancelled++
+		case StatusRejected:
+			summary.OrdersRejected++
+		case StatusExpired:
+			summary.OrdersExpired++
+		}
+		if order.FilledQuantity == 0 {
+			continue
+		}
+		executions, err := s.repo.Executions(ctx, order.ID)
+		if err != nil {
+			return fmt.Errorf("list executions of order %s: %w", order.ID, err)
+		}
+		for _, execution := range executions {
+			summary.Executions++
+			summary.FilledQuantity += execution.Quantity
+			summary.Fees += execution.Fee
+			summary.Taxes += execution.Tax
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("cancel %d DAY orders: %w", len(failed), errors.Join(failed...))
+	}
+	return nil
+}
+
+func (s *service) SessionSummaries(ctx context.Context, limit int) ([]SessionSummary, error) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		return nil, ValidationError{Reason: fmt.Sprintf("limit must not exceed %d", MaxPageSize)}
+	}
+	if s.sessionLog == nil {
+		return []SessionSummary{}, nil
+	}
+	return s.sessionLog.SessionSummaries(ctx, limit)
+}
+
+// allOrders pages through every order matching filter, oldest first.
+func (s *service) allOrders(ctx context.Context, filter OrderFilter) ([]Order, error) {
+	query := OrderQuery{Filter: filter, Limit: MaxPageSize, Ascending: true}
+	var out []Order
+	for {
+		page, err := s.repo.ListOrders(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, page...)
+		if len(page) < query.Limit {
+			return out, nil
+		}
+		last := page[len(page)-1]
+		query.After = &OrderCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+}
+
+// RunSessionCloser calls svc.CloseSession at the end of every trading day of
+// sessions until ctx is cancelled.
+func RunSessionCloser(ctx context.Context, svc Service, sessions TradingSessions, logger *slog.Logger) {
+	for {
+		next := sessions.NextDayClose(time.Now())
+		if next.IsZero() {
+			logger.Warn("session calendar has no trading day close, DAY orders are not cancelled")
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		summary, err := svc.CloseSession(ctx)
+		if err != nil {
+			logger.Error("session close failed", "summary_id", summary.ID, "trading_date", summary.TradingDate, "error", err)
+			continue
+		}
+		logger.Info("trading session closed", "summary_id", summary.ID, "trading_date", summary.TradingDate,
+			"day_orders_cancelled", summary.DayOrdersCancelled, "orders_carried", summary.OrdersCarried)
+	}
+}
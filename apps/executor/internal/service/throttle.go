@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrThrottled matches the ThrottleError returned when a bot or account has
+// exceeded one of its rate limits.
+var ErrThrottled = errors.New("rate limit exceeded")
+
+// Limit names used in ThrottleError and ThrottleCount.
+const (
+	LimitOrdersPerSecond   = "orders_per_second"
+	LimitMaxOpenOrders     = "max_open_orders"
+	LimitMessagesPerMinute = "messages_per_minute"
+)
+
+// RateLimits bounds the order flow of one bot or account. Zero fields are
+// unlimited.
+type RateLimits struct {
+	// OrdersPerSecond is the sustained rate of new orders; OrderBurst is the
+	// token-bucket size and defaults to one second's worth, at least one.
+	OrdersPerSecond float64 `json:"orders_per_second,omitempty"`
+	OrderBurst      int     `json:"order_burst,omitempty"`
+	// MaxOpenOrders caps orders not yet in a terminal status. Algorithm
+	// child orders count through their parent, and a bracket counts once,
+	// through its entry: its exits are the executor's and do not count.
+	MaxOpenOrders int `json:"max_open_orders,omitempty"`
+	// MessagesPerMinute bounds submits, cancels and amends together.
+	MessagesPerMinute float64 `json:"messages_per_minute,omitempty"`
+}
+
+// RateLimitPolicy sets the default limits for every bot and every account,
+// with overrides for individual bots and accounts. An order must fit within
+// both its bot's and its account's limits.
+type RateLimitPolicy struct {
+	Bot      RateLimits            `json:"bot"`
+	Account  RateLimits            `json:"account"`
+	Bots     map[string]RateLimits `json:"bots,omitempty"`
+	Accounts map[string]RateLimits `json:"accounts,omitempty"`
+}
+
+// WithRateLimits enforces policy on orders submitted, cancelled and amended
+// by bots. Orders the executor creates itself, such as algorithm slices and
+// bracket exits, are not limited.
+func WithRateLimits(policy RateLimitPolicy) Option {
+	return func(s *service) {
+		s.throttle = newThrottle(policy)
+	}
+}
+
+// ThrottleError reports which limit a message exceeded.
+type ThrottleError struct {
+	// Scope is "bot" or "account" and Key the bot or account id.
+	Scope string
+	Key   string
+	Limit string
+	// RetryAfter estimates when the limit frees up; zero for open orders,
+	// which free up as orders complete.
+	RetryAfter time.Duration
+}
+
+func (e ThrottleError) Error() string {
+	msg := fmt.Sprintf("%s %s exceeded %s", e.Scope, e.Key, e.Limit)
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf("; retry in %s", e.RetryAfter)
+	}
+	return msg
+}
+
+// Is allows errors.Is(err, ErrThrottled).
+func (e ThrottleError) Is(target error) bool {
+	return target == ErrThrottled
+}
+
+// ThrottleCount is how often a bot or account has hit one limit since the
+// executor started.
+type ThrottleCount struct {
+	Scope  string    `json:"scope"`
+	Key    string    `json:"key"`
+	Limit  string    `json:"limit"`
+	Count  int64     `json:"count"`
+	LastAt time.Time `json:"last_at"`
+}
+
+// RateLimitStatus is the configured policy and the throttling seen so far.
+type RateLimitStatus struct {
+	Policy    RateLimitPolicy `json:"policy"`
+	Throttled []ThrottleCount `json:"throttled"`
+}
+
+// openStatuses are the states of orders not yet terminal.
+var openStatuses = []Status{StatusNew, StatusPendingRisk, StatusRouted, StatusPartiallyFilled}
+
+type throttle struct {
+	policy RateLimitPolicy
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	counts  map[string]*ThrottleCount
+}
+
+type tokenBucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// bucketRequest asks for one token from the bucket of a scope, key and limit
+// refilling at rate per second up to burst.
+type bucketRequest struct {
+	scope, key, limit string
+	rate, burst       float64
+}
+
+func newThrottle(policy RateLimitPolicy) *throttle {
+	return &throttle{
+		policy:  policy,
+		buckets: make(map[string]*tokenBucket),
+		counts:  make(map[string]*ThrottleCount),
+	}
+}
+
+// scopedLimits are the limits of one bot or account.
+type scopedLimits struct {
+	scope, key string
+	limits     RateLimits
+}
+
+// scopes returns the limits that apply to order, bot first.
+func (t *throttle) scopes(order Order) []scopedLimits {
+	limits := t.policy.Bot
+	if override, ok := t.policy.Bots[order.BotID]; ok {
+		limits = override
+	}
+	out := []scopedLimits{{"bot", order.BotID, limits}}
+	if order.AccountID != "" {
+		limits = t.policy.Account
+		if override, ok := t.policy.Accounts[order.AccountID]; ok {
+			limits = override
+		}
+		out = append(out, scopedLimits{"account", order.AccountID, limits})
+	}
+	return out
+}
+
+func (t *throttle) messageRequests(order Order) []bucketRequest {
+	var requests []bucketRequest
+	for _, sc := range t.scopes(order) {
+		if rate := sc.limits.MessagesPerMinute; rate > 0 {
+			// A budget below one message a minute still lets one through
+			// once the bucket has refilled.
+			requests = append(requests, bucketRequest{sc.scope, sc.key, LimitMessagesPerMinute, rate / 60, math.Max(1, rate)})
+		}
+	}
+	return requests
+}
+
+func (t *throttle) orderRequests(order Order) []bucketRequest {
+	requests := t.messageRequests(order)
+	for _, sc := range t.scopes(order) {
+		if rate := sc.limits.OrdersPerSecond; rate > 0 {
+			burst := float64(sc.limits.OrderBurst)
+			if burst <= 0 {
+				burst = math.Max(1, math.Ceil(rate))
+			}
+			requests = append(requests, bucketRequest{sc.scope, sc.key, LimitOrdersPerSecond, rate, burst})
+		}
+	}
+	return requests
+}
+
+// take consumes a token from every requested bucket, or from none when any
+// of them is empty.
+func (t *throttle) take(requests []bucketRequest, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	buckets := make([]*tokenBucket, len(requests))
+	for i, req := range requests {
+		key := req.scope + "/" + req.key + "/" + req.limit
+		b, ok := t.buckets[key]
+		if !ok {
+			b = &tokenBucket{tokens: req.burst, at: now}
+			t.buckets[key] = b
+		}
+		if elapsed := now.Sub(b.at).Seconds(); elapsed > 0 {
+			b.tokens = math.Min(req.burst, b.tokens+elapsed*req.rate)
+			b.at = now
+		}
+		if b.tokens < 1 {
+			wait := time.Duration((1 - b.tokens) / req.rate * float64(time.Second))
+			return t.count(ThrottleError{Scope: req.scope, Key: req.key, Limit: req.limit, RetryAfter: wait.Round(time.Millisecond)}, now)
+		}
+		buckets[i] = b
+	}
+	for _, b := range buckets {
+		b.tokens--
+	}
+	return nil
+}
+
+func (t *throttle) record(err ThrottleError, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.count(err, now)
+}
+
+// count tallies a throttled message. Must be called with t.mu held.
+func (t *throttle) count(err ThrottleError, now time.Time) error {
+	key := err.Scope + "/" + err.Key + "/" + err.Limit
+	c, ok := t.counts[key]
+	if !ok {
+		c = &ThrottleCount{Scope: err.Scope, Key: err.Key, Limit: err.Limit}
+		t.counts[key] = c
+	}
+	c.Count++
+	c.LastAt = now
+	return err
+}
+
+// throttleOrder takes a token from each of a new order's message and order
+// buckets. The open-order caps are checked by the repository as the order is
+// created; see openOrderLimits.
+func (s *service) throttleOrder(order Order) error {
+	if s.throttle == nil {
+		return nil
+	}
+	return s.throttle.take(s.throttle.orderRequests(order), s.now())
+}
+
+// openOrderLimits returns the open-order caps a new order must fit within.
+// Counting and inserting in one unit of work keeps concurrent submissions
+// from both taking the last slot.
+func (s *service) openOrderLimits(order Order) []OpenOrderLimit {
+	if s.throttle == nil {
+		return nil
+	}
+	var limits []OpenOrderLimit
+	for _, sc := range s.throttle.scopes(order) {
+		if sc.limits.MaxOpenOrders > 0 {
+			limits = append(limits, OpenOrderLimit{Scope: sc.scope, Key: sc.key, Max: sc.limits.MaxOpenOrders})
+		}
+	}
+	return limits
+}
+
+// throttleMessage takes a message token for a cancel or amend of order.
+func (s *service) throttleMessage(order Order) error {
+	if s.throttle == nil {
+		return nil
+	}
+	return s.throttle.take(s.throttle.messageRequests(order), s.now())
+}
+
+func (s *service) RateLimitStatus(context.Context) (RateLimitStatus, error) {
+	status := RateLimitStatus{Throttled: []ThrottleCount{}}
+	if s.throttle == nil {
+		return status, nil
+	}
+	status.Policy = s.throttle.policy
+	s.throttle.mu.Lock()
+	for _, c := range s.throttle.counts {
+		status.Throttled = append(status.Throttled, *c)
+	}
+	s.throttle.mu.Unlock()
+	sort.Slice(status.Throttled, func(i, j int) bool {
+		a, b := status.Throttled[i], status.Throttled[j]
+		if a.Scope != b.Scope {
+			return a.Scope > b.Scope
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Limit < b.Limit
+	})
+	return status, nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import "context"
+
+// OrderUpdate reports one stored change to an order: a status transition,
+// including the initial one and amendments, or a fill with the transition it
+// produced. Unlike Events, updates cover every order, algorithm children
+// included.
+type OrderUpdate struct {
+	Order      Order      `json:"order"`
+	Transition Transition `json:"transition"`
+	Execution  *Execution `json:"execution,omitempty"`
+}
+
+// OrderUpdateSink receives order updates once they are persisted. It is
+// called with the order lock held and must not block.
+type OrderUpdateSink interface {
+	OrderUpdated(ctx context.Context, update OrderUpdate)
+}
+
+// WithOrderUpdates registers sink for every persisted order change. It may
+// be supplied multiple times.
+func WithOrderUpdates(sink OrderUpdateSink) Option {
+	return func(s *service) {
+		if sink != nil {
+			s.updates = append(s.updates, sink)
+		}
+	}
+}
+
+// updated hands a persisted change to the update sinks.
+func (s *service) updated(ctx context.Context, order Order, transition Transition, execution *Execution) {
+	for _, sink := range s.updates {
+		sink.OrderUpdated(ctx, OrderUpdate{Order: order, Transition: transition, Execution: execution})
+	}
+}
+
+// created reports a newly stored order in its initial status.
+func (s *service) created(ctx context.Context, order Order) {
+	s.updated(ctx, order, Transition{OrderID: order.ID, To: order.Status, At: order.CreatedAt}, nil)
+}
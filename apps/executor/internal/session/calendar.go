@@ -0,0 +1,214 @@
+// Package session models exchange trading hours so schedules can be expressed
+// in trading time rather than wall-clock time.
+package session
+
+import (
+	"sort"
+	"time"
+)
+
+// Window is one continuous trading session within a day, as offsets from
+// local midnight.
+type Window struct {
+	Open  time.Duration
+	Close time.Duration
+}
+
+// Calendar describes the weekly trading sessions of a venue. Exchange holidays
+// are not modelled.
+type Calendar struct {
+	loc     *time.Location
+	windows []Window
+	// auctions are sessions that take orders without continuous matching.
+	auctions []Window
+}
+
+// NewCalendar builds a calendar trading the given windows, in ascending order,
+// Monday to Friday in loc.
+func NewCalendar(loc *time.Location, windows ...Window) *Calendar {
+	return &Calendar{loc: loc, windows: windows}
+}
+
+// WithAuctions returns a copy of c that also takes orders during the given
+// auction windows. Auctions do not count as trading time for Open, Next and
+// Add, but extend Accepting and the trading day.
+func (c *Calendar) WithAuctions(auctions ...Window) *Calendar {
+	out := *c
+	out.auctions = append(append([]Window(nil), c.auctions...), auctions...)
+	return &out
+}
+
+// vietnam is Indochina Time; Vietnam observes no daylight saving.
+var vietnam = time.FixedZone("ICT", 7*60*60)
+
+// HOSEDerivatives returns the continuous matching sessions of the HNX/HOSE
+// derivatives market: 09:00-11:30 and 13:00-14:30 ICT, with the lunch break in
+// between. The ATO (08:45-09:00) and ATC (14:30-14:45) auctions take orders
+// but are not trading time, so the trading day ends at 14:45.
+func HOSEDerivatives() *Calendar {
+	return NewCalendar(vietnam,
+		Window{Open: 9 * time.Hour, Close: 11*time.Hour + 30*time.Minute},
+		Window{Open: 13 * time.Hour, Close: 14*time.Hour + 30*time.Minute},
+	).WithAuctions(
+		Window{Open: 8*time.Hour + 45*time.Minute, Close: 9 * time.Hour},
+		Window{Open: 14*time.Hour + 30*time.Minute, Close: 14*time.Hour + 45*time.Minute},
+	)
+}
+
+// Location returns the venue time zone.
+func (c *Calendar) Location() *time.Location {
+	return c.loc
+}
+
+// Open reports whether t falls inside a trading session.
+func (c *Calendar) Open(t time.Time) bool {
+	_, ok := c.window(t)
+	return ok
+}
+
+// Next returns t when the market is open, otherwise the start of the next
+// session.
+func (c *Calendar) Next(t time.Time) time.Time {
+	if c.Open(t) {
+		return t
+	}
+	local := t.In(c.loc)
+	day := midnight(local)
+	for i := 0; i < 8; i++ {
+		if tradingDay(day) {
+			for _, w := range c.windows {
+				if open := day.Add(w.Open); !open.Before(local) {
+					return open.In(t.Location())
+				}
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// Add advances t by d of trading time, skipping breaks, nights and weekends.
+// The result is the instant at which d of trading has elapsed.
+func (c *Calendar) Add(t time.Time, d time.Duration) time.Time {
+	if len(c.windows) == 0 {
+		return t.Add(d)
+	}
+	cur := c.Next(t)
+	for {
+		w, _ := c.window(cur)
+		end := midnight(cur.In(c.loc)).Add(w.Close)
+		left := end.Sub(cur)
+		if d < left {
+			return cur.Add(d).In(t.Location())
+		}
+		d -= left
+		if d == 0 {
+			return end.In(t.Location())
+		}
+		cur = c.Next(end)
+	}
+}
+
+// SessionClose returns the end of the session containing t, or the zero time
+// when the market is closed.
+func (c *Calendar) SessionClose(t time.Time) time.Time {
+	w, ok := c.window(t)
+	if !ok {
+		return time.Time{}
+	}
+	return midnight(t.In(c.loc)).Add(w.Close).In(t.Location())
+}
+
+// Accepting reports whether the venue takes orders at t: during a trading
+// session or an auction.
+func (c *Calendar) Accepting(t time.Time) bool {
+	local := t.In(c.loc)
+	if !tradingDay(local) {
+		return false
+	}
+	offset := local.Sub(midnight(local))
+	for _, w := range c.sessions() {
+		if offset >= w.Open && offset < w.Close {
+			return true
+		}
+	}
+	return false
+}
+
+// NextSession returns t when the venue takes orders, otherwise the start of
+// the next session or auction.
+func (c *Calendar) NextSession(t time.Time) time.Time {
+	if c.Accepting(t) {
+		return t
+	}
+	local := t.In(c.loc)
+	day := midnight(local)
+	for i := 0; i < 8; i++ {
+		if tradingDay(day) {
+			for _, w := range c.sessions() {
+				if open := day.Add(w.Open); !open.Before(local) {
+					return open.In(t.Location())
+				}
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// NextDayClose returns the end of the first trading day that closes after t,
+// the close of its last session or auction.
+func (c *Calendar) NextDayClose(t time.Time) time.Time {
+	var last time.Duration
+	for _, w := range c.sessions() {
+		if w.Close > last {
+			last = w.Close
+		}
+	}
+	if last == 0 {
+		return time.Time{}
+	}
+	local := t.In(c.loc)
+	day := midnight(local)
+	for i := 0; i < 8; i++ {
+		if end := day.Add(last); tradingDay(day) && end.After(local) {
+			return end.In(t.Location())
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+// sessions returns the trading windows and auctions in ascending order.
+func (c *Calendar) sessions() []Window {
+	if len(c.auctions) == 0 {
+		return c.windows
+	}
+	out := append(append([]Window(nil), c.windows...), c.auctions...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Open < out[j].Open })
+	return out
+}
+
+func (c *Calendar) window(t time.Time) (Window, bool) {
+	local := t.In(c.loc)
+	if !tradingDay(local) {
+		return Window{}, false
+	}
+	offset := local.Sub(midnight(local))
+	for _, w := range c.windows {
+		if offset >= w.Open && offset < w.Close {
+			return w, true
+		}
+	}
+	return Window{}, false
+}
+
+func tradingDay(t time.Time) bool {
+	wd := t.Weekday()
+	return wd != time.Saturday && wd != time.Sunday
+}
+
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
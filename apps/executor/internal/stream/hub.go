@@ -0,0 +1,167 @@
+// Package stream fans order updates out to live subscribers, such as the
+// server-sent event endpoint, and keeps a bounded replay buffer so a client
+// that reconnects can resume from the last update it saw.
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/future-bots/executor/internal/service"
+)
+
+// DefaultReplaySize is the replay buffer capacity used when none is given.
+const DefaultReplaySize = 1024
+
+// subscriberBuffer bounds the updates queued for one subscriber. A
+// subscriber that falls this far behind is dropped and has to resume.
+const subscriberBuffer = 256
+
+// Entry is an order update with its position in the stream.
+type Entry struct {
+	ID     uint64
+	Update service.OrderUpdate
+}
+
+// Filter narrows a subscription. Empty fields match every order.
+type Filter struct {
+	BotID     string
+	AccountID string
+}
+
+// Matches reports whether the update's order satisfies the filter.
+func (f Filter) Matches(update service.OrderUpdate) bool {
+	return (f.BotID == "" || update.Order.BotID == f.BotID) &&
+		(f.AccountID == "" || update.Order.AccountID == f.AccountID)
+}
+
+// Subscription delivers live updates matching its filter. Updates is closed
+// when the subscriber falls behind or the hub closes.
+type Subscription struct {
+	Updates <-chan Entry
+	updates chan Entry
+	filter  Filter
+}
+
+// Hub numbers order updates, retains the most recent ones and broadcasts
+// them to subscribers. It implements service.OrderUpdateSink.
+type Hub struct {
+	mu     sync.Mutex
+	buffer []Entry
+	next   int
+	full   bool
+	lastID uint64
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// NewHub builds a hub retaining the last replaySize updates, or
+// DefaultReplaySize when replaySize is not positive.
+func NewHub(replaySize int) *Hub {
+	if replaySize <= 0 {
+		replaySize = DefaultReplaySize
+	}
+	return &Hub{buffer: make([]Entry, replaySize), subs: make(map[*Subscription]struct{})}
+}
+
+// OrderUpdated implements service.OrderUpdateSink. It never blocks: a
+// subscriber whose queue is full is dropped instead.
+func (h *Hub) OrderUpdated(_ context.Context, update service.OrderUpdate) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return
+	}
+	h.lastID++
+	entry := Entry{ID: h.lastID, Update: update}
+	h.buffer[h.next] = entry
+	h.next = (h.next + 1) % len(h.buffer)
+	h.full = h.full || h.next == 0
+
+	for sub := range h.subs {
+		if !sub.filter.Matches(update) {
+			continue
+		}
+		select {
+		case sub.updates <- entry:
+		default:
+			h.drop(sub)
+		}
+	}
+}
+
+// Replay is what a new subscriber missed since the update it last saw.
+type Replay struct {
+	// Entries are the retained updates after the last seen one that match
+	// the subscription filter, oldest first.
+	Entries []Entry
+	// Gap is set when updates after the last seen one have already left
+	// the buffer, or the id is not one this hub issued; the subscriber has
+	// to reload its state and resume from Head.
+	Gap bool
+	// Head is the id of the latest update when the subscription started.
+	Head uint64
+}
+
+// Subscribe registers a subscriber for updates matching filter and returns
+// what it missed after lastID. A zero lastID replays nothing.
+func (h *Hub) Subscribe(filter Filter, lastID uint64) (*Subscription, Replay) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	updates := make(chan Entry, subscriberBuffer)
+	sub := &Subscription{Updates: updates, updates: updates, filter: filter}
+	replay := Replay{Head: h.lastID}
+	if h.closed {
+		close(updates)
+		return sub, replay
+	}
+	h.subs[sub] = struct{}{}
+	if lastID == 0 {
+		return sub, replay
+	}
+
+	retained := h.retained()
+	if lastID > h.lastID || (len(retained) > 0 && lastID+1 < retained[0].ID) {
+		replay.Gap = true
+		return sub, replay
+	}
+	for _, entry := range retained {
+		if entry.ID > lastID && filter.Matches(entry.Update) {
+			replay.Entries = append(replay.Entries, entry)
+		}
+	}
+	return sub, replay
+}
+
+// Unsubscribe removes sub and closes its channel.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(sub)
+}
+
+// Close ends every subscription; later updates are discarded.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subs {
+		h.drop(sub)
+	}
+}
+
+func (h *Hub) drop(sub *Subscription) {
+	if _, ok := h.subs[sub]; ok {
+		delete(h.subs, sub)
+		close(sub.updates)
+	}
+}
+
+// retained returns the buffered entries, oldest first.
+func (h *Hub) retained() []Entry {
+	if !h.full {
+		return append([]Entry(nil), h.buffer[:h.next]...)
+	}
+	return append(append([]Entry(nil), h.buffer[h.next:]...), h.buffer[:h.next]...)
+}
@@ -0,0 +1,284 @@
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/future-bots/executor/internal/fix"
+)
+
+// acceptor stands in for a broker's FIX 4.4 gateway on a local port. It
+// acknowledges orders, which rest until the test fills them, answers
+// cancels, replaces, status and mass status requests, and rejects orders
+// for the symbol "REJECT".
+type acceptor struct {
+	t        *testing.T
+	listener net.Listener
+	session  *fix.Session
+
+	mu       sync.Mutex
+	conn     *lossyConn
+	orders   map[string]*gatewayOrder
+	orderSeq int
+	execSeq  int
+	received []*fix.Message
+	// holding answers cancels as pending until confirmCancel.
+	holding bool
+}
+
+type gatewayOrder struct {
+	id, clOrdID, symbol, side string
+	qty, price, cum           float64
+	status                    string
+}
+
+func newAcceptor(t *testing.T) *acceptor {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	a := &acceptor{t: t, listener: listener, orders: make(map[string]*gatewayOrder)}
+	a.session = fix.NewSession(fix.Config{SenderCompID: "GW", TargetCompID: "EXEC"}, a.handle,
+		fix.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			lossy := &lossyConn{Conn: conn}
+			a.mu.Lock()
+			a.conn = lossy
+			a.mu.Unlock()
+			a.session.Accept(ctx, lossy)
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		listener.Close()
+		a.disconnect()
+		<-served
+	})
+	return a
+}
+
+func (a *acceptor) addr() string {
+	return a.listener.Addr().String()
+}
+
+// disconnect drops the current connection.
+func (a *acceptor) disconnect() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.conn != nil {
+		a.conn.Close()
+	}
+}
+
+// loseNext makes the next message written vanish in transit.
+func (a *acceptor) loseNext() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.conn.drop(1)
+}
+
+// requests returns the application messages received of msgType.
+func (a *acceptor) requests(msgType string) []*fix.Message {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var out []*fix.Message
+	for _, msg := range a.received {
+		if msg.MsgType() == msgType {
+			out = append(out, msg)
+		}
+	}
+	return out
+}
+
+// holdCancels answers later cancels with PendingCancel.
+func (a *acceptor) holdCancels() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.holding = true
+}
+
+// confirmCancel reports a pending cancel done.
+func (a *acceptor) confirmCancel(orderID string) {
+	a.t.Helper()
+	a.mu.Lock()
+	o := a.orders[orderID]
+	o.status = "4"
+	report := a.reportLocked(o, "4", a.execID())
+	a.mu.Unlock()
+	if err := a.session.Send(report); err != nil {
+		a.t.Fatalf("send cancel: %v", err)
+	}
+}
+
+// fill executes qty of the order at px.
+func (a *acceptor) fill(orderID string, qty, px float64) {
+	a.t.Helper()
+	a.mu.Lock()
+	o := a.orders[orderID]
+	o.cum += qty
+	o.status = "1"
+	if o.cum >= o.qty {
+		o.status = "2"
+	}
+	a.execSeq++
+	report := a.reportLocked(o, "F", fmt.Sprintf("GWX-%d", a.execSeq)).SetFloat(fix.TagLastQty, qty).SetFloat(fix.TagLastPx, px)
+	a.mu.Unlock()
+	if err := a.session.Send(report); err != nil {
+		a.t.Fatalf("send fill: %v", err)
+	}
+}
+
+func (a *acceptor) handle(msg *fix.Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.received = append(a.received, msg)
+
+	var answers []*fix.Message
+	switch msg.MsgType() {
+	case fix.MsgTypeNewOrderSingle:
+		qty, _ := msg.Float(fix.TagOrderQty)
+		price, _ := msg.Float(fix.TagPrice)
+		a.orderSeq++
+		o := &gatewayOrder{id: fmt.Sprintf("GW-%d", a.orderSeq), clOrdID: msg.Get(fix.TagClOrdID),
+			symbol: msg.Get(fix.TagSymbol), side: msg.Get(fix.TagSide), qty: qty, price: price, status: "0"}
+		if o.symbol == "REJECT" {
+			o.status = "8"
+			answers = append(answers, a.reportLocked(o, "8", a.execID()).Set(fix.TagText, "symbol not tradable"))
+			break
+		}
+		a.orders[o.id] = o
+		answers = append(answers, a.reportLocked(o, "0", a.execID()))
+	case fix.MsgTypeOrderCancelRequest, fix.MsgTypeOrderCancelReplaceRequest:
+		o, ok := a.orders[msg.Get(fix.TagOrderID)]
+		if !ok || (o.status != "0" && o.status != "1") {
+			reason := "1"
+			if ok {
+				reason = "0"
+			}
+			responseTo := "1"
+			if msg.MsgType() == fix.MsgTypeOrderCancelReplaceRequest {
+				responseTo = "2"
+			}
+			answers = append(answers, fix.NewMessage(fix.MsgTypeOrderCancelReject).
+				Set(fix.TagOrderID, msg.Get(fix.TagOrderID)).
+				Set(fix.TagClOrdID, msg.Get(fix.TagClOrdID)).
+				Set(fix.TagOrigClOrdID, msg.Get(fix.TagOrigClOrdID)).
+				Set(fix.TagOrdStatus, "8").
+				Set(fix.TagCxlRejResponseTo, responseTo).
+				Set(fix.TagCxlRejReason, reason).
+				Set(fix.TagText, "cannot act on order"))
+			break
+		}
+		o.clOrdID = msg.Get(fix.TagClOrdID)
+		if msg.MsgType() == fix.MsgTypeOrderCancelRequest && a.holding {
+			o.status = "6"
+			answers = append(answers, a.reportLocked(o, "6", a.execID()))
+			break
+		}
+		if msg.MsgType() == fix.MsgTypeOrderCancelRequest {
+			o.status = "4"
+			answers = append(answers, a.reportLocked(o, "4", a.execID()))
+			break
+		}
+		o.qty, _ = msg.Float(fix.TagOrderQty)
+		o.price, _ = msg.Float(fix.TagPrice)
+		answers = append(answers, a.reportLocked(o, "5", a.execID()))
+	case fix.MsgTypeOrderStatusRequest:
+		o, ok := a.orders[msg.Get(fix.TagOrderID)]
+		if !ok {
+			o = &gatewayOrder{id: msg.Get(fix.TagOrderID), clOrdID: msg.Get(fix.TagClOrdID), status: "8"}
+		}
+		answers = append(answers, a.reportLocked(o, "I", a.execID()).Set(fix.TagOrdStatusReqID, msg.Get(fix.TagOrdStatusReqID)))
+	case fix.MsgTypeOrderMassStatusRequest:
+		id := msg.Get(fix.TagMassStatusReqID)
+		var open []*gatewayOrder
+		for i := 1; i <= a.orderSeq; i++ {
+			if o, ok := a.orders[fmt.Sprintf("GW-%d", i)]; ok && (o.status == "0" || o.status == "1") {
+				open = append(open, o)
+			}
+		}
+		if len(open) == 0 {
+			answers = append(answers, fix.NewMessage(fix.MsgTypeExecutionReport).
+				Set(fix.TagMassStatusReqID, id).Set(fix.TagOrderID, "NONE").Set(fix.TagExecType, "I").
+				SetInt(fix.TagTotNumReports, 0).SetBool(fix.TagLastRptRequested, true))
+		}
+		for i, o := range open {
+			answers = append(answers, a.reportLocked(o, "I", a.execID()).
+				Set(fix.TagMassStatusReqID, id).
+				SetInt(fix.TagTotNumReports, len(open)).
+				SetBool(fix.TagLastRptRequested, i == len(open)-1))
+		}
+	}
+	// The session serves one message at a time, so answering from the
+	// handler keeps them in order.
+	go func() {
+		for _, answer := range answers {
+			if err := a.session.Send(answer); err != nil && !errors.Is(err, fix.ErrNotLoggedOn) {
+				a.t.Errorf("send answer: %v", err)
+			}
+		}
+	}()
+}
+
+func (a *acceptor) execID() string {
+	a.execSeq++
+	return fmt.Sprintf("GWE-%d", a.execSeq)
+}
+
+func (a *acceptor) reportLocked(o *gatewayOrder, execType, execID string) *fix.Message {
+	return fix.NewMessage(fix.MsgTypeExecutionReport).
+		Set(fix.TagOrderID, o.id).
+		Set(fix.TagClOrdID, o.clOrdID).
+		Set(fix.TagExecID, execID).
+		Set(fix.TagExecType, execType).
+		Set(fix.TagOrdStatus, o.status).
+		Set(fix.TagSymbol, o.symbol).
+		Set(fix.TagSide, o.side).
+		SetFloat(fix.TagOrderQty, o.qty).
+		SetFloat(fix.TagPrice, o.price).
+		SetFloat(fix.TagCumQty, o.cum).
+		SetFloat(fix.TagLeavesQty, o.qty-o.cum).
+		SetTime(fix.TagTransactTime, time.Now())
+}
+
+// lossyConn discards the writes it is told to, as if they were lost in
+// transit.
+type lossyConn struct {
+	net.Conn
+	mu    sync.Mutex
+	drops int
+}
+
+func (c *lossyConn) drop(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.drops += n
+}
+
+func (c *lossyConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	if c.drops > 0 {
+		c.drops--
+		c.mu.Unlock()
+		return len(b), nil
+	}
+	c.mu.Unlock()
+	return c.Conn.Write(b)
+}
@@ -0,0 +1,284 @@
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/future-bots/executor/internal/broker"
+	"github.com/future-bots/executor/internal/fix"
+	"github.com/future-bots/executor/internal/service"
+)
+
+// connect runs a FIX adapter against the acceptor and waits for its logon.
+func connect(t *testing.T, a *acceptor) *broker.FIX {
+	t.Helper()
+	f, _ := start(t, a, "")
+	return f
+}
+
+// start runs a FIX adapter keeping its sequence numbers in seqFile, waits
+// for its logon and returns it with a func stopping it, as a process exit
+// would.
+func start(t *testing.T, a *acceptor, seqFile string) (*broker.FIX, func()) {
+	t.Helper()
+	f := broker.NewFIX(broker.FIXConfig{
+		Addr:           a.addr(),
+		SenderCompID:   "EXEC",
+		TargetCompID:   "GW",
+		Account:        "068C000001",
+		RequestTimeout: 2 * time.Second,
+		SeqFile:        seqFile,
+	}, broker.WithFIXReconnect(20*time.Millisecond), broker.WithFIXLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.Run(ctx)
+	}()
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			cancel()
+			<-done
+		})
+	}
+	t.Cleanup(stop)
+	loggedOn(t, f)
+	return f, stop
+}
+
+func loggedOn(t *testing.T, f *broker.FIX) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for f.Ready(context.Background()) != nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("FIX adapter did not log on")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func nextFill(t *testing.T, fills <-chan service.BrokerFill) service.BrokerFill {
+	t.Helper()
+	select {
+	case fill := <-fills:
+		return fill
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no fill reported")
+		return service.BrokerFill{}
+	}
+}
+
+func TestFIXDrivesOrderLifecycle(t *testing.T) {
+	a := newAcceptor(t)
+	f := connect(t, a)
+	ctx := context.Background()
+
+	ack, err := f.Place(ctx, service.Order{ID: "ord-1", Symbol: "VN30F1M", Side: "buy", Quantity: 3, Price: 1200, TimeInForce: service.TimeInForceGTC})
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if ack.ProviderOrderID != "GW-1" {
+		t.Fatalf("expected the gateway order id got %q", ack.ProviderOrderID)
+	}
+	nos := a.requests(fix.MsgTypeNewOrderSingle)[0]
+	if nos.Get(fix.TagClOrdID) != "ord-1" || nos.Get(fix.TagSide) != "1" || nos.Get(fix.TagOrdType) != "2" ||
+		nos.Get(fix.TagTimeInForce) != "1" || nos.Get(fix.TagAccount) != "068C000001" || nos.Get(fix.TagPrice) != "1200" {
+		t.Fatalf("unexpected NewOrderSingle %s", nos)
+	}
+
+	if err := f.Amend(ctx, "GW-1", 1205, 2); err != nil {
+		t.Fatalf("amend: %v", err)
+	}
+	replace := a.requests(fix.MsgTypeOrderCancelReplaceRequest)[0]
+	if replace.Get(fix.TagOrigClOrdID) != "ord-1" || replace.Get(fix.TagClOrdID) != "ord-1.1" {
+		t.Fatalf("unexpected replace request %s", replace)
+	}
+	state, err := f.Query(ctx, "GW-1")
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if !state.Open || state.ClientOrderID != "ord-1" || state.Price != 1205 || state.Quantity != 2 || state.Side != "buy" {
+		t.Fatalf("unexpected state after the amend %+v", state)
+	}
+
+	a.fill("GW-1", 1, 1204)
+	fill := nextFill(t, f.Fills())
+	if fill.ProviderOrderID != "GW-1" || fill.ClientOrderID != "ord-1" || fill.Quantity != 1 || fill.Price != 1204 {
+		t.Fatalf("unexpected fill %+v", fill)
+	}
+	if executions, err := f.Executions(ctx, "GW-1"); err != nil || len(executions) != 1 || executions[0].ExecutionID != fill.ExecutionID {
+		t.Fatalf("expected the fill among the executions got %+v %v", executions, err)
+	}
+	open, err := f.OpenOrders(ctx)
+	if err != nil || len(open) != 1 || open[0].FilledQuantity != 1 {
+		t.Fatalf("expected the partly filled order open got %+v %v", open, err)
+	}
+
+	if err := f.Cancel(ctx, "GW-1"); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if cancel := a.requests(fix.MsgTypeOrderCancelRequest)[0]; cancel.Get(fix.TagOrigClOrdID) != "ord-1.1" || cancel.Get(fix.TagClOrdID) != "ord-1.2" {
+		t.Fatalf("expected the cancel to follow the replace got %s", cancel)
+	}
+	if err := f.Cancel(ctx, "GW-1"); !errors.Is(err, broker.ErrOrderClosed) {
+		t.Fatalf("expected the cancelled order closed got %v", err)
+	}
+	if open, err := f.OpenOrders(ctx); err != nil || len(open) != 0 {
+		t.Fatalf("expected no open orders got %+v %v", open, err)
+	}
+	if _, err := f.Query(ctx, "GW-9"); !errors.Is(err, service.ErrUnknownBrokerOrder) {
+		t.Fatalf("expected an unknown order got %v", err)
+	}
+
+	if _, err := f.Place(ctx, service.Order{ID: "ord-2", Symbol: "REJECT", Side: "sell", Quantity: 1}); !errors.Is(err, broker.ErrFIXReject) {
+		t.Fatalf("expected the order rejected got %v", err)
+	} else if errors.Is(err, service.ErrBrokerUnavailable) {
+		t.Fatalf("expected a reject not to fail over got %v", err)
+	}
+}
+
+func TestFIXAmendKeepsStopOrders(t *testing.T) {
+	a := newAcceptor(t)
+	f := connect(t, a)
+	ctx := context.Background()
+	if _, err := f.Place(ctx, service.Order{ID: "sl-1", Symbol: "VN30F1M", Side: "sell", Quantity: 3, Type: service.OrderTypeStop, StopPrice: 1190}); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if err := f.Amend(ctx, "GW-1", 0, 2); err != nil {
+		t.Fatalf("amend: %v", err)
+	}
+	replace := a.requests(fix.MsgTypeOrderCancelReplaceRequest)[0]
+	if replace.Get(fix.TagOrdType) != "3" || replace.Get(fix.TagStopPx) != "1190" || replace.Has(fix.TagPrice) || replace.Get(fix.TagOrderQty) != "2" {
+		t.Fatalf("expected the stop resized and kept a stop got %s", replace)
+	}
+}
+
+func TestFIXCancelIsDoneOnlyOnceCanceled(t *testing.T) {
+	a := newAcceptor(t)
+	f := connect(t, a)
+	ctx := context.Background()
+	if _, err := f.Place(ctx, service.Order{ID: "ord-1", Symbol: "VN30F1M", Side: "buy", Quantity: 2, Price: 1200}); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+
+	a.holdCancels()
+	if err := f.Cancel(ctx, "GW-1"); !errors.Is(err, service.ErrCancelPending) {
+		t.Fatalf("expected the cancel pending got %v", err)
+	}
+	if err := f.Cancel(ctx, "GW-1"); !errors.Is(err, service.ErrCancelPending) {
+		t.Fatalf("expected the cancel still pending got %v", err)
+	}
+	if sent := a.requests(fix.MsgTypeOrderCancelRequest); len(sent) != 1 {
+		t.Fatalf("expected a pending cancel not to be sent again got %d requests", len(sent))
+	}
+
+	// The order can still fill while the cancel is pending.
+	a.fill("GW-1", 1, 1200)
+	if fill := nextFill(t, f.Fills()); fill.Quantity != 1 {
+		t.Fatalf("unexpected fill %+v", fill)
+	}
+	a.confirmCancel("GW-1")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		state, err := f.Query(ctx, "GW-1")
+		if err != nil {
+			t.Fatalf("query: %v", err)
+		}
+		if !state.Open {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the order closed once the cancel is confirmed got %+v", state)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := f.Cancel(ctx, "GW-1"); !errors.Is(err, broker.ErrOrderClosed) {
+		t.Fatalf("expected the cancelled order closed got %v", err)
+	}
+}
+
+func TestFIXUnavailableWhileLoggedOff(t *testing.T) {
+	f := broker.NewFIX(broker.FIXConfig{Addr: "127.0.0.1:1", SenderCompID: "EXEC", TargetCompID: "GW"})
+	if err := f.Ready(context.Background()); !errors.Is(err, service.ErrBrokerUnavailable) {
+		t.Fatalf("expected not ready got %v", err)
+	}
+	if _, err := f.Place(context.Background(), restingOrder("ord-1")); !errors.Is(err, service.ErrBrokerUnavailable) {
+		t.Fatalf("expected the order to be safe to fail over got %v", err)
+	}
+}
+
+func TestFIXRecoversLostReports(t *testing.T) {
+	a := newAcceptor(t)
+	f := connect(t, a)
+	ctx := context.Background()
+	if _, err := f.Place(ctx, service.Order{ID: "ord-1", Symbol: "VN30F1M", Side: "buy", Quantity: 5, Price: 1200}); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+
+	// The first fill is lost; the second exposes the gap and the adapter
+	// asks for a resend.
+	a.loseNext()
+	a.fill("GW-1", 1, 1200)
+	a.fill("GW-1", 1, 1201)
+	for _, want := range []float64{1200, 1201} {
+		if fill := nextFill(t, f.Fills()); fill.Price != want {
+			t.Fatalf("expected the fill at %v in sequence got %+v", want, fill)
+		}
+	}
+
+	// A fill lost just before the connection drops is recovered when the
+	// adapter logs on again and the sequences resume.
+	sender, _ := a.session.SeqNums()
+	a.loseNext()
+	a.fill("GW-1", 1, 1202)
+	a.disconnect()
+	if fill := nextFill(t, f.Fills()); fill.Price != 1202 {
+		t.Fatalf("expected the fill recovered after the reconnect got %+v", fill)
+	}
+	loggedOn(t, f)
+	if resumed, _ := a.session.SeqNums(); resumed <= sender {
+		t.Fatalf("expected the sequences to resume after %d got %d", sender, resumed)
+	}
+	if executions, err := f.Executions(ctx, "GW-1"); err != nil || len(executions) != 3 {
+		t.Fatalf("expected three executions got %+v %v", executions, err)
+	}
+}
+
+func TestFIXRecoversReportsMissedAcrossRestart(t *testing.T) {
+	a := newAcceptor(t)
+	seqFile := filepath.Join(t.TempDir(), "fix.seq")
+	f, stop := start(t, a, seqFile)
+	ctx := context.Background()
+	if _, err := f.Place(ctx, service.Order{ID: "ord-1", Symbol: "VN30F1M", Side: "buy", Quantity: 3, Price: 1200}); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	a.fill("GW-1", 1, 1200)
+	if fill := nextFill(t, f.Fills()); fill.Price != 1200 {
+		t.Fatalf("unexpected fill %+v", fill)
+	}
+
+	// A fill is lost in transit and the executor exits before noticing.
+	a.loseNext()
+	a.fill("GW-1", 1, 1201)
+	stop()
+	_, expected := a.session.SeqNums()
+
+	restarted, _ := start(t, a, seqFile)
+	fill := nextFill(t, restarted.Fills())
+	if fill.ProviderOrderID != "GW-1" || fill.ClientOrderID != "ord-1" || fill.Quantity != 1 || fill.Price != 1201 {
+		t.Fatalf("expected the missed fill resent after the restart got %+v", fill)
+	}
+	if _, resumed := a.session.SeqNums(); resumed <= expected {
+		t.Fatalf("expected the restarted adapter to resume the sequences after %d got %d", expected, resumed)
+	}
+	if executions, err := restarted.Executions(ctx, "GW-1"); err != nil || len(executions) != 1 || executions[0].ExecutionID != fill.ExecutionID {
+		t.Fatalf("expected the resent fill among the executions got %+v %v", executions, err)
+	}
+}
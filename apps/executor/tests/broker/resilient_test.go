@@ -0,0 +1,191 @@
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/future-bots/executor/internal/broker"
+	"github.com/future-bots/executor/internal/repository"
+	"github.com/future-bots/executor/internal/service"
+)
+
+// flakyBroker is a simulator whose calls fail with err while it is set.
+type flakyBroker struct {
+	*broker.Simulator
+	mu    sync.Mutex
+	err   error
+	calls int
+}
+
+func (f *flakyBroker) fail() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	return f.err
+}
+
+func (f *flakyBroker) set(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err, f.calls = err, 0
+}
+
+func (f *flakyBroker) Place(ctx context.Context, order service.Order) (service.BrokerAck, error) {
+	if err := f.fail(); err != nil {
+		return service.BrokerAck{}, err
+	}
+	return f.Simulator.Place(ctx, order)
+}
+
+func (f *flakyBroker) Query(ctx context.Context, id string) (service.BrokerOrderState, error) {
+	if err := f.fail(); err != nil {
+		return service.BrokerOrderState{}, err
+	}
+	return f.Simulator.Query(ctx, id)
+}
+
+func newFlaky(prefix string) *flakyBroker {
+	sim := broker.NewSimulator(fixedNow, 0, broker.WithIDPrefix(prefix))
+	sim.OnSnapshot(snapshot())
+	return &flakyBroker{Simulator: sim}
+}
+
+var unreachable = fmt.Errorf("dial venue: %w", service.ErrBrokerUnavailable)
+
+func restingOrder(id string) service.Order {
+	return service.Order{ID: id, Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1200}
+}
+
+func TestResilientRetriesCallsThatAreSafeToRepeat(t *testing.T) {
+	ctx := context.Background()
+	primary := newFlaky("SIM")
+	venue := broker.NewResilient(primary, broker.WithRetry(3, time.Millisecond, time.Millisecond))
+
+	ack, err := venue.Place(ctx, restingOrder("ord-1"))
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	primary.set(unreachable)
+	if _, err := venue.Query(ctx, ack.ProviderOrderID); !errors.Is(err, service.ErrBrokerUnavailable) || primary.calls != 3 {
+		t.Fatalf("expected 3 attempts before giving up got %d (%v)", primary.calls, err)
+	}
+
+	// A timed out placement may have reached the venue, so it is not repeated.
+	primary.set(context.DeadlineExceeded)
+	if _, err := venue.Place(ctx, restingOrder("ord-2")); !errors.Is(err, context.DeadlineExceeded) || primary.calls != 1 {
+		t.Fatalf("expected a single placement attempt got %d (%v)", primary.calls, err)
+	}
+	// Venue rejections are answers, not failures.
+	primary.set(errors.New("insufficient margin"))
+	if _, err := venue.Place(ctx, restingOrder("ord-3")); err == nil || primary.calls != 1 {
+		t.Fatalf("expected the rejection returned at once got %d (%v)", primary.calls, err)
+	}
+}
+
+func TestResilientCircuitBreaker(t *testing.T) {
+	ctx := context.Background()
+	now := fixedNow()
+	primary := newFlaky("SIM")
+	venue := broker.NewResilient(primary,
+		broker.WithRetry(1, time.Millisecond, time.Millisecond),
+		broker.WithCircuitBreaker(2, time.Minute),
+		broker.WithResilientClock(func() time.Time { return now }))
+
+	primary.set(unreachable)
+	for i := 0; i < 2; i++ {
+		if _, err := venue.Place(ctx, restingOrder(fmt.Sprintf("ord-%d", i))); !errors.Is(err, service.ErrBrokerUnavailable) {
+			t.Fatalf("expected the broker error got %v", err)
+		}
+	}
+	if _, err := venue.Place(ctx, restingOrder("ord-3")); !errors.Is(err, broker.ErrCircuitOpen) || primary.calls != 2 {
+		t.Fatalf("expected the open circuit to fail fast got %v after %d calls", err, primary.calls)
+	}
+	if err := venue.Ready(ctx); !errors.Is(err, broker.ErrCircuitOpen) {
+		t.Fatalf("expected not ready got %v", err)
+	}
+	if h := venue.Health(); len(h) != 1 || h[0].State != broker.BreakerOpen || h[0].ConsecutiveFailures != 2 {
+		t.Fatalf("unexpected health %+v", h)
+	}
+
+	// After the cooldown a successful probe closes the circuit.
+	now = now.Add(time.Minute)
+	primary.set(nil)
+	if venue.Health()[0].State != broker.BreakerHalfOpen {
+		t.Fatalf("expected half open after the cooldown got %+v", venue.Health())
+	}
+	if _, err := venue.Place(ctx, restingOrder("ord-4")); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if err := venue.Ready(ctx); err != nil || venue.Health()[0].State != broker.BreakerClosed {
+		t.Fatalf("expected the circuit closed got %+v (%v)", venue.Health(), err)
+	}
+}
+
+func TestResilientFailsOverToSecondary(t *testing.T) {
+	ctx := context.Background()
+	primary, secondary := newFlaky("SIM"), newFlaky("SIMB")
+	venue := broker.NewResilient(primary,
+		broker.WithRetry(1, time.Millisecond, time.Millisecond),
+		broker.WithCircuitBreaker(1, time.Minute),
+		broker.WithFailover(secondary))
+
+	resting, err := venue.Place(ctx, restingOrder("ord-1"))
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	primary.set(unreachable)
+	ack, err := venue.Place(ctx, service.Order{ID: "ord-2", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1250})
+	if err != nil || ack.ProviderOrderID != "SIMB-00000001" {
+		t.Fatalf("expected the placement failed over got %+v (%v)", ack, err)
+	}
+	if err := venue.Ready(ctx); err != nil {
+		t.Fatalf("expected ready while the secondary is up got %v", err)
+	}
+	select {
+	case fill := <-venue.Fills():
+		if fill.ClientOrderID != "ord-2" {
+			t.Fatalf("unexpected fill %+v", fill)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the secondary's fill on the merged stream")
+	}
+	if state, err := venue.Query(ctx, ack.ProviderOrderID); err != nil || state.ClientOrderID != "ord-2" {
+		t.Fatalf("expected the secondary queried got %+v (%v)", state, err)
+	}
+	// Orders at the unreachable primary report it rather than going missing.
+	if _, err := venue.Query(ctx, resting.ProviderOrderID); !errors.Is(err, service.ErrBrokerUnavailable) {
+		t.Fatalf("expected the primary outage reported got %v", err)
+	}
+	if _, err := venue.OpenOrders(ctx); err == nil {
+		t.Fatal("expected open orders to fail while the primary is unreachable")
+	}
+}
+
+func TestOpenCircuitRejectsNewOrders(t *testing.T) {
+	ctx := context.Background()
+	primary := newFlaky("SIM")
+	primary.set(unreachable)
+	var rejections []service.Event
+	svc := service.New(repository.NewMemory(), fixedNow,
+		service.WithBroker(broker.NewResilient(primary, broker.WithRetry(1, time.Millisecond, time.Millisecond), broker.WithCircuitBreaker(1, time.Minute))),
+		service.WithEventPublisher(service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+			if event.Type == service.EventRejection {
+				rejections = append(rejections, event)
+			}
+			return nil
+		})))
+
+	for i := 0; i < 2; i++ {
+		order, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1200})
+		if err != nil || order.Status != service.StatusRejected {
+			t.Fatalf("expected the order rejected got %+v (%v)", order, err)
+		}
+	}
+	if primary.calls != 1 || len(rejections) != 2 || rejections[1].Category != service.RejectionBrokerReject {
+		t.Fatalf("expected the second order rejected by the open circuit got %d calls, %+v", primary.calls, rejections)
+	}
+}
@@ -0,0 +1,276 @@
+package broker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/future-bots/executor/internal/broker"
+	"github.com/future-bots/executor/internal/repository"
+	"github.com/future-bots/executor/internal/service"
+	marketsv1 "github.com/future-bots/proto/markets/v1"
+)
+
+func fixedNow() time.Time { return time.Unix(1700, 0).UTC() }
+
+func snapshot() *marketsv1.SsiPsSnapshot {
+	return &marketsv1.SsiPsSnapshot{
+		Code:              "VN30F1M",
+		BestBid_1:         1249.9,
+		BestBid_1Volume:   4,
+		BestBid_2:         1249.8,
+		BestBid_2Volume:   10,
+		BestOffer_1:       1250.0,
+		BestOffer_1Volume: 3,
+		BestOffer_2:       1250.1,
+		BestOffer_2Volume: 5,
+	}
+}
+
+func drain(ch <-chan service.BrokerFill) []service.BrokerFill {
+	var out []service.BrokerFill
+	for {
+		select {
+		case f := <-ch:
+			out = append(out, f)
+		default:
+			return out
+		}
+	}
+}
+
+func TestSimulatorFillsLimitBuyAcrossOfferLevels(t *testing.T) {
+	sim := broker.NewSimulator(fixedNow, 0)
+	sim.OnSnapshot(snapshot())
+
+	ack, err := sim.Place(context.Background(), service.Order{ID: "ord-1", Symbol: "VN30F1M", Side: "buy", Quantity: 6, Price: 1250.1})
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	fills := drain(sim.Fills())
+	if len(fills) != 2 {
+		t.Fatalf("expected 2 fills got %+v", fills)
+	}
+	if fills[0].Quantity != 3 || fills[0].Price != 1250.0 || fills[1].Quantity != 3 || fills[1].Price != 1250.1 {
+		t.Fatalf("unexpected fills %+v", fills)
+	}
+	if fills[0].ClientOrderID != "ord-1" || fills[0].ProviderOrderID != ack.ProviderOrderID {
+		t.Fatalf("fills not linked to order: %+v", fills[0])
+	}
+
+	state, err := sim.Query(context.Background(), ack.ProviderOrderID)
+	if err != nil || state.Open || state.FilledQuantity != 6 {
+		t.Fatalf("unexpected state %+v (%v)", state, err)
+	}
+}
+
+func TestSimulatorHoldsFillsTheStreamHasNoRoomFor(t *testing.T) {
+	sim := broker.NewSimulator(fixedNow, 1)
+	sim.OnSnapshot(snapshot())
+
+	// Two fills against a stream with room for one: the placement must not
+	// wait on a consumer that may itself be calling into the simulator.
+	placed := make(chan error, 1)
+	go func() {
+		_, err := sim.Place(context.Background(), service.Order{ID: "ord-1", Symbol: "VN30F1M", Side: "buy", Quantity: 6, Price: 1250.1})
+		placed <- err
+	}()
+	select {
+	case err := <-placed:
+		if err != nil {
+			t.Fatalf("place: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("place blocked on a full fill stream")
+	}
+
+	// The held fill follows once the stream has room, without another call
+	// into the simulator.
+	for _, price := range []float64{1250.0, 1250.1} {
+		select {
+		case fill := <-sim.Fills():
+			if fill.Price != price {
+				t.Fatalf("expected a fill at %g got %+v", price, fill)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected a fill at %g to be streamed", price)
+		}
+	}
+}
+
+func TestSimulatorRestsUntilSnapshotCrosses(t *testing.T) {
+	sim := broker.NewSimulator(fixedNow, 0)
+	sim.OnSnapshot(snapshot())
+
+	ack, _ := sim.Place(context.Background(), service.Order{ID: "ord-2", Symbol: "VN30F1M", Side: "sell", Quantity: 2, Price: 1250.5})
+	if fills := drain(sim.Fills()); len(fills) != 0 {
+		t.Fatalf("expected resting order got fills %+v", fills)
+	}
+
+	crossed := snapshot()
+	crossed.BestBid_1 = 1250.6
+	crossed.BestBid_1Volume = 1
+	sim.OnSnapshot(crossed)
+	fills := drain(sim.Fills())
+	if len(fills) != 1 || fills[0].Quantity != 1 || fills[0].Price != 1250.6 {
+		t.Fatalf("expected partial fill at crossing bid got %+v", fills)
+	}
+
+	if err := sim.Cancel(context.Background(), ack.ProviderOrderID); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if err := sim.Cancel(context.Background(), ack.ProviderOrderID); !errors.Is(err, broker.ErrOrderClosed) {
+		t.Fatalf("expected ErrOrderClosed got %v", err)
+	}
+	sim.OnSnapshot(crossed)
+	if fills := drain(sim.Fills()); len(fills) != 0 {
+		t.Fatalf("cancelled order should not fill got %+v", fills)
+	}
+}
+
+func TestSimulatorAmendAndUnknownOrders(t *testing.T) {
+	sim := broker.NewSimulator(fixedNow, 0)
+	sim.OnSnapshot(snapshot())
+
+	ack, _ := sim.Place(context.Background(), service.Order{ID: "ord-3", Symbol: "VN30F1M", Side: "buy", Quantity: 2, Price: 1249})
+	if err := sim.Amend(context.Background(), ack.ProviderOrderID, 1250.0, 2); err != nil {
+		t.Fatalf("amend: %v", err)
+	}
+	fills := drain(sim.Fills())
+	if len(fills) != 1 || fills[0].Quantity != 2 || fills[0].Price != 1250.0 {
+		t.Fatalf("expected amended order to fill got %+v", fills)
+	}
+
+	if _, err := sim.Query(context.Background(), "SIM-missing"); !errors.Is(err, service.ErrUnknownBrokerOrder) {
+		t.Fatalf("expected ErrUnknownBrokerOrder got %v", err)
+	}
+	if err := sim.Amend(context.Background(), "SIM-missing", 1, 1); !errors.Is(err, service.ErrUnknownBrokerOrder) {
+		t.Fatalf("expected ErrUnknownBrokerOrder got %v", err)
+	}
+}
+
+func TestSimulatorDrivesOrderLifecycle(t *testing.T) {
+	sim := broker.NewSimulator(fixedNow, 0)
+	sim.OnSnapshot(snapshot())
+
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, e service.Event) error {
+		events = append(events, e)
+		return nil
+	})
+	repo := repository.NewMemory()
+	svc := service.New(repo, fixedNow, service.WithBroker(sim), service.WithEventPublisher(publisher))
+
+	order, err := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", AccountID: "acc-1", Symbol: "VN30F1M", Side: "buy", Quantity: 4, Price: 1250.1})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if order.Status != service.StatusRouted || order.ProviderOrderID == "" {
+		t.Fatalf("expected routed order with provider id got %+v", order)
+	}
+
+	for _, fill := range drain(sim.Fills()) {
+		if err := svc.HandleFill(context.Background(), fill); err != nil {
+			t.Fatalf("handle fill: %v", err)
+		}
+	}
+
+	stored, _ := svc.GetOrder(context.Background(), order.ID)
+	if stored.Status != service.StatusFilled || stored.FilledQuantity != 4 {
+		t.Fatalf("expected filled order got %+v", stored)
+	}
+	history, _ := svc.GetOrderHistory(context.Background(), order.ID)
+	if history[len(history)-2].To != service.StatusPartiallyFilled || history[len(history)-1].To != service.StatusFilled {
+		t.Fatalf("expected partial then full fill transitions got %+v", history)
+	}
+
+	var fills []service.Event
+	for _, e := range events {
+		if e.Type == service.EventFill {
+			fills = append(fills, e)
+		}
+	}
+	if len(fills) != 2 || fills[0].Fill.Remaining != 1 || fills[1].Fill.Remaining != 0 {
+		t.Fatalf("unexpected fill events %+v", fills)
+	}
+}
+
+func TestSimulatorTimeInForceAndStops(t *testing.T) {
+	sim := broker.NewSimulator(fixedNow, 0)
+	sim.OnSnapshot(snapshot())
+
+	if _, err := sim.Place(context.Background(), service.Order{ID: "fok", Symbol: "VN30F1M", Side: "buy", Quantity: 9, Price: 1250.1, TimeInForce: service.TimeInForceFOK}); err == nil {
+		t.Fatalf("expected FOK beyond visible depth to be refused")
+	}
+	if fills := drain(sim.Fills()); len(fills) != 0 {
+		t.Fatalf("refused FOK must not fill got %+v", fills)
+	}
+
+	ack, err := sim.Place(context.Background(), service.Order{ID: "ioc", Symbol: "VN30F1M", Side: "buy", Quantity: 5, Price: 1250.0, TimeInForce: service.TimeInForceIOC})
+	if err != nil {
+		t.Fatalf("place ioc: %v", err)
+	}
+	state, _ := sim.Query(context.Background(), ack.ProviderOrderID)
+	if state.Open || state.FilledQuantity != 3 {
+		t.Fatalf("expected IOC to fill 3 and close got %+v", state)
+	}
+	drain(sim.Fills())
+
+	stop, err := sim.Place(context.Background(), service.Order{ID: "stop", Symbol: "VN30F1M", Side: "sell", Quantity: 2, Type: service.OrderTypeStop, StopPrice: 1249.0})
+	if err != nil {
+		t.Fatalf("place stop: %v", err)
+	}
+	if fills := drain(sim.Fills()); len(fills) != 0 {
+		t.Fatalf("dormant stop must not fill got %+v", fills)
+	}
+	snap := snapshot()
+	snap.LastPrice = 1248.9
+	sim.OnSnapshot(snap)
+	fills := drain(sim.Fills())
+	if len(fills) != 1 || fills[0].ProviderOrderID != stop.ProviderOrderID || fills[0].Price != 1249.9 {
+		t.Fatalf("expected triggered stop to sell at best bid got %+v", fills)
+	}
+}
+
+func TestSimulatorIOCRemainderIsCancelled(t *testing.T) {
+	sim := broker.NewSimulator(fixedNow, 0)
+	sim.OnSnapshot(snapshot())
+
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, e service.Event) error {
+		events = append(events, e)
+		return nil
+	})
+	svc := service.New(repository.NewMemory(), fixedNow, service.WithBroker(sim), service.WithEventPublisher(publisher))
+
+	order, err := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 5, Price: 1250.0, TimeInForce: "ioc"})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if order.Status != service.StatusRouted {
+		t.Fatalf("expected routed while fills are in flight got %s", order.Status)
+	}
+	for _, fill := range drain(sim.Fills()) {
+		if err := svc.HandleFill(context.Background(), fill); err != nil {
+			t.Fatalf("handle fill: %v", err)
+		}
+	}
+
+	stored, _ := svc.GetOrder(context.Background(), order.ID)
+	if stored.Status != service.StatusCancelled || stored.FilledQuantity != 3 {
+		t.Fatalf("expected partially filled IOC to end cancelled got %+v", stored)
+	}
+	last := events[len(events)-1]
+	if last.Type != service.EventCancel || last.InitiatedBy != service.InitiatedByBroker {
+		t.Fatalf("expected broker cancel event got %+v", last)
+	}
+
+	empty, err := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1200, TimeInForce: "IOC"})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if empty.Status != service.StatusCancelled {
+		t.Fatalf("expected unfilled IOC to be cancelled immediately got %s", empty.Status)
+	}
+}
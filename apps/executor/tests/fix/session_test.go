@@ -0,0 +1,287 @@
+package fix_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/future-bots/executor/internal/fix"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	msg := fix.NewMessage(fix.MsgTypeNewOrderSingle).
+		Set(fix.TagClOrdID, "ord-1").
+		SetFloat(fix.TagPrice, 1300.5).
+		Set(fix.TagSenderCompID, "EXEC").
+		Set(fix.TagTargetCompID, "GW").
+		SetInt(fix.TagMsgSeqNum, 7)
+	raw := msg.Bytes()
+	if !strings.HasPrefix(string(raw), "8=FIX.4.4\x019=") ||
+		!strings.Contains(string(raw), "\x0135=D\x0149=EXEC\x0156=GW\x0134=7\x0111=ord-1\x0144=1300.5\x01") {
+		t.Fatalf("expected the header before the body got %s", msg)
+	}
+
+	parsed, err := fix.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if parsed.MsgType() != fix.MsgTypeNewOrderSingle || parsed.Get(fix.TagClOrdID) != "ord-1" {
+		t.Fatalf("unexpected parsed message %s", parsed)
+	}
+	if price, err := parsed.Float(fix.TagPrice); err != nil || price != 1300.5 {
+		t.Fatalf("unexpected price %v %v", price, err)
+	}
+
+	corrupt := append([]byte{}, raw...)
+	corrupt[len(corrupt)-3] = '9'
+	corrupt[len(corrupt)-2] = '9'
+	if _, err := fix.Parse(corrupt); !errors.Is(err, fix.ErrGarbled) {
+		t.Fatalf("expected a bad checksum to be garbled got %v", err)
+	}
+	if _, err := fix.Parse([]byte("8=FIX.4.2\x019=5\x0135=0\x0110=000\x01")); !errors.Is(err, fix.ErrGarbled) {
+		t.Fatalf("expected another protocol version to be refused got %v", err)
+	}
+}
+
+// peer drives the counterparty side of a connection message by message.
+type peer struct {
+	t        *testing.T
+	conn     net.Conn
+	received chan *fix.Message
+	seq      int
+}
+
+func newPeer(t *testing.T) (*peer, net.Conn) {
+	t.Helper()
+	local, remote := net.Pipe()
+	t.Cleanup(func() { remote.Close() })
+	p := &peer{t: t, conn: remote, received: make(chan *fix.Message, 64), seq: 1}
+	// net.Pipe writes block until read, so the peer reads all the time.
+	go func() {
+		r := bufio.NewReader(remote)
+		for {
+			msg, err := fix.ReadMessage(r)
+			if err != nil {
+				close(p.received)
+				return
+			}
+			p.received <- msg
+		}
+	}()
+	return p, local
+}
+
+func (p *peer) send(msg *fix.Message) {
+	p.t.Helper()
+	msg.Set(fix.TagSenderCompID, "GW").Set(fix.TagTargetCompID, "EXEC")
+	if !msg.Has(fix.TagMsgSeqNum) {
+		msg.SetInt(fix.TagMsgSeqNum, p.seq)
+		p.seq++
+	}
+	msg.SetTime(fix.TagSendingTime, time.Now())
+	if _, err := p.conn.Write(msg.Bytes()); err != nil {
+		p.t.Fatalf("peer write: %v", err)
+	}
+}
+
+// expect returns the next message, which must be of msgType, skipping
+// heartbeats.
+func (p *peer) expect(msgType string) *fix.Message {
+	p.t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case msg, ok := <-p.received:
+			if !ok {
+				p.t.Fatalf("connection closed waiting for MsgType %s", msgType)
+			}
+			if msg.MsgType() == msgType {
+				return msg
+			}
+			if msg.MsgType() != fix.MsgTypeHeartbeat {
+				p.t.Fatalf("expected MsgType %s got %s", msgType, msg)
+			}
+		case <-timeout:
+			p.t.Fatalf("timed out waiting for MsgType %s", msgType)
+		}
+	}
+}
+
+// logon answers the initiator's logon.
+func (p *peer) logon() *fix.Message {
+	p.t.Helper()
+	logon := p.expect(fix.MsgTypeLogon)
+	p.send(fix.NewMessage(fix.MsgTypeLogon).SetInt(fix.TagEncryptMethod, 0).Set(fix.TagHeartBtInt, logon.Get(fix.TagHeartBtInt)))
+	return logon
+}
+
+type session struct {
+	*fix.Session
+	received chan *fix.Message
+	done     chan error
+}
+
+func initiate(t *testing.T, cfg fix.Config, conn net.Conn) *session {
+	t.Helper()
+	cfg.SenderCompID, cfg.TargetCompID = "EXEC", "GW"
+	s := &session{received: make(chan *fix.Message, 16), done: make(chan error, 1)}
+	s.Session = fix.NewSession(cfg, func(msg *fix.Message) { s.received <- msg },
+		fix.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { s.done <- s.Initiate(ctx, conn) }()
+	return s
+}
+
+func (s *session) ended(t *testing.T) error {
+	t.Helper()
+	select {
+	case err := <-s.done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatalf("session did not end")
+		return nil
+	}
+}
+
+func TestLogonResetsSequencesAndAnswersTestRequests(t *testing.T) {
+	p, conn := newPeer(t)
+	s := initiate(t, fix.Config{HeartBtInt: 30 * time.Second, ResetSeqNum: true}, conn)
+
+	logon := p.logon()
+	if logon.Get(fix.TagMsgSeqNum) != "1" || !logon.Bool(fix.TagResetSeqNumFlag) || logon.Get(fix.TagHeartBtInt) != "30" {
+		t.Fatalf("unexpected logon %s", logon)
+	}
+	p.send(fix.NewMessage(fix.MsgTypeTestRequest).Set(fix.TagTestReqID, "ping"))
+	if hb := p.expect(fix.MsgTypeHeartbeat); hb.Get(fix.TagTestReqID) != "ping" || hb.Get(fix.TagMsgSeqNum) != "2" {
+		t.Fatalf("expected the test request answered got %s", hb)
+	}
+	p.send(fix.NewMessage(fix.MsgTypeExecutionReport).Set(fix.TagExecID, "X-1"))
+	if msg := <-s.received; msg.Get(fix.TagExecID) != "X-1" {
+		t.Fatalf("expected the report passed on got %s", msg)
+	}
+	if sender, target := s.SeqNums(); sender != 3 || target != 4 {
+		t.Fatalf("expected sequences 3 and 4 got %d and %d", sender, target)
+	}
+
+	p.send(fix.NewMessage(fix.MsgTypeLogout))
+	p.expect(fix.MsgTypeLogout)
+	if err := s.ended(t); !errors.Is(err, fix.ErrLoggedOut) {
+		t.Fatalf("expected a counterparty logout got %v", err)
+	}
+	if s.LoggedOn() {
+		t.Fatalf("expected the session logged off")
+	}
+}
+
+func TestSilentCounterpartyIsTestedThenDropped(t *testing.T) {
+	p, conn := newPeer(t)
+	s := initiate(t, fix.Config{HeartBtInt: time.Second}, conn)
+	p.logon()
+
+	start := time.Now()
+	p.expect(fix.MsgTypeTestRequest)
+	if waited := time.Since(start); waited < time.Second {
+		t.Fatalf("expected the test request after the heartbeat interval, got it after %v", waited)
+	}
+	p.expect(fix.MsgTypeLogout)
+	if err := s.ended(t); !errors.Is(err, fix.ErrHeartbeatTimeout) {
+		t.Fatalf("expected a heartbeat timeout got %v", err)
+	}
+}
+
+func TestGapIsRecoveredByResend(t *testing.T) {
+	p, conn := newPeer(t)
+	s := initiate(t, fix.Config{HeartBtInt: 30 * time.Second}, conn)
+	p.logon()
+
+	// Messages 2 and 3 are lost; 4 exposes the gap.
+	p.seq = 4
+	p.send(fix.NewMessage(fix.MsgTypeExecutionReport).Set(fix.TagExecID, "X-4"))
+	resend := p.expect(fix.MsgTypeResendRequest)
+	if resend.Get(fix.TagBeginSeqNo) != "2" || resend.Get(fix.TagEndSeqNo) != "0" {
+		t.Fatalf("unexpected resend request %s", resend)
+	}
+	select {
+	case msg := <-s.received:
+		t.Fatalf("expected %s held until the gap is filled", msg)
+	default:
+	}
+
+	p.send(fix.NewMessage(fix.MsgTypeExecutionReport).Set(fix.TagExecID, "X-2").
+		SetInt(fix.TagMsgSeqNum, 2).SetBool(fix.TagPossDupFlag, true))
+	p.send(fix.NewMessage(fix.MsgTypeSequenceReset).SetBool(fix.TagGapFillFlag, true).SetInt(fix.TagNewSeqNo, 4).
+		SetInt(fix.TagMsgSeqNum, 3).SetBool(fix.TagPossDupFlag, true))
+	for _, want := range []string{"X-2", "X-4"} {
+		if msg := <-s.received; msg.Get(fix.TagExecID) != want {
+			t.Fatalf("expected %s in sequence got %s", want, msg)
+		}
+	}
+	// A duplicate of an applied message is dropped.
+	p.send(fix.NewMessage(fix.MsgTypeExecutionReport).Set(fix.TagExecID, "X-2").
+		SetInt(fix.TagMsgSeqNum, 2).SetBool(fix.TagPossDupFlag, true))
+	if _, target := s.SeqNums(); target != 5 {
+		t.Fatalf("expected 5 next got %d", target)
+	}
+
+	// Our own messages are resent on request, the logon as a gap fill.
+	if err := s.Send(fix.NewMessage(fix.MsgTypeNewOrderSingle).Set(fix.TagClOrdID, "ord-1")); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	sent := p.expect(fix.MsgTypeNewOrderSingle)
+	p.send(fix.NewMessage(fix.MsgTypeResendRequest).SetInt(fix.TagBeginSeqNo, 1).SetInt(fix.TagEndSeqNo, 0))
+	fill := p.expect(fix.MsgTypeSequenceReset)
+	if fill.Get(fix.TagMsgSeqNum) != "1" || !fill.Bool(fix.TagGapFillFlag) || fill.Get(fix.TagNewSeqNo) != "3" {
+		t.Fatalf("expected a gap fill over the session messages got %s", fill)
+	}
+	again := p.expect(fix.MsgTypeNewOrderSingle)
+	if again.Get(fix.TagMsgSeqNum) != sent.Get(fix.TagMsgSeqNum) || !again.Bool(fix.TagPossDupFlag) ||
+		again.Get(fix.TagOrigSendingTime) != sent.Get(fix.TagSendingTime) || again.Get(fix.TagClOrdID) != "ord-1" {
+		t.Fatalf("expected the order resent as a possible duplicate got %s", again)
+	}
+}
+
+func TestSequenceTooLowEndsTheSession(t *testing.T) {
+	p, conn := newPeer(t)
+	s := initiate(t, fix.Config{HeartBtInt: 30 * time.Second}, conn)
+	p.logon()
+
+	p.send(fix.NewMessage(fix.MsgTypeHeartbeat).SetInt(fix.TagMsgSeqNum, 1))
+	if logout := p.expect(fix.MsgTypeLogout); !strings.Contains(logout.Get(fix.TagText), "expecting 2 but received 1") {
+		t.Fatalf("unexpected logout %s", logout)
+	}
+	if err := s.ended(t); !errors.Is(err, fix.ErrSequence) {
+		t.Fatalf("expected a sequence error got %v", err)
+	}
+	if err := s.Send(fix.NewMessage(fix.MsgTypeNewOrderSingle)); !errors.Is(err, fix.ErrNotLoggedOn) {
+		t.Fatalf("expected sends to fail once logged off got %v", err)
+	}
+}
+
+func TestFileSeqStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fix.seq")
+	store := fix.NewFileSeqStore(path)
+	if _, _, ok, err := store.Load(); ok || err != nil {
+		t.Fatalf("expected nothing stored got %v %v", ok, err)
+	}
+	if err := store.Save(12, 7); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if sender, target, ok, err := store.Load(); !ok || err != nil || sender != 12 || target != 7 {
+		t.Fatalf("expected 12 and 7 got %d %d %v %v", sender, target, ok, err)
+	}
+	if err := os.WriteFile(path, []byte("garbage"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, _, _, err := store.Load(); err == nil {
+		t.Fatalf("expected a corrupt file reported")
+	}
+}
@@ -0,0 +1,252 @@
+package grpc_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/future-bots/executor/internal/broker"
+	executorgrpc "github.com/future-bots/executor/internal/grpc"
+	"github.com/future-bots/executor/internal/repository"
+	"github.com/future-bots/executor/internal/service"
+	ordersv1 "github.com/future-bots/proto/orders/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newTestClient(t *testing.T, opts ...service.Option) (ordersv1.ExecutorServiceClient, *executorgrpc.Events) {
+	t.Helper()
+	events := executorgrpc.NewEvents(nil)
+	svc := service.New(repository.NewMemory(), nil, append([]service.Option{service.WithEventPublisher(events)}, opts...)...)
+	server := executorgrpc.NewGRPCServer(slog.New(slog.NewJSONHandler(io.Discard, nil)), svc, events)
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(func() {
+		events.Close()
+		server.GracefulStop()
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return ordersv1.NewExecutorServiceClient(conn), events
+}
+
+func intent(id, bot string) *ordersv1.OrderIntent {
+	return &ordersv1.OrderIntent{
+		IntentId:   id,
+		BotId:      bot,
+		Symbol:     "VN30F1M",
+		Side:       ordersv1.OrderSide_ORDER_SIDE_BUY,
+		Quantity:   2,
+		LimitPrice: wrapperspb.Double(1300),
+	}
+}
+
+func TestSubmitGetCancelAndList(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	order, err := client.SubmitOrder(ctx, intent("intent-1", "bot-1"))
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if order.GetId() == "" || order.GetIntentId() != "intent-1" || order.GetSide() != ordersv1.OrderSide_ORDER_SIDE_BUY ||
+		order.GetLimitPrice().GetValue() != 1300 || order.GetStatus() != ordersv1.OrderStatus_ORDER_STATUS_ROUTED {
+		t.Fatalf("unexpected submitted order %v", order)
+	}
+
+	var header metadata.MD
+	replayed, err := client.SubmitOrder(ctx, intent("intent-1", "bot-1"), grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if replayed.GetId() != order.GetId() || len(header.Get(executorgrpc.MetadataReplayed)) != 1 {
+		t.Fatalf("expected the original order flagged as replayed, got %v with header %v", replayed, header)
+	}
+
+	got, err := client.GetOrder(ctx, &ordersv1.GetOrderRequest{OrderId: order.GetId()})
+	if err != nil || got.GetId() != order.GetId() {
+		t.Fatalf("get: %v %v", got, err)
+	}
+
+	if _, err := client.SubmitOrder(ctx, intent("intent-2", "bot-2")); err != nil {
+		t.Fatalf("submit second: %v", err)
+	}
+	cancelled, err := client.CancelOrder(ctx, &ordersv1.CancelOrderRequest{OrderId: order.GetId(), InitiatedBy: "bot", Reason: "done"})
+	if err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if cancelled.GetStatus() != ordersv1.OrderStatus_ORDER_STATUS_CANCELLED {
+		t.Fatalf("expected cancelled order got %v", cancelled.GetStatus())
+	}
+
+	list, err := client.ListOrders(ctx, &ordersv1.ListOrdersRequest{
+		Statuses: []ordersv1.OrderStatus{ordersv1.OrderStatus_ORDER_STATUS_CANCELLED},
+	})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list.GetOrders()) != 1 || list.GetOrders()[0].GetId() != order.GetId() {
+		t.Fatalf("expected only the cancelled order, got %v", list.GetOrders())
+	}
+
+	page, err := client.ListOrders(ctx, &ordersv1.ListOrdersRequest{Limit: 1, Ascending: true})
+	if err != nil {
+		t.Fatalf("list page: %v", err)
+	}
+	if len(page.GetOrders()) != 1 || page.GetOrders()[0].GetId() != order.GetId() || page.GetNextCursor() == "" {
+		t.Fatalf("unexpected first page %v", page)
+	}
+	next, err := client.ListOrders(ctx, &ordersv1.ListOrdersRequest{Limit: 1, Ascending: true, Cursor: page.GetNextCursor()})
+	if err != nil || len(next.GetOrders()) != 1 || next.GetOrders()[0].GetBotId() != "bot-2" {
+		t.Fatalf("unexpected second page %v %v", next, err)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	client, _ := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := client.SubmitOrder(ctx, &ordersv1.OrderIntent{BotId: "bot-1", Quantity: -1})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for an invalid intent, got %v", err)
+	}
+	_, err = client.GetOrder(ctx, &ordersv1.GetOrderRequest{OrderId: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound for a missing order, got %v", err)
+	}
+
+	order, err := client.SubmitOrder(ctx, intent("intent-1", "bot-1"))
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if _, err := client.CancelOrder(ctx, &ordersv1.CancelOrderRequest{OrderId: order.GetId()}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	_, err = client.CancelOrder(ctx, &ordersv1.CancelOrderRequest{OrderId: order.GetId()})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition cancelling a cancelled order, got %v", err)
+	}
+}
+
+// pendingCancelBroker is a simulator whose venue acknowledges cancels
+// without completing them.
+type pendingCancelBroker struct {
+	*broker.Simulator
+}
+
+func (b pendingCancelBroker) Cancel(_ context.Context, providerOrderID string) error {
+	return fmt.Errorf("cancel order %s: %w", providerOrderID, service.ErrCancelPending)
+}
+
+func TestCancelPendingAtTheBroker(t *testing.T) {
+	client, _ := newTestClient(t, service.WithBroker(pendingCancelBroker{broker.NewSimulator(nil, 0)}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	order, err := client.SubmitOrder(ctx, intent("intent-1", "bot-1"))
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	_, err = client.CancelOrder(ctx, &ordersv1.CancelOrderRequest{OrderId: order.GetId()})
+	if status.Code(err) != codes.Aborted || !strings.Contains(status.Convert(err).Message(), "cancel pending") {
+		t.Fatalf("expected Aborted for a cancel pending at the broker, got %v", err)
+	}
+}
+
+// timedOutPlacements is a simulator whose placements time out before it
+// takes them.
+type timedOutPlacements struct {
+	*broker.Simulator
+}
+
+func (timedOutPlacements) Place(context.Context, service.Order) (service.BrokerAck, error) {
+	return service.BrokerAck{}, context.DeadlineExceeded
+}
+
+func TestForceCancelOfUnconfirmedPlacement(t *testing.T) {
+	client, _ := newTestClient(t, service.WithBroker(timedOutPlacements{broker.NewSimulator(nil, 0)}))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	order, err := client.SubmitOrder(ctx, intent("intent-1", "bot-1"))
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if _, err := client.CancelOrder(ctx, &ordersv1.CancelOrderRequest{OrderId: order.GetId()}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable cancelling an unconfirmed placement, got %v", err)
+	}
+	cancelled, err := client.CancelOrder(ctx, &ordersv1.CancelOrderRequest{OrderId: order.GetId(), InitiatedBy: "system", Force: true})
+	if err != nil {
+		t.Fatalf("force cancel: %v", err)
+	}
+	if cancelled.GetStatus() != ordersv1.OrderStatus_ORDER_STATUS_CANCELLED {
+		t.Fatalf("expected the order cancelled got %v", cancelled.GetStatus())
+	}
+}
+
+func TestStreamOrderEvents(t *testing.T) {
+	client, events := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.StreamOrderEvents(ctx, &ordersv1.StreamOrderEventsRequest{BotId: "bot-a"})
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	// The server sends its headers once the subscription is registered.
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("header: %v", err)
+	}
+
+	if _, err := client.SubmitOrder(ctx, intent("intent-b", "bot-b")); err != nil {
+		t.Fatalf("submit bot-b: %v", err)
+	}
+	correlated := metadata.AppendToOutgoingContext(ctx, executorgrpc.MetadataCorrelationID, "corr-1")
+	order, err := client.SubmitOrder(correlated, intent("intent-a", "bot-a"))
+	if err != nil {
+		t.Fatalf("submit bot-a: %v", err)
+	}
+	if _, err := client.CancelOrder(ctx, &ordersv1.CancelOrderRequest{OrderId: order.GetId(), InitiatedBy: "bot"}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("recv ack: %v", err)
+	}
+	ack := event.GetAck()
+	if ack == nil || ack.GetExecutorOrderId() != order.GetId() || event.GetBotId() != "bot-a" || event.GetCorrelationId() != "corr-1" {
+		t.Fatalf("expected the bot-a ack first, got %v", event)
+	}
+	event, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("recv cancel: %v", err)
+	}
+	if event.GetCancel().GetExecutorOrderId() != order.GetId() || event.GetCancel().GetInitiatedBy() != "bot" {
+		t.Fatalf("expected the bot-a cancel, got %v", event)
+	}
+
+	events.Close()
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable once events close, got %v", err)
+	}
+}
@@ -1,20 +1,29 @@
 package http_test
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	stdhttp "net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/future-bots/executor/internal/broker"
 	executorhttp "github.com/future-bots/executor/internal/http"
+	"github.com/future-bots/executor/internal/messaging"
+	"github.com/future-bots/executor/internal/position"
 	"github.com/future-bots/executor/internal/repository"
+	"github.com/future-bots/executor/internal/routing"
 	"github.com/future-bots/executor/internal/service"
+	"github.com/future-bots/executor/internal/stream"
+	"github.com/segmentio/kafka-go"
 )
 
 func newTestLogger() *slog.Logger {
@@ -107,7 +116,7 @@ func TestGetOrderStatus(t *testing.T) {
 		Status:    "filled",
 		UpdatedAt: time.Unix(0, 0).UTC(),
 	}
-	if err := repo.Create(context.Background(), order); err != nil {
+	if err := repo.Create(context.Background(), order, service.CreateGuard{}); err != nil {
 		t.Fatalf("failed to seed repository: %v", err)
 	}
 
@@ -127,6 +136,45 @@ func TestGetOrderStatus(t *testing.T) {
 	}
 }
 
+func TestGetOrderHistory(t *testing.T) {
+	router, _ := newTestRouter(t)
+
+	body, _ := json.Marshal(service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "sell", Quantity: 1, Price: 1400})
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodPost, "/api/v1/orders", bytes.NewReader(body)))
+	var order service.Order
+	if err := json.Unmarshal(rr.Body.Bytes(), &order); err != nil {
+		t.Fatalf("failed to decode order: %v", err)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/orders/"+order.ID+"/history", nil))
+	if rr.Code != stdhttp.StatusOK {
+		t.Fatalf("expected 200 got %d", rr.Code)
+	}
+	var payload struct {
+		Items []service.Transition `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("failed to decode history: %v", err)
+	}
+	want := []service.Status{service.StatusNew, service.StatusPendingRisk, service.StatusRouted}
+	if len(payload.Items) != len(want) {
+		t.Fatalf("expected %d transitions got %+v", len(want), payload.Items)
+	}
+	for i, status := range want {
+		if payload.Items[i].To != status {
+			t.Fatalf("transition %d expected %s got %s", i, status, payload.Items[i].To)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/orders/missing/history", nil))
+	if rr.Code != stdhttp.StatusNotFound {
+		t.Fatalf("expected 404 got %d", rr.Code)
+	}
+}
+
 func TestGetOrderNotFound(t *testing.T) {
 	router, _ := newTestRouter(t)
 	req := httptest.NewRequest(stdhttp.MethodGet, "/api/v1/orders/missing", nil)
@@ -137,3 +185,531 @@ func TestGetOrderNotFound(t *testing.T) {
 		t.Fatalf("expected 404 got %d", rr.Code)
 	}
 }
+
+func TestCreateOrderHonoursIdempotencyKey(t *testing.T) {
+	router, _ := newTestRouter(t)
+	body := `{"bot_id":"bot-1","symbol":"SYM","side":"buy","quantity":1}`
+	post := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(stdhttp.MethodPost, "/api/v1/orders", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := post("key-1")
+	if first.Code != stdhttp.StatusAccepted {
+		t.Fatalf("expected 202 got %d: %s", first.Code, first.Body.String())
+	}
+	second := post("key-1")
+	if second.Code != stdhttp.StatusOK || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected replayed 200 got %d", second.Code)
+	}
+	var a, b service.Order
+	_ = json.Unmarshal(first.Body.Bytes(), &a)
+	_ = json.Unmarshal(second.Body.Bytes(), &b)
+	if a.ID == "" || a.ID != b.ID || a.IntentID != "key-1" {
+		t.Fatalf("expected the same order for a replayed key got %s and %s", a.ID, b.ID)
+	}
+
+	req := httptest.NewRequest(stdhttp.MethodPost, "/api/v1/orders",
+		strings.NewReader(`{"intent_id":"other","bot_id":"bot-1","symbol":"SYM","side":"buy","quantity":1}`))
+	req.Header.Set("Idempotency-Key", "key-2")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != stdhttp.StatusBadRequest {
+		t.Fatalf("expected 400 for mismatched key got %d", rr.Code)
+	}
+}
+
+func TestCancelAndAmendOrder(t *testing.T) {
+	router, _ := newTestRouter(t)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodPost, "/api/v1/orders",
+		strings.NewReader(`{"bot_id":"bot-1","symbol":"SYM","side":"buy","quantity":2,"price":10}`)))
+	var order service.Order
+	if err := json.Unmarshal(rr.Body.Bytes(), &order); err != nil {
+		t.Fatalf("decode order: %v", err)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodPatch, "/api/v1/orders/"+order.ID, strings.NewReader(`{"price":10.5}`)))
+	if rr.Code != stdhttp.StatusOK || !strings.Contains(rr.Body.String(), `"price":10.5`) {
+		t.Fatalf("expected amended order got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodDelete, "/api/v1/orders/"+order.ID+"?initiated_by=bot", nil))
+	if rr.Code != stdhttp.StatusOK || !strings.Contains(rr.Body.String(), `"status":"cancelled"`) {
+		t.Fatalf("expected cancelled order got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	for _, tt := range []struct {
+		method, path, body string
+		code               int
+	}{
+		{stdhttp.MethodDelete, "/api/v1/orders/" + order.ID, "", stdhttp.StatusConflict},
+		{stdhttp.MethodDelete, "/api/v1/orders/missing", "", stdhttp.StatusNotFound},
+		{stdhttp.MethodPatch, "/api/v1/orders/" + order.ID, `{}`, stdhttp.StatusBadRequest},
+		{stdhttp.MethodPatch, "/api/v1/orders/" + order.ID, `{"price":11}`, stdhttp.StatusConflict},
+	} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if rr.Code != tt.code {
+			t.Fatalf("%s %s expected %d got %d", tt.method, tt.path, tt.code, rr.Code)
+		}
+	}
+}
+
+// pendingCancels is a simulator whose cancels are taken but not yet done.
+type pendingCancels struct {
+	*broker.Simulator
+}
+
+func (pendingCancels) Cancel(context.Context, string) error {
+	return service.ErrCancelPending
+}
+
+func TestPendingCancelReturns202(t *testing.T) {
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(pendingCancels{broker.NewSimulator(nil, 0)}))
+	router := executorhttp.NewRouter(newTestLogger(), svc)
+	order, err := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 1, Price: 10})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodDelete, "/api/v1/orders/"+order.ID, nil))
+	if rr.Code != stdhttp.StatusAccepted {
+		t.Fatalf("expected 202 got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got, _ := svc.GetOrder(context.Background(), order.ID); got.Status != service.StatusRouted {
+		t.Fatalf("expected the order still working got %s", got.Status)
+	}
+}
+
+// timedOutPlacements is a simulator whose placements time out before it
+// takes them.
+type timedOutPlacements struct {
+	*broker.Simulator
+}
+
+func (timedOutPlacements) Place(context.Context, service.Order) (service.BrokerAck, error) {
+	return service.BrokerAck{}, context.DeadlineExceeded
+}
+
+func TestForceCancelOfUnconfirmedPlacement(t *testing.T) {
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(timedOutPlacements{broker.NewSimulator(nil, 0)}))
+	router := executorhttp.NewRouter(newTestLogger(), svc)
+	order, err := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 1, Price: 10})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	for _, tt := range []struct {
+		query string
+		code  int
+	}{
+		{query: "", code: stdhttp.StatusBadGateway},
+		{query: "?force=maybe", code: stdhttp.StatusBadRequest},
+		{query: "?initiated_by=system&force=true", code: stdhttp.StatusOK},
+	} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodDelete, "/api/v1/orders/"+order.ID+tt.query, nil))
+		if rr.Code != tt.code {
+			t.Fatalf("cancel%s: expected %d got %d: %s", tt.query, tt.code, rr.Code, rr.Body.String())
+		}
+	}
+	if got, _ := svc.GetOrder(context.Background(), order.ID); got.Status != service.StatusCancelled {
+		t.Fatalf("expected the order cancelled got %s", got.Status)
+	}
+}
+
+func TestThrottledOrdersReturn429(t *testing.T) {
+	svc := service.New(repository.NewMemory(), func() time.Time { return time.Unix(0, 0).UTC() },
+		service.WithRateLimits(service.RateLimitPolicy{Bot: service.RateLimits{OrdersPerSecond: 0.5}}))
+	router := executorhttp.NewRouter(newTestLogger(), svc)
+	body := `{"bot_id":"bot-1","symbol":"SYM","side":"buy","quantity":1,"price":10}`
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodPost, "/api/v1/orders", strings.NewReader(body)))
+	if rr.Code != stdhttp.StatusAccepted {
+		t.Fatalf("expected 202 got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodPost, "/api/v1/orders", strings.NewReader(body)))
+	if rr.Code != stdhttp.StatusTooManyRequests || rr.Header().Get("Retry-After") != "2" {
+		t.Fatalf("expected 429 with Retry-After got %d %q: %s", rr.Code, rr.Header().Get("Retry-After"), rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/rate-limits", nil))
+	var status service.RateLimitStatus
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil || len(status.Throttled) != 1 || status.Throttled[0].Count != 1 {
+		t.Fatalf("expected one throttled bot got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestParentOrderChildren(t *testing.T) {
+	router, _ := newTestRouter(t)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodPost, "/api/v1/orders",
+		strings.NewReader(`{"bot_id":"bot-1","symbol":"SYM","side":"buy","quantity":6,"price":10,"algorithm":"twap","algo_duration_seconds":600,"algo_slices":3}`)))
+	if rr.Code != stdhttp.StatusAccepted {
+		t.Fatalf("expected 202 got %d: %s", rr.Code, rr.Body.String())
+	}
+	var parent service.Order
+	if err := json.Unmarshal(rr.Body.Bytes(), &parent); err != nil {
+		t.Fatalf("decode order: %v", err)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/orders/"+parent.ID+"/children", nil))
+	var progress service.ParentProgress
+	if err := json.Unmarshal(rr.Body.Bytes(), &progress); err != nil || rr.Code != stdhttp.StatusOK {
+		t.Fatalf("expected progress got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(progress.Children) != 3 || progress.WorkingQuantity != 2 || progress.ScheduledQuantity != 4 {
+		t.Fatalf("unexpected progress %+v", progress)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/orders?parent_id="+parent.ID, nil))
+	var page service.OrderPage
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil || len(page.Items) != 3 {
+		t.Fatalf("expected children listed by parent_id got %s", rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodDelete, "/api/v1/orders/"+parent.ID, nil))
+	if rr.Code != stdhttp.StatusOK || !strings.Contains(rr.Body.String(), `"status":"cancelled"`) {
+		t.Fatalf("expected cancelled parent got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/orders/"+parent.ID+"/children", nil))
+	progress = service.ParentProgress{}
+	_ = json.Unmarshal(rr.Body.Bytes(), &progress)
+	if progress.CompletedChildren != 3 {
+		t.Fatalf("expected every child closed got %+v", progress)
+	}
+
+	for _, path := range []string{"/api/v1/orders/" + progress.Children[0].ID + "/children", "/api/v1/orders/missing/children"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, path, nil))
+		if rr.Code != stdhttp.StatusBadRequest && rr.Code != stdhttp.StatusNotFound {
+			t.Fatalf("%s expected an error got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestListOrdersAndExecutions(t *testing.T) {
+	router, repo := newTestRouter(t)
+	var orders []service.Order
+	for i, status := range []service.Status{service.StatusFilled, service.StatusRouted, service.StatusFilled} {
+		at := time.Unix(int64(i), 0).UTC()
+		order := service.Order{ID: fmt.Sprintf("ord-%d", i), BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Status: status, CreatedAt: at, UpdatedAt: at}
+		if err := repo.Create(context.Background(), order, service.CreateGuard{}); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+		orders = append(orders, order)
+	}
+	execution := service.Execution{ID: "x-1", OrderID: "ord-0", Quantity: 1, Price: 1250, FilledAt: time.Unix(5, 0).UTC()}
+	if err := repo.RecordExecution(context.Background(), orders[0], execution, service.Transition{OrderID: "ord-0"}); err != nil {
+		t.Fatalf("seed execution: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/orders?bot_id=bot-1&status=filled&limit=1", nil))
+	if rr.Code != stdhttp.StatusOK {
+		t.Fatalf("expected 200 got %d: %s", rr.Code, rr.Body.String())
+	}
+	var page service.OrderPage
+	if err := json.Unmarshal(rr.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode page: %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0].ID != "ord-2" || page.NextCursor == "" {
+		t.Fatalf("expected newest filled order with a cursor got %+v", page)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/orders?status=filled&limit=1&cursor="+page.NextCursor, nil))
+	page = service.OrderPage{}
+	_ = json.Unmarshal(rr.Body.Bytes(), &page)
+	if len(page.Items) != 1 || page.Items[0].ID != "ord-0" || page.NextCursor != "" {
+		t.Fatalf("expected last page with ord-0 got %+v", page)
+	}
+
+	for _, query := range []string{"sort=sideways", "created_from=yesterday", "limit=abc", "status=accepted"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/orders?"+query, nil))
+		if rr.Code != stdhttp.StatusBadRequest {
+			t.Fatalf("%s expected 400 got %d", query, rr.Code)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/orders/ord-0/executions", nil))
+	if rr.Code != stdhttp.StatusOK || !strings.Contains(rr.Body.String(), `"id":"x-1"`) {
+		t.Fatalf("expected executions got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/orders/missing/executions", nil))
+	if rr.Code != stdhttp.StatusNotFound {
+		t.Fatalf("expected 404 got %d", rr.Code)
+	}
+}
+
+func TestListPositions(t *testing.T) {
+	store := repository.NewMemoryPositions()
+	svc := service.New(repository.NewMemory(), nil)
+	router := executorhttp.NewRouter(newTestLogger(), svc, executorhttp.WithPositions(store))
+
+	for _, symbol := range []string{"VN30F1M", "VN30F2M"} {
+		key := position.Key{AccountID: "acc-1", BotID: "bot-1", Mode: service.ModeLive, Symbol: symbol}
+		if _, err := store.Update(context.Background(), key, func(p *position.Position) { p.Apply(2, 1250, 0, time.Time{}) }); err != nil {
+			t.Fatalf("seed position: %v", err)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/positions?account_id=acc-1&symbol=VN30F2M", nil))
+	if rr.Code != stdhttp.StatusOK {
+		t.Fatalf("expected 200 got %d", rr.Code)
+	}
+	var body struct {
+		Items []position.Position `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode positions: %v", err)
+	}
+	if len(body.Items) != 1 || body.Items[0].Symbol != "VN30F2M" || body.Items[0].Quantity != 2 || body.Items[0].AveragePrice != 1250 {
+		t.Fatalf("unexpected positions %+v", body.Items)
+	}
+}
+
+func TestReconciliationRuns(t *testing.T) {
+	repo := repository.NewMemory()
+	svc := service.New(repo, nil, service.WithBroker(broker.NewSimulator(nil, 0)), service.WithReconciliationLog(repo))
+	router := executorhttp.NewRouter(newTestLogger(), svc)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodPost, "/api/v1/reconciliation/runs", nil))
+	if rr.Code != stdhttp.StatusOK {
+		t.Fatalf("expected 200 for a manual run got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/reconciliation/runs?limit=5", nil))
+	if rr.Code != stdhttp.StatusOK {
+		t.Fatalf("expected 200 got %d", rr.Code)
+	}
+	var body struct {
+		Items []service.ReconciliationRun `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode runs: %v", err)
+	}
+	if len(body.Items) != 1 || body.Items[0].ID == "" || body.Items[0].Discrepancies == nil {
+		t.Fatalf("unexpected runs %+v", body.Items)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/reconciliation/runs?limit=many", nil))
+	if rr.Code != stdhttp.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid limit got %d", rr.Code)
+	}
+}
+
+func TestDeadLetterAdmin(t *testing.T) {
+	repo := repository.NewMemory()
+	svc := service.New(repo, nil)
+	consumer := messaging.NewConsumer(nil, svc, newTestLogger(), messaging.WithDeadLetters(nil, repo))
+	router := executorhttp.NewRouter(newTestLogger(), svc, executorhttp.WithDeadLetters(consumer))
+
+	msg := kafka.Message{Topic: messaging.IntentTopic("acc-1", "bot-1"), Offset: 3, Value: []byte{0xff}}
+	if err := consumer.Handle(context.Background(), msg); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/admin/dead-letters?limit=10", nil))
+	var list struct {
+		Items []messaging.DeadLetter `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil || rr.Code != stdhttp.StatusOK || len(list.Items) != 1 {
+		t.Fatalf("expected one dead letter got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := list.Items[0]; got.Topic != msg.Topic || got.Offset != 3 || !bytes.Equal(got.Value, msg.Value) {
+		t.Fatalf("unexpected dead letter %+v", got)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodPost, "/api/v1/admin/dead-letters/"+list.Items[0].ID+"/redrive", nil))
+	if rr.Code != stdhttp.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 re-driving an undecodable intent got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodPost, "/api/v1/admin/dead-letters/unknown/redrive", nil))
+	if rr.Code != stdhttp.StatusNotFound {
+		t.Fatalf("expected 404 got %d: %s", rr.Code, rr.Body.String())
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/admin/dead-letters?limit=0", nil))
+	if rr.Code != stdhttp.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad limit got %d", rr.Code)
+	}
+}
+
+func TestRoutingAdmin(t *testing.T) {
+	repo := repository.NewMemory()
+	routes := routing.NewRouter(repo, []routing.Venue{
+		{Name: "ssi", Broker: broker.NewSimulator(nil, 0, broker.WithIDPrefix("SSI"))},
+		{Name: "vps", Broker: broker.NewSimulator(nil, 0, broker.WithIDPrefix("VPS"))},
+	})
+	router := executorhttp.NewRouter(newTestLogger(), service.New(repo, nil), executorhttp.WithRouting(routes))
+
+	put := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodPut, "/api/v1/admin/routing/rules", strings.NewReader(body)))
+		return rr
+	}
+	if rr := put(`{"items":[{"account_id":"acc-split","brokers":["tcbs"]}]}`); rr.Code != stdhttp.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown broker got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := put(`{"items":`); rr.Code != stdhttp.StatusBadRequest {
+		t.Fatalf("expected 400 for a malformed body got %d", rr.Code)
+	}
+	if rr := put(`{"items":[{"account_id":"acc-split","brokers":["vps","ssi"]}]}`); rr.Code != stdhttp.StatusOK {
+		t.Fatalf("expected the rules replaced got %d: %s", rr.Code, rr.Body.String())
+	}
+	if stored, _ := repo.RoutingRules(context.Background()); len(stored) != 1 || stored[0].AccountID != "acc-split" {
+		t.Fatalf("expected the rules stored got %+v", stored)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/admin/routing/rules", nil))
+	var rules struct {
+		Items []routing.Rule `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &rules); err != nil || len(rules.Items) != 1 || strings.Join(rules.Items[0].Brokers, ",") != "vps,ssi" {
+		t.Fatalf("unexpected rules %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/api/v1/admin/routing/venues", nil))
+	var venues struct {
+		Items []routing.VenueStatus `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &venues); err != nil || len(venues.Items) != 2 || venues.Items[0].Name != "ssi" || !venues.Items[1].Ready {
+		t.Fatalf("unexpected venues %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestOrderStream(t *testing.T) {
+	hub := stream.NewHub(16)
+	svc := service.New(repository.NewMemory(), nil, service.WithOrderUpdates(hub))
+	server := httptest.NewServer(executorhttp.NewRouter(newTestLogger(), svc, executorhttp.WithOrderStream(hub)))
+	defer server.Close()
+	defer hub.Close()
+
+	connect := func(query, lastEventID string) (*stdhttp.Response, *bufio.Reader) {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		t.Cleanup(cancel)
+		req, _ := stdhttp.NewRequestWithContext(ctx, stdhttp.MethodGet, server.URL+"/api/v1/orders/stream"+query, nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := stdhttp.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("connect: %v", err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp, bufio.NewReader(resp.Body)
+	}
+	next := func(r *bufio.Reader) map[string]string {
+		t.Helper()
+		fields := map[string]string{}
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				t.Fatalf("read event: %v", err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" && len(fields) > 0 {
+				return fields
+			}
+			if name, value, ok := strings.Cut(line, ": "); ok && name != "" {
+				fields[name] = value
+			}
+		}
+	}
+
+	resp, events := connect("?bot_id=bot-a", "")
+	if resp.StatusCode != stdhttp.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected stream response %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	for _, bot := range []string{"bot-b", "bot-a"} {
+		if _, err := svc.SubmitOrder(context.Background(), service.OrderIntent{
+			BotID: bot, Symbol: "SYM", Side: "buy", Quantity: 1, Price: 10,
+		}); err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+	}
+	event := next(events)
+	var update service.OrderUpdate
+	if err := json.Unmarshal([]byte(event["data"]), &update); err != nil {
+		t.Fatalf("decode update: %v", err)
+	}
+	if event["event"] != "order" || event["id"] == "" || update.Order.BotID != "bot-a" {
+		t.Fatalf("expected the bot-a order first, got %v", event)
+	}
+
+	// Resuming from the event before bot-a's first update replays it.
+	id, _ := strconv.ParseUint(event["id"], 10, 64)
+	_, replayed := connect("?bot_id=bot-a", strconv.FormatUint(id-1, 10))
+	if again := next(replayed); again["id"] != event["id"] || again["data"] != event["data"] {
+		t.Fatalf("expected replay of %v got %v", event, again)
+	}
+
+	_, reset := connect("", "9999")
+	if got := next(reset); got["event"] != "reset" {
+		t.Fatalf("expected a reset for an unknown event id got %v", got)
+	}
+
+	resp, _ = connect("", "not-a-number")
+	if resp.StatusCode != stdhttp.StatusBadRequest {
+		t.Fatalf("expected 400 for a malformed Last-Event-ID got %d", resp.StatusCode)
+	}
+}
+
+func TestReadinessChecks(t *testing.T) {
+	var brokerErr error
+	svc := service.New(repository.NewMemory(), nil)
+	router := executorhttp.NewRouter(newTestLogger(), svc,
+		executorhttp.WithReadinessCheck("broker", func(context.Context) error { return brokerErr }))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/readyz", nil))
+	if rr.Code != stdhttp.StatusOK {
+		t.Fatalf("expected ready got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	brokerErr = broker.ErrCircuitOpen
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/readyz", nil))
+	var body struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || rr.Code != stdhttp.StatusServiceUnavailable ||
+		body.Status != "unavailable" || body.Checks["broker"] != broker.ErrCircuitOpen.Error() {
+		t.Fatalf("expected 503 naming the broker got %d: %s", rr.Code, rr.Body.String())
+	}
+	// Liveness does not depend on the broker.
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(stdhttp.MethodGet, "/healthz", nil))
+	if rr.Code != stdhttp.StatusOK {
+		t.Fatalf("expected live got %d", rr.Code)
+	}
+}
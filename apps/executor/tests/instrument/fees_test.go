@@ -0,0 +1,53 @@
+package instrument_test
+
+import (
+	"testing"
+
+	"github.com/future-bots/executor/internal/instrument"
+	"github.com/future-bots/executor/internal/service"
+)
+
+func TestDerivativesFees(t *testing.T) {
+	schedule := instrument.DefaultFeeSchedule()
+	schedule.Commission = 3_000
+	fees := instrument.NewDerivativesFees(schedule)
+
+	buy := fees.Charges(service.Order{Symbol: "VN30F1M", Side: "buy"}, 2, 1250.4)
+	if buy.Fee != 2*(3_000+2_700+2_550) || buy.Tax != 0 {
+		t.Fatalf("unexpected buy charges %+v", buy)
+	}
+	// 0.1% of 1250.4 points x 100,000 VND x 2 contracts.
+	sell := fees.Charges(service.Order{Symbol: "41I1F7000", Side: "sell"}, 2, 1250.4)
+	if sell.Fee != buy.Fee || sell.Tax != 250_080 {
+		t.Fatalf("unexpected sell charges %+v", sell)
+	}
+	if got := fees.Charges(service.Order{Symbol: "FPT", Side: "sell"}, 100, 120); got != (service.FillCharges{}) {
+		t.Fatalf("expected non-derivatives to go uncharged got %+v", got)
+	}
+
+	if err := schedule.Validate(); err != nil {
+		t.Fatalf("expected the schedule valid: %v", err)
+	}
+	for _, bad := range []instrument.FeeSchedule{{ExchangeFee: -1}, {IncomeTaxRate: 1}} {
+		if err := bad.Validate(); err == nil {
+			t.Fatalf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestMarginRequirement(t *testing.T) {
+	requirement := instrument.MarginRequirement(0.2)
+
+	// 20% of 1300 points x 100,000 VND x 3 unfilled contracts.
+	limit := service.Order{Symbol: "VN30F1M", Quantity: 5, FilledQuantity: 2, Price: 1300}
+	if got := requirement(limit); got != 78_000_000 {
+		t.Fatalf("unexpected limit order requirement %v", got)
+	}
+	stop := service.Order{Symbol: "VN30F1M", Quantity: 1, StopPrice: 1250}
+	if got := requirement(stop); got != 25_000_000 {
+		t.Fatalf("unexpected stop order requirement %v", got)
+	}
+	if got := requirement(service.Order{Symbol: "VN30F1M", Quantity: 1}); got != 0 {
+		t.Fatalf("expected market orders to require no margin got %v", got)
+	}
+}
@@ -0,0 +1,57 @@
+package instrument_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/future-bots/executor/internal/instrument"
+	"github.com/future-bots/executor/internal/service"
+	marketsv1 "github.com/future-bots/proto/markets/v1"
+)
+
+func TestHOSEDerivativesRules(t *testing.T) {
+	bands := instrument.NewBands()
+	bands.OnSnapshot(&marketsv1.SsiPsSnapshot{Code: "VN30F1M", CeilingPrice: 1337.5, FloorPrice: 1162.5, ReferencePrice: 1250})
+	rules := instrument.NewHOSEDerivatives(bands)
+
+	for _, tt := range []struct {
+		name     string
+		order    service.Order
+		category service.RejectionCategory
+	}{
+		{"on tick inside band", service.Order{Symbol: "VN30F1M", Quantity: 2, Price: 1250.1}, ""},
+		{"market order", service.Order{Symbol: "VN30F1M", Quantity: 1}, ""},
+		{"off tick", service.Order{Symbol: "VN30F1M", Quantity: 1, Price: 1250.15}, service.RejectionInvalidPrice},
+		{"above ceiling", service.Order{Symbol: "VN30F1M", Quantity: 1, Price: 1337.6}, service.RejectionInvalidPrice},
+		{"below floor", service.Order{Symbol: "VN30F1M", Quantity: 1, Price: 1162.4}, service.RejectionInvalidPrice},
+		{"stop trigger off tick", service.Order{Symbol: "VN30F1M", Quantity: 1, StopPrice: 1249.95}, service.RejectionInvalidPrice},
+		{"fractional contracts", service.Order{Symbol: "VN30F1M", Quantity: 1.5, Price: 1250}, service.RejectionInvalidQuantity},
+		{"no band yet", service.Order{Symbol: "VN30F2M", Quantity: 1, Price: 9999.9}, ""},
+		{"not a derivative", service.Order{Symbol: "FPT", Quantity: 0.5, Price: 101.05}, ""},
+	} {
+		err := rules.Check(tt.order)
+		if tt.category == "" {
+			if err != nil {
+				t.Fatalf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		var violation service.RuleViolation
+		if !errors.As(err, &violation) || violation.Category != tt.category {
+			t.Fatalf("%s: expected %s violation got %v", tt.name, tt.category, err)
+		}
+	}
+}
+
+func TestBandDerivedFromReferencePrice(t *testing.T) {
+	bands := instrument.NewBands()
+	bands.OnSnapshot(&marketsv1.SsiPsSnapshot{Code: "VN30F1M", ReferencePrice: 1250})
+	rules := instrument.NewHOSEDerivatives(bands)
+
+	if err := rules.Check(service.Order{Symbol: "VN30F1M", Quantity: 1, Price: 1337.5}); err != nil {
+		t.Fatalf("expected price at the derived ceiling to pass got %v", err)
+	}
+	if err := rules.Check(service.Order{Symbol: "VN30F1M", Quantity: 1, Price: 1337.6}); err == nil {
+		t.Fatalf("expected price above the derived ceiling to fail")
+	}
+}
@@ -0,0 +1,315 @@
+package messaging_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/future-bots/executor/internal/messaging"
+	"github.com/future-bots/executor/internal/repository"
+	"github.com/future-bots/executor/internal/service"
+	ordersv1 "github.com/future-bots/proto/orders/v1"
+	"github.com/segmentio/kafka-go"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type fakeWriter struct {
+	messages []kafka.Message
+	err      error
+}
+
+func (f *fakeWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.messages = append(f.messages, msgs...)
+	return nil
+}
+
+func (f *fakeWriter) Close() error { return nil }
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(io.Discard, nil))
+}
+
+func newPipeline(t *testing.T) (*messaging.Consumer, *fakeWriter) {
+	t.Helper()
+	writer := &fakeWriter{}
+	now := func() time.Time { return time.Unix(1700, 0).UTC() }
+	publisher := messaging.NewPublisher(writer, now)
+	svc := service.New(repository.NewMemory(), now, service.WithEventPublisher(publisher))
+	return messaging.NewConsumer(nil, svc, newTestLogger()), writer
+}
+
+func decodeEvent(t *testing.T, msg kafka.Message) *ordersv1.OrderEvent {
+	t.Helper()
+	var event ordersv1.OrderEvent
+	if err := proto.Unmarshal(msg.Value, &event); err != nil {
+		t.Fatalf("decode order event: %v", err)
+	}
+	return &event
+}
+
+func TestParseIntentTopic(t *testing.T) {
+	account, bot, ok := messaging.ParseIntentTopic("orders.intent.account.acc-1.bot-1")
+	if !ok || account != "acc-1" || bot != "bot-1" {
+		t.Fatalf("unexpected parse result %q %q %v", account, bot, ok)
+	}
+	if _, _, ok := messaging.ParseIntentTopic("orders.event.account.acc-1.bot-1"); ok {
+		t.Fatalf("expected event topic to be rejected")
+	}
+	if got := messaging.EventTopic("acc-1", "bot-1"); got != "orders.event.account.acc-1.bot-1" {
+		t.Fatalf("unexpected event topic %s", got)
+	}
+}
+
+func TestIntentFromProtoMapsExecutionInstructions(t *testing.T) {
+	expires := time.Unix(1800, 0).UTC()
+	intent := messaging.IntentFromProto(&ordersv1.OrderIntent{
+		BotId:       "bot-1",
+		Type:        ordersv1.OrderType_ORDER_TYPE_STOP,
+		StopPrice:   wrapperspb.Double(1240),
+		LimitPrice:  wrapperspb.Double(1239.5),
+		TimeInForce: "GTC",
+		ExpiresAt:   timestamppb.New(expires),
+		Sequence:    42,
+	}, "")
+	if intent.Type != service.OrderTypeStop || intent.StopPrice != 1240 || intent.Price != 1239.5 {
+		t.Fatalf("unexpected order type mapping %+v", intent)
+	}
+	if intent.TimeInForce != service.TimeInForceGTC || intent.ExpiresAt == nil || !intent.ExpiresAt.Equal(expires) || intent.Sequence != 42 {
+		t.Fatalf("unexpected instruction mapping %+v", intent)
+	}
+
+	twap := messaging.IntentFromProto(&ordersv1.OrderIntent{
+		BotId:        "bot-1",
+		Algorithm:    "twap",
+		AlgoDuration: durationpb.New(30 * time.Minute),
+		AlgoSlices:   6,
+	}, "")
+	if twap.Algorithm != service.AlgorithmTWAP || twap.AlgoDurationSeconds != 1800 || twap.AlgoSlices != 6 {
+		t.Fatalf("unexpected algorithm mapping %+v", twap)
+	}
+}
+
+func TestHandlePublishesAckForValidIntent(t *testing.T) {
+	consumer, writer := newPipeline(t)
+
+	payload, _ := proto.Marshal(&ordersv1.OrderIntent{
+		IntentId:   "intent-1",
+		Symbol:     "VN30F1M",
+		Side:       ordersv1.OrderSide_ORDER_SIDE_BUY,
+		Quantity:   2,
+		LimitPrice: wrapperspb.Double(1250.5),
+		Type:       ordersv1.OrderType_ORDER_TYPE_LIMIT,
+	})
+	consumer.Handle(context.Background(), kafka.Message{
+		Topic:   messaging.IntentTopic("acc-1", "bot-1"),
+		Value:   payload,
+		Headers: []kafka.Header{{Key: messaging.HeaderCorrelationID, Value: []byte("trace-1")}},
+	})
+
+	if len(writer.messages) != 1 {
+		t.Fatalf("expected one event published got %d", len(writer.messages))
+	}
+	msg := writer.messages[0]
+	if msg.Topic != "orders.event.account.acc-1.bot-1" {
+		t.Fatalf("unexpected topic %s", msg.Topic)
+	}
+	event := decodeEvent(t, msg)
+	ack := event.GetAck()
+	if ack == nil {
+		t.Fatalf("expected ack event got %v", event)
+	}
+	if ack.GetIntentId() != "intent-1" || ack.GetExecutorOrderId() == "" {
+		t.Fatalf("unexpected ack %+v", ack)
+	}
+	if event.GetCorrelationId() != "trace-1" {
+		t.Fatalf("expected correlation id trace-1 got %s", event.GetCorrelationId())
+	}
+	if event.GetBotId() != "bot-1" || event.GetAccountId() != "acc-1" {
+		t.Fatalf("expected routing ids from topic got %s/%s", event.GetBotId(), event.GetAccountId())
+	}
+}
+
+func TestHandlePublishesRejectionForInvalidIntent(t *testing.T) {
+	consumer, writer := newPipeline(t)
+
+	payload, _ := proto.Marshal(&ordersv1.OrderIntent{IntentId: "intent-2", Symbol: "VN30F1M", Quantity: 1})
+	consumer.Handle(context.Background(), kafka.Message{Topic: messaging.IntentTopic("acc-1", "bot-1"), Value: payload})
+
+	if len(writer.messages) != 1 {
+		t.Fatalf("expected one event published got %d", len(writer.messages))
+	}
+	event := decodeEvent(t, writer.messages[0])
+	rejection := event.GetRejection()
+	if rejection == nil {
+		t.Fatalf("expected rejection event got %v", event)
+	}
+	if rejection.GetIntentId() != "intent-2" || rejection.GetReason() == "" {
+		t.Fatalf("unexpected rejection %+v", rejection)
+	}
+	if event.GetCorrelationId() != "intent-2" {
+		t.Fatalf("expected correlation to fall back to intent id got %s", event.GetCorrelationId())
+	}
+}
+
+func TestHandleSkipsUndecodablePayload(t *testing.T) {
+	consumer, writer := newPipeline(t)
+	consumer.Handle(context.Background(), kafka.Message{Topic: messaging.IntentTopic("acc-1", "bot-1"), Value: []byte{0xff, 0xff}})
+	if len(writer.messages) != 0 {
+		t.Fatalf("expected no events for malformed payload got %d", len(writer.messages))
+	}
+}
+
+func TestEnvelopeCoversAllEventTypes(t *testing.T) {
+	order := service.Order{ID: "ord-1", IntentID: "intent-1", BotID: "bot-1", AccountID: "acc-1"}
+	at := time.Unix(1700, 0).UTC()
+
+	fill, err := messaging.NewEnvelope(service.Event{
+		Type:  service.EventFill,
+		Order: order,
+		Fill:  &service.Fill{ProviderOrderID: "p-1", Quantity: 1, Remaining: 1, Price: 1250, FilledAt: at},
+	}, at)
+	if err != nil || fill.GetFill().GetProviderOrderId() != "p-1" || fill.GetFill().GetRemainingQuantity() != 1 {
+		t.Fatalf("unexpected fill envelope %v (%v)", fill, err)
+	}
+
+	cancel, err := messaging.NewEnvelope(service.Event{Type: service.EventCancel, Order: order, InitiatedBy: "bot"}, at)
+	if err != nil || cancel.GetCancel().GetInitiatedBy() != "bot" {
+		t.Fatalf("unexpected cancel envelope %v (%v)", cancel, err)
+	}
+
+	amended := order
+	amended.Price, amended.Quantity = 1255, 3
+	amend, err := messaging.NewEnvelope(service.Event{Type: service.EventAmend, Order: amended, Reason: "resized"}, at)
+	if err != nil || amend.GetAmend().GetPrice() != 1255 || amend.GetAmend().GetQuantity() != 3 || amend.GetAmend().GetReason() != "resized" {
+		t.Fatalf("unexpected amend envelope %v (%v)", amend, err)
+	}
+
+	rejection, err := messaging.NewEnvelope(service.Event{Type: service.EventRejection, Order: order, Category: service.RejectionRiskLimit}, at)
+	if err != nil || rejection.GetRejection().GetCategory() != ordersv1.RejectionReason_REJECTION_REASON_RISK_LIMIT {
+		t.Fatalf("unexpected rejection envelope %v (%v)", rejection, err)
+	}
+
+	leg := order
+	leg.BracketID, leg.Leg = "ord-entry", service.LegStopLoss
+	legAck, err := messaging.NewEnvelope(service.Event{Type: service.EventAck, Order: leg}, at)
+	if err != nil || legAck.GetBracketId() != "ord-entry" || legAck.GetLeg() != ordersv1.BracketLeg_BRACKET_LEG_STOP_LOSS {
+		t.Fatalf("unexpected bracket leg envelope %v (%v)", legAck, err)
+	}
+
+	paper := order
+	paper.Mode = service.ModePaper
+	paperAck, err := messaging.NewEnvelope(service.Event{Type: service.EventAck, Order: paper}, at)
+	if err != nil || paperAck.GetMode() != ordersv1.ExecutionMode_EXECUTION_MODE_PAPER {
+		t.Fatalf("unexpected paper envelope %v (%v)", paperAck, err)
+	}
+
+	if _, err := messaging.NewEnvelope(service.Event{Type: service.EventFill, Order: order}, at); err == nil {
+		t.Fatalf("expected error for fill event without fill")
+	}
+}
+
+func TestPublisherRequiresRoutingIdentifiers(t *testing.T) {
+	publisher := messaging.NewPublisher(&fakeWriter{}, nil)
+	err := publisher.PublishOrderEvent(context.Background(), service.Event{Type: service.EventAck, Order: service.Order{BotID: "bot-1"}})
+	if !errors.Is(err, messaging.ErrUnroutable) {
+		t.Fatalf("expected ErrUnroutable got %v", err)
+	}
+}
+
+// flakyRepo fails order creation while failing is set.
+type flakyRepo struct {
+	*repository.Memory
+	failing bool
+	creates int
+}
+
+func (r *flakyRepo) Create(ctx context.Context, order service.Order, guard service.CreateGuard, events ...service.Event) error {
+	r.creates++
+	if r.failing {
+		return errors.New("database unavailable")
+	}
+	return r.Memory.Create(ctx, order, guard, events...)
+}
+
+func TestHandleDeadLettersUndecodablePayload(t *testing.T) {
+	dlq := &fakeWriter{}
+	store := repository.NewMemory()
+	svc := service.New(repository.NewMemory(), nil)
+	consumer := messaging.NewConsumer(nil, svc, newTestLogger(), messaging.WithDeadLetters(dlq, store))
+
+	topic := messaging.IntentTopic("acc-1", "bot-1")
+	msg := kafka.Message{Topic: topic, Partition: 2, Offset: 41, Key: []byte("k"), Value: []byte{0xff, 0xff},
+		Headers: []kafka.Header{{Key: messaging.HeaderCorrelationID, Value: []byte("trace-1")}}}
+	if err := consumer.Handle(context.Background(), msg); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+
+	if len(dlq.messages) != 1 {
+		t.Fatalf("expected one dead-lettered message got %d", len(dlq.messages))
+	}
+	dead := dlq.messages[0]
+	if dead.Topic != "dlq."+topic || string(dead.Value) != string(msg.Value) || string(dead.Key) != "k" {
+		t.Fatalf("expected the original bytes on the dead-letter topic got %+v", dead)
+	}
+	headers := map[string]string{}
+	for _, h := range dead.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	if headers[messaging.HeaderCorrelationID] != "trace-1" || headers[messaging.HeaderDeadLetterTopic] != topic ||
+		headers[messaging.HeaderDeadLetterOffset] != "41" || headers[messaging.HeaderDeadLetterAttempts] != "1" || headers[messaging.HeaderDeadLetterError] == "" {
+		t.Fatalf("unexpected dead-letter headers %v", headers)
+	}
+
+	letters, err := consumer.DeadLetters(context.Background(), 10)
+	if err != nil || len(letters) != 1 || letters[0].Partition != 2 || letters[0].Offset != 41 {
+		t.Fatalf("expected the dead letter stored got %+v (%v)", letters, err)
+	}
+	if _, err := consumer.Redrive(context.Background(), letters[0].ID); !errors.Is(err, messaging.ErrUndecodableIntent) {
+		t.Fatalf("expected undecodable re-drive to fail got %v", err)
+	}
+}
+
+func TestHandleRetriesThenDeadLettersAndRedrives(t *testing.T) {
+	ctx := context.Background()
+	repo := &flakyRepo{Memory: repository.NewMemory(), failing: true}
+	store := repository.NewMemory()
+	consumer := messaging.NewConsumer(nil, service.New(repo, nil), newTestLogger(),
+		messaging.WithRetry(3, time.Millisecond, 2*time.Millisecond),
+		messaging.WithDeadLetters(nil, store))
+
+	payload, _ := proto.Marshal(&ordersv1.OrderIntent{
+		IntentId: "intent-9", Symbol: "VN30F1M", Side: ordersv1.OrderSide_ORDER_SIDE_BUY, Quantity: 1,
+		LimitPrice: wrapperspb.Double(1250), Type: ordersv1.OrderType_ORDER_TYPE_LIMIT,
+	})
+	if err := consumer.Handle(ctx, kafka.Message{Topic: messaging.IntentTopic("acc-1", "bot-1"), Offset: 7, Value: payload}); err != nil {
+		t.Fatalf("handle: %v", err)
+	}
+	if repo.creates != 3 {
+		t.Fatalf("expected three attempts got %d", repo.creates)
+	}
+	letters, _ := consumer.DeadLetters(ctx, 10)
+	if len(letters) != 1 || letters[0].Attempts != 3 || letters[0].RedrivenAt != nil {
+		t.Fatalf("expected the intent dead-lettered after three attempts got %+v", letters)
+	}
+
+	repo.failing = false
+	redriven, err := consumer.Redrive(ctx, letters[0].ID)
+	if err != nil || redriven.RedrivenAt == nil {
+		t.Fatalf("expected re-drive to succeed got %+v (%v)", redriven, err)
+	}
+	if _, err := repo.GetByIntent(ctx, "bot-1", "intent-9"); err != nil {
+		t.Fatalf("expected the re-driven intent to create its order: %v", err)
+	}
+	if letters, _ := consumer.DeadLetters(ctx, 10); letters[0].RedrivenAt == nil {
+		t.Fatalf("expected the dead letter marked re-driven got %+v", letters[0])
+	}
+}
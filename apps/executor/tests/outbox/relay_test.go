@@ -0,0 +1,188 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/future-bots/executor/internal/outbox"
+	"github.com/future-bots/executor/internal/repository"
+	"github.com/future-bots/executor/internal/service"
+)
+
+type flakyPublisher struct {
+	failures  map[service.EventType]int
+	delivered []service.Event
+}
+
+func (p *flakyPublisher) PublishOrderEvent(_ context.Context, event service.Event) error {
+	if p.failures[event.Type] > 0 {
+		p.failures[event.Type]--
+		return errors.New("broker unavailable")
+	}
+	if event.Order.AccountID == "" {
+		return fmt.Errorf("%w: no account", service.ErrUndeliverable)
+	}
+	p.delivered = append(p.delivered, event)
+	return nil
+}
+
+func newLogger() *slog.Logger {
+	return slog.New(slog.NewJSONHandler(io.Discard, nil))
+}
+
+func TestServiceStoresEventsWithStateChanges(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	var direct []service.EventType
+	svc := service.New(repo, nil, service.WithOutbox(),
+		service.WithEventPublisher(service.EventPublisherFunc(func(_ context.Context, e service.Event) error {
+			direct = append(direct, e.Type)
+			return nil
+		})))
+
+	order, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", AccountID: "acc-1", Symbol: "VN30F1M", Side: "buy", Quantity: 2, Price: 1250})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: order.ID, Quantity: 2, Price: 1250}); err != nil {
+		t.Fatalf("fill: %v", err)
+	}
+
+	pending, err := repo.Pending(ctx, time.Now(), 0, 10)
+	if err != nil {
+		t.Fatalf("pending: %v", err)
+	}
+	if len(pending) != 2 || pending[0].Event.Type != service.EventAck || pending[1].Event.Type != service.EventFill {
+		t.Fatalf("expected ack and fill in the outbox got %+v", pending)
+	}
+	if pending[1].Event.Order.Status != service.StatusFilled || pending[1].Event.CorrelationID == "" {
+		t.Fatalf("expected stored fill to carry the persisted order got %+v", pending[1].Event)
+	}
+	if len(direct) != 2 {
+		t.Fatalf("expected in-process publishers to still see both events got %v", direct)
+	}
+}
+
+func TestRelayRetriesInOrderAndParksUndeliverable(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	at := time.Unix(1_700_000_000, 0).UTC()
+	now := at
+	order := service.Order{ID: "ord-1", BotID: "bot-1", AccountID: "acc-1"}
+	if err := repo.AppendEvents(ctx,
+		service.Event{Type: service.EventAck, Order: order, OccurredAt: at},
+		service.Event{Type: service.EventFill, Order: order, OccurredAt: at},
+		service.Event{Type: service.EventRejection, Order: service.Order{BotID: "bot-1", IntentID: "i-1"}, OccurredAt: at},
+	); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	publisher := &flakyPublisher{failures: map[service.EventType]int{service.EventAck: 1}}
+	relay := outbox.NewRelay(repo, publisher, outbox.WithLogger(newLogger()),
+		outbox.WithBackoff(time.Second, 4*time.Second), outbox.WithClock(func() time.Time { return now }))
+
+	if n, err := relay.RunOnce(ctx); err != nil || n != 0 {
+		t.Fatalf("expected nothing delivered on the first pass got %d %v", n, err)
+	}
+	pending, _ := repo.Pending(ctx, at.Add(time.Hour), 0, 10)
+	if len(pending) != 2 || pending[0].Attempts != 1 || !pending[0].NextAttemptAt.Equal(at.Add(time.Second)) {
+		t.Fatalf("expected the ack to be scheduled for retry and the rejection parked got %+v", pending)
+	}
+
+	if n, _ := relay.RunOnce(ctx); n != 0 {
+		t.Fatalf("expected the fill to wait behind the ack retry, delivered %d", n)
+	}
+
+	now = now.Add(time.Second)
+	if n, err := relay.RunOnce(ctx); err != nil || n != 2 {
+		t.Fatalf("expected ack and fill delivered got %d %v", n, err)
+	}
+	if len(publisher.delivered) != 2 || publisher.delivered[0].Type != service.EventAck || publisher.delivered[1].Type != service.EventFill {
+		t.Fatalf("unexpected delivery order %+v", publisher.delivered)
+	}
+	if pending, _ := repo.Pending(ctx, at.Add(time.Hour), 0, 10); len(pending) != 0 {
+		t.Fatalf("expected outbox drained got %+v", pending)
+	}
+}
+
+func TestRelayGivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	now := time.Unix(0, 0).UTC()
+	if err := repo.AppendEvents(ctx, service.Event{Type: service.EventAck, Order: service.Order{ID: "ord-1", AccountID: "acc-1"}, OccurredAt: now}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+	publisher := &flakyPublisher{failures: map[service.EventType]int{service.EventAck: 5}}
+	relay := outbox.NewRelay(repo, publisher, outbox.WithLogger(newLogger()), outbox.WithMaxAttempts(2),
+		outbox.WithClock(func() time.Time { return now }))
+
+	for i := 0; i < 2; i++ {
+		if _, err := relay.RunOnce(ctx); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		now = now.Add(time.Minute)
+	}
+	if pending, _ := repo.Pending(ctx, now.Add(time.Hour), 0, 10); len(pending) != 0 {
+		t.Fatalf("expected the record to be parked after two attempts got %+v", pending)
+	}
+}
+
+func TestRelayIsNotStarvedByRecordsAwaitingRetry(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	now := time.Unix(1_700_000_000, 0).UTC()
+	for _, id := range []string{"ord-1", "ord-2", "ord-3"} {
+		if err := repo.AppendEvents(ctx, service.Event{Type: service.EventAck, Order: service.Order{ID: id, AccountID: "acc-1"}, OccurredAt: now}); err != nil {
+			t.Fatalf("append: %v", err)
+		}
+	}
+	if err := repo.AppendEvents(ctx, service.Event{Type: service.EventFill, Order: service.Order{ID: "ord-4", AccountID: "acc-1"}, OccurredAt: now}); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	// The acks keep failing and fill a batch of three on the first pass.
+	publisher := &flakyPublisher{failures: map[service.EventType]int{service.EventAck: 100}}
+	relay := outbox.NewRelay(repo, publisher, outbox.WithLogger(newLogger()), outbox.WithBatchSize(3),
+		outbox.WithBackoff(time.Minute, time.Hour), outbox.WithClock(func() time.Time { return now }))
+	if n, err := relay.RunOnce(ctx); err != nil || n != 0 {
+		t.Fatalf("expected the acks to fail got %d %v", n, err)
+	}
+	now = now.Add(time.Second)
+	if n, err := relay.RunOnce(ctx); err != nil || n != 1 || publisher.delivered[0].Order.ID != "ord-4" {
+		t.Fatalf("expected the fill delivered past the backed off acks got %d %v", n, err)
+	}
+}
+
+func TestRelaysSharingTheOutboxSkipClaimedRecords(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	now := time.Unix(1_700_000_000, 0).UTC()
+	order := service.Order{ID: "ord-1", AccountID: "acc-1"}
+	if err := repo.AppendEvents(ctx,
+		service.Event{Type: service.EventAck, Order: order, OccurredAt: now},
+		service.Event{Type: service.EventFill, Order: order, OccurredAt: now},
+	); err != nil {
+		t.Fatalf("append: %v", err)
+	}
+
+	claimed, err := repo.Pending(ctx, now, time.Minute, 1)
+	if err != nil || len(claimed) != 1 || claimed[0].Event.Type != service.EventAck {
+		t.Fatalf("expected the ack claimed got %+v %v", claimed, err)
+	}
+	// The fill waits behind the claimed ack, so no other relay takes it.
+	if pending, _ := repo.Pending(ctx, now, time.Minute, 10); len(pending) != 0 {
+		t.Fatalf("expected nothing left to claim got %+v", pending)
+	}
+	// A relay that died holding the claim releases it when the lease ends.
+	publisher := &flakyPublisher{}
+	relay := outbox.NewRelay(repo, publisher, outbox.WithLogger(newLogger()),
+		outbox.WithClock(func() time.Time { return now.Add(time.Minute) }))
+	if n, err := relay.RunOnce(ctx); err != nil || n != 2 {
+		t.Fatalf("expected both events delivered after the lease got %d %v", n, err)
+	}
+}
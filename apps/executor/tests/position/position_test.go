@@ -0,0 +1,97 @@
+package position_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/future-bots/executor/internal/instrument"
+	"github.com/future-bots/executor/internal/position"
+	"github.com/future-bots/executor/internal/service"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestApplyAverageCost(t *testing.T) {
+	at := time.Unix(0, 0).UTC()
+	var p position.Position
+
+	p.Apply(2, 1250, 0, at)
+	p.Apply(2, 1260, 0, at)
+	if p.Quantity != 4 || !near(p.AveragePrice, 1255) {
+		t.Fatalf("expected 4 @ 1255 got %+v", p)
+	}
+
+	p.Apply(-1, 1265, 0.5, at)
+	if p.Quantity != 3 || !near(p.AveragePrice, 1255) || !near(p.RealizedPnL, 9.5) {
+		t.Fatalf("expected partial close to realize 9.5 got %+v", p)
+	}
+
+	p.Apply(-5, 1240, 0, at)
+	if p.Quantity != -2 || !near(p.AveragePrice, 1240) || !near(p.RealizedPnL, 9.5-45) {
+		t.Fatalf("expected flip to short 2 @ 1240 got %+v", p)
+	}
+
+	p.Apply(2, 1230, 0, at)
+	if p.Quantity != 0 || p.AveragePrice != 0 || !near(p.RealizedPnL, 9.5-45+20) {
+		t.Fatalf("expected flat position got %+v", p)
+	}
+}
+
+func TestBookSignsTheQuantityBySide(t *testing.T) {
+	at := time.Unix(100, 0).UTC()
+	order := service.Order{ID: "ord-1", BotID: "bot-1", AccountID: "acc-1", Symbol: "VN30F1M", Side: "sell"}
+	key, book := position.Book(order, service.Execution{Quantity: 3, Price: 1250, FilledAt: at}, nil)
+	if key != (position.Key{AccountID: "acc-1", BotID: "bot-1", Mode: service.ModeLive, Symbol: "VN30F1M"}) {
+		t.Fatalf("unexpected key %+v", key)
+	}
+	p := position.Position{Key: key}
+	book(&p)
+	if p.Quantity != -3 || p.AveragePrice != 1250 || !p.UpdatedAt.Equal(at) {
+		t.Fatalf("expected a short of 3 @ 1250 got %+v", p)
+	}
+
+	order.Side = "buy"
+	_, book = position.Book(order, service.Execution{Quantity: 1, Price: 1240, FilledAt: at}, nil)
+	book(&p)
+	if p.Quantity != -2 || !near(p.RealizedPnL, 10) {
+		t.Fatalf("expected the buy to cover 1 got %+v", p)
+	}
+}
+
+func TestBookSeparatesPaperFromLive(t *testing.T) {
+	live := service.Order{BotID: "bot-1", AccountID: "acc-1", Symbol: "VN30F1M", Side: "buy", Mode: service.ModeLive}
+	paper := live
+	paper.Mode = service.ModePaper
+	// Orders stored before execution modes existed count as live.
+	legacy := live
+	legacy.Mode = ""
+
+	liveKey, _ := position.Book(live, service.Execution{Quantity: 1}, nil)
+	paperKey, _ := position.Book(paper, service.Execution{Quantity: 1}, nil)
+	legacyKey, _ := position.Book(legacy, service.Execution{Quantity: 1}, nil)
+	if liveKey == paperKey || paperKey.Mode != service.ModePaper {
+		t.Fatalf("expected paper fills apart from live ones got %+v %+v", liveKey, paperKey)
+	}
+	if legacyKey != liveKey {
+		t.Fatalf("expected orders without a mode booked live got %+v", legacyKey)
+	}
+}
+
+func TestBookDeductsChargesInPriceUnits(t *testing.T) {
+	at := time.Unix(100, 0).UTC()
+	order := service.Order{BotID: "bot-1", AccountID: "acc-1", Symbol: "VN30F1M", Side: "buy"}
+	var p position.Position
+	_, book := position.Book(order, service.Execution{Quantity: 1, Price: 1250, Fee: 5_250, FilledAt: at}, instrument.Multiplier)
+	book(&p)
+	order.Side = "sell"
+	_, book = position.Book(order, service.Execution{Quantity: 1, Price: 1260, Fee: 5_250, Tax: 126_000, FilledAt: at}, instrument.Multiplier)
+	book(&p)
+
+	// 10 points gross less 136,500 VND of charges at 100,000 VND a point.
+	if p.Quantity != 0 || !near(p.RealizedPnL, 8.635) {
+		t.Fatalf("expected PnL net of fees and tax got %+v", p)
+	}
+}
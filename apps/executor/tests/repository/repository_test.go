@@ -0,0 +1,627 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/future-bots/executor/internal/messaging"
+	"github.com/future-bots/executor/internal/migrations"
+	"github.com/future-bots/executor/internal/outbox"
+	"github.com/future-bots/executor/internal/position"
+	"github.com/future-bots/executor/internal/repository"
+	"github.com/future-bots/executor/internal/routing"
+	"github.com/future-bots/executor/internal/service"
+	platformdb "github.com/future-bots/platform/db"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// openPostgres connects to the database named by EXECUTOR_TEST_DATABASE_URL,
+// skipping the test when it is not configured.
+func openPostgres(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("EXECUTOR_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("EXECUTOR_TEST_DATABASE_URL not set")
+	}
+	database, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+	if err := platformdb.Run(context.Background(), database, migrations.Files, migrations.Dir); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	return database
+}
+
+func TestMemoryRepository(t *testing.T) {
+	exerciseRepository(t, repository.NewMemory())
+}
+
+func TestPostgresRepository(t *testing.T) {
+	exerciseRepository(t, repository.NewPostgres(openPostgres(t)))
+}
+
+func TestPostgresOutbox(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewPostgres(openPostgres(t))
+	created := time.Now().UTC().Truncate(time.Microsecond)
+	order := service.Order{ID: fmt.Sprintf("ord-outbox-%d", created.UnixNano()), BotID: "bot-1", AccountID: "acc-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Status: service.StatusNew, CreatedAt: created, UpdatedAt: created}
+	ack := service.Event{Type: service.EventAck, Order: order, CorrelationID: "corr-1", OccurredAt: created}
+	if err := repo.Create(ctx, order, service.CreateGuard{}, ack); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	find := func() (outbox.Record, bool) {
+		pending, err := repo.Pending(ctx, created.Add(time.Hour), 0, 1000)
+		if err != nil {
+			t.Fatalf("pending: %v", err)
+		}
+		for _, rec := range pending {
+			if rec.Event.Order.ID == order.ID {
+				return rec, true
+			}
+		}
+		return outbox.Record{}, false
+	}
+	rec, ok := find()
+	if !ok || rec.Event.Type != service.EventAck || rec.Event.CorrelationID != "corr-1" || !rec.Event.OccurredAt.Equal(created) {
+		t.Fatalf("expected the ack stored with the order got %+v %v", rec, ok)
+	}
+	next := created.Add(time.Minute)
+	if err := repo.MarkRetry(ctx, rec.ID, 1, next, "broker unavailable"); err != nil {
+		t.Fatalf("mark retry: %v", err)
+	}
+	if rec, _ := find(); rec.Attempts != 1 || !rec.NextAttemptAt.Equal(next) {
+		t.Fatalf("expected retry to be recorded got %+v", rec)
+	}
+	if err := repo.MarkDelivered(ctx, rec.ID, next); err != nil {
+		t.Fatalf("mark delivered: %v", err)
+	}
+	if _, ok := find(); ok {
+		t.Fatalf("expected delivered record to leave the pending set")
+	}
+}
+
+func TestMemoryReconciliationLog(t *testing.T) {
+	exerciseReconciliationLog(t, repository.NewMemory())
+}
+
+func TestPostgresReconciliationLog(t *testing.T) {
+	exerciseReconciliationLog(t, repository.NewPostgres(openPostgres(t)))
+}
+
+func exerciseReconciliationLog(t *testing.T, log service.ReconciliationLog) {
+	t.Helper()
+	ctx := context.Background()
+	started := time.Now().UTC().Truncate(time.Microsecond)
+	first := service.ReconciliationRun{ID: fmt.Sprintf("rec-%d-1", started.UnixNano()), StartedAt: started, FinishedAt: started, Discrepancies: []service.Discrepancy{}}
+	second := service.ReconciliationRun{
+		ID:            fmt.Sprintf("rec-%d-2", started.UnixNano()),
+		StartedAt:     started.Add(time.Second),
+		FinishedAt:    started.Add(2 * time.Second),
+		OrdersChecked: 3,
+		Discrepancies: []service.Discrepancy{{Kind: service.DiscrepancyMissedFill, OrderID: "ord-1", Detail: "execution X-1 was not applied", Repaired: true}},
+	}
+	for _, run := range []service.ReconciliationRun{first, second} {
+		if err := log.SaveReconciliationRun(ctx, run); err != nil {
+			t.Fatalf("save run: %v", err)
+		}
+	}
+	runs, err := log.ReconciliationRuns(ctx, 2)
+	if err != nil {
+		t.Fatalf("list runs: %v", err)
+	}
+	if len(runs) != 2 || runs[0].ID != second.ID || runs[1].ID != first.ID {
+		t.Fatalf("expected newest run first got %+v", runs)
+	}
+	if got := runs[0]; got.OrdersChecked != 3 || len(got.Discrepancies) != 1 || !got.Discrepancies[0].Repaired || !got.FinishedAt.Equal(second.FinishedAt) {
+		t.Fatalf("unexpected stored run %+v", got)
+	}
+}
+
+func TestMemorySessionLog(t *testing.T) {
+	exerciseSessionLog(t, repository.NewMemory())
+}
+
+func TestPostgresSessionLog(t *testing.T) {
+	exerciseSessionLog(t, repository.NewPostgres(openPostgres(t)))
+}
+
+func exerciseSessionLog(t *testing.T, log service.SessionLog) {
+	t.Helper()
+	ctx := context.Background()
+	closed := time.Now().UTC().Truncate(time.Microsecond)
+	first := service.SessionSummary{ID: fmt.Sprintf("eod-%d-1", closed.UnixNano()), TradingDate: "2026-10-15", ClosedAt: closed}
+	second := service.SessionSummary{
+		ID: fmt.Sprintf("eod-%d-2", closed.UnixNano()), TradingDate: "2026-10-16", ClosedAt: closed.Add(time.Second),
+		DayOrdersCancelled: 2, OrdersCarried: 1, OrdersSubmitted: 5, OrdersFilled: 2, OrdersCancelled: 2, OrdersRejected: 1,
+		Executions: 3, FilledQuantity: 4, Fees: 21000, Taxes: 5200, Error: "cancel 1 DAY orders: broker request failed",
+	}
+	for _, summary := range []service.SessionSummary{first, second} {
+		if err := log.SaveSessionSummary(ctx, summary); err != nil {
+			t.Fatalf("save summary: %v", err)
+		}
+	}
+	summaries, err := log.SessionSummaries(ctx, 2)
+	if err != nil {
+		t.Fatalf("list summaries: %v", err)
+	}
+	if len(summaries) != 2 || summaries[0].ID != second.ID || summaries[1].ID != first.ID {
+		t.Fatalf("expected newest summary first got %+v", summaries)
+	}
+	if got := summaries[0]; got != second {
+		t.Fatalf("unexpected stored summary %+v", got)
+	}
+}
+
+func TestMemoryRoutingRules(t *testing.T) {
+	exerciseRoutingRules(t, repository.NewMemory())
+}
+
+func TestPostgresRoutingRules(t *testing.T) {
+	exerciseRoutingRules(t, repository.NewPostgres(openPostgres(t)))
+}
+
+func exerciseRoutingRules(t *testing.T, store routing.Store) {
+	t.Helper()
+	ctx := context.Background()
+	rules := []routing.Rule{
+		{AccountID: "acc-split", Brokers: []string{"vps", "ssi"}},
+		{Symbol: "VN30F2M", Brokers: []string{"ssi"}},
+		{Brokers: []string{"ssi", "vps"}},
+	}
+	if err := store.SaveRoutingRules(ctx, rules); err != nil {
+		t.Fatalf("save rules: %v", err)
+	}
+	if err := store.SaveRoutingRules(ctx, rules[1:]); err != nil {
+		t.Fatalf("replace rules: %v", err)
+	}
+	got, err := store.RoutingRules(ctx)
+	if err != nil {
+		t.Fatalf("list rules: %v", err)
+	}
+	if !reflect.DeepEqual(got, rules[1:]) {
+		t.Fatalf("expected the replacement rules in order got %+v", got)
+	}
+	if err := store.SaveRoutingRules(ctx, nil); err != nil {
+		t.Fatalf("clear rules: %v", err)
+	}
+	if got, err := store.RoutingRules(ctx); err != nil || len(got) != 0 {
+		t.Fatalf("expected no rules got %+v %v", got, err)
+	}
+}
+
+func TestMemoryDeadLetters(t *testing.T) {
+	exerciseDeadLetters(t, repository.NewMemory())
+}
+
+func TestPostgresDeadLetters(t *testing.T) {
+	exerciseDeadLetters(t, repository.NewPostgres(openPostgres(t)))
+}
+
+func exerciseDeadLetters(t *testing.T, store messaging.DeadLetterStore) {
+	t.Helper()
+	ctx := context.Background()
+	failed := time.Now().UTC().Truncate(time.Microsecond)
+	topic := fmt.Sprintf("orders.intent.account.acc-%d.bot-1", failed.UnixNano())
+	first := messaging.DeadLetter{ID: topic + ":0:1", Topic: topic, Offset: 1, Value: []byte{0xff}, Error: "undecodable", Attempts: 1, FailedAt: failed}
+	second := messaging.DeadLetter{
+		ID: topic + ":0:2", Topic: topic, Offset: 2, Key: []byte("k"), Value: []byte{0x0a, 0x01},
+		Headers: map[string]string{"correlation_id": "trace-1"}, Error: "database unavailable", Attempts: 3, FailedAt: failed.Add(time.Second),
+	}
+	for _, letter := range []messaging.DeadLetter{first, second, first} {
+		if err := store.SaveDeadLetter(ctx, letter); err != nil {
+			t.Fatalf("save dead letter: %v", err)
+		}
+	}
+	letters, err := store.DeadLetters(ctx, 2)
+	if err != nil {
+		t.Fatalf("list dead letters: %v", err)
+	}
+	if len(letters) != 2 || letters[0].ID != second.ID || letters[1].ID != first.ID {
+		t.Fatalf("expected newest dead letter first, each once, got %+v", letters)
+	}
+	if got := letters[0]; string(got.Value) != string(second.Value) || got.Headers["correlation_id"] != "trace-1" || got.Attempts != 3 || !got.FailedAt.Equal(second.FailedAt) {
+		t.Fatalf("unexpected stored dead letter %+v", got)
+	}
+
+	redriven := failed.Add(time.Minute)
+	if err := store.MarkDeadLetterRedriven(ctx, second.ID, redriven); err != nil {
+		t.Fatalf("mark redriven: %v", err)
+	}
+	if got, err := store.GetDeadLetter(ctx, second.ID); err != nil || got.RedrivenAt == nil || !got.RedrivenAt.Equal(redriven) {
+		t.Fatalf("expected re-drive recorded got %+v (%v)", got, err)
+	}
+	if _, err := store.GetDeadLetter(ctx, topic+":0:3"); !errors.Is(err, messaging.ErrDeadLetterNotFound) {
+		t.Fatalf("expected ErrDeadLetterNotFound got %v", err)
+	}
+}
+
+func TestMemoryPositions(t *testing.T) {
+	exercisePositions(t, repository.NewMemoryPositions())
+}
+
+func TestPostgresPositions(t *testing.T) {
+	exercisePositions(t, repository.NewPostgresPositions(openPostgres(t)))
+}
+
+func exercisePositions(t *testing.T, store position.Store) {
+	t.Helper()
+	ctx := context.Background()
+	key := position.Key{AccountID: fmt.Sprintf("acc-%d", time.Now().UnixNano()), BotID: "bot-1", Mode: service.ModeLive, Symbol: "VN30F1M"}
+	at := time.Unix(1_700_000_000, 0).UTC()
+
+	for _, fill := range []struct{ qty, price float64 }{{2, 1250}, {-1, 1260}} {
+		if _, err := store.Update(ctx, key, func(p *position.Position) { p.Apply(fill.qty, fill.price, 0, at) }); err != nil {
+			t.Fatalf("update: %v", err)
+		}
+	}
+	other := key
+	other.Symbol = "VN30F2M"
+	if _, err := store.Update(ctx, other, func(p *position.Position) { p.Apply(-1, 1240, 0, at) }); err != nil {
+		t.Fatalf("update other: %v", err)
+	}
+
+	got, err := store.List(ctx, position.Filter{AccountID: key.AccountID, Symbol: key.Symbol})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(got) != 1 || got[0].Key != key || got[0].Quantity != 1 || got[0].AveragePrice != 1250 || got[0].RealizedPnL != 10 || !got[0].UpdatedAt.Equal(at) {
+		t.Fatalf("unexpected positions %+v", got)
+	}
+	if all, err := store.List(ctx, position.Filter{AccountID: key.AccountID}); err != nil || len(all) != 2 || all[1].Quantity != -1 {
+		t.Fatalf("expected both symbols got %+v %v", all, err)
+	}
+
+	paper := key
+	paper.Mode = service.ModePaper
+	if _, err := store.Update(ctx, paper, func(p *position.Position) { p.Apply(5, 1230, 0, at) }); err != nil {
+		t.Fatalf("update paper: %v", err)
+	}
+	if live, err := store.List(ctx, position.Filter{AccountID: key.AccountID, Mode: service.ModeLive, Symbol: key.Symbol}); err != nil || len(live) != 1 || live[0].Quantity != 1 {
+		t.Fatalf("expected the live position apart from paper got %+v %v", live, err)
+	}
+	if got, err := store.List(ctx, position.Filter{AccountID: key.AccountID, Mode: service.ModePaper}); err != nil || len(got) != 1 || got[0].Key != paper || got[0].Quantity != 5 {
+		t.Fatalf("unexpected paper positions %+v %v", got, err)
+	}
+}
+
+func TestMemoryOpenOrderLimits(t *testing.T) {
+	exerciseOpenOrderLimits(t, repository.NewMemory())
+}
+
+func TestPostgresOpenOrderLimits(t *testing.T) {
+	exerciseOpenOrderLimits(t, repository.NewPostgres(openPostgres(t)))
+}
+
+// exerciseOpenOrderLimits races creates for the last open-order slots of an
+// account and checks only the slots available are taken. Bracket exits do
+// not count.
+func exerciseOpenOrderLimits(t *testing.T, repo service.OrderRepository) {
+	t.Helper()
+	ctx := context.Background()
+	at := time.Unix(1700, 0).UTC()
+	suffix := time.Now().UnixNano()
+	account := fmt.Sprintf("acc-open-%d", suffix)
+	order := func(id string) service.Order {
+		return service.Order{ID: fmt.Sprintf("ord-open-%d-%s", suffix, id), BotID: "bot-1", AccountID: account, Symbol: "VN30F1M", Side: "buy",
+			Quantity: 1, Price: 1250, Status: service.StatusNew, CreatedAt: at, UpdatedAt: at}
+	}
+	guard := service.CreateGuard{OpenLimits: []service.OpenOrderLimit{{Scope: "account", Key: account, Max: 3}}}
+
+	exit := order("exit")
+	exit.BracketID, exit.Leg = exit.ID, service.LegStopLoss
+	if err := repo.Create(ctx, exit, service.CreateGuard{}); err != nil {
+		t.Fatalf("create exit: %v", err)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		created   int
+		throttled int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := repo.Create(ctx, order(fmt.Sprint(i)), guard)
+			mu.Lock()
+			defer mu.Unlock()
+			var te service.ThrottleError
+			switch {
+			case err == nil:
+				created++
+			case errors.As(err, &te) && te.Scope == "account" && te.Key == account && te.Limit == service.LimitMaxOpenOrders:
+				throttled++
+			default:
+				t.Errorf("create %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if created != 3 || throttled != 7 {
+		t.Fatalf("expected 3 orders created and 7 throttled got %d and %d", created, throttled)
+	}
+}
+
+func TestMemorySequenceGuard(t *testing.T) {
+	exerciseSequenceGuard(t, repository.NewMemory())
+}
+
+func TestPostgresSequenceGuard(t *testing.T) {
+	exerciseSequenceGuard(t, repository.NewPostgres(openPostgres(t)))
+}
+
+// exerciseSequenceGuard races creates carrying the same intent sequence and
+// checks exactly one is stored, then that the stored sequence bounds later
+// creates.
+func exerciseSequenceGuard(t *testing.T, repo service.OrderRepository) {
+	t.Helper()
+	ctx := context.Background()
+	at := time.Unix(1700, 0).UTC()
+	suffix := time.Now().UnixNano()
+	account := fmt.Sprintf("acc-seq-%d", suffix)
+	order := func(id string, seq uint64) service.Order {
+		return service.Order{ID: fmt.Sprintf("ord-seq-%d-%s", suffix, id), Sequence: seq, BotID: "bot-1", AccountID: account, Symbol: "VN30F1M", Side: "buy",
+			Quantity: 1, Price: 1250, Status: service.StatusNew, CreatedAt: at, UpdatedAt: at}
+	}
+	guard := service.CreateGuard{Sequence: true}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		created   int
+		regressed int
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := repo.Create(ctx, order(fmt.Sprint(i), 5), guard)
+			mu.Lock()
+			defer mu.Unlock()
+			var se service.SequenceError
+			switch {
+			case err == nil:
+				created++
+			case errors.As(err, &se) && se.Last == 5 && se.Got == 5:
+				regressed++
+			default:
+				t.Errorf("create %d: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if created != 1 || regressed != 9 {
+		t.Fatalf("expected 1 order created and 9 regressions got %d and %d", created, regressed)
+	}
+
+	if err := repo.Create(ctx, order("older", 4), guard); !errors.Is(err, service.ErrSequenceRegression) {
+		t.Fatalf("expected an older sequence refused got %v", err)
+	}
+	if err := repo.Create(ctx, order("newer", 7), guard); err != nil {
+		t.Fatalf("expected a newer sequence stored got %v", err)
+	}
+	if last, err := repo.LastSequence(ctx, "bot-1", account); err != nil || last != 7 {
+		t.Fatalf("expected last sequence 7 got %d %v", last, err)
+	}
+}
+
+func TestMemoryExecutionsBookPositions(t *testing.T) {
+	repo := repository.NewMemory(repository.WithPositions(nil))
+	exerciseExecutionBooking(t, repo, repo.Positions())
+}
+
+func TestPostgresExecutionsBookPositions(t *testing.T) {
+	database := openPostgres(t)
+	exerciseExecutionBooking(t, repository.NewPostgres(database, repository.WithPositions(nil)), repository.NewPostgresPositions(database))
+}
+
+func exerciseExecutionBooking(t *testing.T, repo service.OrderRepository, positions position.Reader) {
+	t.Helper()
+	ctx := context.Background()
+	at := time.Unix(1700, 0).UTC()
+	suffix := time.Now().UnixNano()
+	order := service.Order{
+		ID:        fmt.Sprintf("ord-book-%d", suffix),
+		BotID:     "bot-1",
+		AccountID: fmt.Sprintf("acc-%d", suffix),
+		Symbol:    "VN30F1M",
+		Side:      "sell",
+		Quantity:  3,
+		Status:    service.StatusRouted,
+		CreatedAt: at,
+		UpdatedAt: at,
+	}
+	if err := repo.Create(ctx, order, service.CreateGuard{}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	partial := order
+	partial.Status = service.StatusPartiallyFilled
+	partial.FilledQuantity = 2
+	execution := service.Execution{ID: order.ID + "-x1", OrderID: order.ID, Quantity: 2, Price: 1250, Fee: 1, FilledAt: at}
+	transition := service.Transition{OrderID: order.ID, From: service.StatusRouted, To: service.StatusPartiallyFilled, At: at}
+	if err := repo.RecordExecution(ctx, partial, execution, transition); err != nil {
+		t.Fatalf("record execution: %v", err)
+	}
+
+	// A redelivered execution neither moves the order nor the position.
+	replay := partial
+	replay.Status = service.StatusFilled
+	replay.FilledQuantity = 3
+	if err := repo.RecordExecution(ctx, replay, execution, transition); !errors.Is(err, service.ErrDuplicateExecution) {
+		t.Fatalf("expected a duplicate execution got %v", err)
+	}
+	if stored, err := repo.Get(ctx, order.ID); err != nil || stored.FilledQuantity != 2 {
+		t.Fatalf("expected the order untouched by the replay got %+v %v", stored, err)
+	}
+
+	got, err := positions.List(ctx, position.Filter{AccountID: order.AccountID})
+	if err != nil {
+		t.Fatalf("list positions: %v", err)
+	}
+	if len(got) != 1 || got[0].Mode != service.ModeLive || got[0].Quantity != -2 || got[0].AveragePrice != 1250 || got[0].RealizedPnL != -1 {
+		t.Fatalf("expected the execution booked once got %+v", got)
+	}
+}
+
+func exerciseRepository(t *testing.T, repo service.OrderRepository) {
+	t.Helper()
+	ctx := context.Background()
+	created := time.Unix(1700, 0).UTC()
+	suffix := time.Now().UnixNano()
+	order := service.Order{
+		ID:        fmt.Sprintf("ord-test-%d", suffix),
+		IntentID:  fmt.Sprintf("intent-%d", suffix),
+		Sequence:  7,
+		BotID:     "mean-reversion-bot",
+		AccountID: fmt.Sprintf("acc-%d", suffix),
+		Symbol:    "VN30F1M",
+		Side:      "buy",
+		Quantity:  2,
+		Price:     1250.5,
+		Status:    service.StatusNew,
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+	if err := repo.Create(ctx, order, service.CreateGuard{}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	stored, err := repo.Get(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if stored.BotID != order.BotID || stored.Price != order.Price || stored.Status != service.StatusNew {
+		t.Fatalf("unexpected stored order %+v", stored)
+	}
+
+	byIntent, err := repo.GetByIntent(ctx, order.BotID, order.IntentID)
+	if err != nil || byIntent.ID != order.ID || byIntent.Sequence != 7 {
+		t.Fatalf("get by intent: %+v %v", byIntent, err)
+	}
+	replay := order
+	replay.ID = order.ID + "-replay"
+	if err := repo.Create(ctx, replay, service.CreateGuard{}); !errors.Is(err, service.ErrDuplicateIntent) {
+		t.Fatalf("expected duplicate intent error got %v", err)
+	}
+	if last, err := repo.LastSequence(ctx, order.BotID, order.AccountID); err != nil || last != 7 {
+		t.Fatalf("expected last sequence 7 got %d %v", last, err)
+	}
+
+	routed := stored
+	routed.Status = service.StatusRouted
+	routed.ProviderOrderID = "SIM-1"
+	routed.Route, routed.RouteReason = "ssi", "rule 1 (account acc-1, symbol *)"
+	routed.UpdatedAt = created.Add(time.Second)
+	if err := repo.Transition(ctx, routed, service.Transition{OrderID: order.ID, From: service.StatusNew, To: service.StatusRouted, At: routed.UpdatedAt}); err != nil {
+		t.Fatalf("transition: %v", err)
+	}
+
+	filled := routed
+	filled.Status = service.StatusFilled
+	filled.FilledQuantity = 2
+	filled.UpdatedAt = created.Add(2 * time.Second)
+	execution := service.Execution{ID: order.ID + "-x1", OrderID: order.ID, Quantity: 2, Price: 1250.4, Fee: 10500, Tax: 250080, FilledAt: filled.UpdatedAt}
+	if err := repo.RecordExecution(ctx, filled, execution, service.Transition{OrderID: order.ID, From: service.StatusRouted, To: service.StatusFilled, At: filled.UpdatedAt}); err != nil {
+		t.Fatalf("record execution: %v", err)
+	}
+
+	stored, err = repo.Get(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if stored.Status != service.StatusFilled || stored.ProviderOrderID != "SIM-1" || stored.FilledQuantity != 2 ||
+		stored.Route != "ssi" || stored.RouteReason != routed.RouteReason {
+		t.Fatalf("unexpected filled order %+v", stored)
+	}
+
+	history, err := repo.Transitions(ctx, order.ID)
+	if err != nil {
+		t.Fatalf("transitions: %v", err)
+	}
+	if len(history) != 3 || history[0].To != service.StatusNew || history[2].From != service.StatusRouted {
+		t.Fatalf("unexpected history %+v", history)
+	}
+
+	fills, err := repo.Executions(ctx, order.ID)
+	if err != nil || len(fills) != 1 || fills[0].ID != execution.ID || fills[0].Price != 1250.4 ||
+		fills[0].Fee != 10500 || fills[0].Tax != 250080 {
+		t.Fatalf("unexpected executions %+v %v", fills, err)
+	}
+
+	second := order
+	second.ID = order.ID + "-2"
+	second.IntentID = ""
+	second.Sequence = 0
+	second.Side = "sell"
+	second.CreatedAt = created.Add(time.Minute)
+	second.UpdatedAt = second.CreatedAt
+	second.BracketID = second.ID
+	second.Leg = service.LegEntry
+	if err := repo.Create(ctx, second, service.CreateGuard{}); err != nil {
+		t.Fatalf("create second: %v", err)
+	}
+	if bracket, err := repo.ListOrders(ctx, service.OrderQuery{Filter: service.OrderFilter{BracketID: second.ID}}); err != nil || len(bracket) != 1 || bracket[0].Leg != service.LegEntry {
+		t.Fatalf("expected the bracket entry got %+v %v", bracket, err)
+	}
+	filter := service.OrderFilter{AccountID: order.AccountID}
+	page, err := repo.ListOrders(ctx, service.OrderQuery{Filter: filter, Limit: 1})
+	if err != nil || len(page) != 1 || page[0].ID != second.ID {
+		t.Fatalf("expected newest order first got %+v %v", page, err)
+	}
+	next, err := repo.ListOrders(ctx, service.OrderQuery{Filter: filter, Limit: 5, After: &service.OrderCursor{CreatedAt: page[0].CreatedAt, ID: page[0].ID}})
+	if err != nil || len(next) != 1 || next[0].ID != order.ID {
+		t.Fatalf("expected older order after cursor got %+v %v", next, err)
+	}
+	filter.Statuses = []service.Status{service.StatusFilled}
+	filter.Side = "buy"
+	if matched, err := repo.ListOrders(ctx, service.OrderQuery{Filter: filter, Limit: 5, Ascending: true}); err != nil || len(matched) != 1 || matched[0].ID != order.ID {
+		t.Fatalf("expected status/side filter to match the filled order got %+v %v", matched, err)
+	}
+
+	scheduled := created.Add(time.Hour)
+	child := service.Order{
+		ID:          order.ID + "-child",
+		ParentID:    order.ID,
+		BotID:       order.BotID,
+		AccountID:   order.AccountID,
+		Symbol:      order.Symbol,
+		Side:        "buy",
+		Quantity:    1,
+		Status:      service.StatusNew,
+		ScheduledAt: &scheduled,
+		CreatedAt:   created.Add(2 * time.Minute),
+		UpdatedAt:   created.Add(2 * time.Minute),
+	}
+	if err := repo.Create(ctx, child, service.CreateGuard{}); err != nil {
+		t.Fatalf("create child: %v", err)
+	}
+	if due, err := repo.Scheduled(ctx, scheduled.Add(-time.Second)); err != nil || len(due) != 0 {
+		t.Fatalf("expected no child due yet got %+v %v", due, err)
+	}
+	due, err := repo.Scheduled(ctx, scheduled)
+	if err != nil || len(due) != 1 || due[0].ID != child.ID || !due[0].ScheduledAt.Equal(scheduled) {
+		t.Fatalf("expected the scheduled child got %+v %v", due, err)
+	}
+	if children, err := repo.ListOrders(ctx, service.OrderQuery{Filter: service.OrderFilter{ParentID: order.ID}, Limit: 5}); err != nil || len(children) != 1 || children[0].ParentID != order.ID {
+		t.Fatalf("expected child listed by parent got %+v %v", children, err)
+	}
+
+	if _, err := repo.Get(ctx, "missing"); !errors.Is(err, service.ErrOrderNotFound) {
+		t.Fatalf("expected ErrOrderNotFound got %v", err)
+	}
+	if err := repo.Transition(ctx, service.Order{ID: "missing"}, service.Transition{OrderID: "missing", To: service.StatusRouted}); !errors.Is(err, service.ErrOrderNotFound) {
+		t.Fatalf("expected ErrOrderNotFound for unknown order got %v", err)
+	}
+}
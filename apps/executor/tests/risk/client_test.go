@@ -0,0 +1,59 @@
+package risk_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/future-bots/executor/internal/risk"
+	"github.com/future-bots/executor/internal/service"
+)
+
+func TestHTTPClientSendsEvaluateRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/risk/evaluate" {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+		if body["bot_id"] != "bot-1" || body["proposed_side"] != "buy" || body["proposed_qty"] != 3.0 {
+			t.Fatalf("unexpected body %+v", body)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"allowed": false, "reason": "limit"})
+	}))
+	defer srv.Close()
+
+	decision, err := risk.NewHTTPClient(srv.URL+"/", srv.Client()).Check(context.Background(), service.RiskRequest{
+		BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 3,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decision.Allowed || decision.Reason != "limit" {
+		t.Fatalf("unexpected decision %+v", decision)
+	}
+}
+
+func TestHTTPClientStatusMapping(t *testing.T) {
+	status := http.StatusBadRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "quantity must be positive"})
+	}))
+	defer srv.Close()
+	client := risk.NewHTTPClient(srv.URL, srv.Client())
+
+	decision, err := client.Check(context.Background(), service.RiskRequest{})
+	if err != nil || decision.Allowed || decision.Reason != "quantity must be positive" {
+		t.Fatalf("expected 4xx denial got %+v %v", decision, err)
+	}
+
+	status = http.StatusInternalServerError
+	if _, err := client.Check(context.Background(), service.RiskRequest{}); err == nil {
+		t.Fatalf("expected error on 5xx")
+	}
+}
@@ -0,0 +1,230 @@
+package routing_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/future-bots/executor/internal/repository"
+	"github.com/future-bots/executor/internal/routing"
+	"github.com/future-bots/executor/internal/service"
+)
+
+// venueBroker is a broker that rests every order it takes. Place fails with
+// placeErr and Ready with readyErr while they are set.
+type venueBroker struct {
+	prefix   string
+	placeErr error
+	readyErr error
+
+	mu        sync.Mutex
+	placed    []string
+	cancelled []string
+}
+
+func (b *venueBroker) Ready(context.Context) error { return b.readyErr }
+
+func (b *venueBroker) Place(_ context.Context, order service.Order) (service.BrokerAck, error) {
+	if b.placeErr != nil {
+		return service.BrokerAck{}, b.placeErr
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.placed = append(b.placed, order.ID)
+	return service.BrokerAck{ProviderOrderID: fmt.Sprintf("%s-%d", b.prefix, len(b.placed))}, nil
+}
+
+func (b *venueBroker) Cancel(_ context.Context, id string) error {
+	if !strings.HasPrefix(id, b.prefix+"-") {
+		return service.ErrUnknownBrokerOrder
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.cancelled = append(b.cancelled, id)
+	return nil
+}
+
+func (b *venueBroker) Amend(context.Context, string, float64, float64) error { return nil }
+
+func (b *venueBroker) Query(_ context.Context, id string) (service.BrokerOrderState, error) {
+	if !strings.HasPrefix(id, b.prefix+"-") {
+		return service.BrokerOrderState{}, service.ErrUnknownBrokerOrder
+	}
+	return service.BrokerOrderState{ProviderOrderID: id}, nil
+}
+
+func (b *venueBroker) Fills() <-chan service.BrokerFill { return nil }
+
+func (b *venueBroker) OpenOrders(context.Context) ([]service.BrokerOrderState, error) {
+	return nil, nil
+}
+
+func (b *venueBroker) Executions(context.Context, string) ([]service.BrokerFill, error) {
+	return nil, nil
+}
+
+// margin reports the same available margin for every account.
+type margin float64
+
+func (m margin) AvailableMargin(context.Context, string) (float64, error) { return float64(m), nil }
+
+func order(account, symbol string) service.Order {
+	return service.Order{ID: "ord-1", AccountID: account, Symbol: symbol, Side: "buy", Quantity: 1, Price: 1300}
+}
+
+func brokers(routes []service.Route) string {
+	names := make([]string, 0, len(routes))
+	for _, route := range routes {
+		names = append(names, route.Broker)
+	}
+	return strings.Join(names, ",")
+}
+
+func TestRoutesFollowTheFirstMatchingRule(t *testing.T) {
+	ctx := context.Background()
+	router := routing.NewRouter(repository.NewMemory(), []routing.Venue{
+		{Name: "ssi", Broker: &venueBroker{prefix: "SSI"}},
+		{Name: "vps", Broker: &venueBroker{prefix: "VPS"}},
+	})
+	err := router.SetRules(ctx, []routing.Rule{
+		{AccountID: "acc-split", Symbol: "VN30F2M", Brokers: []string{"ssi"}},
+		{AccountID: "acc-split", Brokers: []string{"vps", "ssi"}},
+	})
+	if err != nil {
+		t.Fatalf("set rules: %v", err)
+	}
+
+	routes, err := router.Routes(ctx, order("acc-split", "VN30F1M"))
+	if err != nil || brokers(routes) != "vps,ssi" || routes[0].Reason != "rule 2 (account acc-split, symbol *)" {
+		t.Fatalf("expected the account rule to prefer vps got %+v %v", routes, err)
+	}
+	routes, err = router.Routes(ctx, order("acc-split", "VN30F2M"))
+	if err != nil || brokers(routes) != "ssi" || routes[0].Reason != "rule 1 (account acc-split, symbol VN30F2M)" {
+		t.Fatalf("expected the symbol rule to send VN30F2M to ssi got %+v %v", routes, err)
+	}
+	routes, err = router.Routes(ctx, order("acc-1", "VN30F1M"))
+	if err != nil || brokers(routes) != "ssi,vps" || routes[0].Reason != "default broker order" {
+		t.Fatalf("expected unmatched orders to follow the venue order got %+v %v", routes, err)
+	}
+}
+
+func TestRoutesSkipUnhealthyBrokersAndBrokersShortOfMargin(t *testing.T) {
+	ctx := context.Background()
+	down := &venueBroker{prefix: "SSI", readyErr: errors.New("circuit open")}
+	router := routing.NewRouter(repository.NewMemory(), []routing.Venue{
+		{Name: "ssi", Broker: down},
+		{Name: "vps", Broker: &venueBroker{prefix: "VPS"}, Margin: margin(50_000_000)},
+		{Name: "mbs", Broker: &venueBroker{prefix: "MBS"}, Margin: margin(100_000_000)},
+	}, routing.WithMarginRequirement(func(o service.Order) float64 { return o.Price * 100_000 * o.Quantity * 0.5 }))
+
+	// 65,000,000 VND is required: vps has too little.
+	routes, err := router.Routes(ctx, order("acc-1", "VN30F1M"))
+	if err != nil || brokers(routes) != "mbs" {
+		t.Fatalf("expected only mbs to take the order got %+v %v", routes, err)
+	}
+	if reason := routes[0].Reason; !strings.Contains(reason, "ssi: circuit open") ||
+		!strings.Contains(reason, "vps: available margin 50000000 below required 65000000") {
+		t.Fatalf("expected the skipped brokers in the reason got %q", reason)
+	}
+
+	big := order("acc-1", "VN30F1M")
+	big.Quantity = 2
+	if _, err := router.Routes(ctx, big); !errors.Is(err, routing.ErrNoRoute) {
+		t.Fatalf("expected no route for an order no broker has margin for got %v", err)
+	}
+
+	down.readyErr = nil
+	if routes, err := router.Routes(ctx, big); err != nil || brokers(routes) != "ssi" {
+		t.Fatalf("expected the recovered broker without a margin source to take it got %+v %v", routes, err)
+	}
+	if err := router.Ready(ctx); err != nil {
+		t.Fatalf("expected the router ready: %v", err)
+	}
+}
+
+func TestSetRulesValidatesStoresAndReloads(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	venues := []routing.Venue{{Name: "ssi", Broker: &venueBroker{prefix: "SSI"}}, {Name: "vps", Broker: &venueBroker{prefix: "VPS"}}}
+	router := routing.NewRouter(repo, venues)
+
+	for _, bad := range [][]routing.Rule{
+		{{AccountID: "acc-1"}},
+		{{Brokers: []string{"tcbs"}}},
+		{{Brokers: []string{"ssi", "ssi"}}},
+	} {
+		var ve service.ValidationError
+		if err := router.SetRules(ctx, bad); !errors.As(err, &ve) {
+			t.Fatalf("expected %+v rejected got %v", bad, err)
+		}
+	}
+	if stored, _ := repo.RoutingRules(ctx); len(stored) != 0 {
+		t.Fatalf("expected invalid rules left unstored got %+v", stored)
+	}
+
+	rules := []routing.Rule{{AccountID: "acc-split", Brokers: []string{"vps"}}}
+	if err := router.SetRules(ctx, rules); err != nil {
+		t.Fatalf("set rules: %v", err)
+	}
+
+	// Another instance sharing the store picks the rules up on reload.
+	other := routing.NewRouter(repo, venues)
+	if routes, _ := other.Routes(ctx, order("acc-split", "VN30F1M")); brokers(routes) != "ssi,vps" {
+		t.Fatalf("expected the default order before loading got %+v", routes)
+	}
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go other.Run(runCtx, 5*time.Millisecond)
+	deadline := time.Now().Add(2 * time.Second)
+	for len(other.Rules()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the stored rules to be reloaded")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if routes, _ := other.Routes(ctx, order("acc-split", "VN30F1M")); brokers(routes) != "vps" {
+		t.Fatalf("expected the reloaded rule to apply got %+v", routes)
+	}
+}
+
+func TestServiceRecordsTheRouteAndFailsOver(t *testing.T) {
+	ctx := context.Background()
+	ssi := &venueBroker{prefix: "SSI", placeErr: fmt.Errorf("dial: %w", service.ErrBrokerUnavailable)}
+	vps := &venueBroker{prefix: "VPS"}
+	router := routing.NewRouter(repository.NewMemory(), []routing.Venue{{Name: "ssi", Broker: ssi}, {Name: "vps", Broker: vps}})
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(router), service.WithOrderRouter(router))
+
+	intent := service.OrderIntent{IntentID: "i-1", BotID: "bot-1", AccountID: "acc-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1300}
+	order, err := svc.SubmitOrder(ctx, intent)
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if order.Status != service.StatusRouted || order.Route != "vps" || order.ProviderOrderID != "VPS-1" {
+		t.Fatalf("expected the order routed to vps got %+v", order)
+	}
+	if !strings.Contains(order.RouteReason, "failed over from ssi") {
+		t.Fatalf("expected the failover in the route reason got %q", order.RouteReason)
+	}
+
+	if _, err := svc.CancelOrder(ctx, order.ID, service.CancelRequest{InitiatedBy: "bot"}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if len(vps.cancelled) != 1 || vps.cancelled[0] != "VPS-1" {
+		t.Fatalf("expected the cancel sent to vps got %v", vps.cancelled)
+	}
+
+	// A rejection is final: the order is not retried elsewhere.
+	ssi.placeErr = service.BrokerRejection{Reason: "insufficient margin"}
+	intent.IntentID = "i-2"
+	rejected, err := svc.SubmitOrder(ctx, intent)
+	if err != nil {
+		t.Fatalf("submit second: %v", err)
+	}
+	if rejected.Status != service.StatusRejected || rejected.Route != "" || len(vps.placed) != 1 {
+		t.Fatalf("expected the ssi rejection to stand, got %+v with vps placing %v", rejected, vps.placed)
+	}
+}
@@ -3,19 +3,30 @@ package service_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/future-bots/executor/internal/broker"
+	"github.com/future-bots/executor/internal/instrument"
+	"github.com/future-bots/executor/internal/position"
+	"github.com/future-bots/executor/internal/repository"
 	service "github.com/future-bots/executor/internal/service"
+	"github.com/future-bots/executor/internal/session"
+	marketsv1 "github.com/future-bots/proto/markets/v1"
 )
 
 type stubRepo struct {
-	stored service.Order
-	err    error
-	getErr error
+	stored      service.Order
+	transitions []service.Transition
+	err         error
+	getErr      error
 }
 
-func (s *stubRepo) Create(_ context.Context, order service.Order) error {
+func (s *stubRepo) Create(_ context.Context, order service.Order, _ service.CreateGuard, _ ...service.Event) error {
 	if s.err != nil {
 		return s.err
 	}
@@ -33,6 +44,53 @@ func (s *stubRepo) Get(_ context.Context, id string) (service.Order, error) {
 	return service.Order{}, service.ErrOrderNotFound
 }
 
+func (s *stubRepo) GetByIntent(_ context.Context, botID, intentID string) (service.Order, error) {
+	if s.stored.IntentID != "" && s.stored.BotID == botID && s.stored.IntentID == intentID {
+		return s.stored, nil
+	}
+	return service.Order{}, service.ErrOrderNotFound
+}
+
+func (s *stubRepo) LastSequence(context.Context, string, string) (uint64, error) {
+	return s.stored.Sequence, nil
+}
+
+func (s *stubRepo) Transition(_ context.Context, order service.Order, transition service.Transition, _ ...service.Event) error {
+	s.stored = order
+	s.transitions = append(s.transitions, transition)
+	return nil
+}
+
+func (s *stubRepo) Transitions(_ context.Context, _ string) ([]service.Transition, error) {
+	return s.transitions, nil
+}
+
+func (s *stubRepo) Expiring(context.Context, time.Time) ([]service.Order, error) {
+	return nil, nil
+}
+
+func (s *stubRepo) Scheduled(context.Context, time.Time) ([]service.Order, error) {
+	return nil, nil
+}
+
+func (s *stubRepo) ListOrders(context.Context, service.OrderQuery) ([]service.Order, error) {
+	return nil, nil
+}
+
+func (s *stubRepo) Executions(context.Context, string) ([]service.Execution, error) {
+	return nil, nil
+}
+
+func (s *stubRepo) RecordExecution(_ context.Context, order service.Order, _ service.Execution, transition service.Transition, _ ...service.Event) error {
+	s.stored = order
+	s.transitions = append(s.transitions, transition)
+	return nil
+}
+
+func (s *stubRepo) AppendEvents(context.Context, ...service.Event) error {
+	return nil
+}
+
 func TestSubmitOrderValidatesIntent(t *testing.T) {
 	repo := &stubRepo{}
 	svc := service.New(repo, func() time.Time { return time.Unix(0, 0).UTC() })
@@ -64,14 +122,71 @@ func TestSubmitOrderPersistsAndReturnsOrder(t *testing.T) {
 	if repo.stored.ID != order.ID {
 		t.Fatalf("expected order to be stored")
 	}
-	if order.Status != "accepted" {
-		t.Fatalf("expected status accepted got %s", order.Status)
+	if order.Status != service.StatusRouted {
+		t.Fatalf("expected status routed got %s", order.Status)
+	}
+	if len(repo.transitions) != 2 || repo.transitions[0].To != service.StatusPendingRisk || repo.transitions[1].To != service.StatusRouted {
+		t.Fatalf("expected new -> pending_risk -> routed transitions got %+v", repo.transitions)
 	}
 	if !order.UpdatedAt.Equal(time.Unix(0, 0).UTC()) {
 		t.Fatalf("expected deterministic timestamp got %v", order.UpdatedAt)
 	}
 }
 
+func TestSubmitOrderPublishesEvents(t *testing.T) {
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	svc := service.New(&stubRepo{}, func() time.Time { return time.Unix(0, 0).UTC() }, service.WithEventPublisher(publisher))
+
+	ctx := service.WithCorrelationID(context.Background(), "trace-1")
+	order, err := svc.SubmitOrder(ctx, service.OrderIntent{
+		IntentID:  "intent-1",
+		BotID:     "bot-1",
+		AccountID: "acc-1",
+		Symbol:    "SYM",
+		Side:      "buy",
+		Quantity:  1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != service.EventAck {
+		t.Fatalf("expected single ack event got %+v", events)
+	}
+	if events[0].Order.ID != order.ID || events[0].CorrelationID != "trace-1" {
+		t.Fatalf("unexpected ack event %+v", events[0])
+	}
+
+	_, _ = svc.SubmitOrder(context.Background(), service.OrderIntent{IntentID: "intent-2", BotID: "bot-1"})
+	if len(events) != 2 || events[1].Type != service.EventRejection {
+		t.Fatalf("expected rejection event got %+v", events)
+	}
+	if events[1].CorrelationID != "intent-2" || events[1].Reason == "" {
+		t.Fatalf("unexpected rejection event %+v", events[1])
+	}
+}
+
+func TestSubmitOrderPublishesSystemRejectionOnPersistFailure(t *testing.T) {
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	repo := &stubRepo{err: errors.New("db down")}
+	svc := service.New(repo, nil, service.WithEventPublisher(publisher))
+
+	_, err := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "sell", Quantity: 1})
+	if err == nil {
+		t.Fatalf("expected persistence error")
+	}
+	if len(events) != 1 || events[0].Category != service.RejectionSystemError {
+		t.Fatalf("expected system error rejection got %+v", events)
+	}
+}
+
 func TestGetOrderNotFound(t *testing.T) {
 	repo := &stubRepo{}
 	svc := service.New(repo, func() time.Time { return time.Unix(0, 0).UTC() })
@@ -81,3 +196,1815 @@ func TestGetOrderNotFound(t *testing.T) {
 		t.Fatalf("expected ErrOrderNotFound got %v", err)
 	}
 }
+
+func TestStatusTransitions(t *testing.T) {
+	allowed := []struct{ from, to service.Status }{
+		{service.StatusNew, service.StatusPendingRisk},
+		{service.StatusPendingRisk, service.StatusRouted},
+		{service.StatusPendingRisk, service.StatusRejected},
+		{service.StatusRouted, service.StatusPartiallyFilled},
+		{service.StatusPartiallyFilled, service.StatusPartiallyFilled},
+		{service.StatusPartiallyFilled, service.StatusFilled},
+		{service.StatusRouted, service.StatusExpired},
+	}
+	for _, tt := range allowed {
+		if !tt.from.CanTransitionTo(tt.to) {
+			t.Fatalf("expected %s -> %s to be allowed", tt.from, tt.to)
+		}
+	}
+
+	denied := []struct{ from, to service.Status }{
+		{service.StatusNew, service.StatusFilled},
+		{service.StatusFilled, service.StatusCancelled},
+		{service.StatusCancelled, service.StatusRouted},
+		{service.StatusPartiallyFilled, service.StatusRejected},
+		{service.StatusExpired, service.StatusFilled},
+	}
+	for _, tt := range denied {
+		if tt.from.CanTransitionTo(tt.to) {
+			t.Fatalf("expected %s -> %s to be rejected", tt.from, tt.to)
+		}
+	}
+
+	for _, terminal := range []service.Status{service.StatusFilled, service.StatusCancelled, service.StatusRejected, service.StatusExpired} {
+		if !terminal.Terminal() {
+			t.Fatalf("expected %s to be terminal", terminal)
+		}
+	}
+}
+
+func TestTransitionErrorMatchesSentinel(t *testing.T) {
+	var err error = service.TransitionError{OrderID: "ord-1", From: service.StatusFilled, To: service.StatusRouted}
+	if !errors.Is(err, service.ErrInvalidTransition) {
+		t.Fatalf("expected TransitionError to match ErrInvalidTransition")
+	}
+}
+
+func TestGetOrderHistory(t *testing.T) {
+	repo := &stubRepo{}
+	svc := service.New(repo, func() time.Time { return time.Unix(0, 0).UTC() })
+
+	order, err := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	history, err := svc.GetOrderHistory(context.Background(), order.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(history) != 2 || history[1].From != service.StatusPendingRisk {
+		t.Fatalf("unexpected history %+v", history)
+	}
+
+	if _, err := svc.GetOrderHistory(context.Background(), "missing"); !errors.Is(err, service.ErrOrderNotFound) {
+		t.Fatalf("expected ErrOrderNotFound got %v", err)
+	}
+}
+
+type rejectingBroker struct{}
+
+func (rejectingBroker) Place(context.Context, service.Order) (service.BrokerAck, error) {
+	return service.BrokerAck{}, service.BrokerRejection{Reason: "insufficient margin"}
+}
+func (rejectingBroker) Cancel(context.Context, string) error { return nil }
+func (rejectingBroker) Amend(context.Context, string, float64, float64) error {
+	return nil
+}
+func (rejectingBroker) Query(context.Context, string) (service.BrokerOrderState, error) {
+	return service.BrokerOrderState{}, service.ErrUnknownBrokerOrder
+}
+func (rejectingBroker) Fills() <-chan service.BrokerFill { return nil }
+func (rejectingBroker) OpenOrders(context.Context) ([]service.BrokerOrderState, error) {
+	return nil, nil
+}
+func (rejectingBroker) Executions(context.Context, string) ([]service.BrokerFill, error) {
+	return nil, service.ErrUnknownBrokerOrder
+}
+
+func TestSubmitOrderRecordsBrokerRejection(t *testing.T) {
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	repo := &stubRepo{}
+	svc := service.New(repo, nil, service.WithBroker(rejectingBroker{}), service.WithEventPublisher(publisher))
+
+	order, err := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 1, Price: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.Status != service.StatusRejected {
+		t.Fatalf("expected rejected order got %s", order.Status)
+	}
+	last := events[len(events)-1]
+	if last.Type != service.EventRejection || last.Category != service.RejectionBrokerReject {
+		t.Fatalf("expected broker rejection event got %+v", last)
+	}
+}
+
+func TestHandleFillRejectsFillOnTerminalOrder(t *testing.T) {
+	repo := &stubRepo{stored: service.Order{ID: "ord-1", Quantity: 1, Status: service.StatusRejected}}
+	svc := service.New(repo, nil)
+
+	err := svc.HandleFill(context.Background(), service.BrokerFill{ClientOrderID: "ord-1", Quantity: 1, Price: 10})
+	if !errors.Is(err, service.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition got %v", err)
+	}
+}
+
+func TestLateFillsOnClosedOrdersAreBooked(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700, 0).UTC()
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	repo := repository.NewMemory(repository.WithPositions(nil))
+	svc := service.New(repo, func() time.Time { return now }, service.WithBroker(&recordingBroker{}), service.WithEventPublisher(publisher))
+
+	cancelled, _ := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 3, Price: 1250})
+	if _, err := svc.CancelOrder(ctx, cancelled.ID, service.CancelRequest{}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	soon := now.Add(time.Minute)
+	expired, _ := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 3, Price: 1250, ExpiresAt: &soon})
+	now = now.Add(2 * time.Minute)
+	if n, _ := svc.ExpireOrders(ctx); n != 1 {
+		t.Fatalf("expected the order expired got %d", n)
+	}
+
+	for id, status := range map[string]service.Status{cancelled.ID: service.StatusCancelled, expired.ID: service.StatusExpired} {
+		if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: id, ExecutionID: "X-" + id, Quantity: 1, Price: 1250}); err != nil {
+			t.Fatalf("late fill: %v", err)
+		}
+		got, _ := svc.GetOrder(ctx, id)
+		if got.Status != status || got.FilledQuantity != 1 {
+			t.Fatalf("expected the late fill recorded on the closed order got %+v", got)
+		}
+		if executions, _ := svc.GetOrderExecutions(ctx, id); len(executions) != 1 {
+			t.Fatalf("expected the late execution stored got %+v", executions)
+		}
+	}
+	if last := events[len(events)-1]; last.Type != service.EventFill || last.Fill.Remaining != 0 || !last.Order.Status.Terminal() {
+		t.Fatalf("expected a fill event on the closed order got %+v", last)
+	}
+	positions, _ := repo.Positions().List(ctx, position.Filter{})
+	if len(positions) != 1 || positions[0].Quantity != 2 {
+		t.Fatalf("expected both late fills booked got %+v", positions)
+	}
+}
+
+func TestLateEntryFillGrowsBracketExits(t *testing.T) {
+	ctx := context.Background()
+	broker := &recordingBroker{}
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(broker))
+
+	entry, err := svc.SubmitOrder(ctx, service.OrderIntent{
+		BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 3, Price: 1250, TakeProfitPrice: 1260,
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: entry.ID, Quantity: 1, Price: 1250}); err != nil {
+		t.Fatalf("fill entry: %v", err)
+	}
+	if _, err := svc.CancelOrder(ctx, entry.ID, service.CancelRequest{}); err != nil {
+		t.Fatalf("cancel entry: %v", err)
+	}
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: entry.ID, Quantity: 1, Price: 1250}); err != nil {
+		t.Fatalf("late fill: %v", err)
+	}
+	tp := bracketLegs(t, svc, entry.ID)[service.LegTakeProfit]
+	if tp.Status != service.StatusRouted || tp.Quantity != 2 {
+		t.Fatalf("expected the exit grown to the filled quantity got %+v", tp)
+	}
+	if len(broker.amended) != 2 || broker.amended[1] != 2 {
+		t.Fatalf("expected the exit amended at the broker got %v", broker.amended)
+	}
+}
+
+func TestSubmitOrderRejectsOnRiskDenial(t *testing.T) {
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	checker := service.RiskCheckerFunc(func(_ context.Context, req service.RiskRequest) (service.RiskDecision, error) {
+		if req.BotID != "bot-1" || req.Quantity != 5 {
+			t.Fatalf("unexpected risk request %+v", req)
+		}
+		return service.RiskDecision{Allowed: false, Reason: "max position exceeded"}, nil
+	})
+	repo := &stubRepo{}
+	svc := service.New(repo, nil, service.WithEventPublisher(publisher), service.WithRiskChecker(checker, service.RiskPolicy{}))
+
+	order, err := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.Status != service.StatusRejected {
+		t.Fatalf("expected rejected got %s", order.Status)
+	}
+	last := events[len(events)-1]
+	if last.Type != service.EventRejection || last.Category != service.RejectionRiskLimit || last.Reason != "max position exceeded" {
+		t.Fatalf("unexpected rejection event %+v", last)
+	}
+}
+
+func TestSubmitOrderRiskTimeoutPolicy(t *testing.T) {
+	slow := service.RiskCheckerFunc(func(ctx context.Context, _ service.RiskRequest) (service.RiskDecision, error) {
+		<-ctx.Done()
+		return service.RiskDecision{}, ctx.Err()
+	})
+	intent := service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 1}
+
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	closed := service.New(&stubRepo{}, nil, service.WithEventPublisher(publisher),
+		service.WithRiskChecker(slow, service.RiskPolicy{Timeout: 10 * time.Millisecond}))
+	order, err := closed.SubmitOrder(context.Background(), intent)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.Status != service.StatusRejected || events[len(events)-1].Category != service.RejectionTimeout {
+		t.Fatalf("expected timeout rejection got %s %+v", order.Status, events)
+	}
+
+	open := service.New(&stubRepo{}, nil,
+		service.WithRiskChecker(slow, service.RiskPolicy{Timeout: 10 * time.Millisecond, FailOpen: true}))
+	order, err = open.SubmitOrder(context.Background(), intent)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.Status != service.StatusRouted {
+		t.Fatalf("expected fail-open to route got %s", order.Status)
+	}
+}
+
+func TestSubmitOrderGeneratesUniqueIDs(t *testing.T) {
+	repo := repository.NewMemory()
+	svc := service.New(repo, func() time.Time { return time.Unix(0, 0).UTC() })
+
+	seen := make(map[string]bool)
+	for i := 0; i < 50; i++ {
+		order, err := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 1})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if seen[order.ID] {
+			t.Fatalf("duplicate order id %s", order.ID)
+		}
+		seen[order.ID] = true
+	}
+}
+
+func TestSubmitOrderDeduplicatesIntentID(t *testing.T) {
+	var acks int
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		if event.Type == service.EventAck {
+			acks++
+		}
+		return nil
+	})
+	svc := service.New(repository.NewMemory(), nil, service.WithEventPublisher(publisher))
+	intent := service.OrderIntent{IntentID: "intent-1", BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 1}
+
+	first, err := svc.SubmitOrder(context.Background(), intent)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	replayed, err := svc.SubmitOrder(context.Background(), intent)
+	if !errors.Is(err, service.ErrDuplicateIntent) {
+		t.Fatalf("expected duplicate intent error got %v", err)
+	}
+	if replayed.ID != first.ID || acks != 1 {
+		t.Fatalf("expected original order without a second ack got %s (acks %d)", replayed.ID, acks)
+	}
+
+	other := intent
+	other.BotID = "bot-2"
+	if order, err := svc.SubmitOrder(context.Background(), other); err != nil || order.ID == first.ID {
+		t.Fatalf("expected intent ids to be scoped per bot got %+v %v", order, err)
+	}
+}
+
+func TestSubmitOrderDetectsSequenceRegression(t *testing.T) {
+	var rejections []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		if event.Type == service.EventRejection {
+			rejections = append(rejections, event)
+		}
+		return nil
+	})
+	svc := service.New(repository.NewMemory(), nil, service.WithEventPublisher(publisher))
+	submit := func(intentID string, seq uint64) error {
+		_, err := svc.SubmitOrder(context.Background(), service.OrderIntent{
+			IntentID: intentID, Sequence: seq, BotID: "bot-1", AccountID: "acc-1", Symbol: "SYM", Side: "buy", Quantity: 1,
+		})
+		return err
+	}
+
+	if err := submit("i-1", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := submit("i-3", 3); err != nil {
+		t.Fatalf("expected gap to be accepted got %v", err)
+	}
+	err := submit("i-2", 2)
+	var seqErr service.SequenceError
+	if !errors.Is(err, service.ErrSequenceRegression) || !errors.As(err, &seqErr) || seqErr.Last != 3 || seqErr.Got != 2 {
+		t.Fatalf("expected sequence regression got %v", err)
+	}
+	if len(rejections) != 1 {
+		t.Fatalf("expected a rejection for the regressed intent got %+v", rejections)
+	}
+}
+
+func TestSequenceRegressionDetectedAcrossInstances(t *testing.T) {
+	// Two executors sharing one store must agree on the last sequence.
+	repo := repository.NewMemory()
+	first, second := service.New(repo, nil), service.New(repo, nil)
+	submit := func(svc service.Service, intentID string, seq uint64) error {
+		_, err := svc.SubmitOrder(context.Background(), service.OrderIntent{
+			IntentID: intentID, Sequence: seq, BotID: "bot-1", AccountID: "acc-1", Symbol: "SYM", Side: "buy", Quantity: 1,
+		})
+		return err
+	}
+
+	if err := submit(second, "i-1", 1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := submit(first, "i-3", 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var seqErr service.SequenceError
+	if err := submit(second, "i-2", 2); !errors.As(err, &seqErr) || seqErr.Last != 3 || seqErr.Got != 2 {
+		t.Fatalf("expected the regression refused by the other instance got %v", err)
+	}
+}
+
+type recordingBroker struct {
+	cancelled []string
+	amended   []float64
+	cancelErr error
+}
+
+func (b *recordingBroker) Place(context.Context, service.Order) (service.BrokerAck, error) {
+	return service.BrokerAck{ProviderOrderID: "P-1"}, nil
+}
+func (b *recordingBroker) Cancel(_ context.Context, id string) error {
+	b.cancelled = append(b.cancelled, id)
+	return b.cancelErr
+}
+func (b *recordingBroker) Amend(_ context.Context, _ string, price, quantity float64) error {
+	b.amended = append(b.amended, price, quantity)
+	return nil
+}
+func (b *recordingBroker) Query(context.Context, string) (service.BrokerOrderState, error) {
+	return service.BrokerOrderState{}, service.ErrUnknownBrokerOrder
+}
+func (b *recordingBroker) Fills() <-chan service.BrokerFill { return nil }
+func (b *recordingBroker) OpenOrders(context.Context) ([]service.BrokerOrderState, error) {
+	return nil, nil
+}
+func (b *recordingBroker) Executions(context.Context, string) ([]service.BrokerFill, error) {
+	return nil, service.ErrUnknownBrokerOrder
+}
+
+func TestCancelOrderForwardsToBrokerAndPublishes(t *testing.T) {
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	broker := &recordingBroker{}
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(broker), service.WithEventPublisher(publisher))
+	order, err := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 2, Price: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := svc.CancelOrder(context.Background(), order.ID, service.CancelRequest{InitiatedBy: "nobody"}); err == nil {
+		t.Fatalf("expected validation error for unknown initiator")
+	}
+	cancelled, err := svc.CancelOrder(context.Background(), order.ID, service.CancelRequest{InitiatedBy: service.InitiatedByRisk})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cancelled.Status != service.StatusCancelled || len(broker.cancelled) != 1 || broker.cancelled[0] != "P-1" {
+		t.Fatalf("expected broker cancel and cancelled status got %+v %v", cancelled, broker.cancelled)
+	}
+	last := events[len(events)-1]
+	if last.Type != service.EventCancel || last.InitiatedBy != service.InitiatedByRisk {
+		t.Fatalf("unexpected cancel event %+v", last)
+	}
+
+	if _, err := svc.CancelOrder(context.Background(), order.ID, service.CancelRequest{}); !errors.Is(err, service.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition cancelling twice got %v", err)
+	}
+}
+
+func TestCancelOrderKeepsOrderWhenBrokerRefuses(t *testing.T) {
+	repo := repository.NewMemory()
+	broker := &recordingBroker{cancelErr: errors.New("already filled")}
+	svc := service.New(repo, nil, service.WithBroker(broker))
+	order, _ := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 1})
+
+	if _, err := svc.CancelOrder(context.Background(), order.ID, service.CancelRequest{}); !errors.Is(err, service.ErrBrokerRequest) {
+		t.Fatalf("expected ErrBrokerRequest got %v", err)
+	}
+	stored, _ := repo.Get(context.Background(), order.ID)
+	if stored.Status != service.StatusRouted {
+		t.Fatalf("expected order to stay routed got %s", stored.Status)
+	}
+}
+
+func TestPendingCancelKeepsOrderWorking(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	svc := service.New(repo, nil, service.WithBroker(&recordingBroker{cancelErr: service.ErrCancelPending}))
+	order, _ := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 2, Price: 10})
+
+	if _, err := svc.CancelOrder(ctx, order.ID, service.CancelRequest{}); !errors.Is(err, service.ErrCancelPending) {
+		t.Fatalf("expected ErrCancelPending got %v", err)
+	}
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: order.ID, Quantity: 1, Price: 10}); err != nil {
+		t.Fatalf("fill: %v", err)
+	}
+	if stored, _ := repo.Get(ctx, order.ID); stored.Status != service.StatusPartiallyFilled {
+		t.Fatalf("expected the order still working got %s", stored.Status)
+	}
+}
+
+func TestAmendOrder(t *testing.T) {
+	repo := repository.NewMemory()
+	broker := &recordingBroker{}
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	svc := service.New(repo, nil, service.WithBroker(broker), service.WithEventPublisher(publisher))
+	order, _ := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 4, Price: 10})
+	if err := svc.HandleFill(context.Background(), service.BrokerFill{ClientOrderID: order.ID, Quantity: 1, Price: 10}); err != nil {
+		t.Fatalf("fill: %v", err)
+	}
+
+	price, tooSmall, quantity := 11.0, 1.0, 6.0
+	if _, err := svc.AmendOrder(context.Background(), order.ID, service.AmendRequest{Quantity: &tooSmall}); err == nil {
+		t.Fatalf("expected validation error when quantity does not exceed filled")
+	}
+	amended, err := svc.AmendOrder(context.Background(), order.ID, service.AmendRequest{Price: &price, Quantity: &quantity})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if amended.Price != 11 || amended.Quantity != 6 || amended.Status != service.StatusPartiallyFilled {
+		t.Fatalf("unexpected amended order %+v", amended)
+	}
+	if len(broker.amended) != 2 || broker.amended[0] != 11 || broker.amended[1] != 6 {
+		t.Fatalf("expected broker amend got %v", broker.amended)
+	}
+	history, _ := svc.GetOrderHistory(context.Background(), order.ID)
+	if last := history[len(history)-1]; last.From != last.To || last.Reason == "" {
+		t.Fatalf("expected amendment in history got %+v", last)
+	}
+	if last := events[len(events)-1]; last.Type != service.EventAmend || last.Order.Price != 11 || last.Order.Quantity != 6 || last.Reason == "" {
+		t.Fatalf("expected an amend event got %+v", last)
+	}
+
+	var ve service.ValidationError
+	zero := 0.0
+	if _, err := svc.AmendOrder(context.Background(), order.ID, service.AmendRequest{Price: &zero}); !errors.As(err, &ve) {
+		t.Fatalf("expected a limit order amended to price 0 rejected got %v", err)
+	}
+}
+
+func TestAmendOrderRunsRiskChecks(t *testing.T) {
+	ctx := context.Background()
+	var checked []service.RiskRequest
+	checker := service.RiskCheckerFunc(func(_ context.Context, req service.RiskRequest) (service.RiskDecision, error) {
+		checked = append(checked, req)
+		if req.Quantity*req.Price > 50 {
+			return service.RiskDecision{Reason: "notional limit exceeded"}, nil
+		}
+		return service.RiskDecision{Allowed: true}, nil
+	})
+	broker := &recordingBroker{}
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(broker), service.WithRiskChecker(checker, service.RiskPolicy{}))
+	order, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 4, Price: 10})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	var ve service.ValidationError
+	quantity := 6.0
+	if _, err := svc.AmendOrder(ctx, order.ID, service.AmendRequest{Quantity: &quantity}); !errors.As(err, &ve) || ve.Reason != "notional limit exceeded" {
+		t.Fatalf("expected the amend past the risk limit rejected got %v", err)
+	}
+	if len(broker.amended) != 0 {
+		t.Fatalf("expected nothing sent to the broker got %v", broker.amended)
+	}
+	if last := checked[len(checked)-1]; last.Quantity != 6 || last.Price != 10 {
+		t.Fatalf("expected the amended order checked got %+v", last)
+	}
+
+	smaller := 2.0
+	if _, err := svc.AmendOrder(ctx, order.ID, service.AmendRequest{Quantity: &smaller}); err != nil {
+		t.Fatalf("expected a smaller order amended got %v", err)
+	}
+	if len(checked) != 2 {
+		t.Fatalf("expected a smaller quantity not to be risk checked got %+v", checked)
+	}
+}
+
+func TestAmendOrderRejectsPricesOnMarketOrders(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(&recordingBroker{}))
+	market, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 4, Type: service.OrderTypeMarket})
+	if err != nil {
+		t.Fatalf("submit market: %v", err)
+	}
+	stop, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "sell", Quantity: 4, Type: service.OrderTypeStop, StopPrice: 9})
+	if err != nil {
+		t.Fatalf("submit stop: %v", err)
+	}
+
+	price := 10.0
+	for _, order := range []service.Order{market, stop} {
+		var ve service.ValidationError
+		if _, err := svc.AmendOrder(ctx, order.ID, service.AmendRequest{Price: &price}); !errors.As(err, &ve) {
+			t.Fatalf("expected a price on %s order %s rejected got %v", order.Type, order.ID, err)
+		}
+	}
+}
+
+func TestAmendOrderRejectsChildrenAndBracketLegs(t *testing.T) {
+	ctx := context.Background()
+	broker := &recordingBroker{}
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(broker))
+
+	parent, err := svc.SubmitOrder(ctx, service.OrderIntent{
+		BotID: "bot-1", Symbol: "VN30F1M", Side: "sell", Quantity: 5, Price: 1250,
+		Algorithm: service.AlgorithmIceberg, DisplayQuantity: 2,
+	})
+	if err != nil {
+		t.Fatalf("submit parent: %v", err)
+	}
+	entry, err := svc.SubmitOrder(ctx, service.OrderIntent{
+		BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 2, Price: 1250,
+		TakeProfitPrice: 1260, StopLossPrice: 1240,
+	})
+	if err != nil {
+		t.Fatalf("submit bracket: %v", err)
+	}
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: entry.ID, Quantity: 2, Price: 1250}); err != nil {
+		t.Fatalf("fill entry: %v", err)
+	}
+	legs := bracketLegs(t, svc, entry.ID)
+
+	quantity := 3.0
+	for name, id := range map[string]string{
+		"child":       childOrders(t, svc, parent.ID)[0].ID,
+		"take-profit": legs[service.LegTakeProfit].ID,
+	} {
+		var ve service.ValidationError
+		if _, err := svc.AmendOrder(ctx, id, service.AmendRequest{Quantity: &quantity}); !errors.As(err, &ve) {
+			t.Fatalf("expected the %s amend rejected got %v", name, err)
+		}
+	}
+	if len(broker.amended) != 0 {
+		t.Fatalf("expected nothing amended at the broker got %v", broker.amended)
+	}
+}
+
+func TestSubmitOrderValidatesOrderTypes(t *testing.T) {
+	now := time.Unix(1700, 0).UTC()
+	past := now.Add(-time.Minute)
+	svc := service.New(&stubRepo{}, func() time.Time { return now })
+	base := service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 1}
+
+	for _, tt := range []struct {
+		name   string
+		mutate func(*service.OrderIntent)
+	}{
+		{"negative price", func(i *service.OrderIntent) { i.Price = -1 }},
+		{"limit without price", func(i *service.OrderIntent) { i.Type = service.OrderTypeLimit }},
+		{"market with price", func(i *service.OrderIntent) { i.Type = service.OrderTypeMarket; i.Price = 10 }},
+		{"stop without trigger", func(i *service.OrderIntent) { i.Type = service.OrderTypeStop }},
+		{"trigger on limit", func(i *service.OrderIntent) { i.Price = 10; i.StopPrice = 9 }},
+		{"stop with IOC", func(i *service.OrderIntent) {
+			i.Type = service.OrderTypeStop
+			i.StopPrice = 9
+			i.TimeInForce = service.TimeInForceIOC
+		}},
+		{"unknown type", func(i *service.OrderIntent) { i.Type = "iceberg" }},
+		{"unknown tif", func(i *service.OrderIntent) { i.TimeInForce = "GTX" }},
+		{"expired deadline", func(i *service.OrderIntent) { i.ExpiresAt = &past }},
+	} {
+		intent := base
+		tt.mutate(&intent)
+		var ve service.ValidationError
+		if _, err := svc.SubmitOrder(context.Background(), intent); !errors.As(err, &ve) {
+			t.Fatalf("%s: expected validation error got %v", tt.name, err)
+		}
+	}
+
+	order, err := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 1, Price: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.Type != service.OrderTypeLimit || order.TimeInForce != service.TimeInForceGTC {
+		t.Fatalf("expected limit GTC defaults got %s %s", order.Type, order.TimeInForce)
+	}
+}
+
+func TestExpireOrdersMovesStaleOrdersToExpired(t *testing.T) {
+	now := time.Unix(1700, 0).UTC()
+	clock := func() time.Time { return now }
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	broker := &recordingBroker{}
+	svc := service.New(repository.NewMemory(), clock, service.WithBroker(broker), service.WithEventPublisher(publisher))
+
+	soon, later := now.Add(time.Minute), now.Add(time.Hour)
+	stale, _ := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 1, Price: 10, ExpiresAt: &soon})
+	fresh, _ := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 1, Price: 10, ExpiresAt: &later})
+
+	now = now.Add(2 * time.Minute)
+	n, err := svc.ExpireOrders(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("expected one expired order got %d %v", n, err)
+	}
+	if got, _ := svc.GetOrder(context.Background(), stale.ID); got.Status != service.StatusExpired {
+		t.Fatalf("expected stale order expired got %s", got.Status)
+	}
+	if got, _ := svc.GetOrder(context.Background(), fresh.ID); got.Status != service.StatusRouted {
+		t.Fatalf("expected fresh order to keep working got %s", got.Status)
+	}
+	if len(broker.cancelled) != 1 {
+		t.Fatalf("expected broker cancel for the expired order got %v", broker.cancelled)
+	}
+	last := events[len(events)-1]
+	if last.Type != service.EventCancel || last.InitiatedBy != service.InitiatedBySystem || last.Order.ID != stale.ID {
+		t.Fatalf("unexpected expiry event %+v", last)
+	}
+	if n, _ := svc.ExpireOrders(context.Background()); n != 0 {
+		t.Fatalf("expected expired orders to be skipped on the next sweep got %d", n)
+	}
+}
+
+func TestSubmitOrderRejectsInstrumentRuleViolations(t *testing.T) {
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	riskCalled := false
+	checker := service.RiskCheckerFunc(func(context.Context, service.RiskRequest) (service.RiskDecision, error) {
+		riskCalled = true
+		return service.RiskDecision{Allowed: true}, nil
+	})
+	rules := instrument.NewHOSEDerivatives(instrument.NewBands())
+	svc := service.New(&stubRepo{}, nil, service.WithEventPublisher(publisher),
+		service.WithInstrumentRules(rules), service.WithRiskChecker(checker, service.RiskPolicy{}))
+
+	order, err := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1250.05})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.Status != service.StatusRejected || riskCalled {
+		t.Fatalf("expected rejection before risk got %s (risk called %v)", order.Status, riskCalled)
+	}
+	last := events[len(events)-1]
+	if last.Type != service.EventRejection || last.Category != service.RejectionInvalidPrice {
+		t.Fatalf("expected invalid price rejection got %+v", last)
+	}
+}
+
+func TestListOrdersPaginates(t *testing.T) {
+	now := time.Unix(1700, 0).UTC()
+	svc := service.New(repository.NewMemory(), func() time.Time { now = now.Add(time.Second); return now })
+	for i := 0; i < 5; i++ {
+		side := "buy"
+		if i%2 == 1 {
+			side = "sell"
+		}
+		if _, err := svc.SubmitOrder(context.Background(), service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: side, Quantity: 1}); err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+	}
+
+	var seen []service.Order
+	page := service.PageRequest{Limit: 2, Ascending: true}
+	for {
+		result, err := svc.ListOrders(context.Background(), service.OrderFilter{BotID: "bot-1"}, page)
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		seen = append(seen, result.Items...)
+		if result.NextCursor == "" {
+			break
+		}
+		page.Cursor = result.NextCursor
+	}
+	if len(seen) != 5 {
+		t.Fatalf("expected 5 orders across pages got %d", len(seen))
+	}
+	for i := 1; i < len(seen); i++ {
+		if !seen[i-1].CreatedAt.Before(seen[i].CreatedAt) {
+			t.Fatalf("expected ascending creation order got %+v", seen)
+		}
+	}
+
+	sells, err := svc.ListOrders(context.Background(), service.OrderFilter{Side: "SELL"}, service.PageRequest{})
+	if err != nil || len(sells.Items) != 2 || sells.NextCursor != "" {
+		t.Fatalf("expected two sells on one page got %+v %v", sells, err)
+	}
+
+	for _, tt := range []struct {
+		filter service.OrderFilter
+		page   service.PageRequest
+	}{
+		{service.OrderFilter{Statuses: []service.Status{"accepted"}}, service.PageRequest{}},
+		{service.OrderFilter{Side: "hold"}, service.PageRequest{}},
+		{service.OrderFilter{}, service.PageRequest{Limit: service.MaxPageSize + 1}},
+		{service.OrderFilter{}, service.PageRequest{Cursor: "not-a-cursor"}},
+	} {
+		var ve service.ValidationError
+		if _, err := svc.ListOrders(context.Background(), tt.filter, tt.page); !errors.As(err, &ve) {
+			t.Fatalf("expected validation error for %+v %+v got %v", tt.filter, tt.page, err)
+		}
+	}
+}
+
+func TestReconcileRepairsMissedFillsAndCancels(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	sim := broker.NewSimulator(nil, 0)
+	svc := service.New(repo, nil, service.WithBroker(sim), service.WithReconciliationLog(repo))
+
+	// Fills are never drained from the simulator stream, as if the executor
+	// had been disconnected while the broker kept trading.
+	filled, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 2, Price: 1250})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	sim.OnSnapshot(&marketsv1.SsiPsSnapshot{Code: "VN30F1M", BestOffer_1: 1250, BestOffer_1Volume: 5})
+	cancelled, _ := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "VN30F2M", Side: "buy", Quantity: 1, Price: 1200})
+	if err := sim.Cancel(ctx, cancelled.ProviderOrderID); err != nil {
+		t.Fatalf("cancel at broker: %v", err)
+	}
+	orphan, _ := sim.Place(ctx, service.Order{ID: "ord-elsewhere", Symbol: "VN30F2M", Side: "sell", Quantity: 1, Price: 1300})
+	unknown := service.Order{ID: "ord-lost", BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Status: service.StatusRouted, ProviderOrderID: "SIM-404", CreatedAt: time.Now()}
+	if err := repo.Create(ctx, unknown, service.CreateGuard{}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	run, err := svc.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	kinds := make(map[service.DiscrepancyKind]service.Discrepancy)
+	for _, d := range run.Discrepancies {
+		kinds[d.Kind] = d
+	}
+	if d := kinds[service.DiscrepancyMissedFill]; d.OrderID != filled.ID || !d.Repaired {
+		t.Fatalf("expected repaired missed fill got %+v", run.Discrepancies)
+	}
+	if d := kinds[service.DiscrepancyMissedCancel]; d.OrderID != cancelled.ID || !d.Repaired {
+		t.Fatalf("expected repaired missed cancel got %+v", run.Discrepancies)
+	}
+	if d := kinds[service.DiscrepancyOrphanBrokerOrder]; d.ProviderOrderID != orphan.ProviderOrderID || d.Repaired {
+		t.Fatalf("expected orphan broker order got %+v", run.Discrepancies)
+	}
+	if d := kinds[service.DiscrepancyUnknownProviderOrder]; d.OrderID != unknown.ID {
+		t.Fatalf("expected unknown provider order got %+v", run.Discrepancies)
+	}
+
+	if order, _ := svc.GetOrder(ctx, filled.ID); order.Status != service.StatusFilled || order.FilledQuantity != 2 {
+		t.Fatalf("expected missed fill applied got %+v", order)
+	}
+	if order, _ := svc.GetOrder(ctx, cancelled.ID); order.Status != service.StatusCancelled {
+		t.Fatalf("expected missed cancel applied got %+v", order)
+	}
+
+	// The late stream delivery of the repaired fill must not double count.
+	fill := <-sim.Fills()
+	if err := svc.HandleFill(ctx, fill); !errors.Is(err, service.ErrDuplicateExecution) {
+		t.Fatalf("expected duplicate execution got %v", err)
+	}
+
+	again, err := svc.Reconcile(ctx)
+	if err != nil || len(again.Discrepancies) != 2 {
+		t.Fatalf("expected only the unrepairable discrepancies to remain got %+v %v", again.Discrepancies, err)
+	}
+	runs, err := svc.ReconciliationRuns(ctx, 0)
+	if err != nil || len(runs) != 2 || runs[0].ID != again.ID {
+		t.Fatalf("expected both runs newest first got %+v %v", runs, err)
+	}
+}
+
+// unqueryableBroker is a simulator that fails to answer queries for one
+// provider order id.
+type unqueryableBroker struct {
+	*broker.Simulator
+	failing string
+}
+
+func (b *unqueryableBroker) Query(ctx context.Context, providerOrderID string) (service.BrokerOrderState, error) {
+	if providerOrderID == b.failing {
+		return service.BrokerOrderState{}, errors.New("venue timeout")
+	}
+	return b.Simulator.Query(ctx, providerOrderID)
+}
+
+func TestReconcileContinuesPastFailingOrders(t *testing.T) {
+	ctx := context.Background()
+	venue := &unqueryableBroker{Simulator: broker.NewSimulator(nil, 0)}
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(venue))
+
+	stuck, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1200})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	cancelled, _ := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "VN30F2M", Side: "buy", Quantity: 1, Price: 1200})
+	venue.failing = stuck.ProviderOrderID
+	if err := venue.Cancel(ctx, cancelled.ProviderOrderID); err != nil {
+		t.Fatalf("cancel at broker: %v", err)
+	}
+
+	run, err := svc.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if run.OrdersChecked != 2 || run.Error != "" {
+		t.Fatalf("expected both orders checked got %+v", run)
+	}
+	kinds := make(map[service.DiscrepancyKind]service.Discrepancy)
+	for _, d := range run.Discrepancies {
+		kinds[d.Kind] = d
+	}
+	if d := kinds[service.DiscrepancyCheckFailed]; d.OrderID != stuck.ID || d.Repaired || !strings.Contains(d.Detail, "venue timeout") {
+		t.Fatalf("expected the failing order recorded got %+v", run.Discrepancies)
+	}
+	if d := kinds[service.DiscrepancyMissedCancel]; d.OrderID != cancelled.ID || !d.Repaired {
+		t.Fatalf("expected the next order still repaired got %+v", run.Discrepancies)
+	}
+}
+
+// unansweredBroker is a simulator whose placements time out, whether or not
+// they reached it.
+type unansweredBroker struct {
+	*broker.Simulator
+	deliver bool
+}
+
+func (b *unansweredBroker) Place(ctx context.Context, order service.Order) (service.BrokerAck, error) {
+	if b.deliver {
+		if _, err := b.Simulator.Place(ctx, order); err != nil {
+			return service.BrokerAck{}, err
+		}
+	}
+	return service.BrokerAck{}, fmt.Errorf("no answer from venue: %w", context.DeadlineExceeded)
+}
+
+func TestUnconfirmedPlacementsAwaitReconciliation(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewMemory()
+	venue := &unansweredBroker{Simulator: broker.NewSimulator(nil, 0), deliver: true}
+	svc := service.New(repo, nil, service.WithBroker(venue))
+
+	taken, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 2, Price: 1250})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if taken.Status != service.StatusRouted || taken.ProviderOrderID != "" {
+		t.Fatalf("expected a timed out placement to stay routed got %+v", taken)
+	}
+	if _, err := svc.CancelOrder(ctx, taken.ID, service.CancelRequest{}); !errors.Is(err, service.ErrBrokerRequest) {
+		t.Fatalf("expected the cancel refused until the placement is confirmed got %v", err)
+	}
+	venue.deliver = false
+	lost, _ := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1250})
+
+	run, err := svc.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	found := make(map[string]service.Discrepancy)
+	for _, d := range run.Discrepancies {
+		if d.Kind == service.DiscrepancyUnconfirmedPlacement {
+			found[d.OrderID] = d
+		}
+	}
+	if d := found[taken.ID]; !d.Repaired || d.ProviderOrderID != "SIM-00000001" {
+		t.Fatalf("expected the broker order adopted got %+v", run.Discrepancies)
+	}
+	if d, ok := found[lost.ID]; !ok || d.Repaired {
+		t.Fatalf("expected the lost placement flagged got %+v", run.Discrepancies)
+	}
+
+	// Fills of the adopted order are applied as usual.
+	venue.OnSnapshot(&marketsv1.SsiPsSnapshot{Code: "VN30F1M", BestOffer_1: 1250, BestOffer_1Volume: 5})
+	if err := svc.HandleFill(ctx, <-venue.Fills()); err != nil {
+		t.Fatalf("fill: %v", err)
+	}
+	if order, _ := svc.GetOrder(ctx, taken.ID); order.Status != service.StatusFilled || order.ProviderOrderID != "SIM-00000001" {
+		t.Fatalf("expected the adopted order filled got %+v", order)
+	}
+}
+
+func TestUnconfirmedPlacementsRejectedWhenTheBrokerNeverListsThem(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700, 0).UTC()
+	var rejections []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		if event.Type == service.EventRejection {
+			rejections = append(rejections, event)
+		}
+		return nil
+	})
+	venue := &unansweredBroker{Simulator: broker.NewSimulator(nil, 0)}
+	svc := service.New(repository.NewMemory(), func() time.Time { return now },
+		service.WithBroker(venue), service.WithEventPublisher(publisher), service.WithUnconfirmedTimeout(5*time.Minute),
+		service.WithRateLimits(service.RateLimitPolicy{Bot: service.RateLimits{MaxOpenOrders: 1}}))
+	intent := service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1250}
+
+	lost, err := svc.SubmitOrder(ctx, intent)
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	now = now.Add(4 * time.Minute)
+	if _, err := svc.Reconcile(ctx); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if got, _ := svc.GetOrder(ctx, lost.ID); got.Status != service.StatusRouted {
+		t.Fatalf("expected the order kept until the timeout got %s", got.Status)
+	}
+
+	now = now.Add(time.Minute)
+	run, err := svc.Reconcile(ctx)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(run.Discrepancies) != 1 || !run.Discrepancies[0].Repaired || run.Discrepancies[0].Kind != service.DiscrepancyUnconfirmedPlacement {
+		t.Fatalf("expected the placement repaired got %+v", run.Discrepancies)
+	}
+	if got, _ := svc.GetOrder(ctx, lost.ID); got.Status != service.StatusRejected {
+		t.Fatalf("expected the order rejected as never placed got %s", got.Status)
+	}
+	if len(rejections) != 1 || rejections[0].Order.ID != lost.ID || rejections[0].Category != service.RejectionBrokerReject {
+		t.Fatalf("expected a rejection event got %+v", rejections)
+	}
+	// The open-order slot is free again.
+	if _, err := svc.SubmitOrder(ctx, intent); err != nil {
+		t.Fatalf("expected the slot released got %v", err)
+	}
+}
+
+func TestForceCancelOfUnconfirmedPlacement(t *testing.T) {
+	ctx := context.Background()
+	venue := &unansweredBroker{Simulator: broker.NewSimulator(nil, 0)}
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(venue))
+	lost, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1250})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	if _, err := svc.CancelOrder(ctx, lost.ID, service.CancelRequest{InitiatedBy: service.InitiatedBySystem}); !errors.Is(err, service.ErrBrokerRequest) {
+		t.Fatalf("expected a plain cancel refused got %v", err)
+	}
+	cancelled, err := svc.CancelOrder(ctx, lost.ID, service.CancelRequest{InitiatedBy: service.InitiatedBySystem, Force: true})
+	if err != nil {
+		t.Fatalf("force cancel: %v", err)
+	}
+	if cancelled.Status != service.StatusCancelled {
+		t.Fatalf("expected the order cancelled got %+v", cancelled)
+	}
+	history, _ := svc.GetOrderHistory(ctx, lost.ID)
+	if last := history[len(history)-1]; !strings.Contains(last.Reason, "placement unconfirmed") {
+		t.Fatalf("expected the forced cancel in the history got %+v", last)
+	}
+}
+
+func TestExpiryLeavesUnconfirmedPlacementsToReconciliation(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700, 0).UTC()
+	venue := &unansweredBroker{Simulator: broker.NewSimulator(nil, 0), deliver: true}
+	svc := service.New(repository.NewMemory(), func() time.Time { return now }, service.WithBroker(venue))
+
+	soon := now.Add(time.Minute)
+	order, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1250, ExpiresAt: &soon})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if n, _ := svc.ExpireOrders(ctx); n != 0 {
+		t.Fatalf("expected the unconfirmed order kept working got %d expired", n)
+	}
+	if got, _ := svc.GetOrder(ctx, order.ID); got.Status != service.StatusRouted {
+		t.Fatalf("expected the unconfirmed order still routed got %s", got.Status)
+	}
+
+	if _, err := svc.Reconcile(ctx); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if n, err := svc.ExpireOrders(ctx); err != nil || n != 1 {
+		t.Fatalf("expected the adopted order expired got %d %v", n, err)
+	}
+	if state, _ := venue.Query(ctx, "SIM-00000001"); state.Open {
+		t.Fatalf("expected the broker order withdrawn got %+v", state)
+	}
+}
+
+func TestCancelParentLeavesUnconfirmedChildrenToReconciliation(t *testing.T) {
+	ctx := context.Background()
+	venue := &unansweredBroker{Simulator: broker.NewSimulator(nil, 0), deliver: true}
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(venue))
+
+	parent, err := svc.SubmitOrder(ctx, service.OrderIntent{
+		BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 4, Price: 1250,
+		Algorithm: service.AlgorithmTWAP, AlgoDurationSeconds: 3600, AlgoSlices: 4,
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	first := childOrders(t, svc, parent.ID)[0]
+	if _, err := svc.CancelOrder(ctx, parent.ID, service.CancelRequest{}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if child, _ := svc.GetOrder(ctx, first.ID); child.Status != service.StatusRouted {
+		t.Fatalf("expected the unconfirmed child kept working got %s", child.Status)
+	}
+
+	if _, err := svc.Reconcile(ctx); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if child, _ := svc.GetOrder(ctx, first.ID); child.Status != service.StatusCancelled || child.ProviderOrderID != "SIM-00000001" {
+		t.Fatalf("expected the adopted child cancelled got %+v", child)
+	}
+	if state, _ := venue.Query(ctx, "SIM-00000001"); state.Open {
+		t.Fatalf("expected the broker order withdrawn got %+v", state)
+	}
+}
+
+func TestOCOLeavesUnconfirmedLegsToReconciliation(t *testing.T) {
+	ctx := context.Background()
+	venue := &unansweredBroker{Simulator: broker.NewSimulator(nil, 0), deliver: true}
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(venue))
+
+	entry, err := svc.SubmitOrder(ctx, service.OrderIntent{
+		BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 2, Price: 1250,
+		TakeProfitPrice: 1260, StopLossPrice: 1240,
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: entry.ID, Quantity: 2, Price: 1250}); err != nil {
+		t.Fatalf("fill entry: %v", err)
+	}
+	legs := bracketLegs(t, svc, entry.ID)
+	tp, sl := legs[service.LegTakeProfit], legs[service.LegStopLoss]
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: tp.ID, Quantity: 2, Price: 1260}); err != nil {
+		t.Fatalf("fill take-profit: %v", err)
+	}
+	if got, _ := svc.GetOrder(ctx, sl.ID); got.Status != service.StatusRouted {
+		t.Fatalf("expected the unconfirmed stop-loss kept working got %s", got.Status)
+	}
+
+	if _, err := svc.Reconcile(ctx); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	got, _ := svc.GetOrder(ctx, sl.ID)
+	if got.Status != service.StatusCancelled || got.ProviderOrderID == "" {
+		t.Fatalf("expected the adopted stop-loss cancelled got %+v", got)
+	}
+	if state, _ := venue.Query(ctx, got.ProviderOrderID); state.Open {
+		t.Fatalf("expected the broker order withdrawn got %+v", state)
+	}
+}
+
+// flakyCancelBroker is a simulator that fails the first cancels it is sent.
+type flakyCancelBroker struct {
+	*broker.Simulator
+	failures int
+}
+
+func (b *flakyCancelBroker) Cancel(ctx context.Context, providerOrderID string) error {
+	if b.failures > 0 {
+		b.failures--
+		return errors.New("venue unavailable")
+	}
+	return b.Simulator.Cancel(ctx, providerOrderID)
+}
+
+func TestReconcileRetriesRefusedOCOCancels(t *testing.T) {
+	ctx := context.Background()
+	venue := &flakyCancelBroker{Simulator: broker.NewSimulator(nil, 0), failures: 1}
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(venue))
+
+	entry, err := svc.SubmitOrder(ctx, service.OrderIntent{
+		BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 2, Price: 1250,
+		TakeProfitPrice: 1260, StopLossPrice: 1240,
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: entry.ID, Quantity: 2, Price: 1250}); err != nil {
+		t.Fatalf("fill entry: %v", err)
+	}
+	legs := bracketLegs(t, svc, entry.ID)
+	tp, sl := legs[service.LegTakeProfit], legs[service.LegStopLoss]
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: tp.ID, Quantity: 2, Price: 1260}); err != nil {
+		t.Fatalf("fill take-profit: %v", err)
+	}
+	sl, _ = svc.GetOrder(ctx, sl.ID)
+	if sl.Status != service.StatusRouted {
+		t.Fatalf("expected the refused stop-loss cancel to leave it working got %s", sl.Status)
+	}
+
+	if _, err := svc.Reconcile(ctx); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if got, _ := svc.GetOrder(ctx, sl.ID); got.Status != service.StatusCancelled {
+		t.Fatalf("expected reconciliation to cancel the stop-loss got %s", got.Status)
+	}
+	if state, _ := venue.Query(ctx, sl.ProviderOrderID); state.Open {
+		t.Fatalf("expected the broker order withdrawn got %+v", state)
+	}
+}
+
+func childOrders(t *testing.T, svc service.Service, parentID string) []service.Order {
+	t.Helper()
+	progress, err := svc.GetParentProgress(context.Background(), parentID)
+	if err != nil {
+		t.Fatalf("progress: %v", err)
+	}
+	return progress.Children
+}
+
+func TestTWAPSchedulesSlicesInTradingTime(t *testing.T) {
+	ctx := context.Background()
+	ict := time.FixedZone("ICT", 7*60*60)
+	now := time.Date(2026, time.October, 12, 10, 0, 0, 0, ict)
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	svc := service.New(repository.NewMemory(), func() time.Time { return now },
+		service.WithBroker(&recordingBroker{}), service.WithEventPublisher(publisher),
+		service.WithSessionClock(session.HOSEDerivatives()))
+
+	parent, err := svc.SubmitOrder(ctx, service.OrderIntent{
+		BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 5, Price: 1250,
+		Algorithm: service.AlgorithmTWAP, AlgoDurationSeconds: 3 * 3600, AlgoSlices: 3,
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if parent.Status != service.StatusRouted || parent.ProviderOrderID != "" {
+		t.Fatalf("expected parent working without a broker order got %+v", parent)
+	}
+
+	children := childOrders(t, svc, parent.ID)
+	wantQty := []float64{2, 2, 1}
+	// Three hours of trading from 10:00 spans the lunch break.
+	wantAt := []time.Time{now, now.Add(time.Hour), time.Date(2026, time.October, 12, 13, 30, 0, 0, ict)}
+	if len(children) != 3 {
+		t.Fatalf("expected 3 slices got %d", len(children))
+	}
+	for i, child := range children {
+		if child.ParentID != parent.ID || child.Quantity != wantQty[i] || !child.ScheduledAt.Equal(wantAt[i]) {
+			t.Fatalf("slice %d: unexpected child %+v", i, child)
+		}
+	}
+	if children[0].Status != service.StatusRouted || children[1].Status != service.StatusNew {
+		t.Fatalf("expected only the first slice released got %s %s", children[0].Status, children[1].Status)
+	}
+
+	now = wantAt[2]
+	if n, err := svc.ReleaseChildOrders(ctx); err != nil || n != 2 {
+		t.Fatalf("expected two slices released got %d %v", n, err)
+	}
+	for _, child := range childOrders(t, svc, parent.ID) {
+		if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: child.ID, Quantity: child.Quantity, Price: 1250}); err != nil {
+			t.Fatalf("fill: %v", err)
+		}
+	}
+
+	got, _ := svc.GetOrder(ctx, parent.ID)
+	if got.Status != service.StatusFilled || got.FilledQuantity != 5 {
+		t.Fatalf("expected filled parent got %+v", got)
+	}
+	fills := 0
+	for _, event := range events {
+		if event.Order.ID != parent.ID {
+			t.Fatalf("expected only parent events got %+v", event)
+		}
+		if event.Type == service.EventFill {
+			fills++
+		}
+	}
+	if fills != 3 {
+		t.Fatalf("expected a parent fill per slice got %d", fills)
+	}
+	if executions, _ := svc.GetOrderExecutions(ctx, parent.ID); len(executions) != 3 {
+		t.Fatalf("expected parent executions from every child got %d", len(executions))
+	}
+}
+
+func TestIcebergReplenishesDisplayQuantity(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(&recordingBroker{}))
+
+	parent, err := svc.SubmitOrder(ctx, service.OrderIntent{
+		BotID: "bot-1", Symbol: "VN30F1M", Side: "sell", Quantity: 5, Price: 1250,
+		Algorithm: service.AlgorithmIceberg, DisplayQuantity: 2,
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	for _, want := range []float64{2, 2, 1} {
+		children := childOrders(t, svc, parent.ID)
+		working := children[len(children)-1]
+		if working.Status != service.StatusRouted || working.Quantity != want {
+			t.Fatalf("expected a working child of %g got %+v", want, working)
+		}
+		if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: working.ID, Quantity: working.Quantity, Price: 1250}); err != nil {
+			t.Fatalf("fill: %v", err)
+		}
+	}
+	if got, _ := svc.GetOrder(ctx, parent.ID); got.Status != service.StatusFilled {
+		t.Fatalf("expected filled parent got %+v", got)
+	}
+	if children := childOrders(t, svc, parent.ID); len(children) != 3 {
+		t.Fatalf("expected 3 children got %d", len(children))
+	}
+
+	var ve service.ValidationError
+	if _, err := svc.SubmitOrder(ctx, service.OrderIntent{
+		BotID: "bot-1", Symbol: "VN30F1M", Side: "sell", Quantity: 5, Price: 1250,
+		Algorithm: service.AlgorithmIceberg, DisplayQuantity: 5,
+	}); !errors.As(err, &ve) {
+		t.Fatalf("expected display quantity validation got %v", err)
+	}
+}
+
+func TestCancelParentCancelsChildren(t *testing.T) {
+	ctx := context.Background()
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	broker := &recordingBroker{}
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(broker), service.WithEventPublisher(publisher))
+
+	parent, err := svc.SubmitOrder(ctx, service.OrderIntent{
+		BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 4, Price: 1250,
+		Algorithm: service.AlgorithmTWAP, AlgoDurationSeconds: 3600, AlgoSlices: 4,
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	first := childOrders(t, svc, parent.ID)[0]
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: first.ID, Quantity: 1, Price: 1250}); err != nil {
+		t.Fatalf("fill: %v", err)
+	}
+	if _, err := svc.ReleaseChildOrders(ctx); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+
+	cancelled, err := svc.CancelOrder(ctx, parent.ID, service.CancelRequest{})
+	if err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	if cancelled.Status != service.StatusCancelled || cancelled.FilledQuantity != 1 {
+		t.Fatalf("expected partially filled parent cancelled got %+v", cancelled)
+	}
+	progress, _ := svc.GetParentProgress(ctx, parent.ID)
+	if progress.CompletedChildren != 4 || progress.WorkingQuantity != 0 || progress.ScheduledQuantity != 0 {
+		t.Fatalf("expected every child closed got %+v", progress)
+	}
+	if len(broker.cancelled) != 0 {
+		t.Fatalf("expected no broker cancels for unreleased slices got %v", broker.cancelled)
+	}
+	last := events[len(events)-1]
+	if last.Type != service.EventCancel || last.Order.ID != parent.ID {
+		t.Fatalf("expected parent cancel event last got %+v", last)
+	}
+	if _, err := svc.AmendOrder(ctx, parent.ID, service.AmendRequest{Quantity: &cancelled.Quantity}); err == nil {
+		t.Fatalf("expected amend of a closed parent to fail")
+	}
+}
+
+func bracketLegs(t *testing.T, svc service.Service, bracketID string) map[service.Leg]service.Order {
+	t.Helper()
+	page, err := svc.ListOrders(context.Background(), service.OrderFilter{BracketID: bracketID}, service.PageRequest{})
+	if err != nil {
+		t.Fatalf("list bracket: %v", err)
+	}
+	legs := make(map[service.Leg]service.Order)
+	for _, order := range page.Items {
+		legs[order.Leg] = order
+	}
+	return legs
+}
+
+func TestBracketArmsExitsOnEntryFillAndCancelsOCO(t *testing.T) {
+	ctx := context.Background()
+	var events []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		events = append(events, event)
+		return nil
+	})
+	broker := &recordingBroker{}
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(broker), service.WithEventPublisher(publisher))
+
+	entry, err := svc.SubmitOrder(ctx, service.OrderIntent{
+		BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 2, Price: 1250,
+		TakeProfitPrice: 1260, StopLossPrice: 1240,
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if entry.BracketID != entry.ID || entry.Leg != service.LegEntry || entry.Status != service.StatusRouted {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	legs := bracketLegs(t, svc, entry.ID)
+	tp, sl := legs[service.LegTakeProfit], legs[service.LegStopLoss]
+	if tp.Status != service.StatusNew || tp.Side != "sell" || tp.Type != service.OrderTypeLimit || tp.Price != 1260 {
+		t.Fatalf("unexpected take-profit leg %+v", tp)
+	}
+	if sl.Status != service.StatusNew || sl.Type != service.OrderTypeStop || sl.StopPrice != 1240 {
+		t.Fatalf("unexpected stop-loss leg %+v", sl)
+	}
+	acks := 0
+	for _, event := range events {
+		if event.Type == service.EventAck && event.Order.BracketID == entry.ID {
+			acks++
+		}
+	}
+	if acks != 3 {
+		t.Fatalf("expected an ack for the entry and each leg got %d", acks)
+	}
+
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: entry.ID, Quantity: 2, Price: 1250}); err != nil {
+		t.Fatalf("fill entry: %v", err)
+	}
+	legs = bracketLegs(t, svc, entry.ID)
+	if legs[service.LegTakeProfit].Status != service.StatusRouted || legs[service.LegStopLoss].Status != service.StatusRouted {
+		t.Fatalf("expected both exits armed got %+v", legs)
+	}
+
+	// A partial exit leaves the rest of the position protected by a smaller
+	// stop-loss.
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: tp.ID, Quantity: 1, Price: 1260}); err != nil {
+		t.Fatalf("fill take-profit: %v", err)
+	}
+	legs = bracketLegs(t, svc, entry.ID)
+	if legs[service.LegStopLoss].Status != service.StatusRouted || legs[service.LegStopLoss].Quantity != 1 || legs[service.LegTakeProfit].Status != service.StatusPartiallyFilled {
+		t.Fatalf("expected the stop-loss shrunk to the open quantity got %+v", legs)
+	}
+	if len(broker.cancelled) != 0 || len(broker.amended) != 2 || broker.amended[1] != 1 {
+		t.Fatalf("expected the stop-loss amended at the broker got cancels %v amends %v", broker.cancelled, broker.amended)
+	}
+	if last := events[len(events)-1]; last.Type != service.EventAmend || last.Order.ID != sl.ID || last.Order.Quantity != 1 {
+		t.Fatalf("expected a stop-loss amend event got %+v", last)
+	}
+
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: tp.ID, Quantity: 1, Price: 1260}); err != nil {
+		t.Fatalf("fill take-profit: %v", err)
+	}
+	legs = bracketLegs(t, svc, entry.ID)
+	if legs[service.LegStopLoss].Status != service.StatusCancelled || legs[service.LegTakeProfit].Status != service.StatusFilled {
+		t.Fatalf("expected the stop-loss cancelled by the take-profit fill got %+v", legs)
+	}
+	if len(broker.cancelled) != 1 {
+		t.Fatalf("expected the stop-loss withdrawn at the broker got %v", broker.cancelled)
+	}
+	last := events[len(events)-1]
+	if last.Type != service.EventCancel || last.Order.ID != sl.ID || last.InitiatedBy != service.InitiatedBySystem {
+		t.Fatalf("expected stop-loss cancel event got %+v", last)
+	}
+}
+
+func TestBracketWithdrawsExitsWhenEntryCloses(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(&recordingBroker{}))
+
+	entry, err := svc.SubmitOrder(ctx, service.OrderIntent{
+		BotID: "bot-1", Symbol: "VN30F1M", Side: "sell", Quantity: 3, Price: 1250, StopLossPrice: 1260,
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if _, err := svc.CancelOrder(ctx, entry.ID, service.CancelRequest{}); err != nil {
+		t.Fatalf("cancel entry: %v", err)
+	}
+	legs := bracketLegs(t, svc, entry.ID)
+	if len(legs) != 2 || legs[service.LegStopLoss].Status != service.StatusCancelled {
+		t.Fatalf("expected the unfilled entry to withdraw its exit got %+v", legs)
+	}
+
+	partial, _ := svc.SubmitOrder(ctx, service.OrderIntent{
+		BotID: "bot-1", Symbol: "VN30F1M", Side: "sell", Quantity: 3, Price: 1250, TakeProfitPrice: 1240,
+	})
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: partial.ID, Quantity: 1, Price: 1250}); err != nil {
+		t.Fatalf("fill entry: %v", err)
+	}
+	if _, err := svc.CancelOrder(ctx, partial.ID, service.CancelRequest{}); err != nil {
+		t.Fatalf("cancel entry: %v", err)
+	}
+	tp := bracketLegs(t, svc, partial.ID)[service.LegTakeProfit]
+	if tp.Status != service.StatusRouted || tp.Quantity != 1 || tp.Side != "buy" {
+		t.Fatalf("expected the exit armed for the filled quantity got %+v", tp)
+	}
+
+	var ve service.ValidationError
+	for _, intent := range []service.OrderIntent{
+		{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1250, TakeProfitPrice: 1240},
+		{BotID: "bot-1", Symbol: "VN30F1M", Side: "sell", Quantity: 1, Price: 1250, StopLossPrice: 1240},
+		{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, TakeProfitPrice: 1240, StopLossPrice: 1250},
+	} {
+		if _, err := svc.SubmitOrder(ctx, intent); !errors.As(err, &ve) {
+			t.Fatalf("expected validation error for %+v got %v", intent, err)
+		}
+	}
+}
+
+func TestRateLimitsThrottleBotsAndAccounts(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700, 0).UTC()
+	var rejections []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		if event.Type == service.EventRejection {
+			rejections = append(rejections, event)
+		}
+		return nil
+	})
+	svc := service.New(repository.NewMemory(), func() time.Time { return now }, service.WithEventPublisher(publisher),
+		service.WithRateLimits(service.RateLimitPolicy{
+			Bot:     service.RateLimits{OrdersPerSecond: 2, MessagesPerMinute: 60},
+			Account: service.RateLimits{MaxOpenOrders: 3},
+			Bots:    map[string]service.RateLimits{"chatty": {MessagesPerMinute: 1}},
+		}))
+	intent := service.OrderIntent{BotID: "bot-1", AccountID: "acc-1", Symbol: "SYM", Side: "buy", Quantity: 1, Price: 10}
+
+	for i := 0; i < 2; i++ {
+		if _, err := svc.SubmitOrder(ctx, intent); err != nil {
+			t.Fatalf("submit %d: %v", i, err)
+		}
+	}
+	_, err := svc.SubmitOrder(ctx, intent)
+	var te service.ThrottleError
+	if !errors.As(err, &te) || te.Limit != service.LimitOrdersPerSecond || te.Scope != "bot" || te.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("expected orders per second throttle got %v", err)
+	}
+	if len(rejections) != 1 || rejections[0].Category != service.RejectionRateLimited || rejections[0].Reason != err.Error() {
+		t.Fatalf("expected a rate limited rejection event got %+v", rejections)
+	}
+
+	now = now.Add(time.Second)
+	if _, err := svc.SubmitOrder(ctx, intent); err != nil {
+		t.Fatalf("expected the bucket to refill: %v", err)
+	}
+	now = now.Add(time.Second)
+	if _, err := svc.SubmitOrder(ctx, intent); !errors.As(err, &te) || te.Limit != service.LimitMaxOpenOrders || te.Scope != "account" {
+		t.Fatalf("expected open order cap on the account got %v", err)
+	}
+
+	chatty := intent
+	chatty.BotID, chatty.AccountID = "chatty", ""
+	order, err := svc.SubmitOrder(ctx, chatty)
+	if err != nil {
+		t.Fatalf("submit chatty: %v", err)
+	}
+	if _, err := svc.CancelOrder(ctx, order.ID, service.CancelRequest{}); !errors.Is(err, service.ErrThrottled) {
+		t.Fatalf("expected cancel to be throttled got %v", err)
+	}
+	if _, err := svc.CancelOrder(ctx, order.ID, service.CancelRequest{InitiatedBy: service.InitiatedByRisk}); err != nil {
+		t.Fatalf("expected risk cancels to bypass bot limits got %v", err)
+	}
+
+	status, _ := svc.RateLimitStatus(ctx)
+	counts := make(map[string]int64)
+	for _, c := range status.Throttled {
+		counts[c.Scope+"/"+c.Key+"/"+c.Limit] = c.Count
+	}
+	if counts["bot/bot-1/orders_per_second"] != 1 || counts["account/acc-1/max_open_orders"] != 1 || counts["bot/chatty/messages_per_minute"] != 1 {
+		t.Fatalf("unexpected throttle counts %+v", status.Throttled)
+	}
+}
+
+func TestFractionalMessageBudgetStillAdmitsMessages(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1700, 0).UTC()
+	svc := service.New(repository.NewMemory(), func() time.Time { return now },
+		service.WithRateLimits(service.RateLimitPolicy{Bot: service.RateLimits{MessagesPerMinute: 0.5}}))
+	intent := service.OrderIntent{BotID: "bot-1", Symbol: "SYM", Side: "buy", Quantity: 1, Price: 10}
+
+	if _, err := svc.SubmitOrder(ctx, intent); err != nil {
+		t.Fatalf("expected the first message admitted got %v", err)
+	}
+	var te service.ThrottleError
+	if _, err := svc.SubmitOrder(ctx, intent); !errors.As(err, &te) || te.Limit != service.LimitMessagesPerMinute || te.RetryAfter != 2*time.Minute {
+		t.Fatalf("expected the second message throttled for two minutes got %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if _, err := svc.SubmitOrder(ctx, intent); err != nil {
+		t.Fatalf("expected the bucket to refill got %v", err)
+	}
+}
+
+func TestBracketCountsOnceAgainstMaxOpenOrders(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(&recordingBroker{}),
+		service.WithRateLimits(service.RateLimitPolicy{Bot: service.RateLimits{MaxOpenOrders: 2}}))
+	plain := service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1250}
+	bracket := plain
+	bracket.TakeProfitPrice, bracket.StopLossPrice = 1260, 1240
+
+	// The entry counts; its two exits do not.
+	entry, err := svc.SubmitOrder(ctx, bracket)
+	if err != nil {
+		t.Fatalf("submit bracket: %v", err)
+	}
+	if _, err := svc.SubmitOrder(ctx, plain); err != nil {
+		t.Fatalf("expected room for a second order beside the bracket got %v", err)
+	}
+	var te service.ThrottleError
+	if _, err := svc.SubmitOrder(ctx, plain); !errors.As(err, &te) || te.Limit != service.LimitMaxOpenOrders {
+		t.Fatalf("expected the cap reached got %v", err)
+	}
+
+	// Once the entry fills, its working exits still do not count.
+	if err := svc.HandleFill(ctx, service.BrokerFill{ClientOrderID: entry.ID, Quantity: 1, Price: 1250}); err != nil {
+		t.Fatalf("fill entry: %v", err)
+	}
+	if legs := bracketLegs(t, svc, entry.ID); legs[service.LegStopLoss].Status != service.StatusRouted {
+		t.Fatalf("expected the exits armed got %+v", legs)
+	}
+	if _, err := svc.SubmitOrder(ctx, plain); err != nil {
+		t.Fatalf("expected the filled entry to free its slot got %v", err)
+	}
+}
+
+func TestMaxOpenOrdersHoldsUnderConcurrentSubmits(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(repository.NewMemory(), nil,
+		service.WithRateLimits(service.RateLimitPolicy{Account: service.RateLimits{MaxOpenOrders: 3}}))
+
+	var (
+		wg       sync.WaitGroup
+		accepted atomic.Int32
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", AccountID: "acc-1", Symbol: "SYM", Side: "buy", Quantity: 1, Price: 10}); err == nil {
+				accepted.Add(1)
+			} else if !errors.Is(err, service.ErrThrottled) {
+				t.Errorf("submit: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := accepted.Load(); n != 3 {
+		t.Fatalf("expected 3 orders accepted under the cap got %d", n)
+	}
+}
+
+func TestPaperOrdersFillAtThePaperBroker(t *testing.T) {
+	ctx := context.Background()
+	live := &recordingBroker{}
+	paper := broker.NewSimulator(nil, 0, broker.WithIDPrefix("PAPER"))
+	svc := service.New(repository.NewMemory(), nil,
+		service.WithBroker(live),
+		service.WithPaperBroker(paper),
+		service.WithExecutionModes(service.ExecutionModes{
+			Bots:     map[string]service.Mode{"bot-paper": service.ModePaper},
+			Accounts: map[string]service.Mode{"acc-live": service.ModeLive},
+		}))
+
+	paper.OnSnapshot(&marketsv1.SsiPsSnapshot{Code: "VN30F1M", BestOffer_1: 1250, BestOffer_1Volume: 5})
+	// A paper bot stays on paper even on an account configured as live.
+	order, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-paper", AccountID: "acc-live", Symbol: "VN30F1M", Side: "buy", Quantity: 2, Price: 1250})
+	if err != nil {
+		t.Fatalf("submit paper: %v", err)
+	}
+	if order.Mode != service.ModePaper || order.ProviderOrderID != "PAPER-00000001" {
+		t.Fatalf("expected the order placed with the paper broker got %+v", order)
+	}
+	if err := svc.HandleFill(ctx, <-paper.Fills()); err != nil {
+		t.Fatalf("apply paper fill: %v", err)
+	}
+	if filled, _ := svc.GetOrder(ctx, order.ID); filled.Status != service.StatusFilled {
+		t.Fatalf("expected the paper order filled against the snapshot got %+v", filled)
+	}
+	if _, err := svc.CancelOrder(ctx, order.ID, service.CancelRequest{}); err == nil {
+		t.Fatal("expected filled paper order not to be cancellable")
+	}
+
+	liveOrder, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-live", AccountID: "acc-live", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1240})
+	if err != nil {
+		t.Fatalf("submit live: %v", err)
+	}
+	if liveOrder.Mode != service.ModeLive || liveOrder.ProviderOrderID != "P-1" {
+		t.Fatalf("expected the order placed with the live broker got %+v", liveOrder)
+	}
+	if _, err := svc.CancelOrder(ctx, liveOrder.ID, service.CancelRequest{}); err != nil {
+		t.Fatalf("cancel live: %v", err)
+	}
+	if len(live.cancelled) != 1 || live.cancelled[0] != "P-1" {
+		t.Fatalf("expected the live cancel at the live broker got %v", live.cancelled)
+	}
+
+	page, err := svc.ListOrders(ctx, service.OrderFilter{Mode: service.ModePaper}, service.PageRequest{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(page.Items) != 1 || page.Items[0].ID != order.ID {
+		t.Fatalf("expected only the paper order got %+v", page.Items)
+	}
+}
+
+type updateRecorder struct {
+	updates []service.OrderUpdate
+}
+
+func (r *updateRecorder) OrderUpdated(_ context.Context, update service.OrderUpdate) {
+	r.updates = append(r.updates, update)
+}
+
+func TestOrderUpdatesCoverCreationTransitionsAndFills(t *testing.T) {
+	ctx := context.Background()
+	sim := broker.NewSimulator(nil, 0)
+	updates := &updateRecorder{}
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(sim), service.WithOrderUpdates(updates))
+
+	sim.OnSnapshot(&marketsv1.SsiPsSnapshot{Code: "VN30F1M", BestOffer_1: 1250, BestOffer_1Volume: 5})
+	order, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 2, Price: 1250})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if err := svc.HandleFill(ctx, <-sim.Fills()); err != nil {
+		t.Fatalf("fill: %v", err)
+	}
+
+	if len(updates.updates) < 3 {
+		t.Fatalf("expected creation, transitions and a fill got %+v", updates.updates)
+	}
+	first, last := updates.updates[0], updates.updates[len(updates.updates)-1]
+	if first.Order.ID != order.ID || first.Transition.From != "" || first.Execution != nil {
+		t.Fatalf("expected the creation update first got %+v", first)
+	}
+	if last.Execution == nil || last.Execution.Quantity != 2 || last.Order.Status != service.StatusFilled ||
+		last.Transition.To != service.StatusFilled {
+		t.Fatalf("expected the fill update last got %+v", last)
+	}
+}
+
+type flatFees struct{}
+
+func (flatFees) Charges(_ service.Order, quantity, price float64) service.FillCharges {
+	return service.FillCharges{Fee: 1_000 * quantity, Tax: price}
+}
+
+func TestFillsAreChargedByTheFeeModel(t *testing.T) {
+	ctx := context.Background()
+	sim := broker.NewSimulator(nil, 0)
+	var fills []service.Fill
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		if event.Fill != nil {
+			fills = append(fills, *event.Fill)
+		}
+		return nil
+	})
+	svc := service.New(repository.NewMemory(), nil, service.WithBroker(sim), service.WithFeeModel(flatFees{}), service.WithEventPublisher(publisher))
+
+	sim.OnSnapshot(&marketsv1.SsiPsSnapshot{Code: "VN30F1M", BestBid_1: 1250, BestBid_1Volume: 5})
+	order, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "sell", Quantity: 2, Price: 1250})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if err := svc.HandleFill(ctx, <-sim.Fills()); err != nil {
+		t.Fatalf("fill: %v", err)
+	}
+
+	executions, err := svc.GetOrderExecutions(ctx, order.ID)
+	if err != nil || len(executions) != 1 || executions[0].Fee != 2_000 || executions[0].Tax != 1250 {
+		t.Fatalf("expected the charges stored on the execution got %+v (%v)", executions, err)
+	}
+	if len(fills) != 1 || fills[0].Fee != 2_000 || fills[0].Tax != 1250 {
+		t.Fatalf("expected the charges on the fill event got %+v", fills)
+	}
+}
+
+func TestSubmitOrderRejectsIntentsOutsideTradingSessions(t *testing.T) {
+	ctx := context.Background()
+	ict := time.FixedZone("ICT", 7*60*60)
+	now := time.Date(2026, time.October, 12, 12, 0, 0, 0, ict)
+	var rejections []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		if event.Type == service.EventRejection {
+			rejections = append(rejections, event)
+		}
+		return nil
+	})
+	svc := service.New(repository.NewMemory(), func() time.Time { return now },
+		service.WithBroker(&recordingBroker{}), service.WithEventPublisher(publisher),
+		service.WithTradingSessions(session.HOSEDerivatives()))
+	intent := service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 1, Price: 1250}
+
+	order, err := svc.SubmitOrder(ctx, intent)
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	if order.Status != service.StatusRejected || len(rejections) != 1 || rejections[0].Category != service.RejectionMarketClosed ||
+		rejections[0].Reason != "market closed: orders are accepted again from 2026-10-12 13:00 ICT" {
+		t.Fatalf("expected a market closed rejection during the lunch break got %+v %+v", order, rejections)
+	}
+
+	now = time.Date(2026, time.October, 12, 14, 40, 0, 0, ict)
+	order, err = svc.SubmitOrder(ctx, intent)
+	if err != nil || order.Status != service.StatusRouted {
+		t.Fatalf("expected the ATC auction to take orders got %+v (%v)", order, err)
+	}
+}
+
+func TestCloseSessionCancelsDayOrdersAndRecordsSummary(t *testing.T) {
+	ctx := context.Background()
+	ict := time.FixedZone("ICT", 7*60*60)
+	now := time.Date(2026, time.October, 12, 10, 0, 0, 0, ict)
+	var cancels []service.Event
+	publisher := service.EventPublisherFunc(func(_ context.Context, event service.Event) error {
+		if event.Type == service.EventCancel {
+			cancels = append(cancels, event)
+		}
+		return nil
+	})
+	repo := repository.NewMemory()
+	venue := &recordingBroker{}
+	svc := service.New(repo, func() time.Time { return now },
+		service.WithBroker(venue), service.WithEventPublisher(publisher),
+		service.WithTradingSessions(session.HOSEDerivatives()), service.WithSessionLog(repo))
+
+	submit := func(tif service.TimeInForce) service.Order {
+		t.Helper()
+		order, err := svc.SubmitOrder(ctx, service.OrderIntent{BotID: "bot-1", Symbol: "VN30F1M", Side: "buy", Quantity: 2, Price: 1250, TimeInForce: tif})
+		if err != nil || order.Status != service.StatusRouted {
+			t.Fatalf("submit %s: %+v (%v)", tif, order, err)
+		}
+		return order
+	}
+	day := submit(service.TimeInForceDay)
+	partial := submit(service.TimeInForceDay)
+	filled := submit(service.TimeInForceGTC)
+	carried := submit(service.TimeInForceGTC)
+	for _, fill := range []service.BrokerFill{
+		{ExecutionID: "E-1", ClientOrderID: partial.ID, Quantity: 1, Price: 1250},
+		{ExecutionID: "E-2", ClientOrderID: filled.ID, Quantity: 2, Price: 1249},
+	} {
+		if err := svc.HandleFill(ctx, fill); err != nil {
+			t.Fatalf("fill: %v", err)
+		}
+	}
+
+	now = time.Date(2026, time.October, 12, 14, 45, 0, 0, ict)
+	summary, err := svc.CloseSession(ctx)
+	if err != nil {
+		t.Fatalf("close session: %v", err)
+	}
+	if summary.TradingDate != "2026-10-12" || summary.DayOrdersCancelled != 2 || summary.OrdersCarried != 1 ||
+		summary.OrdersSubmitted != 4 || summary.OrdersFilled != 1 || summary.OrdersCancelled != 2 ||
+		summary.Executions != 2 || summary.FilledQuantity != 3 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	for _, id := range []string{day.ID, partial.ID} {
+		if order, _ := svc.GetOrder(ctx, id); order.Status != service.StatusCancelled {
+			t.Fatalf("expected DAY order %s cancelled got %s", id, order.Status)
+		}
+	}
+	if order, _ := svc.GetOrder(ctx, carried.ID); order.Status != service.StatusRouted {
+		t.Fatalf("expected GTC order to stay working got %s", order.Status)
+	}
+	if len(venue.cancelled) != 2 || len(cancels) != 2 || cancels[0].InitiatedBy != service.InitiatedBySystem {
+		t.Fatalf("expected DAY orders cancelled at the broker by the system got %v %+v", venue.cancelled, cancels)
+	}
+
+	summaries, err := svc.SessionSummaries(ctx, 0)
+	if err != nil || len(summaries) != 1 || summaries[0].ID != summary.ID {
+		t.Fatalf("expected the summary recorded got %+v (%v)", summaries, err)
+	}
+}
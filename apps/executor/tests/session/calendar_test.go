@@ -0,0 +1,112 @@
+package session_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/future-bots/executor/internal/session"
+)
+
+var ict = time.FixedZone("ICT", 7*60*60)
+
+func at(day, hour, minute int) time.Time {
+	// October 2026: the 12th is a Monday.
+	return time.Date(2026, time.October, day, hour, minute, 0, 0, ict)
+}
+
+func TestHOSEDerivativesSessions(t *testing.T) {
+	cal := session.HOSEDerivatives()
+	for _, tt := range []struct {
+		name string
+		t    time.Time
+		open bool
+		next time.Time
+	}{
+		{"before the open", at(12, 8, 45), false, at(12, 9, 0)},
+		{"morning session", at(12, 10, 0), true, at(12, 10, 0)},
+		{"lunch break", at(12, 11, 30), false, at(12, 13, 0)},
+		{"afternoon session", at(12, 14, 29), true, at(12, 14, 29)},
+		{"after the close", at(12, 14, 30), false, at(13, 9, 0)},
+		{"friday evening", at(16, 18, 0), false, at(19, 9, 0)},
+		{"sunday", at(18, 10, 0), false, at(19, 9, 0)},
+	} {
+		if got := cal.Open(tt.t); got != tt.open {
+			t.Fatalf("%s: expected open=%v got %v", tt.name, tt.open, got)
+		}
+		if got := cal.Next(tt.t); !got.Equal(tt.next) {
+			t.Fatalf("%s: expected next %s got %s", tt.name, tt.next, got)
+		}
+	}
+}
+
+func TestCalendarAddSkipsBreaks(t *testing.T) {
+	cal := session.HOSEDerivatives()
+	for _, tt := range []struct {
+		name  string
+		start time.Time
+		d     time.Duration
+		want  time.Time
+	}{
+		{"within the session", at(12, 9, 0), time.Hour, at(12, 10, 0)},
+		{"across lunch", at(12, 11, 0), time.Hour, at(12, 13, 30)},
+		{"overnight", at(12, 14, 0), time.Hour, at(13, 9, 30)},
+		{"over the weekend", at(16, 14, 0), 4 * time.Hour, at(19, 14, 0)},
+		{"from a closed market", at(12, 7, 0), 30 * time.Minute, at(12, 9, 30)},
+	} {
+		if got := cal.Add(tt.start, tt.d); !got.Equal(tt.want) {
+			t.Fatalf("%s: expected %s got %s", tt.name, tt.want, got)
+		}
+	}
+	if got := cal.SessionClose(at(12, 13, 15)); !got.Equal(at(12, 14, 30)) {
+		t.Fatalf("expected afternoon close got %s", got)
+	}
+	if got := cal.SessionClose(at(12, 12, 0)); !got.IsZero() {
+		t.Fatalf("expected no session during lunch got %s", got)
+	}
+}
+
+func TestHOSEDerivativesAcceptsOrdersDuringAuctions(t *testing.T) {
+	cal := session.HOSEDerivatives()
+	for _, tt := range []struct {
+		name      string
+		t         time.Time
+		accepting bool
+		next      time.Time
+	}{
+		{"before the ATO", at(12, 8, 30), false, at(12, 8, 45)},
+		{"ATO auction", at(12, 8, 50), true, at(12, 8, 50)},
+		{"lunch break", at(12, 12, 0), false, at(12, 13, 0)},
+		{"ATC auction", at(12, 14, 40), true, at(12, 14, 40)},
+		{"after the ATC", at(12, 14, 45), false, at(13, 8, 45)},
+		{"saturday", at(17, 10, 0), false, at(19, 8, 45)},
+	} {
+		if got := cal.Accepting(tt.t); got != tt.accepting {
+			t.Fatalf("%s: expected accepting=%v got %v", tt.name, tt.accepting, got)
+		}
+		if got := cal.NextSession(tt.t); !got.Equal(tt.next) {
+			t.Fatalf("%s: expected next session %s got %s", tt.name, tt.next, got)
+		}
+	}
+	// Auctions are not trading time.
+	if cal.Open(at(12, 14, 40)) || !cal.Next(at(12, 8, 50)).Equal(at(12, 9, 0)) {
+		t.Fatalf("expected auctions to be excluded from trading time")
+	}
+}
+
+func TestNextDayCloseIsTheATCClose(t *testing.T) {
+	cal := session.HOSEDerivatives()
+	for _, tt := range []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{"morning", at(12, 10, 0), at(12, 14, 45)},
+		{"during the ATC", at(12, 14, 40), at(12, 14, 45)},
+		{"at the close", at(12, 14, 45), at(13, 14, 45)},
+		{"friday evening", at(16, 18, 0), at(19, 14, 45)},
+	} {
+		if got := cal.NextDayClose(tt.t); !got.Equal(tt.want) {
+			t.Fatalf("%s: expected %s got %s", tt.name, tt.want, got)
+		}
+	}
+}
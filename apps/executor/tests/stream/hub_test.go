@@ -0,0 +1,85 @@
+package stream_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/future-bots/executor/internal/service"
+	"github.com/future-bots/executor/internal/stream"
+)
+
+func update(bot, account, orderID string) service.OrderUpdate {
+	return service.OrderUpdate{Order: service.Order{ID: orderID, BotID: bot, AccountID: account}}
+}
+
+func TestHubReplaysRetainedUpdatesAfterLastID(t *testing.T) {
+	hub := stream.NewHub(3)
+	ctx := context.Background()
+	for _, u := range []service.OrderUpdate{
+		update("bot-a", "acc-1", "o1"),
+		update("bot-b", "acc-1", "o2"),
+		update("bot-a", "acc-2", "o3"),
+		update("bot-a", "acc-1", "o4"),
+	} {
+		hub.OrderUpdated(ctx, u)
+	}
+
+	sub, replay := hub.Subscribe(stream.Filter{BotID: "bot-a"}, 2)
+	defer hub.Unsubscribe(sub)
+	if replay.Gap || replay.Head != 4 || len(replay.Entries) != 2 ||
+		replay.Entries[0].ID != 3 || replay.Entries[1].Update.Order.ID != "o4" {
+		t.Fatalf("unexpected replay %+v", replay)
+	}
+
+	_, replay = hub.Subscribe(stream.Filter{AccountID: "acc-1"}, 4)
+	if replay.Gap || len(replay.Entries) != 0 {
+		t.Fatalf("expected nothing to replay for an up-to-date client, got %+v", replay)
+	}
+}
+
+func TestHubReportsGaps(t *testing.T) {
+	hub := stream.NewHub(2)
+	ctx := context.Background()
+	for _, id := range []string{"o1", "o2", "o3", "o4"} {
+		hub.OrderUpdated(ctx, update("bot-a", "acc-1", id))
+	}
+	// Update 2 has been evicted, so a client that saw 1 missed it.
+	if _, replay := hub.Subscribe(stream.Filter{}, 1); !replay.Gap || replay.Head != 4 {
+		t.Fatalf("expected a gap after an evicted update, got %+v", replay)
+	}
+	if _, replay := hub.Subscribe(stream.Filter{}, 2); replay.Gap || len(replay.Entries) != 2 {
+		t.Fatalf("expected a complete replay from the oldest retained update, got %+v", replay)
+	}
+	// Ids from before a restart are ahead of a fresh hub.
+	if _, replay := stream.NewHub(2).Subscribe(stream.Filter{}, 7); !replay.Gap || replay.Head != 0 {
+		t.Fatalf("expected a gap for an unknown id, got %+v", replay)
+	}
+}
+
+func TestHubFiltersLiveUpdatesAndDropsSlowSubscribers(t *testing.T) {
+	hub := stream.NewHub(0)
+	ctx := context.Background()
+	sub, _ := hub.Subscribe(stream.Filter{AccountID: "acc-2"}, 0)
+	hub.OrderUpdated(ctx, update("bot-a", "acc-1", "o1"))
+	hub.OrderUpdated(ctx, update("bot-a", "acc-2", "o2"))
+	if entry := <-sub.Updates; entry.ID != 2 || entry.Update.Order.ID != "o2" {
+		t.Fatalf("unexpected live update %+v", entry)
+	}
+
+	for i := 0; i < 1000; i++ {
+		hub.OrderUpdated(ctx, update("bot-a", "acc-2", "o"))
+	}
+	drained := 0
+	for range sub.Updates {
+		drained++
+	}
+	if drained == 0 || drained >= 1000 {
+		t.Fatalf("expected a slow subscriber to be dropped after its queue filled, drained %d", drained)
+	}
+
+	closing, _ := hub.Subscribe(stream.Filter{}, 0)
+	hub.Close()
+	if _, ok := <-closing.Updates; ok {
+		t.Fatal("expected Close to end subscriptions")
+	}
+}
@@ -34,23 +34,116 @@ CREATE TABLE IF NOT EXISTS ticks(
 -- SELECT create_hypertable('ticks', by_range('ts'), if_not_exists => TRUE);
 
 CREATE TABLE IF NOT EXISTS orders(
-  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
-  bot_id uuid NOT NULL,
+  id text PRIMARY KEY,
+  intent_id text,
+  sequence bigint,
+  bot_id text NOT NULL,
+  account_id text,
   symbol text NOT NULL,
   side text NOT NULL CHECK (side in ('buy','sell')),
   qty numeric NOT NULL,
   price numeric,
+  order_type text NOT NULL DEFAULT 'limit',
+  time_in_force text NOT NULL DEFAULT 'GTC',
+  stop_price numeric,
+  expires_at timestamptz,
+  filled_qty numeric NOT NULL DEFAULT 0,
   status text NOT NULL DEFAULT 'new',
   provider_order_id text,
   created_at timestamptz NOT NULL DEFAULT now(),
-  updated_at timestamptz NOT NULL DEFAULT now()
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  parent_id text REFERENCES orders(id),
+  scheduled_at timestamptz,
+  algorithm text,
+  algo_duration_seconds bigint,
+  algo_slices integer,
+  display_quantity numeric,
+  bracket_id text,
+  leg text,
+  mode text NOT NULL DEFAULT 'live',
+  route text,
+  route_reason text
 );
 
 CREATE TABLE IF NOT EXISTS executions(
-  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
-  order_id uuid NOT NULL REFERENCES orders(id),
+  id text PRIMARY KEY,
+  order_id text NOT NULL REFERENCES orders(id),
   fill_qty numeric NOT NULL,
   fill_price numeric NOT NULL,
   fee numeric NOT NULL DEFAULT 0,
+  tax numeric NOT NULL DEFAULT 0,
   filled_at timestamptz NOT NULL DEFAULT now()
 );
+
+CREATE TABLE IF NOT EXISTS positions(
+  account_id text NOT NULL DEFAULT '',
+  bot_id text NOT NULL,
+  mode text NOT NULL DEFAULT 'live',
+  symbol text NOT NULL,
+  quantity numeric NOT NULL DEFAULT 0,
+  average_price numeric NOT NULL DEFAULT 0,
+  realized_pnl numeric NOT NULL DEFAULT 0,
+  updated_at timestamptz,
+  PRIMARY KEY(account_id, bot_id, mode, symbol)
+);
+
+CREATE TABLE IF NOT EXISTS order_outbox(
+  id bigserial PRIMARY KEY,
+  order_id text,
+  event_type text NOT NULL,
+  payload jsonb NOT NULL,
+  status text NOT NULL DEFAULT 'pending',
+  attempts integer NOT NULL DEFAULT 0,
+  last_error text,
+  next_attempt_at timestamptz NOT NULL DEFAULT now(),
+  created_at timestamptz NOT NULL DEFAULT now(),
+  delivered_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS reconciliation_runs(
+  id text PRIMARY KEY,
+  started_at timestamptz NOT NULL,
+  finished_at timestamptz NOT NULL,
+  orders_checked integer NOT NULL DEFAULT 0,
+  discrepancies jsonb NOT NULL DEFAULT '[]',
+  error text
+);
+
+CREATE TABLE IF NOT EXISTS dead_letters(
+  id text PRIMARY KEY,
+  topic text NOT NULL,
+  kafka_partition integer NOT NULL,
+  kafka_offset bigint NOT NULL,
+  message_key bytea,
+  message_value bytea NOT NULL,
+  headers jsonb NOT NULL DEFAULT '{}',
+  error text NOT NULL,
+  attempts integer NOT NULL,
+  failed_at timestamptz NOT NULL,
+  redriven_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS session_summaries(
+  id text PRIMARY KEY,
+  trading_date date NOT NULL,
+  closed_at timestamptz NOT NULL,
+  day_orders_cancelled integer NOT NULL DEFAULT 0,
+  orders_carried integer NOT NULL DEFAULT 0,
+  orders_submitted integer NOT NULL DEFAULT 0,
+  orders_filled integer NOT NULL DEFAULT 0,
+  orders_cancelled integer NOT NULL DEFAULT 0,
+  orders_rejected integer NOT NULL DEFAULT 0,
+  orders_expired integer NOT NULL DEFAULT 0,
+  executions integer NOT NULL DEFAULT 0,
+  filled_quantity numeric NOT NULL DEFAULT 0,
+  fees numeric NOT NULL DEFAULT 0,
+  taxes numeric NOT NULL DEFAULT 0,
+  error text
+);
+
+CREATE TABLE IF NOT EXISTS routing_rules(
+  position integer PRIMARY KEY,
+  account_id text,
+  symbol text,
+  brokers jsonb NOT NULL
+);
@@ -51,3 +51,31 @@ func IntFromEnv(key string, fallback int) int {
 	}
 	return n
 }
+
+// FloatFromEnv parses a float64 from the given environment variable key.
+// If the value is missing or parsing fails the fallback is returned.
+func FloatFromEnv(key string, fallback float64) float64 {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fallback
+	}
+	return f
+}
+
+// BoolFromEnv parses a boolean from the given environment variable key using
+// strconv.ParseBool. If the value is missing or parsing fails the fallback is returned.
+func BoolFromEnv(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback
+	}
+	return b
+}
@@ -61,3 +61,33 @@ func TestIntFromEnv(t *testing.T) {
 		t.Fatalf("expected fallback when missing got %d", got)
 	}
 }
+
+func TestFloatFromEnv(t *testing.T) {
+	t.Setenv("FLOAT_VALUE", "2.5")
+	if got := platformconfig.FloatFromEnv("FLOAT_VALUE", 0); got != 2.5 {
+		t.Fatalf("expected 2.5 got %v", got)
+	}
+	t.Setenv("FLOAT_VALUE", "bad")
+	if got := platformconfig.FloatFromEnv("FLOAT_VALUE", 1.5); got != 1.5 {
+		t.Fatalf("expected fallback when parse fails got %v", got)
+	}
+	os.Unsetenv("FLOAT_VALUE")
+	if got := platformconfig.FloatFromEnv("FLOAT_VALUE", 3); got != 3 {
+		t.Fatalf("expected fallback when missing got %v", got)
+	}
+}
+
+func TestBoolFromEnv(t *testing.T) {
+	t.Setenv("BOOL_VALUE", "true")
+	if got := platformconfig.BoolFromEnv("BOOL_VALUE", false); !got {
+		t.Fatalf("expected true got %v", got)
+	}
+	t.Setenv("BOOL_VALUE", "bad")
+	if got := platformconfig.BoolFromEnv("BOOL_VALUE", true); !got {
+		t.Fatalf("expected fallback when parse fails got %v", got)
+	}
+	os.Unsetenv("BOOL_VALUE")
+	if got := platformconfig.BoolFromEnv("BOOL_VALUE", false); got {
+		t.Fatalf("expected fallback when missing got %v", got)
+	}
+}
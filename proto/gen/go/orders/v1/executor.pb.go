@@ -0,0 +1,860 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v3.21.12
+// source: proto/orders/v1/executor.proto
+
+package ordersv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// OrderStatus is the lifecycle state of an order.
+type OrderStatus int32
+
+const (
+	OrderStatus_ORDER_STATUS_UNSPECIFIED      OrderStatus = 0
+	OrderStatus_ORDER_STATUS_NEW              OrderStatus = 1
+	OrderStatus_ORDER_STATUS_PENDING_RISK     OrderStatus = 2
+	OrderStatus_ORDER_STATUS_ROUTED           OrderStatus = 3
+	OrderStatus_ORDER_STATUS_PARTIALLY_FILLED OrderStatus = 4
+	OrderStatus_ORDER_STATUS_FILLED           OrderStatus = 5
+	OrderStatus_ORDER_STATUS_CANCELLED        OrderStatus = 6
+	OrderStatus_ORDER_STATUS_REJECTED         OrderStatus = 7
+	OrderStatus_ORDER_STATUS_EXPIRED          OrderStatus = 8
+)
+
+// Enum value maps for OrderStatus.
+var (
+	OrderStatus_name = map[int32]string{
+		0: "ORDER_STATUS_UNSPECIFIED",
+		1: "ORDER_STATUS_NEW",
+		2: "ORDER_STATUS_PENDING_RISK",
+		3: "ORDER_STATUS_ROUTED",
+		4: "ORDER_STATUS_PARTIALLY_FILLED",
+		5: "ORDER_STATUS_FILLED",
+		6: "ORDER_STATUS_CANCELLED",
+		7: "ORDER_STATUS_REJECTED",
+		8: "ORDER_STATUS_EXPIRED",
+	}
+	OrderStatus_value = map[string]int32{
+		"ORDER_STATUS_UNSPECIFIED":      0,
+		"ORDER_STATUS_NEW":              1,
+		"ORDER_STATUS_PENDING_RISK":     2,
+		"ORDER_STATUS_ROUTED":           3,
+		"ORDER_STATUS_PARTIALLY_FILLED": 4,
+		"ORDER_STATUS_FILLED":           5,
+		"ORDER_STATUS_CANCELLED":        6,
+		"ORDER_STATUS_REJECTED":         7,
+		"ORDER_STATUS_EXPIRED":          8,
+	}
+)
+
+func (x OrderStatus) Enum() *OrderStatus {
+	p := new(OrderStatus)
+	*p = x
+	return p
+}
+
+func (x OrderStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrderStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_orders_v1_executor_proto_enumTypes[0].Descriptor()
+}
+
+func (OrderStatus) Type() protoreflect.EnumType {
+	return &file_proto_orders_v1_executor_proto_enumTypes[0]
+}
+
+func (x OrderStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrderStatus.Descriptor instead.
+func (OrderStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_orders_v1_executor_proto_rawDescGZIP(), []int{0}
+}
+
+// Order is the executor's view of an order.
+type Order struct {
+	state           protoimpl.MessageState  `protogen:"open.v1"`
+	Id              string                  `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	IntentId        string                  `protobuf:"bytes,2,opt,name=intent_id,json=intentId,proto3" json:"intent_id,omitempty"`
+	Sequence        uint64                  `protobuf:"varint,3,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	BotId           string                  `protobuf:"bytes,4,opt,name=bot_id,json=botId,proto3" json:"bot_id,omitempty"`
+	AccountId       string                  `protobuf:"bytes,5,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Symbol          string                  `protobuf:"bytes,6,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Side            OrderSide               `protobuf:"varint,7,opt,name=side,proto3,enum=qubit.orders.v1.OrderSide" json:"side,omitempty"`
+	Type            OrderType               `protobuf:"varint,8,opt,name=type,proto3,enum=qubit.orders.v1.OrderType" json:"type,omitempty"`
+	TimeInForce     string                  `protobuf:"bytes,9,opt,name=time_in_force,json=timeInForce,proto3" json:"time_in_force,omitempty"`
+	Quantity        float64                 `protobuf:"fixed64,10,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	LimitPrice      *wrapperspb.DoubleValue `protobuf:"bytes,11,opt,name=limit_price,json=limitPrice,proto3" json:"limit_price,omitempty"`
+	StopPrice       *wrapperspb.DoubleValue `protobuf:"bytes,12,opt,name=stop_price,json=stopPrice,proto3" json:"stop_price,omitempty"`
+	FilledQuantity  float64                 `protobuf:"fixed64,13,opt,name=filled_quantity,json=filledQuantity,proto3" json:"filled_quantity,omitempty"`
+	Status          OrderStatus             `protobuf:"varint,14,opt,name=status,proto3,enum=qubit.orders.v1.OrderStatus" json:"status,omitempty"`
+	ProviderOrderId string                  `protobuf:"bytes,15,opt,name=provider_order_id,json=providerOrderId,proto3" json:"provider_order_id,omitempty"`
+	ExpiresAt       *timestamppb.Timestamp  `protobuf:"bytes,16,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	CreatedAt       *timestamppb.Timestamp  `protobuf:"bytes,17,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt       *timestamppb.Timestamp  `protobuf:"bytes,18,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Algorithm parent of a child order.
+	ParentId  string        `protobuf:"bytes,19,opt,name=parent_id,json=parentId,proto3" json:"parent_id,omitempty"`
+	Algorithm string        `protobuf:"bytes,20,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	BracketId string        `protobuf:"bytes,21,opt,name=bracket_id,json=bracketId,proto3" json:"bracket_id,omitempty"`
+	Leg       BracketLeg    `protobuf:"varint,22,opt,name=leg,proto3,enum=qubit.orders.v1.BracketLeg" json:"leg,omitempty"`
+	Mode      ExecutionMode `protobuf:"varint,23,opt,name=mode,proto3,enum=qubit.orders.v1.ExecutionMode" json:"mode,omitempty"`
+	// Broker the order was routed to and why, when routing is enabled.
+	Route         string `protobuf:"bytes,24,opt,name=route,proto3" json:"route,omitempty"`
+	RouteReason   string `protobuf:"bytes,25,opt,name=route_reason,json=routeReason,proto3" json:"route_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Order) Reset() {
+	*x = Order{}
+	mi := &file_proto_orders_v1_executor_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Order) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order) ProtoMessage() {}
+
+func (x *Order) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_v1_executor_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order.ProtoReflect.Descriptor instead.
+func (*Order) Descriptor() ([]byte, []int) {
+	return file_proto_orders_v1_executor_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Order) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Order) GetIntentId() string {
+	if x != nil {
+		return x.IntentId
+	}
+	return ""
+}
+
+func (x *Order) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *Order) GetBotId() string {
+	if x != nil {
+		return x.BotId
+	}
+	return ""
+}
+
+func (x *Order) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *Order) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *Order) GetSide() OrderSide {
+	if x != nil {
+		return x.Side
+	}
+	return OrderSide_ORDER_SIDE_UNSPECIFIED
+}
+
+func (x *Order) GetType() OrderType {
+	if x != nil {
+		return x.Type
+	}
+	return OrderType_ORDER_TYPE_UNSPECIFIED
+}
+
+func (x *Order) GetTimeInForce() string {
+	if x != nil {
+		return x.TimeInForce
+	}
+	return ""
+}
+
+func (x *Order) GetQuantity() float64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *Order) GetLimitPrice() *wrapperspb.DoubleValue {
+	if x != nil {
+		return x.LimitPrice
+	}
+	return nil
+}
+
+func (x *Order) GetStopPrice() *wrapperspb.DoubleValue {
+	if x != nil {
+		return x.StopPrice
+	}
+	return nil
+}
+
+func (x *Order) GetFilledQuantity() float64 {
+	if x != nil {
+		return x.FilledQuantity
+	}
+	return 0
+}
+
+func (x *Order) GetStatus() OrderStatus {
+	if x != nil {
+		return x.Status
+	}
+	return OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+func (x *Order) GetProviderOrderId() string {
+	if x != nil {
+		return x.ProviderOrderId
+	}
+	return ""
+}
+
+func (x *Order) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *Order) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Order) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *Order) GetParentId() string {
+	if x != nil {
+		return x.ParentId
+	}
+	return ""
+}
+
+func (x *Order) GetAlgorithm() string {
+	if x != nil {
+		return x.Algorithm
+	}
+	return ""
+}
+
+func (x *Order) GetBracketId() string {
+	if x != nil {
+		return x.BracketId
+	}
+	return ""
+}
+
+func (x *Order) GetLeg() BracketLeg {
+	if x != nil {
+		return x.Leg
+	}
+	return BracketLeg_BRACKET_LEG_UNSPECIFIED
+}
+
+func (x *Order) GetMode() ExecutionMode {
+	if x != nil {
+		return x.Mode
+	}
+	return ExecutionMode_EXECUTION_MODE_UNSPECIFIED
+}
+
+func (x *Order) GetRoute() string {
+	if x != nil {
+		return x.Route
+	}
+	return ""
+}
+
+func (x *Order) GetRouteReason() string {
+	if x != nil {
+		return x.RouteReason
+	}
+	return ""
+}
+
+type GetOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderRequest) Reset() {
+	*x = GetOrderRequest{}
+	mi := &file_proto_orders_v1_executor_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderRequest) ProtoMessage() {}
+
+func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_v1_executor_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_orders_v1_executor_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+type CancelOrderRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	OrderId string                 `protobuf:"bytes,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	// bot|risk|broker|system; bot when empty.
+	InitiatedBy string `protobuf:"bytes,2,opt,name=initiated_by,json=initiatedBy,proto3" json:"initiated_by,omitempty"`
+	Reason      string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Cancel an order whose placement is unconfirmed without the broker, for
+	// an operator who knows it never reached the venue.
+	Force         bool `protobuf:"varint,4,opt,name=force,proto3" json:"force,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelOrderRequest) Reset() {
+	*x = CancelOrderRequest{}
+	mi := &file_proto_orders_v1_executor_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelOrderRequest) ProtoMessage() {}
+
+func (x *CancelOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_v1_executor_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelOrderRequest.ProtoReflect.Descriptor instead.
+func (*CancelOrderRequest) Descriptor() ([]byte, []int) {
+	return file_proto_orders_v1_executor_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CancelOrderRequest) GetOrderId() string {
+	if x != nil {
+		return x.OrderId
+	}
+	return ""
+}
+
+func (x *CancelOrderRequest) GetInitiatedBy() string {
+	if x != nil {
+		return x.InitiatedBy
+	}
+	return ""
+}
+
+func (x *CancelOrderRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *CancelOrderRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+// ListOrdersRequest filters orders like GET /api/v1/orders. Empty fields
+// match every order.
+type ListOrdersRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	BotId       string                 `protobuf:"bytes,1,opt,name=bot_id,json=botId,proto3" json:"bot_id,omitempty"`
+	AccountId   string                 `protobuf:"bytes,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	Symbol      string                 `protobuf:"bytes,3,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	Side        OrderSide              `protobuf:"varint,4,opt,name=side,proto3,enum=qubit.orders.v1.OrderSide" json:"side,omitempty"`
+	Statuses    []OrderStatus          `protobuf:"varint,5,rep,packed,name=statuses,proto3,enum=qubit.orders.v1.OrderStatus" json:"statuses,omitempty"`
+	Mode        ExecutionMode          `protobuf:"varint,6,opt,name=mode,proto3,enum=qubit.orders.v1.ExecutionMode" json:"mode,omitempty"`
+	CreatedFrom *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_from,json=createdFrom,proto3" json:"created_from,omitempty"`
+	CreatedTo   *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_to,json=createdTo,proto3" json:"created_to,omitempty"`
+	// Page size, 50 when zero and at most 500.
+	Limit uint32 `protobuf:"varint,9,opt,name=limit,proto3" json:"limit,omitempty"`
+	// next_cursor of the previous page.
+	Cursor string `protobuf:"bytes,10,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// Oldest first instead of newest first.
+	Ascending     bool `protobuf:"varint,11,opt,name=ascending,proto3" json:"ascending,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersRequest) Reset() {
+	*x = ListOrdersRequest{}
+	mi := &file_proto_orders_v1_executor_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersRequest) ProtoMessage() {}
+
+func (x *ListOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_v1_executor_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersRequest.ProtoReflect.Descriptor instead.
+func (*ListOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_proto_orders_v1_executor_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListOrdersRequest) GetBotId() string {
+	if x != nil {
+		return x.BotId
+	}
+	return ""
+}
+
+func (x *ListOrdersRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *ListOrdersRequest) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *ListOrdersRequest) GetSide() OrderSide {
+	if x != nil {
+		return x.Side
+	}
+	return OrderSide_ORDER_SIDE_UNSPECIFIED
+}
+
+func (x *ListOrdersRequest) GetStatuses() []OrderStatus {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+func (x *ListOrdersRequest) GetMode() ExecutionMode {
+	if x != nil {
+		return x.Mode
+	}
+	return ExecutionMode_EXECUTION_MODE_UNSPECIFIED
+}
+
+func (x *ListOrdersRequest) GetCreatedFrom() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedFrom
+	}
+	return nil
+}
+
+func (x *ListOrdersRequest) GetCreatedTo() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedTo
+	}
+	return nil
+}
+
+func (x *ListOrdersRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListOrdersRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ListOrdersRequest) GetAscending() bool {
+	if x != nil {
+		return x.Ascending
+	}
+	return false
+}
+
+type ListOrdersResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Orders []*Order               `protobuf:"bytes,1,rep,name=orders,proto3" json:"orders,omitempty"`
+	// Empty on the last page.
+	NextCursor    string `protobuf:"bytes,2,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListOrdersResponse) Reset() {
+	*x = ListOrdersResponse{}
+	mi := &file_proto_orders_v1_executor_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListOrdersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListOrdersResponse) ProtoMessage() {}
+
+func (x *ListOrdersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_v1_executor_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListOrdersResponse.ProtoReflect.Descriptor instead.
+func (*ListOrdersResponse) Descriptor() ([]byte, []int) {
+	return file_proto_orders_v1_executor_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListOrdersResponse) GetOrders() []*Order {
+	if x != nil {
+		return x.Orders
+	}
+	return nil
+}
+
+func (x *ListOrdersResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+// StreamOrderEventsRequest narrows the stream. Empty fields match every
+// order.
+type StreamOrderEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	BotId         string                 `protobuf:"bytes,1,opt,name=bot_id,json=botId,proto3" json:"bot_id,omitempty"`
+	AccountId     string                 `protobuf:"bytes,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamOrderEventsRequest) Reset() {
+	*x = StreamOrderEventsRequest{}
+	mi := &file_proto_orders_v1_executor_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamOrderEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamOrderEventsRequest) ProtoMessage() {}
+
+func (x *StreamOrderEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_v1_executor_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamOrderEventsRequest.ProtoReflect.Descriptor instead.
+func (*StreamOrderEventsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_orders_v1_executor_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *StreamOrderEventsRequest) GetBotId() string {
+	if x != nil {
+		return x.BotId
+	}
+	return ""
+}
+
+func (x *StreamOrderEventsRequest) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+var File_proto_orders_v1_executor_proto protoreflect.FileDescriptor
+
+const file_proto_orders_v1_executor_proto_rawDesc = "" +
+	"\n" +
+	"\x1eproto/orders/v1/executor.proto\x12\x0fqubit.orders.v1\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1egoogle/protobuf/wrappers.proto\x1a\x1cproto/orders/v1/orders.proto\"\xec\a\n" +
+	"\x05Order\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tintent_id\x18\x02 \x01(\tR\bintentId\x12\x1a\n" +
+	"\bsequence\x18\x03 \x01(\x04R\bsequence\x12\x15\n" +
+	"\x06bot_id\x18\x04 \x01(\tR\x05botId\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x05 \x01(\tR\taccountId\x12\x16\n" +
+	"\x06symbol\x18\x06 \x01(\tR\x06symbol\x12.\n" +
+	"\x04side\x18\a \x01(\x0e2\x1a.qubit.orders.v1.OrderSideR\x04side\x12.\n" +
+	"\x04type\x18\b \x01(\x0e2\x1a.qubit.orders.v1.OrderTypeR\x04type\x12\"\n" +
+	"\rtime_in_force\x18\t \x01(\tR\vtimeInForce\x12\x1a\n" +
+	"\bquantity\x18\n" +
+	" \x01(\x01R\bquantity\x12=\n" +
+	"\vlimit_price\x18\v \x01(\v2\x1c.google.protobuf.DoubleValueR\n" +
+	"limitPrice\x12;\n" +
+	"\n" +
+	"stop_price\x18\f \x01(\v2\x1c.google.protobuf.DoubleValueR\tstopPrice\x12'\n" +
+	"\x0ffilled_quantity\x18\r \x01(\x01R\x0efilledQuantity\x124\n" +
+	"\x06status\x18\x0e \x01(\x0e2\x1c.qubit.orders.v1.OrderStatusR\x06status\x12*\n" +
+	"\x11provider_order_id\x18\x0f \x01(\tR\x0fproviderOrderId\x129\n" +
+	"\n" +
+	"expires_at\x18\x10 \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x129\n" +
+	"\n" +
+	"created_at\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x12 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1b\n" +
+	"\tparent_id\x18\x13 \x01(\tR\bparentId\x12\x1c\n" +
+	"\talgorithm\x18\x14 \x01(\tR\talgorithm\x12\x1d\n" +
+	"\n" +
+	"bracket_id\x18\x15 \x01(\tR\tbracketId\x12-\n" +
+	"\x03leg\x18\x16 \x01(\x0e2\x1b.qubit.orders.v1.BracketLegR\x03leg\x122\n" +
+	"\x04mode\x18\x17 \x01(\x0e2\x1e.qubit.orders.v1.ExecutionModeR\x04mode\x12\x14\n" +
+	"\x05route\x18\x18 \x01(\tR\x05route\x12!\n" +
+	"\froute_reason\x18\x19 \x01(\tR\vrouteReason\",\n" +
+	"\x0fGetOrderRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\"\x80\x01\n" +
+	"\x12CancelOrderRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\tR\aorderId\x12!\n" +
+	"\finitiated_by\x18\x02 \x01(\tR\vinitiatedBy\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x14\n" +
+	"\x05force\x18\x04 \x01(\bR\x05force\"\xc5\x03\n" +
+	"\x11ListOrdersRequest\x12\x15\n" +
+	"\x06bot_id\x18\x01 \x01(\tR\x05botId\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x02 \x01(\tR\taccountId\x12\x16\n" +
+	"\x06symbol\x18\x03 \x01(\tR\x06symbol\x12.\n" +
+	"\x04side\x18\x04 \x01(\x0e2\x1a.qubit.orders.v1.OrderSideR\x04side\x128\n" +
+	"\bstatuses\x18\x05 \x03(\x0e2\x1c.qubit.orders.v1.OrderStatusR\bstatuses\x122\n" +
+	"\x04mode\x18\x06 \x01(\x0e2\x1e.qubit.orders.v1.ExecutionModeR\x04mode\x12=\n" +
+	"\fcreated_from\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\vcreatedFrom\x129\n" +
+	"\n" +
+	"created_to\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedTo\x12\x14\n" +
+	"\x05limit\x18\t \x01(\rR\x05limit\x12\x16\n" +
+	"\x06cursor\x18\n" +
+	" \x01(\tR\x06cursor\x12\x1c\n" +
+	"\tascending\x18\v \x01(\bR\tascending\"e\n" +
+	"\x12ListOrdersResponse\x12.\n" +
+	"\x06orders\x18\x01 \x03(\v2\x16.qubit.orders.v1.OrderR\x06orders\x12\x1f\n" +
+	"\vnext_cursor\x18\x02 \x01(\tR\n" +
+	"nextCursor\"P\n" +
+	"\x18StreamOrderEventsRequest\x12\x15\n" +
+	"\x06bot_id\x18\x01 \x01(\tR\x05botId\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x02 \x01(\tR\taccountId*\x86\x02\n" +
+	"\vOrderStatus\x12\x1c\n" +
+	"\x18ORDER_STATUS_UNSPECIFIED\x10\x00\x12\x14\n" +
+	"\x10ORDER_STATUS_NEW\x10\x01\x12\x1d\n" +
+	"\x19ORDER_STATUS_PENDING_RISK\x10\x02\x12\x17\n" +
+	"\x13ORDER_STATUS_ROUTED\x10\x03\x12!\n" +
+	"\x1dORDER_STATUS_PARTIALLY_FILLED\x10\x04\x12\x17\n" +
+	"\x13ORDER_STATUS_FILLED\x10\x05\x12\x1a\n" +
+	"\x16ORDER_STATUS_CANCELLED\x10\x06\x12\x19\n" +
+	"\x15ORDER_STATUS_REJECTED\x10\a\x12\x18\n" +
+	"\x14ORDER_STATUS_EXPIRED\x10\b2\x9e\x03\n" +
+	"\x0fExecutorService\x12C\n" +
+	"\vSubmitOrder\x12\x1c.qubit.orders.v1.OrderIntent\x1a\x16.qubit.orders.v1.Order\x12D\n" +
+	"\bGetOrder\x12 .qubit.orders.v1.GetOrderRequest\x1a\x16.qubit.orders.v1.Order\x12J\n" +
+	"\vCancelOrder\x12#.qubit.orders.v1.CancelOrderRequest\x1a\x16.qubit.orders.v1.Order\x12U\n" +
+	"\n" +
+	"ListOrders\x12\".qubit.orders.v1.ListOrdersRequest\x1a#.qubit.orders.v1.ListOrdersResponse\x12]\n" +
+	"\x11StreamOrderEvents\x12).qubit.orders.v1.StreamOrderEventsRequest\x1a\x1b.qubit.orders.v1.OrderEvent0\x01B1Z/github.com/future-bots/proto/orders/v1;ordersv1b\x06proto3"
+
+var (
+	file_proto_orders_v1_executor_proto_rawDescOnce sync.Once
+	file_proto_orders_v1_executor_proto_rawDescData []byte
+)
+
+func file_proto_orders_v1_executor_proto_rawDescGZIP() []byte {
+	file_proto_orders_v1_executor_proto_rawDescOnce.Do(func() {
+		file_proto_orders_v1_executor_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_orders_v1_executor_proto_rawDesc), len(file_proto_orders_v1_executor_proto_rawDesc)))
+	})
+	return file_proto_orders_v1_executor_proto_rawDescData
+}
+
+var file_proto_orders_v1_executor_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_orders_v1_executor_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_proto_orders_v1_executor_proto_goTypes = []any{
+	(OrderStatus)(0),                 // 0: qubit.orders.v1.OrderStatus
+	(*Order)(nil),                    // 1: qubit.orders.v1.Order
+	(*GetOrderRequest)(nil),          // 2: qubit.orders.v1.GetOrderRequest
+	(*CancelOrderRequest)(nil),       // 3: qubit.orders.v1.CancelOrderRequest
+	(*ListOrdersRequest)(nil),        // 4: qubit.orders.v1.ListOrdersRequest
+	(*ListOrdersResponse)(nil),       // 5: qubit.orders.v1.ListOrdersResponse
+	(*StreamOrderEventsRequest)(nil), // 6: qubit.orders.v1.StreamOrderEventsRequest
+	(OrderSide)(0),                   // 7: qubit.orders.v1.OrderSide
+	(OrderType)(0),                   // 8: qubit.orders.v1.OrderType
+	(*wrapperspb.DoubleValue)(nil),   // 9: google.protobuf.DoubleValue
+	(*timestamppb.Timestamp)(nil),    // 10: google.protobuf.Timestamp
+	(BracketLeg)(0),                  // 11: qubit.orders.v1.BracketLeg
+	(ExecutionMode)(0),               // 12: qubit.orders.v1.ExecutionMode
+	(*OrderIntent)(nil),              // 13: qubit.orders.v1.OrderIntent
+	(*OrderEvent)(nil),               // 14: qubit.orders.v1.OrderEvent
+}
+var file_proto_orders_v1_executor_proto_depIdxs = []int32{
+	7,  // 0: qubit.orders.v1.Order.side:type_name -> qubit.orders.v1.OrderSide
+	8,  // 1: qubit.orders.v1.Order.type:type_name -> qubit.orders.v1.OrderType
+	9,  // 2: qubit.orders.v1.Order.limit_price:type_name -> google.protobuf.DoubleValue
+	9,  // 3: qubit.orders.v1.Order.stop_price:type_name -> google.protobuf.DoubleValue
+	0,  // 4: qubit.orders.v1.Order.status:type_name -> qubit.orders.v1.OrderStatus
+	10, // 5: qubit.orders.v1.Order.expires_at:type_name -> google.protobuf.Timestamp
+	10, // 6: qubit.orders.v1.Order.created_at:type_name -> google.protobuf.Timestamp
+	10, // 7: qubit.orders.v1.Order.updated_at:type_name -> google.protobuf.Timestamp
+	11, // 8: qubit.orders.v1.Order.leg:type_name -> qubit.orders.v1.BracketLeg
+	12, // 9: qubit.orders.v1.Order.mode:type_name -> qubit.orders.v1.ExecutionMode
+	7,  // 10: qubit.orders.v1.ListOrdersRequest.side:type_name -> qubit.orders.v1.OrderSide
+	0,  // 11: qubit.orders.v1.ListOrdersRequest.statuses:type_name -> qubit.orders.v1.OrderStatus
+	12, // 12: qubit.orders.v1.ListOrdersRequest.mode:type_name -> qubit.orders.v1.ExecutionMode
+	10, // 13: qubit.orders.v1.ListOrdersRequest.created_from:type_name -> google.protobuf.Timestamp
+	10, // 14: qubit.orders.v1.ListOrdersRequest.created_to:type_name -> google.protobuf.Timestamp
+	1,  // 15: qubit.orders.v1.ListOrdersResponse.orders:type_name -> qubit.orders.v1.Order
+	13, // 16: qubit.orders.v1.ExecutorService.SubmitOrder:input_type -> qubit.orders.v1.OrderIntent
+	2,  // 17: qubit.orders.v1.ExecutorService.GetOrder:input_type -> qubit.orders.v1.GetOrderRequest
+	3,  // 18: qubit.orders.v1.ExecutorService.CancelOrder:input_type -> qubit.orders.v1.CancelOrderRequest
+	4,  // 19: qubit.orders.v1.ExecutorService.ListOrders:input_type -> qubit.orders.v1.ListOrdersRequest
+	6,  // 20: qubit.orders.v1.ExecutorService.StreamOrderEvents:input_type -> qubit.orders.v1.StreamOrderEventsRequest
+	1,  // 21: qubit.orders.v1.ExecutorService.SubmitOrder:output_type -> qubit.orders.v1.Order
+	1,  // 22: qubit.orders.v1.ExecutorService.GetOrder:output_type -> qubit.orders.v1.Order
+	1,  // 23: qubit.orders.v1.ExecutorService.CancelOrder:output_type -> qubit.orders.v1.Order
+	5,  // 24: qubit.orders.v1.ExecutorService.ListOrders:output_type -> qubit.orders.v1.ListOrdersResponse
+	14, // 25: qubit.orders.v1.ExecutorService.StreamOrderEvents:output_type -> qubit.orders.v1.OrderEvent
+	21, // [21:26] is the sub-list for method output_type
+	16, // [16:21] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_proto_orders_v1_executor_proto_init() }
+func file_proto_orders_v1_executor_proto_init() {
+	if File_proto_orders_v1_executor_proto != nil {
+		return
+	}
+	file_proto_orders_v1_orders_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_orders_v1_executor_proto_rawDesc), len(file_proto_orders_v1_executor_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_proto_orders_v1_executor_proto_goTypes,
+		DependencyIndexes: file_proto_orders_v1_executor_proto_depIdxs,
+		EnumInfos:         file_proto_orders_v1_executor_proto_enumTypes,
+		MessageInfos:      file_proto_orders_v1_executor_proto_msgTypes,
+	}.Build()
+	File_proto_orders_v1_executor_proto = out.File
+	file_proto_orders_v1_executor_proto_goTypes = nil
+	file_proto_orders_v1_executor_proto_depIdxs = nil
+}
@@ -0,0 +1,1334 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v3.21.12
+// source: proto/orders/v1/orders.proto
+
+package ordersv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// OrderSide enumerates available sides for an order intent.
+type OrderSide int32
+
+const (
+	OrderSide_ORDER_SIDE_UNSPECIFIED OrderSide = 0
+	OrderSide_ORDER_SIDE_BUY         OrderSide = 1
+	OrderSide_ORDER_SIDE_SELL        OrderSide = 2
+)
+
+// Enum value maps for OrderSide.
+var (
+	OrderSide_name = map[int32]string{
+		0: "ORDER_SIDE_UNSPECIFIED",
+		1: "ORDER_SIDE_BUY",
+		2: "ORDER_SIDE_SELL",
+	}
+	OrderSide_value = map[string]int32{
+		"ORDER_SIDE_UNSPECIFIED": 0,
+		"ORDER_SIDE_BUY":         1,
+		"ORDER_SIDE_SELL":        2,
+	}
+)
+
+func (x OrderSide) Enum() *OrderSide {
+	p := new(OrderSide)
+	*p = x
+	return p
+}
+
+func (x OrderSide) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrderSide) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_orders_v1_orders_proto_enumTypes[0].Descriptor()
+}
+
+func (OrderSide) Type() protoreflect.EnumType {
+	return &file_proto_orders_v1_orders_proto_enumTypes[0]
+}
+
+func (x OrderSide) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrderSide.Descriptor instead.
+func (OrderSide) EnumDescriptor() ([]byte, []int) {
+	return file_proto_orders_v1_orders_proto_rawDescGZIP(), []int{0}
+}
+
+// BracketLeg identifies an order's role within a bracket.
+type BracketLeg int32
+
+const (
+	BracketLeg_BRACKET_LEG_UNSPECIFIED BracketLeg = 0
+	BracketLeg_BRACKET_LEG_ENTRY       BracketLeg = 1
+	BracketLeg_BRACKET_LEG_TAKE_PROFIT BracketLeg = 2
+	BracketLeg_BRACKET_LEG_STOP_LOSS   BracketLeg = 3
+)
+
+// Enum value maps for BracketLeg.
+var (
+	BracketLeg_name = map[int32]string{
+		0: "BRACKET_LEG_UNSPECIFIED",
+		1: "BRACKET_LEG_ENTRY",
+		2: "BRACKET_LEG_TAKE_PROFIT",
+		3: "BRACKET_LEG_STOP_LOSS",
+	}
+	BracketLeg_value = map[string]int32{
+		"BRACKET_LEG_UNSPECIFIED": 0,
+		"BRACKET_LEG_ENTRY":       1,
+		"BRACKET_LEG_TAKE_PROFIT": 2,
+		"BRACKET_LEG_STOP_LOSS":   3,
+	}
+)
+
+func (x BracketLeg) Enum() *BracketLeg {
+	p := new(BracketLeg)
+	*p = x
+	return p
+}
+
+func (x BracketLeg) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (BracketLeg) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_orders_v1_orders_proto_enumTypes[1].Descriptor()
+}
+
+func (BracketLeg) Type() protoreflect.EnumType {
+	return &file_proto_orders_v1_orders_proto_enumTypes[1]
+}
+
+func (x BracketLeg) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use BracketLeg.Descriptor instead.
+func (BracketLeg) EnumDescriptor() ([]byte, []int) {
+	return file_proto_orders_v1_orders_proto_rawDescGZIP(), []int{1}
+}
+
+// ExecutionMode distinguishes live orders from paper orders filled by a
+// simulator against live market data.
+type ExecutionMode int32
+
+const (
+	ExecutionMode_EXECUTION_MODE_UNSPECIFIED ExecutionMode = 0
+	ExecutionMode_EXECUTION_MODE_LIVE        ExecutionMode = 1
+	ExecutionMode_EXECUTION_MODE_PAPER       ExecutionMode = 2
+)
+
+// Enum value maps for ExecutionMode.
+var (
+	ExecutionMode_name = map[int32]string{
+		0: "EXECUTION_MODE_UNSPECIFIED",
+		1: "EXECUTION_MODE_LIVE",
+		2: "EXECUTION_MODE_PAPER",
+	}
+	ExecutionMode_value = map[string]int32{
+		"EXECUTION_MODE_UNSPECIFIED": 0,
+		"EXECUTION_MODE_LIVE":        1,
+		"EXECUTION_MODE_PAPER":       2,
+	}
+)
+
+func (x ExecutionMode) Enum() *ExecutionMode {
+	p := new(ExecutionMode)
+	*p = x
+	return p
+}
+
+func (x ExecutionMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ExecutionMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_orders_v1_orders_proto_enumTypes[2].Descriptor()
+}
+
+func (ExecutionMode) Type() protoreflect.EnumType {
+	return &file_proto_orders_v1_orders_proto_enumTypes[2]
+}
+
+func (x ExecutionMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ExecutionMode.Descriptor instead.
+func (ExecutionMode) EnumDescriptor() ([]byte, []int) {
+	return file_proto_orders_v1_orders_proto_rawDescGZIP(), []int{2}
+}
+
+// OrderType enumerates supported order types.
+type OrderType int32
+
+const (
+	OrderType_ORDER_TYPE_UNSPECIFIED OrderType = 0
+	OrderType_ORDER_TYPE_MARKET      OrderType = 1
+	OrderType_ORDER_TYPE_LIMIT       OrderType = 2
+	OrderType_ORDER_TYPE_STOP        OrderType = 3
+)
+
+// Enum value maps for OrderType.
+var (
+	OrderType_name = map[int32]string{
+		0: "ORDER_TYPE_UNSPECIFIED",
+		1: "ORDER_TYPE_MARKET",
+		2: "ORDER_TYPE_LIMIT",
+		3: "ORDER_TYPE_STOP",
+	}
+	OrderType_value = map[string]int32{
+		"ORDER_TYPE_UNSPECIFIED": 0,
+		"ORDER_TYPE_MARKET":      1,
+		"ORDER_TYPE_LIMIT":       2,
+		"ORDER_TYPE_STOP":        3,
+	}
+)
+
+func (x OrderType) Enum() *OrderType {
+	p := new(OrderType)
+	*p = x
+	return p
+}
+
+func (x OrderType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrderType) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_orders_v1_orders_proto_enumTypes[3].Descriptor()
+}
+
+func (OrderType) Type() protoreflect.EnumType {
+	return &file_proto_orders_v1_orders_proto_enumTypes[3]
+}
+
+func (x OrderType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrderType.Descriptor instead.
+func (OrderType) EnumDescriptor() ([]byte, []int) {
+	return file_proto_orders_v1_orders_proto_rawDescGZIP(), []int{3}
+}
+
+// RejectionReason describes broad categories of order rejection events.
+type RejectionReason int32
+
+const (
+	RejectionReason_REJECTION_REASON_UNSPECIFIED       RejectionReason = 0
+	RejectionReason_REJECTION_REASON_RISK_LIMIT        RejectionReason = 1
+	RejectionReason_REJECTION_REASON_INSTRUMENT_HALTED RejectionReason = 2
+	RejectionReason_REJECTION_REASON_BROKER_REJECT     RejectionReason = 3
+	RejectionReason_REJECTION_REASON_TIMEOUT           RejectionReason = 4
+	RejectionReason_REJECTION_REASON_SYSTEM_ERROR      RejectionReason = 5
+	// Price is off the instrument's tick grid or outside the day's ceiling/floor band.
+	RejectionReason_REJECTION_REASON_INVALID_PRICE RejectionReason = 6
+	// Quantity is not a whole number of contracts/lots.
+	RejectionReason_REJECTION_REASON_INVALID_QUANTITY RejectionReason = 7
+	// Bot or account exceeded its order rate limits.
+	RejectionReason_REJECTION_REASON_RATE_LIMIT RejectionReason = 8
+	// Intent arrived outside the venue's trading sessions, e.g. during the lunch break.
+	RejectionReason_REJECTION_REASON_MARKET_CLOSED RejectionReason = 9
+)
+
+// Enum value maps for RejectionReason.
+var (
+	RejectionReason_name = map[int32]string{
+		0: "REJECTION_REASON_UNSPECIFIED",
+		1: "REJECTION_REASON_RISK_LIMIT",
+		2: "REJECTION_REASON_INSTRUMENT_HALTED",
+		3: "REJECTION_REASON_BROKER_REJECT",
+		4: "REJECTION_REASON_TIMEOUT",
+		5: "REJECTION_REASON_SYSTEM_ERROR",
+		6: "REJECTION_REASON_INVALID_PRICE",
+		7: "REJECTION_REASON_INVALID_QUANTITY",
+		8: "REJECTION_REASON_RATE_LIMIT",
+		9: "REJECTION_REASON_MARKET_CLOSED",
+	}
+	RejectionReason_value = map[string]int32{
+		"REJECTION_REASON_UNSPECIFIED":       0,
+		"REJECTION_REASON_RISK_LIMIT":        1,
+		"REJECTION_REASON_INSTRUMENT_HALTED": 2,
+		"REJECTION_REASON_BROKER_REJECT":     3,
+		"REJECTION_REASON_TIMEOUT":           4,
+		"REJECTION_REASON_SYSTEM_ERROR":      5,
+		"REJECTION_REASON_INVALID_PRICE":     6,
+		"REJECTION_REASON_INVALID_QUANTITY":  7,
+		"REJECTION_REASON_RATE_LIMIT":        8,
+		"REJECTION_REASON_MARKET_CLOSED":     9,
+	}
+)
+
+func (x RejectionReason) Enum() *RejectionReason {
+	p := new(RejectionReason)
+	*p = x
+	return p
+}
+
+func (x RejectionReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RejectionReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_orders_v1_orders_proto_enumTypes[4].Descriptor()
+}
+
+func (RejectionReason) Type() protoreflect.EnumType {
+	return &file_proto_orders_v1_orders_proto_enumTypes[4]
+}
+
+func (x RejectionReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RejectionReason.Descriptor instead.
+func (RejectionReason) EnumDescriptor() ([]byte, []int) {
+	return file_proto_orders_v1_orders_proto_rawDescGZIP(), []int{4}
+}
+
+// OrderIntent represents an order request emitted by a trading bot
+// to the trade executor service on the `orders.intent` Kafka topic.
+type OrderIntent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unique identifier assigned by the bot runtime.
+	IntentId string `protobuf:"bytes,1,opt,name=intent_id,json=intentId,proto3" json:"intent_id,omitempty"`
+	// Immutable bot identifier.
+	BotId string `protobuf:"bytes,2,opt,name=bot_id,json=botId,proto3" json:"bot_id,omitempty"`
+	// Account identifier used for routing and authorization.
+	AccountId string `protobuf:"bytes,3,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	// Market symbol for the instrument being traded (e.g. VN30F1M).
+	Symbol string `protobuf:"bytes,4,opt,name=symbol,proto3" json:"symbol,omitempty"`
+	// Side of the trade (buy or sell).
+	Side OrderSide `protobuf:"varint,5,opt,name=side,proto3,enum=qubit.orders.v1.OrderSide" json:"side,omitempty"`
+	// Requested quantity in instrument units.
+	Quantity float64 `protobuf:"fixed64,6,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	// Optional price for limit orders. Not set for market orders.
+	LimitPrice *wrapperspb.DoubleValue `protobuf:"bytes,7,opt,name=limit_price,json=limitPrice,proto3" json:"limit_price,omitempty"`
+	// Order type describing how the order should be executed.
+	Type OrderType `protobuf:"varint,8,opt,name=type,proto3,enum=qubit.orders.v1.OrderType" json:"type,omitempty"`
+	// Optional time in force instruction (e.g. GTC, IOC, FOK).
+	TimeInForce string `protobuf:"bytes,9,opt,name=time_in_force,json=timeInForce,proto3" json:"time_in_force,omitempty"`
+	// Deadline requested by the bot for the order to be processed.
+	ExpiresAt *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
+	// Monotonically increasing sequence number per bot + account pair
+	// enabling idempotent execution.
+	Sequence uint64 `protobuf:"varint,11,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// Free-form metadata for downstream auditing or debugging.
+	Annotations map[string]string `protobuf:"bytes,12,rep,name=annotations,proto3" json:"annotations,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Trigger price for stop orders. Once the market trades through it the
+	// order becomes a market order, or a limit order when limit_price is set.
+	StopPrice *wrapperspb.DoubleValue `protobuf:"bytes,13,opt,name=stop_price,json=stopPrice,proto3" json:"stop_price,omitempty"`
+	// Optional execution algorithm slicing the intent into child orders:
+	// "twap" spreads it over algo_duration of trading time, "iceberg" shows
+	// display_quantity at a time.
+	Algorithm string `protobuf:"bytes,14,opt,name=algorithm,proto3" json:"algorithm,omitempty"`
+	// TWAP schedule length in trading time.
+	AlgoDuration *durationpb.Duration `protobuf:"bytes,15,opt,name=algo_duration,json=algoDuration,proto3" json:"algo_duration,omitempty"`
+	// Number of TWAP slices; one per minute of algo_duration when zero.
+	AlgoSlices uint32 `protobuf:"varint,16,opt,name=algo_slices,json=algoSlices,proto3" json:"algo_slices,omitempty"`
+	// Iceberg quantity visible at the broker per child order.
+	DisplayQuantity float64 `protobuf:"fixed64,17,opt,name=display_quantity,json=displayQuantity,proto3" json:"display_quantity,omitempty"`
+	// Optional bracket exits, armed once the entry fills: a take-profit limit
+	// and a stop-loss stop order on the opposite side. When both are set they
+	// form an OCO pair and a fill on one cancels the other.
+	TakeProfitPrice *wrapperspb.DoubleValue `protobuf:"bytes,18,opt,name=take_profit_price,json=takeProfitPrice,proto3" json:"take_profit_price,omitempty"`
+	StopLossPrice   *wrapperspb.DoubleValue `protobuf:"bytes,19,opt,name=stop_loss_price,json=stopLossPrice,proto3" json:"stop_loss_price,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *OrderIntent) Reset() {
+	*x = OrderIntent{}
+	mi := &file_proto_orders_v1_orders_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderIntent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderIntent) ProtoMessage() {}
+
+func (x *OrderIntent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_v1_orders_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderIntent.ProtoReflect.Descriptor instead.
+func (*OrderIntent) Descriptor() ([]byte, []int) {
+	return file_proto_orders_v1_orders_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *OrderIntent) GetIntentId() string {
+	if x != nil {
+		return x.IntentId
+	}
+	return ""
+}
+
+func (x *OrderIntent) GetBotId() string {
+	if x != nil {
+		return x.BotId
+	}
+	return ""
+}
+
+func (x *OrderIntent) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *OrderIntent) GetSymbol() string {
+	if x != nil {
+		return x.Symbol
+	}
+	return ""
+}
+
+func (x *OrderIntent) GetSide() OrderSide {
+	if x != nil {
+		return x.Side
+	}
+	return OrderSide_ORDER_SIDE_UNSPECIFIED
+}
+
+func (x *OrderIntent) GetQuantity() float64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *OrderIntent) GetLimitPrice() *wrapperspb.DoubleValue {
+	if x != nil {
+		return x.LimitPrice
+	}
+	return nil
+}
+
+func (x *OrderIntent) GetType() OrderType {
+	if x != nil {
+		return x.Type
+	}
+	return OrderType_ORDER_TYPE_UNSPECIFIED
+}
+
+func (x *OrderIntent) GetTimeInForce() string {
+	if x != nil {
+		return x.TimeInForce
+	}
+	return ""
+}
+
+func (x *OrderIntent) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
+func (x *OrderIntent) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *OrderIntent) GetAnnotations() map[string]string {
+	if x != nil {
+		return x.Annotations
+	}
+	return nil
+}
+
+func (x *OrderIntent) GetStopPrice() *wrapperspb.DoubleValue {
+	if x != nil {
+		return x.StopPrice
+	}
+	return nil
+}
+
+func (x *OrderIntent) GetAlgorithm() string {
+	if x != nil {
+		return x.Algorithm
+	}
+	return ""
+}
+
+func (x *OrderIntent) GetAlgoDuration() *durationpb.Duration {
+	if x != nil {
+		return x.AlgoDuration
+	}
+	return nil
+}
+
+func (x *OrderIntent) GetAlgoSlices() uint32 {
+	if x != nil {
+		return x.AlgoSlices
+	}
+	return 0
+}
+
+func (x *OrderIntent) GetDisplayQuantity() float64 {
+	if x != nil {
+		return x.DisplayQuantity
+	}
+	return 0
+}
+
+func (x *OrderIntent) GetTakeProfitPrice() *wrapperspb.DoubleValue {
+	if x != nil {
+		return x.TakeProfitPrice
+	}
+	return nil
+}
+
+func (x *OrderIntent) GetStopLossPrice() *wrapperspb.DoubleValue {
+	if x != nil {
+		return x.StopLossPrice
+	}
+	return nil
+}
+
+// OrderIntentAck is published by the executor on the orders.event topic
+// to confirm receipt of an intent before processing.
+type OrderIntentAck struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	IntentId        string                 `protobuf:"bytes,1,opt,name=intent_id,json=intentId,proto3" json:"intent_id,omitempty"`
+	ExecutorOrderId string                 `protobuf:"bytes,2,opt,name=executor_order_id,json=executorOrderId,proto3" json:"executor_order_id,omitempty"`
+	ReceivedAt      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=received_at,json=receivedAt,proto3" json:"received_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *OrderIntentAck) Reset() {
+	*x = OrderIntentAck{}
+	mi := &file_proto_orders_v1_orders_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderIntentAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderIntentAck) ProtoMessage() {}
+
+func (x *OrderIntentAck) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_v1_orders_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderIntentAck.ProtoReflect.Descriptor instead.
+func (*OrderIntentAck) Descriptor() ([]byte, []int) {
+	return file_proto_orders_v1_orders_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *OrderIntentAck) GetIntentId() string {
+	if x != nil {
+		return x.IntentId
+	}
+	return ""
+}
+
+func (x *OrderIntentAck) GetExecutorOrderId() string {
+	if x != nil {
+		return x.ExecutorOrderId
+	}
+	return ""
+}
+
+func (x *OrderIntentAck) GetReceivedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReceivedAt
+	}
+	return nil
+}
+
+// ExecutionFill captures a partial or complete fill event for an order.
+type ExecutionFill struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	IntentId          string                 `protobuf:"bytes,1,opt,name=intent_id,json=intentId,proto3" json:"intent_id,omitempty"`
+	ExecutorOrderId   string                 `protobuf:"bytes,2,opt,name=executor_order_id,json=executorOrderId,proto3" json:"executor_order_id,omitempty"`
+	ProviderOrderId   string                 `protobuf:"bytes,3,opt,name=provider_order_id,json=providerOrderId,proto3" json:"provider_order_id,omitempty"`
+	FilledQuantity    float64                `protobuf:"fixed64,4,opt,name=filled_quantity,json=filledQuantity,proto3" json:"filled_quantity,omitempty"`
+	RemainingQuantity float64                `protobuf:"fixed64,5,opt,name=remaining_quantity,json=remainingQuantity,proto3" json:"remaining_quantity,omitempty"`
+	FillPrice         float64                `protobuf:"fixed64,6,opt,name=fill_price,json=fillPrice,proto3" json:"fill_price,omitempty"`
+	// Broker, exchange and depository fees charged on the fill, in VND.
+	FeePaid  float64                `protobuf:"fixed64,7,opt,name=fee_paid,json=feePaid,proto3" json:"fee_paid,omitempty"`
+	FilledAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=filled_at,json=filledAt,proto3" json:"filled_at,omitempty"`
+	// Personal income tax withheld on the fill, in VND.
+	TaxPaid       float64 `protobuf:"fixed64,9,opt,name=tax_paid,json=taxPaid,proto3" json:"tax_paid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecutionFill) Reset() {
+	*x = ExecutionFill{}
+	mi := &file_proto_orders_v1_orders_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecutionFill) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutionFill) ProtoMessage() {}
+
+func (x *ExecutionFill) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_v1_orders_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutionFill.ProtoReflect.Descriptor instead.
+func (*ExecutionFill) Descriptor() ([]byte, []int) {
+	return file_proto_orders_v1_orders_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ExecutionFill) GetIntentId() string {
+	if x != nil {
+		return x.IntentId
+	}
+	return ""
+}
+
+func (x *ExecutionFill) GetExecutorOrderId() string {
+	if x != nil {
+		return x.ExecutorOrderId
+	}
+	return ""
+}
+
+func (x *ExecutionFill) GetProviderOrderId() string {
+	if x != nil {
+		return x.ProviderOrderId
+	}
+	return ""
+}
+
+func (x *ExecutionFill) GetFilledQuantity() float64 {
+	if x != nil {
+		return x.FilledQuantity
+	}
+	return 0
+}
+
+func (x *ExecutionFill) GetRemainingQuantity() float64 {
+	if x != nil {
+		return x.RemainingQuantity
+	}
+	return 0
+}
+
+func (x *ExecutionFill) GetFillPrice() float64 {
+	if x != nil {
+		return x.FillPrice
+	}
+	return 0
+}
+
+func (x *ExecutionFill) GetFeePaid() float64 {
+	if x != nil {
+		return x.FeePaid
+	}
+	return 0
+}
+
+func (x *ExecutionFill) GetFilledAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FilledAt
+	}
+	return nil
+}
+
+func (x *ExecutionFill) GetTaxPaid() float64 {
+	if x != nil {
+		return x.TaxPaid
+	}
+	return 0
+}
+
+// OrderRejection is emitted when the risk engine or broker rejects an intent.
+type OrderRejection struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	IntentId        string                 `protobuf:"bytes,1,opt,name=intent_id,json=intentId,proto3" json:"intent_id,omitempty"`
+	ExecutorOrderId string                 `protobuf:"bytes,2,opt,name=executor_order_id,json=executorOrderId,proto3" json:"executor_order_id,omitempty"`
+	// Human readable reason.
+	Reason string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Machine friendly rejection category.
+	Category      RejectionReason        `protobuf:"varint,4,opt,name=category,proto3,enum=qubit.orders.v1.RejectionReason" json:"category,omitempty"`
+	RejectedAt    *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=rejected_at,json=rejectedAt,proto3" json:"rejected_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderRejection) Reset() {
+	*x = OrderRejection{}
+	mi := &file_proto_orders_v1_orders_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderRejection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderRejection) ProtoMessage() {}
+
+func (x *OrderRejection) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_v1_orders_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderRejection.ProtoReflect.Descriptor instead.
+func (*OrderRejection) Descriptor() ([]byte, []int) {
+	return file_proto_orders_v1_orders_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *OrderRejection) GetIntentId() string {
+	if x != nil {
+		return x.IntentId
+	}
+	return ""
+}
+
+func (x *OrderRejection) GetExecutorOrderId() string {
+	if x != nil {
+		return x.ExecutorOrderId
+	}
+	return ""
+}
+
+func (x *OrderRejection) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *OrderRejection) GetCategory() RejectionReason {
+	if x != nil {
+		return x.Category
+	}
+	return RejectionReason_REJECTION_REASON_UNSPECIFIED
+}
+
+func (x *OrderRejection) GetRejectedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RejectedAt
+	}
+	return nil
+}
+
+// OrderCancel notifies a bot that an order was cancelled prior to execution.
+type OrderCancel struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	IntentId        string                 `protobuf:"bytes,1,opt,name=intent_id,json=intentId,proto3" json:"intent_id,omitempty"`
+	ExecutorOrderId string                 `protobuf:"bytes,2,opt,name=executor_order_id,json=executorOrderId,proto3" json:"executor_order_id,omitempty"`
+	InitiatedBy     string                 `protobuf:"bytes,3,opt,name=initiated_by,json=initiatedBy,proto3" json:"initiated_by,omitempty"` // bot|risk|broker|system
+	CancelledAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=cancelled_at,json=cancelledAt,proto3" json:"cancelled_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *OrderCancel) Reset() {
+	*x = OrderCancel{}
+	mi := &file_proto_orders_v1_orders_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderCancel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderCancel) ProtoMessage() {}
+
+func (x *OrderCancel) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_v1_orders_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderCancel.ProtoReflect.Descriptor instead.
+func (*OrderCancel) Descriptor() ([]byte, []int) {
+	return file_proto_orders_v1_orders_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *OrderCancel) GetIntentId() string {
+	if x != nil {
+		return x.IntentId
+	}
+	return ""
+}
+
+func (x *OrderCancel) GetExecutorOrderId() string {
+	if x != nil {
+		return x.ExecutorOrderId
+	}
+	return ""
+}
+
+func (x *OrderCancel) GetInitiatedBy() string {
+	if x != nil {
+		return x.InitiatedBy
+	}
+	return ""
+}
+
+func (x *OrderCancel) GetCancelledAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CancelledAt
+	}
+	return nil
+}
+
+// OrderAmend notifies a bot that a working order's price or quantity changed.
+type OrderAmend struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	IntentId        string                 `protobuf:"bytes,1,opt,name=intent_id,json=intentId,proto3" json:"intent_id,omitempty"`
+	ExecutorOrderId string                 `protobuf:"bytes,2,opt,name=executor_order_id,json=executorOrderId,proto3" json:"executor_order_id,omitempty"`
+	// Price and total quantity after the amendment.
+	Price         float64                `protobuf:"fixed64,3,opt,name=price,proto3" json:"price,omitempty"`
+	Quantity      float64                `protobuf:"fixed64,4,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	Reason        string                 `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	AmendedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=amended_at,json=amendedAt,proto3" json:"amended_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderAmend) Reset() {
+	*x = OrderAmend{}
+	mi := &file_proto_orders_v1_orders_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderAmend) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderAmend) ProtoMessage() {}
+
+func (x *OrderAmend) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_v1_orders_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderAmend.ProtoReflect.Descriptor instead.
+func (*OrderAmend) Descriptor() ([]byte, []int) {
+	return file_proto_orders_v1_orders_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *OrderAmend) GetIntentId() string {
+	if x != nil {
+		return x.IntentId
+	}
+	return ""
+}
+
+func (x *OrderAmend) GetExecutorOrderId() string {
+	if x != nil {
+		return x.ExecutorOrderId
+	}
+	return ""
+}
+
+func (x *OrderAmend) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *OrderAmend) GetQuantity() float64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *OrderAmend) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *OrderAmend) GetAmendedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AmendedAt
+	}
+	return nil
+}
+
+// OrderEvent is the envelope that appears on the orders.event topic.
+type OrderEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*OrderEvent_Ack
+	//	*OrderEvent_Fill
+	//	*OrderEvent_Rejection
+	//	*OrderEvent_Cancel
+	//	*OrderEvent_Amend
+	Event isOrderEvent_Event `protobuf_oneof:"event"`
+	// Bot + account used to route the message.
+	BotId     string `protobuf:"bytes,20,opt,name=bot_id,json=botId,proto3" json:"bot_id,omitempty"`
+	AccountId string `protobuf:"bytes,21,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+	// Correlation identifier for tracing.
+	CorrelationId string                 `protobuf:"bytes,22,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+	PublishedAt   *timestamppb.Timestamp `protobuf:"bytes,23,opt,name=published_at,json=publishedAt,proto3" json:"published_at,omitempty"`
+	// Bracket the order belongs to: the executor order id of its entry.
+	BracketId string     `protobuf:"bytes,24,opt,name=bracket_id,json=bracketId,proto3" json:"bracket_id,omitempty"`
+	Leg       BracketLeg `protobuf:"varint,25,opt,name=leg,proto3,enum=qubit.orders.v1.BracketLeg" json:"leg,omitempty"`
+	// Whether the order trades live or on paper; paper fills must never be
+	// booked against live positions or PnL.
+	Mode          ExecutionMode `protobuf:"varint,26,opt,name=mode,proto3,enum=qubit.orders.v1.ExecutionMode" json:"mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OrderEvent) Reset() {
+	*x = OrderEvent{}
+	mi := &file_proto_orders_v1_orders_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OrderEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OrderEvent) ProtoMessage() {}
+
+func (x *OrderEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_orders_v1_orders_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OrderEvent.ProtoReflect.Descriptor instead.
+func (*OrderEvent) Descriptor() ([]byte, []int) {
+	return file_proto_orders_v1_orders_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *OrderEvent) GetEvent() isOrderEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetAck() *OrderIntentAck {
+	if x != nil {
+		if x, ok := x.Event.(*OrderEvent_Ack); ok {
+			return x.Ack
+		}
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetFill() *ExecutionFill {
+	if x != nil {
+		if x, ok := x.Event.(*OrderEvent_Fill); ok {
+			return x.Fill
+		}
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetRejection() *OrderRejection {
+	if x != nil {
+		if x, ok := x.Event.(*OrderEvent_Rejection); ok {
+			return x.Rejection
+		}
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetCancel() *OrderCancel {
+	if x != nil {
+		if x, ok := x.Event.(*OrderEvent_Cancel); ok {
+			return x.Cancel
+		}
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetAmend() *OrderAmend {
+	if x != nil {
+		if x, ok := x.Event.(*OrderEvent_Amend); ok {
+			return x.Amend
+		}
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetBotId() string {
+	if x != nil {
+		return x.BotId
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetAccountId() string {
+	if x != nil {
+		return x.AccountId
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetPublishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PublishedAt
+	}
+	return nil
+}
+
+func (x *OrderEvent) GetBracketId() string {
+	if x != nil {
+		return x.BracketId
+	}
+	return ""
+}
+
+func (x *OrderEvent) GetLeg() BracketLeg {
+	if x != nil {
+		return x.Leg
+	}
+	return BracketLeg_BRACKET_LEG_UNSPECIFIED
+}
+
+func (x *OrderEvent) GetMode() ExecutionMode {
+	if x != nil {
+		return x.Mode
+	}
+	return ExecutionMode_EXECUTION_MODE_UNSPECIFIED
+}
+
+type isOrderEvent_Event interface {
+	isOrderEvent_Event()
+}
+
+type OrderEvent_Ack struct {
+	Ack *OrderIntentAck `protobuf:"bytes,1,opt,name=ack,proto3,oneof"`
+}
+
+type OrderEvent_Fill struct {
+	Fill *ExecutionFill `protobuf:"bytes,2,opt,name=fill,proto3,oneof"`
+}
+
+type OrderEvent_Rejection struct {
+	Rejection *OrderRejection `protobuf:"bytes,3,opt,name=rejection,proto3,oneof"`
+}
+
+type OrderEvent_Cancel struct {
+	Cancel *OrderCancel `protobuf:"bytes,4,opt,name=cancel,proto3,oneof"`
+}
+
+type OrderEvent_Amend struct {
+	Amend *OrderAmend `protobuf:"bytes,5,opt,name=amend,proto3,oneof"`
+}
+
+func (*OrderEvent_Ack) isOrderEvent_Event() {}
+
+func (*OrderEvent_Fill) isOrderEvent_Event() {}
+
+func (*OrderEvent_Rejection) isOrderEvent_Event() {}
+
+func (*OrderEvent_Cancel) isOrderEvent_Event() {}
+
+func (*OrderEvent_Amend) isOrderEvent_Event() {}
+
+var File_proto_orders_v1_orders_proto protoreflect.FileDescriptor
+
+const file_proto_orders_v1_orders_proto_rawDesc = "" +
+	"\n" +
+	"\x1cproto/orders/v1/orders.proto\x12\x0fqubit.orders.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x1egoogle/protobuf/wrappers.proto\"\xb6\a\n" +
+	"\vOrderIntent\x12\x1b\n" +
+	"\tintent_id\x18\x01 \x01(\tR\bintentId\x12\x15\n" +
+	"\x06bot_id\x18\x02 \x01(\tR\x05botId\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x03 \x01(\tR\taccountId\x12\x16\n" +
+	"\x06symbol\x18\x04 \x01(\tR\x06symbol\x12.\n" +
+	"\x04side\x18\x05 \x01(\x0e2\x1a.qubit.orders.v1.OrderSideR\x04side\x12\x1a\n" +
+	"\bquantity\x18\x06 \x01(\x01R\bquantity\x12=\n" +
+	"\vlimit_price\x18\a \x01(\v2\x1c.google.protobuf.DoubleValueR\n" +
+	"limitPrice\x12.\n" +
+	"\x04type\x18\b \x01(\x0e2\x1a.qubit.orders.v1.OrderTypeR\x04type\x12\"\n" +
+	"\rtime_in_force\x18\t \x01(\tR\vtimeInForce\x129\n" +
+	"\n" +
+	"expires_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\x12\x1a\n" +
+	"\bsequence\x18\v \x01(\x04R\bsequence\x12O\n" +
+	"\vannotations\x18\f \x03(\v2-.qubit.orders.v1.OrderIntent.AnnotationsEntryR\vannotations\x12;\n" +
+	"\n" +
+	"stop_price\x18\r \x01(\v2\x1c.google.protobuf.DoubleValueR\tstopPrice\x12\x1c\n" +
+	"\talgorithm\x18\x0e \x01(\tR\talgorithm\x12>\n" +
+	"\ralgo_duration\x18\x0f \x01(\v2\x19.google.protobuf.DurationR\falgoDuration\x12\x1f\n" +
+	"\valgo_slices\x18\x10 \x01(\rR\n" +
+	"algoSlices\x12)\n" +
+	"\x10display_quantity\x18\x11 \x01(\x01R\x0fdisplayQuantity\x12H\n" +
+	"\x11take_profit_price\x18\x12 \x01(\v2\x1c.google.protobuf.DoubleValueR\x0ftakeProfitPrice\x12D\n" +
+	"\x0fstop_loss_price\x18\x13 \x01(\v2\x1c.google.protobuf.DoubleValueR\rstopLossPrice\x1a>\n" +
+	"\x10AnnotationsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x96\x01\n" +
+	"\x0eOrderIntentAck\x12\x1b\n" +
+	"\tintent_id\x18\x01 \x01(\tR\bintentId\x12*\n" +
+	"\x11executor_order_id\x18\x02 \x01(\tR\x0fexecutorOrderId\x12;\n" +
+	"\vreceived_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"receivedAt\"\xea\x02\n" +
+	"\rExecutionFill\x12\x1b\n" +
+	"\tintent_id\x18\x01 \x01(\tR\bintentId\x12*\n" +
+	"\x11executor_order_id\x18\x02 \x01(\tR\x0fexecutorOrderId\x12*\n" +
+	"\x11provider_order_id\x18\x03 \x01(\tR\x0fproviderOrderId\x12'\n" +
+	"\x0ffilled_quantity\x18\x04 \x01(\x01R\x0efilledQuantity\x12-\n" +
+	"\x12remaining_quantity\x18\x05 \x01(\x01R\x11remainingQuantity\x12\x1d\n" +
+	"\n" +
+	"fill_price\x18\x06 \x01(\x01R\tfillPrice\x12\x19\n" +
+	"\bfee_paid\x18\a \x01(\x01R\afeePaid\x127\n" +
+	"\tfilled_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\bfilledAt\x12\x19\n" +
+	"\btax_paid\x18\t \x01(\x01R\ataxPaid\"\xec\x01\n" +
+	"\x0eOrderRejection\x12\x1b\n" +
+	"\tintent_id\x18\x01 \x01(\tR\bintentId\x12*\n" +
+	"\x11executor_order_id\x18\x02 \x01(\tR\x0fexecutorOrderId\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12<\n" +
+	"\bcategory\x18\x04 \x01(\x0e2 .qubit.orders.v1.RejectionReasonR\bcategory\x12;\n" +
+	"\vrejected_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"rejectedAt\"\xb8\x01\n" +
+	"\vOrderCancel\x12\x1b\n" +
+	"\tintent_id\x18\x01 \x01(\tR\bintentId\x12*\n" +
+	"\x11executor_order_id\x18\x02 \x01(\tR\x0fexecutorOrderId\x12!\n" +
+	"\finitiated_by\x18\x03 \x01(\tR\vinitiatedBy\x12=\n" +
+	"\fcancelled_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vcancelledAt\"\xda\x01\n" +
+	"\n" +
+	"OrderAmend\x12\x1b\n" +
+	"\tintent_id\x18\x01 \x01(\tR\bintentId\x12*\n" +
+	"\x11executor_order_id\x18\x02 \x01(\tR\x0fexecutorOrderId\x12\x14\n" +
+	"\x05price\x18\x03 \x01(\x01R\x05price\x12\x1a\n" +
+	"\bquantity\x18\x04 \x01(\x01R\bquantity\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\x129\n" +
+	"\n" +
+	"amended_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tamendedAt\"\xcc\x04\n" +
+	"\n" +
+	"OrderEvent\x123\n" +
+	"\x03ack\x18\x01 \x01(\v2\x1f.qubit.orders.v1.OrderIntentAckH\x00R\x03ack\x124\n" +
+	"\x04fill\x18\x02 \x01(\v2\x1e.qubit.orders.v1.ExecutionFillH\x00R\x04fill\x12?\n" +
+	"\trejection\x18\x03 \x01(\v2\x1f.qubit.orders.v1.OrderRejectionH\x00R\trejection\x126\n" +
+	"\x06cancel\x18\x04 \x01(\v2\x1c.qubit.orders.v1.OrderCancelH\x00R\x06cancel\x123\n" +
+	"\x05amend\x18\x05 \x01(\v2\x1b.qubit.orders.v1.OrderAmendH\x00R\x05amend\x12\x15\n" +
+	"\x06bot_id\x18\x14 \x01(\tR\x05botId\x12\x1d\n" +
+	"\n" +
+	"account_id\x18\x15 \x01(\tR\taccountId\x12%\n" +
+	"\x0ecorrelation_id\x18\x16 \x01(\tR\rcorrelationId\x12=\n" +
+	"\fpublished_at\x18\x17 \x01(\v2\x1a.google.protobuf.TimestampR\vpublishedAt\x12\x1d\n" +
+	"\n" +
+	"bracket_id\x18\x18 \x01(\tR\tbracketId\x12-\n" +
+	"\x03leg\x18\x19 \x01(\x0e2\x1b.qubit.orders.v1.BracketLegR\x03leg\x122\n" +
+	"\x04mode\x18\x1a \x01(\x0e2\x1e.qubit.orders.v1.ExecutionModeR\x04modeB\a\n" +
+	"\x05event*P\n" +
+	"\tOrderSide\x12\x1a\n" +
+	"\x16ORDER_SIDE_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eORDER_SIDE_BUY\x10\x01\x12\x13\n" +
+	"\x0fORDER_SIDE_SELL\x10\x02*x\n" +
+	"\n" +
+	"BracketLeg\x12\x1b\n" +
+	"\x17BRACKET_LEG_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11BRACKET_LEG_ENTRY\x10\x01\x12\x1b\n" +
+	"\x17BRACKET_LEG_TAKE_PROFIT\x10\x02\x12\x19\n" +
+	"\x15BRACKET_LEG_STOP_LOSS\x10\x03*b\n" +
+	"\rExecutionMode\x12\x1e\n" +
+	"\x1aEXECUTION_MODE_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13EXECUTION_MODE_LIVE\x10\x01\x12\x18\n" +
+	"\x14EXECUTION_MODE_PAPER\x10\x02*i\n" +
+	"\tOrderType\x12\x1a\n" +
+	"\x16ORDER_TYPE_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11ORDER_TYPE_MARKET\x10\x01\x12\x14\n" +
+	"\x10ORDER_TYPE_LIMIT\x10\x02\x12\x13\n" +
+	"\x0fORDER_TYPE_STOP\x10\x03*\xf1\x02\n" +
+	"\x0fRejectionReason\x12 \n" +
+	"\x1cREJECTION_REASON_UNSPECIFIED\x10\x00\x12\x1f\n" +
+	"\x1bREJECTION_REASON_RISK_LIMIT\x10\x01\x12&\n" +
+	"\"REJECTION_REASON_INSTRUMENT_HALTED\x10\x02\x12\"\n" +
+	"\x1eREJECTION_REASON_BROKER_REJECT\x10\x03\x12\x1c\n" +
+	"\x18REJECTION_REASON_TIMEOUT\x10\x04\x12!\n" +
+	"\x1dREJECTION_REASON_SYSTEM_ERROR\x10\x05\x12\"\n" +
+	"\x1eREJECTION_REASON_INVALID_PRICE\x10\x06\x12%\n" +
+	"!REJECTION_REASON_INVALID_QUANTITY\x10\a\x12\x1f\n" +
+	"\x1bREJECTION_REASON_RATE_LIMIT\x10\b\x12\"\n" +
+	"\x1eREJECTION_REASON_MARKET_CLOSED\x10\tB1Z/github.com/future-bots/proto/orders/v1;ordersv1b\x06proto3"
+
+var (
+	file_proto_orders_v1_orders_proto_rawDescOnce sync.Once
+	file_proto_orders_v1_orders_proto_rawDescData []byte
+)
+
+func file_proto_orders_v1_orders_proto_rawDescGZIP() []byte {
+	file_proto_orders_v1_orders_proto_rawDescOnce.Do(func() {
+		file_proto_orders_v1_orders_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_orders_v1_orders_proto_rawDesc), len(file_proto_orders_v1_orders_proto_rawDesc)))
+	})
+	return file_proto_orders_v1_orders_proto_rawDescData
+}
+
+var file_proto_orders_v1_orders_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_proto_orders_v1_orders_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_proto_orders_v1_orders_proto_goTypes = []any{
+	(OrderSide)(0),                 // 0: qubit.orders.v1.OrderSide
+	(BracketLeg)(0),                // 1: qubit.orders.v1.BracketLeg
+	(ExecutionMode)(0),             // 2: qubit.orders.v1.ExecutionMode
+	(OrderType)(0),                 // 3: qubit.orders.v1.OrderType
+	(RejectionReason)(0),           // 4: qubit.orders.v1.RejectionReason
+	(*OrderIntent)(nil),            // 5: qubit.orders.v1.OrderIntent
+	(*OrderIntentAck)(nil),         // 6: qubit.orders.v1.OrderIntentAck
+	(*ExecutionFill)(nil),          // 7: qubit.orders.v1.ExecutionFill
+	(*OrderRejection)(nil),         // 8: qubit.orders.v1.OrderRejection
+	(*OrderCancel)(nil),            // 9: qubit.orders.v1.OrderCancel
+	(*OrderAmend)(nil),             // 10: qubit.orders.v1.OrderAmend
+	(*OrderEvent)(nil),             // 11: qubit.orders.v1.OrderEvent
+	nil,                            // 12: qubit.orders.v1.OrderIntent.AnnotationsEntry
+	(*wrapperspb.DoubleValue)(nil), // 13: google.protobuf.DoubleValue
+	(*timestamppb.Timestamp)(nil),  // 14: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),    // 15: google.protobuf.Duration
+}
+var file_proto_orders_v1_orders_proto_depIdxs = []int32{
+	0,  // 0: qubit.orders.v1.OrderIntent.side:type_name -> qubit.orders.v1.OrderSide
+	13, // 1: qubit.orders.v1.OrderIntent.limit_price:type_name -> google.protobuf.DoubleValue
+	3,  // 2: qubit.orders.v1.OrderIntent.type:type_name -> qubit.orders.v1.OrderType
+	14, // 3: qubit.orders.v1.OrderIntent.expires_at:type_name -> google.protobuf.Timestamp
+	12, // 4: qubit.orders.v1.OrderIntent.annotations:type_name -> qubit.orders.v1.OrderIntent.AnnotationsEntry
+	13, // 5: qubit.orders.v1.OrderIntent.stop_price:type_name -> google.protobuf.DoubleValue
+	15, // 6: qubit.orders.v1.OrderIntent.algo_duration:type_name -> google.protobuf.Duration
+	13, // 7: qubit.orders.v1.OrderIntent.take_profit_price:type_name -> google.protobuf.DoubleValue
+	13, // 8: qubit.orders.v1.OrderIntent.stop_loss_price:type_name -> google.protobuf.DoubleValue
+	14, // 9: qubit.orders.v1.OrderIntentAck.received_at:type_name -> google.protobuf.Timestamp
+	14, // 10: qubit.orders.v1.ExecutionFill.filled_at:type_name -> google.protobuf.Timestamp
+	4,  // 11: qubit.orders.v1.OrderRejection.category:type_name -> qubit.orders.v1.RejectionReason
+	14, // 12: qubit.orders.v1.OrderRejection.rejected_at:type_name -> google.protobuf.Timestamp
+	14, // 13: qubit.orders.v1.OrderCancel.cancelled_at:type_name -> google.protobuf.Timestamp
+	14, // 14: qubit.orders.v1.OrderAmend.amended_at:type_name -> google.protobuf.Timestamp
+	6,  // 15: qubit.orders.v1.OrderEvent.ack:type_name -> qubit.orders.v1.OrderIntentAck
+	7,  // 16: qubit.orders.v1.OrderEvent.fill:type_name -> qubit.orders.v1.ExecutionFill
+	8,  // 17: qubit.orders.v1.OrderEvent.rejection:type_name -> qubit.orders.v1.OrderRejection
+	9,  // 18: qubit.orders.v1.OrderEvent.cancel:type_name -> qubit.orders.v1.OrderCancel
+	10, // 19: qubit.orders.v1.OrderEvent.amend:type_name -> qubit.orders.v1.OrderAmend
+	14, // 20: qubit.orders.v1.OrderEvent.published_at:type_name -> google.protobuf.Timestamp
+	1,  // 21: qubit.orders.v1.OrderEvent.leg:type_name -> qubit.orders.v1.BracketLeg
+	2,  // 22: qubit.orders.v1.OrderEvent.mode:type_name -> qubit.orders.v1.ExecutionMode
+	23, // [23:23] is the sub-list for method output_type
+	23, // [23:23] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
+}
+
+func init() { file_proto_orders_v1_orders_proto_init() }
+func file_proto_orders_v1_orders_proto_init() {
+	if File_proto_orders_v1_orders_proto != nil {
+		return
+	}
+	file_proto_orders_v1_orders_proto_msgTypes[6].OneofWrappers = []any{
+		(*OrderEvent_Ack)(nil),
+		(*OrderEvent_Fill)(nil),
+		(*OrderEvent_Rejection)(nil),
+		(*OrderEvent_Cancel)(nil),
+		(*OrderEvent_Amend)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_orders_v1_orders_proto_rawDesc), len(file_proto_orders_v1_orders_proto_rawDesc)),
+			NumEnums:      5,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_orders_v1_orders_proto_goTypes,
+		DependencyIndexes: file_proto_orders_v1_orders_proto_depIdxs,
+		EnumInfos:         file_proto_orders_v1_orders_proto_enumTypes,
+		MessageInfos:      file_proto_orders_v1_orders_proto_msgTypes,
+	}.Build()
+	File_proto_orders_v1_orders_proto = out.File
+	file_proto_orders_v1_orders_proto_goTypes = nil
+	file_proto_orders_v1_orders_proto_depIdxs = nil
+}